@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"uno/cmd/shortener/models"
+	"uno/pkg/client"
+)
+
+// shortenResult результат сокращения одного URL
+type shortenResult struct {
+	OriginalURL string `json:"original_url"`
+	ShortURL    string `json:"short_url"`
+	Existed     bool   `json:"existed"` // URL был сокращен ранее (ответ 409)
+}
+
+// cmdShorten сокращает URL из аргументов
+// С флагом -json использует JSON API, иначе text/plain эндпоинт
+func cmdShorten(ctx context.Context, api *client.Client, out *printer, args []string) error {
+	fset := flag.NewFlagSet("shorten", flag.ContinueOnError)
+	useJSON := fset.Bool("json", false, "use JSON API (/api/shorten)")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		return errors.New("usage: shorten [-json] <url>...")
+	}
+
+	results := make([]shortenResult, 0, fset.NArg())
+	for _, originalURL := range fset.Args() {
+		shorten := api.Shorten
+		if *useJSON {
+			shorten = api.ShortenJSON
+		}
+		shortURL, err := shorten(ctx, originalURL)
+		existed := errors.Is(err, client.ErrConflict)
+		if err != nil && !existed {
+			return fmt.Errorf("failed to shorten %s: %w", originalURL, err)
+		}
+		results = append(results, shortenResult{OriginalURL: originalURL, ShortURL: shortURL, Existed: existed})
+	}
+
+	return out.print(results, []string{"SHORT URL", "ORIGINAL URL", "EXISTED"}, len(results), func(i int) []string {
+		r := results[i]
+		return []string{r.ShortURL, r.OriginalURL, strconv.FormatBool(r.Existed)}
+	})
+}
+
+// cmdBatch пакетно сокращает URL из файла (-f) или stdin
+// Вход - JSON массив запросов batch API или по одному URL на строку;
+// во втором случае correlation_id равен номеру строки. Если часть ссылок не сохранена
+// или ответ оборвался, выводит полученные результаты с причинами и возвращает ошибку
+func cmdBatch(ctx context.Context, api *client.Client, out *printer, args []string, stdin io.Reader) error {
+	fset := flag.NewFlagSet("batch", flag.ContinueOnError)
+	file := fset.String("f", "", "input file (default stdin)")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	in := stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	requests, err := parseBatchInput(data)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return errors.New("no URLs in input")
+	}
+
+	responses, err := api.ShortenBatch(ctx, requests)
+	var batchErr *client.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		return err
+	}
+
+	originals := make(map[string]string, len(requests))
+	for _, r := range requests {
+		originals[r.CorrelationID] = r.OriginalURL
+	}
+	printErr := out.print(responses, []string{"CORRELATION ID", "SHORT URL", "ORIGINAL URL", "ERROR"}, len(responses), func(i int) []string {
+		r := responses[i]
+		return []string{r.CorrelationID, r.ShortURL, originals[r.CorrelationID], r.Error}
+	})
+	return errors.Join(printErr, err)
+}
+
+// parseBatchInput разбирает вход команды batch
+func parseBatchInput(data []byte) ([]models.BatchRequest, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var requests []models.BatchRequest
+		if err := models.UnmarshalBatchRequest(trimmed, &requests); err != nil {
+			return nil, fmt.Errorf("invalid JSON input: %w", err)
+		}
+		return requests, nil
+	}
+
+	var requests []models.BatchRequest
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		originalURL := strings.TrimSpace(scanner.Text())
+		if originalURL == "" || strings.HasPrefix(originalURL, "#") {
+			continue
+		}
+		requests = append(requests, models.BatchRequest{
+			CorrelationID: strconv.Itoa(line),
+			OriginalURL:   originalURL,
+		})
+	}
+	return requests, scanner.Err()
+}
+
+// cmdList выводит URL текущего пользователя
+func cmdList(ctx context.Context, api *client.Client, out *printer) error {
+	urls, err := api.UserURLs(ctx)
+	if err != nil {
+		return err
+	}
+	if urls == nil {
+		urls = []models.UserURL{}
+	}
+	return out.print(urls, []string{"SHORT URL", "ORIGINAL URL"}, len(urls), func(i int) []string {
+		return []string{urls[i].ShortURL, urls[i].OriginalURL}
+	})
+}
+
+// cmdDelete ставит в очередь удаление URL пользователя
+// Принимает как сокращенные ID, так и полные сокращенные URL
+func cmdDelete(ctx context.Context, api *client.Client, out *printer, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: delete <id|url>...")
+	}
+	ids := make([]string, len(args))
+	for i, arg := range args {
+		ids[i] = shortID(arg)
+	}
+	if err := api.DeleteURLs(ctx, ids); err != nil {
+		return err
+	}
+	return out.message(fmt.Sprintf("deletion of %d URL(s) accepted", len(ids)))
+}
+
+// cmdResolve выводит оригинальный URL для сокращенного ID
+func cmdResolve(ctx context.Context, api *client.Client, out *printer, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: resolve <id|url>")
+	}
+	id := shortID(args[0])
+	originalURL, err := api.Resolve(ctx, id)
+	if err != nil {
+		return err
+	}
+	result := shortenResult{OriginalURL: originalURL, ShortURL: api.ShortURL(id)}
+	return out.print(result, []string{"SHORT URL", "ORIGINAL URL"}, 1, func(int) []string {
+		return []string{result.ShortURL, result.OriginalURL}
+	})
+}
+
+// cmdPing проверяет доступность сервиса
+func cmdPing(ctx context.Context, api *client.Client, out *printer) error {
+	if err := api.Ping(ctx); err != nil {
+		return err
+	}
+	return out.message("ok")
+}
+
+// shortID извлекает сокращенный ID из полного сокращенного URL
+func shortID(s string) string {
+	s = strings.TrimRight(s, "/")
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -1,58 +1,131 @@
+// Command client - консольный клиент сервиса сокращения URL.
+//
+// Использование:
+//
+//	client [флаги] <команда> [аргументы]
+//
+// Команды:
+//
+//	shorten [-json] <url>...   сократить URL (text/plain или JSON API)
+//	batch [-f файл]            пакетно сократить URL из файла или stdin
+//	list                       показать URL пользователя
+//	delete <id|url>...         удалить URL пользователя
+//	resolve <id|url>           показать оригинальный URL без перехода
+//	ping                       проверить доступность сервиса
+//
+// Идентификатор пользователя (cookie auth_user) сохраняется в файле профиля
+// и используется в следующих запусках.
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
-	"strings"
+	"path/filepath"
+	"uno/pkg/client"
 )
 
-const defaultBaseURL = "http://localhost:8080/"
+const defaultServerURL = "http://localhost:8080"
+
+// options общие параметры клиента
+type options struct {
+	server  string // Адрес сервиса
+	output  string // Формат вывода: table или json
+	gzip    bool   // Сжимать тела запросов
+	profile string // Путь к файлу профиля
+}
 
 func main() {
-	endpoint := defaultBaseURL
-	// контейнер данных для запроса
-	data := url.Values{}
-	// приглашение в консоли
-	fmt.Println("Введите длинный URL")
-	// открываем потоковое чтение из консоли
-	reader := bufio.NewReader(os.Stdin)
-	// читаем строку из консоли
-	long, err := reader.ReadString('\n')
-	if err != nil {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
 		log.Fatal(err)
 	}
-	long = strings.TrimSuffix(long, "\n")
-	// заполняем контейнер данными
-	data.Set("url", long)
-	// добавляем HTTP-клиент
-	client := &http.Client{}
-	// пишем запрос
-	// запрос методом POST должен, помимо заголовков, содержать тело
-	// тело должно быть источником потокового чтения io.Reader
-	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+}
+
+// run разбирает общие флаги и выполняет команду
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fset := flag.NewFlagSet("client", flag.ContinueOnError)
+	fset.SetOutput(stdout)
+	var opts options
+	fset.StringVar(&opts.server, "server", envOr("SHORTENER_SERVER", defaultServerURL), "shortener service URL")
+	fset.StringVar(&opts.output, "o", "table", "output format: table or json")
+	fset.BoolVar(&opts.gzip, "gzip", false, "gzip request bodies")
+	fset.StringVar(&opts.profile, "profile", envOr("SHORTENER_PROFILE", defaultProfilePath()), "profile file with saved user cookie")
+	fset.Usage = func() {
+		fmt.Fprintln(stdout, "Usage: client [flags] <shorten|batch|list|delete|resolve|ping> [args]")
+		fset.PrintDefaults()
+	}
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if opts.output != outputTable && opts.output != outputJSON {
+		return fmt.Errorf("unknown output format %q", opts.output)
+	}
+	if fset.NArg() == 0 {
+		fset.Usage()
+		return errors.New("command is required")
+	}
+
+	profile, err := loadProfile(opts.profile)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	clientOpts := []client.Option{client.WithUserID(profile.Cookies[opts.server])}
+	if opts.gzip {
+		clientOpts = append(clientOpts, client.WithGzip())
 	}
-	// в заголовках запроса указываем кодировку
-	request.Header.Add("Content-Type", "text/plain; charset=utf-8")
-	// отправляем запрос и получаем ответ
-	response, err := client.Do(request)
+	api, err := client.New(opts.server, clientOpts...)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	ctx := context.Background()
+	out := newPrinter(stdout, opts.output)
+
+	cmd, cmdArgs := fset.Arg(0), fset.Args()[1:]
+	switch cmd {
+	case "shorten":
+		err = cmdShorten(ctx, api, out, cmdArgs)
+	case "batch":
+		err = cmdBatch(ctx, api, out, cmdArgs, stdin)
+	case "list":
+		err = cmdList(ctx, api, out)
+	case "delete":
+		err = cmdDelete(ctx, api, out, cmdArgs)
+	case "resolve":
+		err = cmdResolve(ctx, api, out, cmdArgs)
+	case "ping":
+		err = cmdPing(ctx, api, out)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
 	}
-	// выводим код ответа
-	fmt.Println("Статус-код ", response.Status)
-	defer response.Body.Close()
-	// читаем поток из тела ответа
-	body, err := io.ReadAll(response.Body)
+
+	// Сохраняем cookie пользователя, даже если команда завершилась ошибкой:
+	// сервер мог успеть выдать новый идентификатор
+	if userID := api.UserID(); userID != "" && userID != profile.Cookies[opts.server] {
+		profile.Cookies[opts.server] = userID
+		if saveErr := saveProfile(opts.profile, profile); saveErr != nil {
+			return errors.Join(err, saveErr)
+		}
+	}
+	return err
+}
+
+// envOr возвращает значение переменной окружения или def, если она не задана
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// defaultProfilePath возвращает путь к профилю в пользовательском каталоге настроек
+func defaultProfilePath() string {
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		log.Fatal(err)
+		return ".shortener-profile.json"
 	}
-	// и печатаем его
-	fmt.Println(string(body))
+	return filepath.Join(dir, "shortener", "profile.json")
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/handlers"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+	"uno/pkg/client"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// newTestServer запускает сервис сокращения URL на реальных обработчиках
+func newTestServer(t *testing.T) (*httptest.Server, storage.Storage) {
+	t.Helper()
+	store := storage.NewInMemoryStorage()
+	queue := make(chan handlers.DeleteRequest, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go handlers.RunDeletionWorker(ctx, store, zap.NewNop(), queue)
+
+	cfg := &config.Config{}
+	r := chi.NewRouter()
+	r.Use(middleware.GzipMiddleware)
+	r.Use(middleware.WithUserID)
+	r.Post("/", handlers.ShortenURLHandler(cfg, store))
+	r.Post("/api/shorten", handlers.APIShortenHandler(cfg, store))
+	r.Post("/api/shorten/batch", handlers.BatchShortenHandler(cfg, store))
+	r.Get("/{id}", handlers.RedirectHandler(store))
+	r.Get("/ping", handlers.PingHandler(nil))
+	r.Get("/api/user/urls", handlers.UserURLsHandler(cfg, store))
+	r.Delete("/api/user/urls", handlers.DeleteUserURLsHandler(store, zap.NewNop(), queue))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	return srv, store
+}
+
+func runClient(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &out)
+	return out.String(), err
+}
+
+func TestClient_Commands(t *testing.T) {
+	srv, store := newTestServer(t)
+	profilePath := filepath.Join(t.TempDir(), "profile.json")
+	base := []string{"-server", srv.URL, "-profile", profilePath}
+
+	out, err := runClient(t, "", append(base, "-o", "json", "shorten", "https://example.com/a")...)
+	if err != nil {
+		t.Fatalf("shorten failed: %v", err)
+	}
+	var shortened []shortenResult
+	if err := json.Unmarshal([]byte(out), &shortened); err != nil || len(shortened) != 1 {
+		t.Fatalf("unexpected shorten output %q: %v", out, err)
+	}
+
+	// Повторное сокращение через JSON API с gzip возвращает тот же URL
+	out, err = runClient(t, "", append(base, "-o", "json", "-gzip", "shorten", "-json", "https://example.com/a")...)
+	if err != nil {
+		t.Fatalf("shorten -json failed: %v", err)
+	}
+	var again []shortenResult
+	if err := json.Unmarshal([]byte(out), &again); err != nil || !again[0].Existed || again[0].ShortURL != shortened[0].ShortURL {
+		t.Fatalf("expected existing short URL, got %q (%v)", out, err)
+	}
+
+	if _, err := runClient(t, "https://example.com/b\n\nhttps://example.com/c\n", append(base, "batch")...); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+
+	// Несохраненные ссылки пакета выводятся с причиной, а команда завершается ошибкой
+	out, err = runClient(t, "https://example.com/b\n", append(base, "batch")...)
+	var batchErr *client.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 1 || !strings.Contains(out, storage.ErrConflict.Error()) {
+		t.Fatalf("expected failed batch with conflict, got %q (%v)", out, err)
+	}
+
+	// Профиль сохраняет пользователя между запусками
+	out, err = runClient(t, "", append(base, "-o", "json", "list")...)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	var urls []models.UserURL
+	if err := json.Unmarshal([]byte(out), &urls); err != nil || len(urls) != 3 {
+		t.Fatalf("expected 3 user URLs, got %q (%v)", out, err)
+	}
+
+	out, err = runClient(t, "", append(base, "resolve", shortened[0].ShortURL)...)
+	if err != nil || !strings.Contains(out, "https://example.com/a") {
+		t.Fatalf("unexpected resolve output %q: %v", out, err)
+	}
+
+	if _, err := runClient(t, "", append(base, "delete", shortened[0].ShortURL)...); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	id := shortID(shortened[0].ShortURL)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, deleted, _ := store.Get(id); deleted || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := runClient(t, "", append(base, "resolve", id)...); !errors.Is(err, client.ErrGone) {
+		t.Errorf("expected errGone after deletion, got %v", err)
+	}
+
+	if out, err := runClient(t, "", append(base, "ping")...); err != nil || strings.TrimSpace(out) != "ok" {
+		t.Errorf("unexpected ping output %q: %v", out, err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv, _ := newTestServer(t)
+	profilePath := filepath.Join(t.TempDir(), "profile.json")
+
+	if _, err := runClient(t, "", "-profile", profilePath); err == nil {
+		t.Error("expected error without command")
+	}
+	if _, err := runClient(t, "", "-profile", profilePath, "-server", srv.URL, "unknown"); err == nil {
+		t.Error("expected error for unknown command")
+	}
+	if _, err := runClient(t, "", "-profile", profilePath, "-o", "xml", "ping"); err == nil {
+		t.Error("expected error for unknown output format")
+	}
+	if _, err := runClient(t, "", "-profile", profilePath, "-server", srv.URL, "resolve", "missing"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("expected errNotFound, got %v", err)
+	}
+}
+
+func TestParseBatchInput(t *testing.T) {
+	requests, err := parseBatchInput([]byte(`[{"correlation_id":"x","original_url":"https://a.example"}]`))
+	if err != nil || len(requests) != 1 || requests[0].CorrelationID != "x" {
+		t.Errorf("unexpected JSON batch %+v: %v", requests, err)
+	}
+
+	requests, err = parseBatchInput([]byte("# comment\nhttps://a.example\n\nhttps://b.example\n"))
+	if err != nil || len(requests) != 2 || requests[1].CorrelationID != "4" {
+		t.Errorf("unexpected line batch %+v: %v", requests, err)
+	}
+}
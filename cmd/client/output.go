@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Форматы вывода
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer выводит результаты команд в выбранном формате
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, format: format}
+}
+
+// print выводит v как JSON или таблицу с заголовками columns
+// row возвращает ячейки строки таблицы с номером i
+func (p *printer) print(v any, columns []string, rows int, row func(i int) []string) error {
+	if p.format == outputJSON {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for i := 0; i < rows; i++ {
+		fmt.Fprintln(tw, strings.Join(row(i), "\t"))
+	}
+	return tw.Flush()
+}
+
+// message выводит короткое сообщение; в формате json - как объект {"status": ...}
+func (p *printer) message(status string) error {
+	if p.format == outputJSON {
+		return json.NewEncoder(p.w).Encode(map[string]string{"status": status})
+	}
+	_, err := fmt.Fprintln(p.w, status)
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// profile сохраняемое между запусками состояние клиента
+type profile struct {
+	Cookies map[string]string `json:"cookies"` // Адрес сервиса -> значение cookie auth_user
+}
+
+// loadProfile читает профиль; отсутствующий файл дает пустой профиль
+func loadProfile(path string) (*profile, error) {
+	p := &profile{Cookies: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	if p.Cookies == nil {
+		p.Cookies = make(map[string]string)
+	}
+	return p, nil
+}
+
+// saveProfile атомарно записывает профиль, доступный только владельцу
+func saveProfile(path string, p *profile) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,298 @@
+// Command loadgen - генератор нагрузки для сервиса сокращения URL.
+//
+// Работает в двух режимах:
+//   - синтетическая нагрузка: смесь операций shorten, batch, redirect, list и delete
+//     в заданных пропорциях (-mix);
+//   - воспроизведение журнала трафика в формате pkg/traffic (-replay), например
+//     записанного middleware захвата трафика.
+//
+// Параллельность задается флагом -c, общий темп - флагом -rate. По завершении
+// выводится отчет с перцентилями задержек, долей ошибок и пропускной способностью.
+// С флагами -pprof и -cpu-profile/-heap-profile генератор снимает профили сервиса
+// во время прогона для сравнения с profiles/base.pprof:
+//
+//	go tool pprof -top -diff_base=profiles/base.pprof cpu.pprof
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/http/cookiejar"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"uno/pkg/client"
+	"uno/pkg/traffic"
+)
+
+// options параметры прогона
+type options struct {
+	server      string
+	replay      string
+	speed       float64
+	mix         string
+	concurrency int
+	rate        float64
+	requests    int
+	duration    time.Duration
+	batchSize   int
+	seed        uint64
+	pprofURL    string
+	cpuProfile  string
+	heapProfile string
+	profileSecs int
+	jsonReport  bool
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run разбирает флаги, выполняет прогон и выводит отчет
+func run(args []string, stdout io.Writer) error {
+	opts, err := parseFlags(args, stdout)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	// CPU профиль снимается параллельно с нагрузкой
+	var profileErr error
+	var profileWG sync.WaitGroup
+	if opts.cpuProfile != "" {
+		profileWG.Add(1)
+		go func() {
+			defer profileWG.Done()
+			profileErr = fetchProfile(context.Background(), opts.pprofURL, "profile", opts.profileSecs, opts.cpuProfile)
+		}()
+	}
+
+	stats := newCollector()
+	start := time.Now()
+	if opts.replay != "" {
+		err = runReplay(ctx, opts, stats)
+	} else {
+		err = runSynthetic(ctx, opts, stats)
+	}
+	elapsed := time.Since(start)
+	if err != nil {
+		return err
+	}
+
+	profileWG.Wait()
+	if opts.heapProfile != "" {
+		profileErr = errors.Join(profileErr, fetchProfile(context.Background(), opts.pprofURL, "heap", 0, opts.heapProfile))
+	}
+
+	report := stats.report(elapsed)
+	if opts.jsonReport {
+		err = report.writeJSON(stdout)
+	} else {
+		err = report.writeText(stdout)
+	}
+	return errors.Join(err, profileErr)
+}
+
+// parseFlags разбирает и проверяет флаги
+func parseFlags(args []string, output io.Writer) (*options, error) {
+	fset := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fset.SetOutput(output)
+	opts := &options{}
+	fset.StringVar(&opts.server, "server", "http://localhost:8080", "shortener service URL")
+	fset.StringVar(&opts.replay, "replay", "", "JSONL traffic log to replay instead of synthetic load")
+	fset.Float64Var(&opts.speed, "speed", 0, "replay with original request timing sped up N times (0 - as fast as allowed)")
+	fset.StringVar(&opts.mix, "mix", defaultMix, "synthetic operation weights")
+	fset.IntVar(&opts.concurrency, "c", 10, "number of concurrent workers")
+	fset.Float64Var(&opts.rate, "rate", 0, "total request rate per second (0 - unlimited)")
+	fset.IntVar(&opts.requests, "n", 0, "total number of requests (0 - until -d expires or log ends)")
+	fset.DurationVar(&opts.duration, "d", 0, "test duration (default 10s for synthetic load without -n)")
+	fset.IntVar(&opts.batchSize, "batch-size", 10, "URLs per batch request")
+	fset.Uint64Var(&opts.seed, "seed", 0, "random seed for reproducible synthetic load (0 - random)")
+	fset.StringVar(&opts.pprofURL, "pprof", "", "pprof server URL of the service, e.g. http://localhost:6060")
+	fset.StringVar(&opts.cpuProfile, "cpu-profile", "", "save CPU profile captured during the run to file (requires -pprof)")
+	fset.StringVar(&opts.heapProfile, "heap-profile", "", "save heap profile captured after the run to file (requires -pprof)")
+	fset.IntVar(&opts.profileSecs, "profile-seconds", 0, "CPU profile duration in seconds (default -d)")
+	fset.BoolVar(&opts.jsonReport, "json", false, "print report as JSON")
+	if err := fset.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.concurrency <= 0 {
+		return nil, errors.New("-c must be positive")
+	}
+	if opts.batchSize <= 0 {
+		return nil, errors.New("-batch-size must be positive")
+	}
+	if opts.replay == "" && opts.requests == 0 && opts.duration == 0 {
+		opts.duration = 10 * time.Second
+	}
+	if (opts.cpuProfile != "" || opts.heapProfile != "") && opts.pprofURL == "" {
+		return nil, errors.New("-cpu-profile and -heap-profile require -pprof")
+	}
+	if opts.cpuProfile != "" && opts.profileSecs == 0 {
+		opts.profileSecs = int(math.Ceil(opts.duration.Seconds()))
+		if opts.profileSecs == 0 {
+			opts.profileSecs = 10
+		}
+	}
+	if opts.seed == 0 {
+		opts.seed = uint64(time.Now().UnixNano())
+	}
+	return opts, nil
+}
+
+// pacer ограничивает общий темп запросов всех воркеров
+type pacer struct {
+	ticker *time.Ticker
+}
+
+// newPacer создает ограничитель темпа; rate <= 0 - без ограничений
+func newPacer(rate float64) *pacer {
+	if rate <= 0 {
+		return &pacer{}
+	}
+	return &pacer{ticker: time.NewTicker(time.Duration(float64(time.Second) / rate))}
+}
+
+// wait ожидает разрешения на следующий запрос; false, если контекст завершен
+func (p *pacer) wait(ctx context.Context) bool {
+	if p.ticker == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-p.ticker.C:
+		return true
+	}
+}
+
+func (p *pacer) stop() {
+	if p.ticker != nil {
+		p.ticker.Stop()
+	}
+}
+
+// budget ограничивает общее количество запросов; limit = 0 - без ограничений
+type budget struct {
+	limit  int64
+	issued atomic.Int64
+}
+
+// take резервирует один запрос; false, если лимит исчерпан
+func (b *budget) take() bool {
+	return b.limit == 0 || b.issued.Add(1) <= b.limit
+}
+
+// runSynthetic выполняет синтетическую нагрузку
+// Каждый воркер работает от имени отдельного пользователя
+func runSynthetic(ctx context.Context, opts *options, stats *collector) error {
+	m, err := parseMix(opts.mix)
+	if err != nil {
+		return err
+	}
+
+	p := newPacer(opts.rate)
+	defer p.stop()
+	b := &budget{limit: int64(opts.requests)}
+	pool := newIDPool()
+	var seq atomic.Uint64
+	runID := strconv.FormatUint(opts.seed, 36)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		c, err := client.New(opts.server, client.WithRetry(0, 0))
+		if err != nil {
+			return err
+		}
+		w := &synthWorker{
+			c:         c,
+			rnd:       rand.New(rand.NewPCG(opts.seed, uint64(i))),
+			pool:      pool,
+			seq:       &seq,
+			runID:     runID,
+			batchSize: opts.batchSize,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b.take() && p.wait(ctx) {
+				r := w.do(ctx, m.pick(w.rnd))
+				// Запрос, прерванный окончанием прогона, не учитывается
+				if ctx.Err() != nil && r.err != nil {
+					return
+				}
+				stats.add(r)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// runReplay воспроизводит журнал трафика
+func runReplay(ctx context.Context, opts *options, stats *collector) error {
+	f, err := os.Open(opts.replay)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	rp := newReplayer(opts.server, jar)
+	p := newPacer(opts.rate)
+	defer p.stop()
+	b := &budget{limit: int64(opts.requests)}
+
+	feedCtx, cancelFeed := context.WithCancel(ctx)
+	defer cancelFeed()
+	records := make(chan traffic.Record, opts.concurrency)
+	feedErr := make(chan error, 1)
+	go func() {
+		feedErr <- feedRecords(feedCtx, traffic.NewReader(f), opts.speed, records)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rec := range records {
+				if !b.take() || !p.wait(ctx) {
+					cancelFeed()
+					continue
+				}
+				r := rp.do(ctx, rec)
+				if ctx.Err() != nil && r.err != nil {
+					continue
+				}
+				stats.add(r)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := <-feedErr; err != nil {
+		return fmt.Errorf("failed to read %s: %w", opts.replay, err)
+	}
+	return nil
+}
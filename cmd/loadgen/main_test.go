@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/handlers"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/storage"
+	"uno/pkg/traffic"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// newTestServer запускает сервис сокращения URL на реальных обработчиках
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	store := storage.NewInMemoryStorage()
+	queue := make(chan handlers.DeleteRequest, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go handlers.RunDeletionWorker(ctx, store, zap.NewNop(), queue)
+
+	cfg := &config.Config{}
+	r := chi.NewRouter()
+	r.Use(middleware.WithUserID)
+	r.Post("/", handlers.ShortenURLHandler(cfg, store))
+	r.Post("/api/shorten", handlers.APIShortenHandler(cfg, store))
+	r.Post("/api/shorten/batch", handlers.BatchShortenHandler(cfg, store))
+	r.Get("/{id}", handlers.RedirectHandler(store))
+	r.Get("/ping", handlers.PingHandler(nil))
+	r.Get("/api/user/urls", handlers.UserURLsHandler(cfg, store))
+	r.Delete("/api/user/urls", handlers.DeleteUserURLsHandler(store, zap.NewNop(), queue))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	return srv
+}
+
+func runReport(t *testing.T, args ...string) Report {
+	t.Helper()
+	var out bytes.Buffer
+	if err := run(append(args, "-json"), &out); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	var rep Report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid report %q: %v", out.String(), err)
+	}
+	return rep
+}
+
+func TestRun_Synthetic(t *testing.T) {
+	srv := newTestServer(t)
+
+	rep := runReport(t, "-server", srv.URL, "-n", "300", "-c", "4", "-seed", "1")
+	if rep.Requests != 300 {
+		t.Errorf("expected 300 requests, got %d", rep.Requests)
+	}
+	if rep.Errors != 0 {
+		t.Errorf("expected no errors, got %+v", rep.Ops)
+	}
+	if len(rep.Ops) < 3 {
+		t.Errorf("expected several operations in the mix, got %+v", rep.Ops)
+	}
+	if rep.Total.P99MS < rep.Total.P50MS {
+		t.Errorf("invalid percentiles: %+v", rep.Total)
+	}
+}
+
+func TestRun_SyntheticRateAndDuration(t *testing.T) {
+	srv := newTestServer(t)
+
+	start := time.Now()
+	rep := runReport(t, "-server", srv.URL, "-d", "200ms", "-rate", "50", "-c", "2", "-mix", "shorten=1")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("run took too long: %v", elapsed)
+	}
+	if rep.Requests == 0 || rep.Requests > 15 {
+		t.Errorf("expected about 10 requests at 50 req/s for 200ms, got %d", rep.Requests)
+	}
+}
+
+func TestRun_Replay(t *testing.T) {
+	srv := newTestServer(t)
+
+	path := filepath.Join(t.TempDir(), "traffic.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create log: %v", err)
+	}
+	w := traffic.NewWriter(f)
+	records := []traffic.Record{
+		{Method: "POST", Path: "/", ContentType: "text/plain", Body: "https://a.example", Status: 201},
+		{Method: "POST", Path: "/api/shorten", ContentType: "application/json", Body: `{"url":"https://b.example"}`, Status: 201},
+		{Method: "GET", Path: "/missing?utm_source=x", Status: 307},
+		{Method: "GET", Path: "/ping", Status: 200},
+		{Method: "POST", Path: "/api/shorten", ContentType: "application/json", Body: `{"url":"https://c.ex`, BodyTruncated: true, Status: 201},
+	}
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("failed to write record: %v", err)
+		}
+	}
+	f.Close()
+
+	rep := runReport(t, "-server", srv.URL, "-replay", path, "-c", "2")
+	if rep.Requests != len(records)-1 || rep.Skipped != 1 {
+		t.Errorf("expected %d requests and 1 skipped record, got %d and %d", len(records)-1, rep.Requests, rep.Skipped)
+	}
+	if rep.Total.Mismatches != 1 {
+		t.Errorf("expected 1 status mismatch for missing link, got %d", rep.Total.Mismatches)
+	}
+	if rep.Errors != 0 {
+		t.Errorf("expected no errors, got %d", rep.Errors)
+	}
+}
+
+func TestRouteOf(t *testing.T) {
+	cases := map[string]string{
+		"/":                                 "/",
+		"/abc?utm_source=x":                 "/{id}",
+		"/abc/qr":                           "/{id}/qr",
+		"/api/shorten/batch":                "/api/shorten/batch",
+		"/api/user/urls":                    "/api/user/urls",
+		"/api/user/urls.csv":                "/api/user/urls.csv",
+		"/api/user/urls/abc":                "/api/user/urls/{id}",
+		"/api/user/urls/abc/variants":       "/api/user/urls/{id}/variants",
+		"/api/user/webhooks/7/deliveries?x": "/api/user/webhooks/{id}/deliveries",
+	}
+	for path, want := range cases {
+		if got := routeOf(path); got != want {
+			t.Errorf("routeOf(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := parseFlags([]string{"-cpu-profile", "cpu.pprof"}, &out); err == nil {
+		t.Error("expected error for profile without -pprof")
+	}
+	if _, err := parseFlags([]string{"-c", "0"}, &out); err == nil {
+		t.Error("expected error for zero concurrency")
+	}
+	opts, err := parseFlags([]string{"-pprof", "http://localhost:6060", "-cpu-profile", "cpu.pprof", "-d", "1500ms"}, &out)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	if opts.profileSecs != 2 {
+		t.Errorf("expected CPU profile to cover the run, got %d seconds", opts.profileSecs)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// fetchProfile сохраняет профиль pprof из pprofURL/debug/pprof/<name> в файл path
+// Для профиля profile (CPU) сервис собирает данные seconds секунд
+func fetchProfile(ctx context.Context, pprofURL, name string, seconds int, path string) error {
+	url := strings.TrimRight(pprofURL, "/") + "/debug/pprof/" + name
+	if seconds > 0 {
+		url += fmt.Sprintf("?seconds=%d", seconds)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: time.Duration(seconds)*time.Second + 30*time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s profile: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to fetch %s profile: status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save %s profile: %w", name, err)
+	}
+	return f.Close()
+}
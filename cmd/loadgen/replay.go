@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"uno/pkg/traffic"
+)
+
+// replayer воспроизводит записи журнала трафика
+type replayer struct {
+	base string
+	http *http.Client
+}
+
+// newReplayer создает воспроизводящий клиент; редиректы не выполняются,
+// cookie пользователя общая для всех запросов прогона
+func newReplayer(base string, jar http.CookieJar) *replayer {
+	return &replayer{
+		base: strings.TrimRight(base, "/"),
+		http: &http.Client{
+			Jar:     jar,
+			Timeout: 30 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// do выполняет запрос записи rec
+// Несовпадение статуса с записанным учитывается отдельно и ошибкой не считается.
+// Запись с усеченным телом не воспроизводится: запрос с обрезанным телом
+// отличался бы от исходного, и ответ сервиса ничего бы не сказал о нагрузке
+func (p *replayer) do(ctx context.Context, rec traffic.Record) result {
+	r := result{op: rec.Method + " " + routeOf(rec.Path)}
+	if rec.BodyTruncated {
+		r.skipped = true
+		return r
+	}
+
+	var body io.Reader
+	if rec.Body != "" {
+		body = strings.NewReader(rec.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, rec.Method, p.base+rec.Path, body)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	if rec.ContentType != "" {
+		req.Header.Set("Content-Type", rec.ContentType)
+	}
+
+	start := time.Now()
+	resp, err := p.http.Do(req)
+	if err != nil {
+		r.latency = time.Since(start)
+		r.err = err
+		return r
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	r.latency = time.Since(start)
+	r.status = resp.StatusCode
+
+	switch {
+	case err != nil:
+		r.err = err
+	case resp.StatusCode >= 500:
+		r.err = fmt.Errorf("status %d", resp.StatusCode)
+	}
+	r.mismatch = rec.Status != 0 && rec.Status != resp.StatusCode
+	return r
+}
+
+// idRoutes префиксы маршрутов API, за которыми следует ID ссылки или вебхука
+var idRoutes = []string{"/api/user/urls/", "/api/user/webhooks/"}
+
+// routeOf сводит путь к маршруту сервиса, чтобы статистика группировалась
+// по эндпоинтам, а не по отдельным сокращенным ID
+func routeOf(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	switch {
+	case path == "/", path == "/ping":
+		return path
+	case strings.HasPrefix(path, "/api/"):
+		for _, prefix := range idRoutes {
+			if rest, ok := strings.CutPrefix(path, prefix); ok && rest != "" {
+				if _, tail, nested := strings.Cut(rest, "/"); nested {
+					return prefix + "{id}/" + tail
+				}
+				return prefix + "{id}"
+			}
+		}
+		return path
+	case strings.HasSuffix(path, "/qr"):
+		return "/{id}/qr"
+	default:
+		return "/{id}"
+	}
+}
+
+// feedRecords отправляет записи журнала в канал
+// При speed > 0 сохраняются исходные интервалы между запросами, ускоренные в speed раз
+func feedRecords(ctx context.Context, reader *traffic.Reader, speed float64, out chan<- traffic.Record) error {
+	defer close(out)
+
+	var first time.Time
+	start := time.Now()
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if speed > 0 && !rec.Time.IsZero() {
+			if first.IsZero() {
+				first = rec.Time
+			}
+			due := start.Add(time.Duration(float64(rec.Time.Sub(first)) / speed))
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- rec:
+		}
+	}
+}
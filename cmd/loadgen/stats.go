@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// result результат одного запроса
+type result struct {
+	op       string        // Операция (shorten, redirect, ...) или метод и путь при воспроизведении
+	status   int           // HTTP статус; 0 при сетевой ошибке
+	latency  time.Duration // Время выполнения запроса
+	err      error         // Сетевая ошибка или неожиданный статус
+	mismatch bool          // Статус отличается от записанного в журнале
+	skipped  bool          // Запись журнала не воспроизводилась (усеченное тело)
+}
+
+// collector собирает результаты запросов по операциям
+type collector struct {
+	mu  sync.Mutex
+	ops map[string]*opStats
+}
+
+// opStats результаты одной операции
+type opStats struct {
+	latencies  []time.Duration
+	errors     int
+	mismatches int
+	skipped    int
+	statuses   map[int]int
+}
+
+func newCollector() *collector {
+	return &collector{ops: make(map[string]*opStats)}
+}
+
+// add учитывает результат запроса
+func (c *collector) add(r result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.ops[r.op]
+	if !ok {
+		s = &opStats{statuses: make(map[int]int)}
+		c.ops[r.op] = s
+	}
+	if r.skipped {
+		s.skipped++
+		return
+	}
+	s.latencies = append(s.latencies, r.latency)
+	s.statuses[r.status]++
+	if r.err != nil {
+		s.errors++
+	}
+	if r.mismatch {
+		s.mismatches++
+	}
+}
+
+// OpReport сводка по одной операции
+type OpReport struct {
+	Op         string      `json:"op"`
+	Requests   int         `json:"requests"`
+	Errors     int         `json:"errors"`
+	ErrorRate  float64     `json:"error_rate"`
+	Mismatches int         `json:"status_mismatches,omitempty"`
+	Skipped    int         `json:"skipped,omitempty"`
+	Statuses   map[int]int `json:"statuses"`
+	P50MS      float64     `json:"p50_ms"`
+	P90MS      float64     `json:"p90_ms"`
+	P99MS      float64     `json:"p99_ms"`
+	MaxMS      float64     `json:"max_ms"`
+}
+
+// Report итоговый отчет генератора нагрузки
+type Report struct {
+	DurationSec float64    `json:"duration_sec"`
+	Requests    int        `json:"requests"`
+	Errors      int        `json:"errors"`
+	ErrorRate   float64    `json:"error_rate"`
+	Skipped     int        `json:"skipped,omitempty"`
+	RPS         float64    `json:"rps"`
+	Ops         []OpReport `json:"ops"`
+	Total       OpReport   `json:"total"`
+}
+
+// report формирует отчет за прогон длительностью elapsed
+func (c *collector) report(elapsed time.Duration) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.ops))
+	for name := range c.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	total := &opStats{statuses: make(map[int]int)}
+	rep := Report{DurationSec: elapsed.Seconds()}
+	for _, name := range names {
+		s := c.ops[name]
+		rep.Ops = append(rep.Ops, s.report(name))
+
+		total.latencies = append(total.latencies, s.latencies...)
+		total.errors += s.errors
+		total.mismatches += s.mismatches
+		total.skipped += s.skipped
+		for status, n := range s.statuses {
+			total.statuses[status] += n
+		}
+	}
+	rep.Total = total.report("total")
+	rep.Requests = rep.Total.Requests
+	rep.Errors = rep.Total.Errors
+	rep.ErrorRate = rep.Total.ErrorRate
+	rep.Skipped = rep.Total.Skipped
+	if elapsed > 0 {
+		rep.RPS = float64(rep.Requests) / elapsed.Seconds()
+	}
+	return rep
+}
+
+// report формирует сводку по операции
+func (s *opStats) report(name string) OpReport {
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	r := OpReport{
+		Op:         name,
+		Requests:   len(s.latencies),
+		Errors:     s.errors,
+		Mismatches: s.mismatches,
+		Skipped:    s.skipped,
+		Statuses:   s.statuses,
+		P50MS:      ms(percentile(s.latencies, 50)),
+		P90MS:      ms(percentile(s.latencies, 90)),
+		P99MS:      ms(percentile(s.latencies, 99)),
+	}
+	if n := len(s.latencies); n > 0 {
+		r.MaxMS = ms(s.latencies[n-1])
+		r.ErrorRate = float64(s.errors) / float64(n)
+	}
+	return r
+}
+
+// percentile возвращает перцентиль p отсортированных значений (метод ближайшего ранга)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// ms переводит длительность в миллисекунды
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// writeText выводит отчет таблицей
+func (r Report) writeText(w io.Writer) error {
+	fmt.Fprintf(w, "Duration: %.2fs, requests: %d, errors: %d (%.2f%%), throughput: %.1f req/s\n",
+		r.DurationSec, r.Requests, r.Errors, r.ErrorRate*100, r.RPS)
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, "Skipped %d records with truncated body\n", r.Skipped)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "OP\tREQUESTS\tERRORS\tMISMATCH\tP50 ms\tP90 ms\tP99 ms\tMAX ms\t")
+	for _, op := range append(r.Ops, r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+			op.Op, op.Requests, op.Errors, op.Mismatches, op.P50MS, op.P90MS, op.P99MS, op.MaxMS)
+	}
+	return tw.Flush()
+}
+
+// writeJSON выводит отчет в JSON
+func (r Report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	values := make([]time.Duration, 100)
+	for i := range values {
+		values[i] = time.Duration(i+1) * time.Millisecond
+	}
+	cases := map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond}
+	for p, want := range cases {
+		if got := percentile(values, p); got != want {
+			t.Errorf("p%v: expected %v, got %v", p, want, got)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("expected 0 for empty input, got %v", got)
+	}
+}
+
+func TestCollectorReport(t *testing.T) {
+	c := newCollector()
+	c.add(result{op: "a", status: 200, latency: time.Millisecond})
+	c.add(result{op: "a", status: 500, latency: 3 * time.Millisecond, err: errors.New("status 500")})
+	c.add(result{op: "b", status: 201, latency: 2 * time.Millisecond, mismatch: true})
+
+	rep := c.report(time.Second)
+	if rep.Requests != 3 || rep.Errors != 1 || rep.RPS != 3 {
+		t.Errorf("unexpected totals: %+v", rep)
+	}
+	if len(rep.Ops) != 2 || rep.Ops[0].ErrorRate != 0.5 || rep.Total.Mismatches != 1 {
+		t.Errorf("unexpected per-op report: %+v", rep.Ops)
+	}
+	if rep.Total.MaxMS != 3 {
+		t.Errorf("expected max 3ms, got %v", rep.Total.MaxMS)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	m, err := parseMix("shorten=1, redirect=3,delete=0")
+	if err != nil {
+		t.Fatalf("parseMix failed: %v", err)
+	}
+	if m.String() != "redirect=3,shorten=1" {
+		t.Errorf("unexpected mix %s", m)
+	}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[m.pick(rnd)]++
+	}
+	if counts[opRedirect] < 2700 || counts[opRedirect] > 3300 {
+		t.Errorf("unexpected distribution %v", counts)
+	}
+
+	for _, bad := range []string{"", "shorten", "unknown=1", "shorten=-1", "shorten=0"} {
+		if _, err := parseMix(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"uno/cmd/shortener/models"
+	"uno/pkg/client"
+)
+
+// Синтетические операции
+const (
+	opShorten  = "shorten"
+	opBatch    = "batch"
+	opRedirect = "redirect"
+	opList     = "list"
+	opDelete   = "delete"
+)
+
+// defaultMix распределение операций по умолчанию
+const defaultMix = "shorten=20,batch=5,redirect=60,list=10,delete=5"
+
+// mix взвешенное распределение операций
+type mix struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+// parseMix разбирает распределение вида "shorten=20,redirect=80"
+func parseMix(s string) (mix, error) {
+	known := map[string]bool{opShorten: true, opBatch: true, opRedirect: true, opList: true, opDelete: true}
+	var m mix
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weight, ok := strings.Cut(part, "=")
+		if !ok {
+			return mix{}, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		if !known[name] {
+			return mix{}, fmt.Errorf("unknown operation %q", name)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return mix{}, fmt.Errorf("invalid weight for %s: %q", name, weight)
+		}
+		if w == 0 {
+			continue
+		}
+		m.ops = append(m.ops, name)
+		m.weights = append(m.weights, w)
+		m.total += w
+	}
+	if m.total == 0 {
+		return mix{}, errors.New("mix has no operations")
+	}
+	return m, nil
+}
+
+// pick выбирает операцию согласно весам
+func (m mix) pick(rnd *rand.Rand) string {
+	n := rnd.IntN(m.total)
+	for i, w := range m.weights {
+		if n < w {
+			return m.ops[i]
+		}
+		n -= w
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+// idPool общий для воркеров набор созданных сокращенных ID для переходов
+type idPool struct {
+	mu  sync.Mutex
+	ids []string
+	pos map[string]int
+}
+
+func newIDPool() *idPool {
+	return &idPool{pos: make(map[string]int)}
+}
+
+func (p *idPool) add(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.pos[id]; ok {
+		return
+	}
+	p.pos[id] = len(p.ids)
+	p.ids = append(p.ids, id)
+}
+
+func (p *idPool) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i, ok := p.pos[id]
+	if !ok {
+		return
+	}
+	last := p.ids[len(p.ids)-1]
+	p.ids[i] = last
+	p.pos[last] = i
+	p.ids = p.ids[:len(p.ids)-1]
+	delete(p.pos, id)
+}
+
+func (p *idPool) random(rnd *rand.Rand) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.ids) == 0 {
+		return "", false
+	}
+	return p.ids[rnd.IntN(len(p.ids))], true
+}
+
+// synthWorker выполняет синтетические операции от имени одного пользователя
+type synthWorker struct {
+	c         *client.Client
+	rnd       *rand.Rand
+	pool      *idPool
+	own       []string // ID, созданные этим пользователем
+	seq       *atomic.Uint64
+	runID     string
+	batchSize int
+}
+
+// nextURL возвращает уникальный оригинальный URL
+func (w *synthWorker) nextURL() string {
+	return fmt.Sprintf("https://loadgen.example/%s/%d", w.runID, w.seq.Add(1))
+}
+
+// do выполняет операцию op
+func (w *synthWorker) do(ctx context.Context, op string) result {
+	// Переходы, списки и удаления требуют уже созданных ссылок
+	if (op == opDelete || op == opList) && len(w.own) == 0 {
+		op = opShorten
+	}
+	var redirectID string
+	if op == opRedirect {
+		id, ok := w.pool.random(w.rnd)
+		if !ok {
+			op = opShorten
+		}
+		redirectID = id
+	}
+
+	start := time.Now()
+	var err error
+	switch op {
+	case opShorten:
+		var shortURL string
+		shortURL, err = w.c.ShortenJSON(ctx, w.nextURL())
+		if err == nil {
+			w.remember(shortURL)
+		}
+	case opBatch:
+		requests := make([]models.BatchRequest, w.batchSize)
+		for i := range requests {
+			requests[i] = models.BatchRequest{CorrelationID: strconv.Itoa(i), OriginalURL: w.nextURL()}
+		}
+		var responses []models.BatchResponse
+		responses, err = w.c.ShortenBatch(ctx, requests)
+		for _, r := range responses {
+			w.remember(r.ShortURL)
+		}
+	case opRedirect:
+		_, err = w.c.Resolve(ctx, redirectID)
+	case opList:
+		_, err = w.c.UserURLs(ctx)
+	case opDelete:
+		i := w.rnd.IntN(len(w.own))
+		id := w.own[i]
+		w.own[i] = w.own[len(w.own)-1]
+		w.own = w.own[:len(w.own)-1]
+		w.pool.remove(id)
+		err = w.c.DeleteURLs(ctx, []string{id})
+	}
+	latency := time.Since(start)
+
+	status := statusOf(op, err)
+	if expected(status) {
+		err = nil
+	}
+	return result{op: op, status: status, latency: latency, err: err}
+}
+
+// remember сохраняет созданный ID для последующих переходов и удалений
+func (w *synthWorker) remember(shortURL string) {
+	id := shortURL[strings.LastIndex(shortURL, "/")+1:]
+	w.own = append(w.own, id)
+	w.pool.add(id)
+}
+
+// statusOf восстанавливает HTTP статус по результату вызова клиента
+func statusOf(op string, err error) int {
+	var statusErr *client.StatusError
+	switch {
+	case err == nil:
+		switch op {
+		case opShorten, opBatch:
+			return http.StatusCreated
+		case opRedirect:
+			return http.StatusTemporaryRedirect
+		case opDelete:
+			return http.StatusAccepted
+		default:
+			return http.StatusOK
+		}
+	case errors.Is(err, client.ErrConflict):
+		return http.StatusConflict
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode
+	default:
+		return 0
+	}
+}
+
+// expected сообщает, является ли статус штатным ответом, а не ошибкой
+// 409 и 410 возможны при конкурентных удалениях и повторных сокращениях
+func expected(status int) bool {
+	return status != 0 && (status < 400 || status == http.StatusConflict || status == http.StatusGone)
+}
+
+// String возвращает распределение в виде op=weight, отсортированное по имени
+func (m mix) String() string {
+	parts := make([]string, len(m.ops))
+	for i, op := range m.ops {
+		parts[i] = fmt.Sprintf("%s=%d", op, m.weights[i])
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
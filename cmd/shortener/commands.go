@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/transfer"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runCommand выполняет служебную команду, переданную после флагов конфигурации
+// Например: shortener -f /tmp/short-url-db.json compact
+func runCommand(cfg *config.Config, args []string) error {
+	switch args[0] {
+	case "compact":
+		return runCompact(cfg)
+	case "export":
+		return runExport(cfg, args[1:])
+	case "import":
+		return runImport(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runCompact вручную уплотняет файловое хранилище
+// Пока файл открыт работающим сервером, команда завершается ошибкой storage.ErrLocked:
+// сервер держит старый файл открытым и продолжил бы писать в него
+func runCompact(cfg *config.Config) error {
+	if cfg.FileStoragePath == "" {
+		return fmt.Errorf("file storage path is not set")
+	}
+
+	s, err := storage.NewFileStorage(cfg.FileStoragePath, storage.WithCompactionPolicy(storage.CompactionPolicy{}))
+	if err != nil {
+		return err
+	}
+	fs := s.(*storage.FileStorage)
+	defer fs.Close()
+
+	before, _ := fs.Stats()
+	if err := fs.Compact(); err != nil {
+		return err
+	}
+	after, _ := fs.Stats()
+
+	fmt.Printf("Compacted %s: %d -> %d records\n", cfg.FileStoragePath, before, after)
+	return nil
+}
+
+// runExport выгружает все ссылки хранилища из конфигурации
+// Например: shortener -f /tmp/short-url-db.json export -format csv -o dump.csv
+func runExport(cfg *config.Config, args []string) error {
+	fset := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fset.String("format", transfer.FormatJSONL, "output format: jsonl or csv")
+	output := fset.String("o", "", "output file (default stdout)")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	src, closeStore, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc, err := transfer.NewEncoder(w, *format)
+	if err != nil {
+		return err
+	}
+	n, err := transfer.Export(src, enc, func(n int) {
+		fmt.Fprintf(os.Stderr, "exported %d records\n", n)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d records\n", n)
+	return nil
+}
+
+// runImport загружает ссылки в хранилище из конфигурации
+// Например: shortener -d postgres://... import -i dump.jsonl -dry-run
+func runImport(cfg *config.Config, args []string) error {
+	fset := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fset.String("format", transfer.FormatJSONL, "input format: jsonl or csv")
+	input := fset.String("i", "", "input file (default stdin)")
+	dryRun := fset.Bool("dry-run", false, "only report changes without writing them")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	dec, err := transfer.NewDecoder(r, *format)
+	if err != nil {
+		return err
+	}
+
+	dst, closeStore, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	stats, err := transfer.Import(dst, dec, transfer.ImportOptions{
+		DryRun: *dryRun,
+		Progress: func(s transfer.ImportStats) {
+			fmt.Fprintf(os.Stderr, "imported %d records\n", s.Read)
+		},
+	})
+	if *dryRun {
+		fmt.Fprint(os.Stderr, "Dry run: ")
+	}
+	fmt.Fprintf(os.Stderr, "read %d, created %d, updated %d, skipped %d, conflicts %d\n",
+		stats.Read, stats.Created, stats.Updated, stats.Skipped, stats.Conflicts)
+	return err
+}
+
+// openStorage открывает постоянное хранилище из конфигурации: PostgreSQL, если задан DSN,
+// иначе файловое хранилище. Возвращает функцию для освобождения ресурсов
+func openStorage(cfg *config.Config) (storage.Storage, func(), error) {
+	if cfg.DatabaseDSN != "" {
+		pool, err := pgxpool.New(context.Background(), cfg.DatabaseDSN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("DB connection failed: %w", err)
+		}
+		s, err := storage.NewPostgresStorage(pool)
+		if err != nil {
+			pool.Close()
+			return nil, nil, err
+		}
+		return s, pool.Close, nil
+	}
+
+	if cfg.FileStoragePath != "" {
+		s, err := storage.NewFileStorage(cfg.FileStoragePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, func() { s.(*storage.FileStorage).Close() }, nil
+	}
+
+	return nil, nil, fmt.Errorf("neither database DSN nor file storage path is set")
+}
@@ -3,12 +3,36 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
+	"time"
 )
 
 const (
-	defaultAddress     = "localhost:8080"
-	defaultBaseURL     = "http://localhost:8080"
-	defaultStoragePath = "/tmp/short-url-db.json"
+	defaultAddress      = "localhost:8080"
+	defaultBaseURL      = "http://localhost:8080"
+	defaultStoragePath  = "/tmp/short-url-db.json"
+	defaultCompactSize  = 64 << 20
+	defaultCompactRatio = 0.5
+	defaultSyncMode     = "none"
+	defaultSyncInterval = 100
+	defaultCacheSize    = 10000
+	defaultCacheTTL     = time.Minute
+	defaultNegativeTTL  = 5 * time.Second
+	defaultIDStrategy   = "random"
+	defaultIDLength     = 8
+	defaultCaptureSize  = 100 << 20
+	defaultCaptureBody  = 4096
+	defaultUnlockTTL    = 15 * time.Minute
+	defaultMaxAttempts  = 5
+	defaultLockout      = 15 * time.Minute
+	defaultInterstitial = 5 * time.Second
+	defaultClickFlush   = 5 * time.Second
+	defaultMilestones   = "100,1000,10000,100000,1000000"
+	defaultWebhookTries = 8
+	defaultWebhookWait  = 10 * time.Second
+	defaultEventsBuffer = 1000
+	defaultHeartbeat    = 15 * time.Second
+	defaultBatchMax     = 100000
 )
 
 // Config содержит конфигурационные параметры сервиса сокращения URL
@@ -18,6 +42,45 @@ type Config struct {
 	FileStoragePath string // Путь к файлу для хранения данных (если используется файловое хранилище)
 	DatabaseDSN     string // Строка подключения к PostgreSQL (если используется база данных)
 	EnablePprof     bool   // Включение pprof сервера (только для разработки)
+
+	FileCompactSize  int64   // Размер файла хранилища в байтах, после которого запускается уплотнение
+	FileCompactRatio float64 // Доля устаревших записей в файле, после которой запускается уплотнение
+
+	FileSyncMode     string        // Режим синхронизации файлового хранилища с диском: none, always, group
+	FileSyncInterval time.Duration // Интервал групповой синхронизации (режим group)
+
+	CacheSize        int           // Размер кэша ссылок в записях (0 - кэш отключен)
+	CacheTTL         time.Duration // Время жизни найденных ссылок в кэше
+	CacheNegativeTTL time.Duration // Время жизни промахов в кэше
+
+	IDStrategy string // Стратегия генерации сокращенных ID: random, sequential, hash
+	IDLength   int    // Начальная длина сокращенных ID
+	IDSalt     string // Соль для перемешивания алфавита стратегии sequential
+
+	CaptureFile    string  // Файл журнала трафика (пусто - запись отключена)
+	CaptureSample  float64 // Доля записываемых запросов от 0 до 1
+	CaptureMaxSize int64   // Размер файла журнала трафика, после которого он ротируется
+	CaptureMaxBody int     // Максимальная длина сохраняемых тел запроса и ответа
+
+	LinkSecret      string        // Ключ подписи cookie доступа к защищенным ссылкам (пусто - случайный)
+	LinkUnlockTTL   time.Duration // Время жизни cookie доступа к защищенной ссылке
+	LinkMaxAttempts int           // Неудачных попыток ввода пароля ссылки до блокировки
+	LinkLockout     time.Duration // Окно подсчета неудачных попыток и длительность блокировки
+
+	Interstitial      bool          // Показывать страницу "вы покидаете сайт" перед каждым перенаправлением
+	InterstitialDelay time.Duration // Обратный отсчет страницы перед перенаправлением
+
+	ClickFlushInterval time.Duration // Интервал сохранения накопленных переходов по ссылкам
+
+	WebhookMilestones  string        // Пороги переходов для событий link.clicks через запятую (пусто - без событий)
+	WebhookMaxAttempts int           // Попыток доставки события вебхуку
+	WebhookTimeout     time.Duration // Таймаут запроса к получателю вебхука
+	WebhookPrivate     bool          // Разрешить вебхуки на адреса внутренней сети (только для разработки)
+
+	EventsHistory   int           // Событий в буфере для возобновления потока событий
+	EventsHeartbeat time.Duration // Период комментариев, поддерживающих поток событий открытым
+
+	BatchMaxSize int // Максимальное количество ссылок в одном пакетном запросе (0 - без ограничения)
 }
 
 // NewConfig создает новый экземпляр конфигурации, читая параметры из:
@@ -31,6 +94,34 @@ type Config struct {
 // - FILE_STORAGE_PATH: путь к файлу хранилища
 // - DATABASE_DSN: строка подключения к PostgreSQL
 // - ENABLE_PPROF: включение pprof сервера (true/false, только для разработки)
+// - FILE_COMPACT_SIZE: размер файла хранилища в байтах для запуска уплотнения (0 - отключено)
+// - FILE_COMPACT_RATIO: доля устаревших записей для запуска уплотнения (0 - отключено)
+// - FILE_STORAGE_SYNC: режим синхронизации файла с диском (none, always, group)
+// - FILE_STORAGE_SYNC_INTERVAL: интервал групповой синхронизации в миллисекундах
+// - CACHE_SIZE: размер кэша ссылок в записях (0 - кэш отключен)
+// - CACHE_TTL: время жизни найденных ссылок в кэше (например, 1m)
+// - CACHE_NEGATIVE_TTL: время жизни промахов в кэше (например, 5s)
+// - ID_STRATEGY: стратегия генерации сокращенных ID (random, sequential, hash)
+// - ID_LENGTH: начальная длина сокращенных ID
+// - ID_SALT: соль для стратегии sequential
+// - CAPTURE_FILE: файл журнала трафика (пусто - запись отключена)
+// - CAPTURE_SAMPLE: доля записываемых запросов от 0 до 1
+// - CAPTURE_MAX_SIZE: размер файла журнала в байтах, после которого он ротируется
+// - CAPTURE_MAX_BODY: максимальная длина сохраняемых тел запроса и ответа
+// - LINK_SECRET: ключ подписи cookie доступа к защищенным паролем ссылкам
+// - LINK_UNLOCK_TTL: время жизни cookie доступа (например, 15m)
+// - LINK_MAX_ATTEMPTS: неудачных попыток ввода пароля ссылки до блокировки
+// - LINK_LOCKOUT: окно подсчета неудачных попыток и длительность блокировки
+// - INTERSTITIAL: страница "вы покидаете сайт" перед каждым перенаправлением (true/false)
+// - INTERSTITIAL_DELAY: обратный отсчет этой страницы (например, 5s)
+// - CLICK_FLUSH_INTERVAL: интервал сохранения переходов по ссылкам (например, 5s)
+// - WEBHOOK_MILESTONES: пороги переходов для событий link.clicks через запятую
+// - WEBHOOK_MAX_ATTEMPTS: попыток доставки события вебхуку
+// - WEBHOOK_TIMEOUT: таймаут запроса к получателю вебхука (например, 10s)
+// - WEBHOOK_ALLOW_PRIVATE: разрешить вебхуки на адреса внутренней сети (true/false, только для разработки)
+// - EVENTS_HISTORY: событий в буфере для возобновления потока событий по Last-Event-ID
+// - EVENTS_HEARTBEAT: период комментариев в потоке событий (например, 15s)
+// - BATCH_MAX_SIZE: максимальное количество ссылок в пакетном запросе (0 - без ограничения)
 //
 // Поддерживаемые флаги командной строки:
 // - -a: адрес сервера
@@ -38,12 +129,70 @@ type Config struct {
 // - -f: путь к файлу хранилища
 // - -d: строка подключения к PostgreSQL
 // - -pprof: включение pprof сервера (только для разработки)
+// - -compact-size: размер файла хранилища в байтах для запуска уплотнения
+// - -compact-ratio: доля устаревших записей для запуска уплотнения
+// - -file-sync: режим синхронизации файла с диском (none, always, group)
+// - -file-sync-interval: интервал групповой синхронизации в миллисекундах
+// - -cache-size: размер кэша ссылок в записях
+// - -cache-ttl: время жизни найденных ссылок в кэше
+// - -cache-negative-ttl: время жизни промахов в кэше
+// - -id-strategy: стратегия генерации сокращенных ID
+// - -id-length: начальная длина сокращенных ID
+// - -id-salt: соль для стратегии sequential
+// - -capture-file: файл журнала трафика
+// - -capture-sample: доля записываемых запросов
+// - -capture-max-size: размер файла журнала в байтах для ротации
+// - -capture-max-body: максимальная длина сохраняемых тел
+// - -link-secret: ключ подписи cookie доступа к защищенным ссылкам
+// - -link-unlock-ttl: время жизни cookie доступа
+// - -link-max-attempts: неудачных попыток ввода пароля до блокировки
+// - -link-lockout: окно подсчета неудачных попыток
+// - -interstitial: страница "вы покидаете сайт" перед каждым перенаправлением
+// - -interstitial-delay: обратный отсчет этой страницы
+// - -click-flush-interval: интервал сохранения переходов по ссылкам
+// - -webhook-milestones: пороги переходов для событий link.clicks
+// - -webhook-max-attempts: попыток доставки события вебхуку
+// - -webhook-timeout: таймаут запроса к получателю вебхука
+// - -webhook-allow-private: разрешить вебхуки на адреса внутренней сети
+// - -events-history: событий в буфере для возобновления потока событий
+// - -events-heartbeat: период комментариев в потоке событий
+// - -batch-max-size: максимальное количество ссылок в пакетном запросе
+//
+// Аргументы после флагов (flag.Args) трактуются как служебная команда, например compact
 func NewConfig() *Config {
 	addressFlag := flag.String("a", defaultAddress, "http service address")
 	baseURLFlag := flag.String("b", defaultBaseURL, "http base url")
 	filePathFlag := flag.String("f", defaultStoragePath, "storage path")
 	dsnFlag := flag.String("d", "", "PostgreSQL DSN")
 	pprofFlag := flag.Bool("pprof", false, "enable pprof server (development only)")
+	compactSizeFlag := flag.Int64("compact-size", defaultCompactSize, "file storage size in bytes that triggers compaction (0 disables)")
+	compactRatioFlag := flag.Float64("compact-ratio", defaultCompactRatio, "garbage ratio of file storage that triggers compaction (0 disables)")
+	syncModeFlag := flag.String("file-sync", defaultSyncMode, "file storage fsync mode: none, always or group")
+	syncIntervalFlag := flag.Int("file-sync-interval", defaultSyncInterval, "group fsync interval in milliseconds")
+	cacheSizeFlag := flag.Int("cache-size", defaultCacheSize, "link cache size in entries (0 disables cache)")
+	cacheTTLFlag := flag.Duration("cache-ttl", defaultCacheTTL, "link cache TTL")
+	cacheNegativeTTLFlag := flag.Duration("cache-negative-ttl", defaultNegativeTTL, "link cache TTL for misses")
+	idStrategyFlag := flag.String("id-strategy", defaultIDStrategy, "short ID strategy: random, sequential or hash")
+	idLengthFlag := flag.Int("id-length", defaultIDLength, "initial short ID length")
+	idSaltFlag := flag.String("id-salt", "", "alphabet salt for sequential short IDs")
+	captureFileFlag := flag.String("capture-file", "", "record sampled traffic to this JSONL file (empty disables)")
+	captureSampleFlag := flag.Float64("capture-sample", 1, "fraction of requests to record, from 0 to 1")
+	captureMaxSizeFlag := flag.Int64("capture-max-size", defaultCaptureSize, "traffic log size in bytes that triggers rotation")
+	captureMaxBodyFlag := flag.Int("capture-max-body", defaultCaptureBody, "max recorded request and response body length")
+	linkSecretFlag := flag.String("link-secret", "", "key for signing access cookies of password-protected links (empty - random on every start)")
+	linkUnlockTTLFlag := flag.Duration("link-unlock-ttl", defaultUnlockTTL, "access cookie lifetime for password-protected links")
+	linkMaxAttemptsFlag := flag.Int("link-max-attempts", defaultMaxAttempts, "failed password attempts per link before lockout")
+	linkLockoutFlag := flag.Duration("link-lockout", defaultLockout, "window for counting failed password attempts per link")
+	interstitialFlag := flag.Bool("interstitial", false, "show a \"you are leaving\" page before every redirect")
+	interstitialDelayFlag := flag.Duration("interstitial-delay", defaultInterstitial, "countdown of the \"you are leaving\" page")
+	clickFlushFlag := flag.Duration("click-flush-interval", defaultClickFlush, "interval for saving accumulated link clicks")
+	webhookMilestonesFlag := flag.String("webhook-milestones", defaultMilestones, "comma-separated click counts that trigger link.clicks webhook events (empty disables)")
+	webhookMaxAttemptsFlag := flag.Int("webhook-max-attempts", defaultWebhookTries, "webhook delivery attempts before giving up")
+	webhookTimeoutFlag := flag.Duration("webhook-timeout", defaultWebhookWait, "timeout of a single webhook request")
+	webhookPrivateFlag := flag.Bool("webhook-allow-private", false, "allow webhooks to loopback, private and link-local addresses (development only)")
+	eventsHistoryFlag := flag.Int("events-history", defaultEventsBuffer, "number of recent events kept for resuming event streams")
+	eventsHeartbeatFlag := flag.Duration("events-heartbeat", defaultHeartbeat, "keep-alive comment interval of event streams")
+	batchMaxSizeFlag := flag.Int("batch-max-size", defaultBatchMax, "max number of links in a batch request (0 disables the limit)")
 	flag.Parse()
 
 	addr := os.Getenv("SERVER_ADDRESS")
@@ -71,11 +220,177 @@ func NewConfig() *Config {
 		enablePprof = pprofEnv == "true" || pprofEnv == "1"
 	}
 
+	compactSize := *compactSizeFlag
+	if v, err := strconv.ParseInt(os.Getenv("FILE_COMPACT_SIZE"), 10, 64); err == nil {
+		compactSize = v
+	}
+
+	compactRatio := *compactRatioFlag
+	if v, err := strconv.ParseFloat(os.Getenv("FILE_COMPACT_RATIO"), 64); err == nil {
+		compactRatio = v
+	}
+
+	syncMode := os.Getenv("FILE_STORAGE_SYNC")
+	if syncMode == "" {
+		syncMode = *syncModeFlag
+	}
+
+	syncInterval := *syncIntervalFlag
+	if v, err := strconv.Atoi(os.Getenv("FILE_STORAGE_SYNC_INTERVAL")); err == nil {
+		syncInterval = v
+	}
+
+	cacheSize := *cacheSizeFlag
+	if v, err := strconv.Atoi(os.Getenv("CACHE_SIZE")); err == nil {
+		cacheSize = v
+	}
+
+	cacheTTL := *cacheTTLFlag
+	if v, err := time.ParseDuration(os.Getenv("CACHE_TTL")); err == nil {
+		cacheTTL = v
+	}
+
+	cacheNegativeTTL := *cacheNegativeTTLFlag
+	if v, err := time.ParseDuration(os.Getenv("CACHE_NEGATIVE_TTL")); err == nil {
+		cacheNegativeTTL = v
+	}
+
+	idStrategy := os.Getenv("ID_STRATEGY")
+	if idStrategy == "" {
+		idStrategy = *idStrategyFlag
+	}
+
+	idLength := *idLengthFlag
+	if v, err := strconv.Atoi(os.Getenv("ID_LENGTH")); err == nil {
+		idLength = v
+	}
+
+	idSalt := os.Getenv("ID_SALT")
+	if idSalt == "" {
+		idSalt = *idSaltFlag
+	}
+
+	captureFile := os.Getenv("CAPTURE_FILE")
+	if captureFile == "" {
+		captureFile = *captureFileFlag
+	}
+
+	captureSample := *captureSampleFlag
+	if v, err := strconv.ParseFloat(os.Getenv("CAPTURE_SAMPLE"), 64); err == nil {
+		captureSample = v
+	}
+
+	captureMaxSize := *captureMaxSizeFlag
+	if v, err := strconv.ParseInt(os.Getenv("CAPTURE_MAX_SIZE"), 10, 64); err == nil {
+		captureMaxSize = v
+	}
+
+	captureMaxBody := *captureMaxBodyFlag
+	if v, err := strconv.Atoi(os.Getenv("CAPTURE_MAX_BODY")); err == nil {
+		captureMaxBody = v
+	}
+
+	linkSecret := os.Getenv("LINK_SECRET")
+	if linkSecret == "" {
+		linkSecret = *linkSecretFlag
+	}
+
+	linkUnlockTTL := *linkUnlockTTLFlag
+	if v, err := time.ParseDuration(os.Getenv("LINK_UNLOCK_TTL")); err == nil {
+		linkUnlockTTL = v
+	}
+
+	linkMaxAttempts := *linkMaxAttemptsFlag
+	if v, err := strconv.Atoi(os.Getenv("LINK_MAX_ATTEMPTS")); err == nil {
+		linkMaxAttempts = v
+	}
+
+	linkLockout := *linkLockoutFlag
+	if v, err := time.ParseDuration(os.Getenv("LINK_LOCKOUT")); err == nil {
+		linkLockout = v
+	}
+
+	interstitial := *interstitialFlag
+	if v := os.Getenv("INTERSTITIAL"); v != "" {
+		interstitial = v == "true" || v == "1"
+	}
+
+	interstitialDelay := *interstitialDelayFlag
+	if v, err := time.ParseDuration(os.Getenv("INTERSTITIAL_DELAY")); err == nil {
+		interstitialDelay = v
+	}
+
+	clickFlush := *clickFlushFlag
+	if v, err := time.ParseDuration(os.Getenv("CLICK_FLUSH_INTERVAL")); err == nil {
+		clickFlush = v
+	}
+	webhookMilestones := *webhookMilestonesFlag
+	if v, ok := os.LookupEnv("WEBHOOK_MILESTONES"); ok {
+		webhookMilestones = v
+	}
+	webhookMaxAttempts := *webhookMaxAttemptsFlag
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil {
+		webhookMaxAttempts = v
+	}
+	webhookTimeout := *webhookTimeoutFlag
+	if v, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil {
+		webhookTimeout = v
+	}
+	webhookPrivate := *webhookPrivateFlag
+	if v := os.Getenv("WEBHOOK_ALLOW_PRIVATE"); v != "" {
+		webhookPrivate = v == "true" || v == "1"
+	}
+	eventsHistory := *eventsHistoryFlag
+	if v, err := strconv.Atoi(os.Getenv("EVENTS_HISTORY")); err == nil {
+		eventsHistory = v
+	}
+	eventsHeartbeat := *eventsHeartbeatFlag
+	if v, err := time.ParseDuration(os.Getenv("EVENTS_HEARTBEAT")); err == nil {
+		eventsHeartbeat = v
+	}
+	batchMaxSize := *batchMaxSizeFlag
+	if v, err := strconv.Atoi(os.Getenv("BATCH_MAX_SIZE")); err == nil {
+		batchMaxSize = v
+	}
+
 	return &Config{
-		Address:         addr,
-		BaseURL:         baseURL,
-		FileStoragePath: fileStorage,
-		DatabaseDSN:     dsn,
-		EnablePprof:     enablePprof,
+		Address:          addr,
+		BaseURL:          baseURL,
+		FileStoragePath:  fileStorage,
+		DatabaseDSN:      dsn,
+		EnablePprof:      enablePprof,
+		FileCompactSize:  compactSize,
+		FileCompactRatio: compactRatio,
+		FileSyncMode:     syncMode,
+		FileSyncInterval: time.Duration(syncInterval) * time.Millisecond,
+		CacheSize:        cacheSize,
+		CacheTTL:         cacheTTL,
+		CacheNegativeTTL: cacheNegativeTTL,
+		IDStrategy:       idStrategy,
+		IDLength:         idLength,
+		IDSalt:           idSalt,
+		CaptureFile:      captureFile,
+		CaptureSample:    captureSample,
+		CaptureMaxSize:   captureMaxSize,
+		CaptureMaxBody:   captureMaxBody,
+		LinkSecret:       linkSecret,
+		LinkUnlockTTL:    linkUnlockTTL,
+		LinkMaxAttempts:  linkMaxAttempts,
+		LinkLockout:      linkLockout,
+
+		Interstitial:      interstitial,
+		InterstitialDelay: interstitialDelay,
+
+		ClickFlushInterval: clickFlush,
+
+		WebhookMilestones:  webhookMilestones,
+		WebhookMaxAttempts: webhookMaxAttempts,
+		WebhookTimeout:     webhookTimeout,
+		WebhookPrivate:     webhookPrivate,
+
+		EventsHistory:   eventsHistory,
+		EventsHeartbeat: eventsHeartbeat,
+
+		BatchMaxSize: batchMaxSize,
 	}
 }
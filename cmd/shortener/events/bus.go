@@ -0,0 +1,172 @@
+// Package events передает события ссылок подписчикам внутри процесса.
+//
+// Bus хранит последние события в кольцевом буфере, чтобы переподключившийся
+// подписчик мог получить пропущенное по идентификатору последнего события.
+// Публикация никогда не блокируется: подписчик, не успевающий разбирать
+// свою очередь, отключается и может переподключиться с возобновлением.
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// Параметры шины по умолчанию
+const (
+	DefaultHistorySize    = 1000 // Событий в буфере для возобновления
+	DefaultQueueSize      = 64   // Событий в очереди подписчика
+	DefaultMaxSubscribers = 16   // Одновременных подписок одного пользователя
+)
+
+// ErrTooManySubscribers возвращается, если у пользователя уже DefaultMaxSubscribers подписок
+var ErrTooManySubscribers = errors.New("too many event subscribers")
+
+// Event событие шины с порядковым идентификатором
+type Event struct {
+	ID uint64 // Идентификатор, возрастающий в порядке публикации
+	models.LinkEvent
+}
+
+// Bus шина событий ссылок с подписками по пользователям
+type Bus struct {
+	mu      sync.Mutex
+	lastID  uint64                                // Идентификатор последнего опубликованного события
+	history []Event                               // Кольцевой буфер последних событий
+	next    int                                   // Позиция следующей записи в history
+	size    int                                   // Количество событий в history
+	subs    map[string]map[*Subscription]struct{} // Пользователь -> подписки
+}
+
+// Subscription подписка на события пользователя
+type Subscription struct {
+	C       <-chan Event // События в порядке публикации; закрывается при отписке или переполнении
+	Backlog []Event      // События из буфера, опубликованные после запрошенного идентификатора
+	Gap     bool         // Часть событий после запрошенного идентификатора уже недоступна
+
+	bus        *Bus
+	userID     string
+	ch         chan Event
+	overflowed bool // Подписка отключена из-за переполнения очереди (защищено bus.mu)
+}
+
+// NewBus создает шину, хранящую historySize последних событий
+// (0 или меньше - DefaultHistorySize)
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		// Идентификаторы начинаются с текущего времени в микросекундах, поэтому
+		// идентификаторы предыдущего запуска сервиса меньше любых текущих
+		lastID:  uint64(time.Now().UnixMicro()),
+		history: make([]Event, historySize),
+		subs:    make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish публикует событие и передает его подпискам владельца ссылки
+// Подписки с заполненной очередью отключаются. Подходит как обработчик событий ссылок
+func (b *Bus) Publish(e models.LinkEvent) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	event := Event{ID: b.lastID, LinkEvent: e}
+	b.history[b.next] = event
+	b.next = (b.next + 1) % len(b.history)
+	b.size = min(b.size+1, len(b.history))
+
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.overflowed = true
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribe подписывает на события пользователя userID
+// Если since не 0, в Backlog попадают события пользователя из буфера с идентификатором
+// больше since, а Gap сообщает, что часть из них уже вытеснена из буфера или since
+// неизвестен (например, выдан до перезапуска сервиса)
+func (b *Bus) Subscribe(userID string, since uint64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs[userID]) >= DefaultMaxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	ch := make(chan Event, DefaultQueueSize)
+	sub := &Subscription{C: ch, bus: b, userID: userID, ch: ch}
+	if since != 0 {
+		sub.Backlog, sub.Gap = b.backlogLocked(userID, since)
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub, nil
+}
+
+// backlogLocked возвращает события пользователя из буфера после since
+// и признак того, что часть событий после since недоступна
+func (b *Bus) backlogLocked(userID string, since uint64) ([]Event, bool) {
+	oldest := b.lastID - uint64(b.size) + 1
+	if since > b.lastID || since+1 < oldest {
+		return nil, true
+	}
+	var backlog []Event
+	for i := 0; i < b.size; i++ {
+		e := b.history[(b.next-b.size+i+len(b.history))%len(b.history)]
+		if e.ID > since && e.UserID == userID {
+			backlog = append(backlog, e)
+		}
+	}
+	return backlog, false
+}
+
+// removeLocked удаляет подписку и закрывает ее канал
+func (b *Bus) removeLocked(sub *Subscription) {
+	subs, ok := b.subs[sub.userID]
+	if _, subscribed := subs[sub]; !ok || !subscribed {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	close(sub.ch)
+}
+
+// Close отключает все подписки, например при остановке сервера, чтобы потоки
+// событий завершились; клиенты переподключатся с возобновлением
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Close отменяет подписку; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.removeLocked(s)
+}
+
+// Overflowed сообщает, что подписка отключена, потому что подписчик не успевал
+// разбирать события
+func (s *Subscription) Overflowed() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.overflowed
+}
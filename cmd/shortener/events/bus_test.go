@@ -0,0 +1,141 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"uno/cmd/shortener/models"
+)
+
+// linkEvent создает событие создания ссылки shortID пользователя userID
+func linkEvent(userID, shortID string) models.LinkEvent {
+	return models.LinkEvent{Type: models.EventLinkCreated, UserID: userID, ShortID: shortID}
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	bus := NewBus(10)
+	sub, err := bus.Subscribe("user1", 0)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	bus.Publish(linkEvent("user1", "a"))
+	bus.Publish(linkEvent("user2", "b"))
+	bus.Publish(linkEvent("user1", "c"))
+
+	first, second := <-sub.C, <-sub.C
+	if first.ShortID != "a" || second.ShortID != "c" || second.ID != first.ID+2 || first.Time.IsZero() {
+		t.Errorf("unexpected events %+v %+v", first, second)
+	}
+	if len(sub.C) != 0 {
+		t.Error("expected events of other users to be skipped")
+	}
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("expected closed subscription channel")
+	}
+}
+
+func TestBus_Resume(t *testing.T) {
+	bus := NewBus(4)
+	sub, _ := bus.Subscribe("user1", 0)
+	bus.Publish(linkEvent("user1", "a"))
+	first := <-sub.C
+	sub.Close()
+
+	bus.Publish(linkEvent("user2", "b"))
+	bus.Publish(linkEvent("user1", "c"))
+
+	resumed, _ := bus.Subscribe("user1", first.ID)
+	defer resumed.Close()
+	if resumed.Gap || len(resumed.Backlog) != 1 || resumed.Backlog[0].ShortID != "c" {
+		t.Errorf("unexpected backlog %+v gap %v", resumed.Backlog, resumed.Gap)
+	}
+
+	// Событие после first вытеснено из буфера
+	for i := 0; i < 4; i++ {
+		bus.Publish(linkEvent("user1", fmt.Sprintf("x%d", i)))
+	}
+	late, _ := bus.Subscribe("user1", first.ID)
+	defer late.Close()
+	if !late.Gap || len(late.Backlog) != 0 {
+		t.Errorf("expected gap for evicted events, got %+v", late.Backlog)
+	}
+
+	// Идентификатор из будущего (например, предыдущего запуска) неизвестен
+	unknown, _ := bus.Subscribe("user1", first.ID+1000)
+	defer unknown.Close()
+	if !unknown.Gap {
+		t.Error("expected gap for unknown event id")
+	}
+
+	// Идентификаторы нового запуска больше идентификаторов предыдущего
+	if restarted, _ := NewBus(4).Subscribe("user1", first.ID); !restarted.Gap {
+		t.Error("expected gap for event id of a previous run")
+	}
+}
+
+func TestBus_SlowSubscriber(t *testing.T) {
+	bus := NewBus(DefaultQueueSize * 2)
+	slow, _ := bus.Subscribe("user1", 0)
+	fast, _ := bus.Subscribe("user1", 0)
+	defer fast.Close()
+
+	var last Event
+	for i := 0; i <= DefaultQueueSize; i++ {
+		bus.Publish(linkEvent("user1", fmt.Sprintf("l%d", i)))
+		last = <-fast.C
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != DefaultQueueSize || !slow.Overflowed() || fast.Overflowed() {
+		t.Errorf("expected slow subscriber to be dropped after %d events, got %d", DefaultQueueSize, received)
+	}
+
+	// Отключенный подписчик возобновляет поток без потерь
+	resumed, _ := bus.Subscribe("user1", last.ID-1)
+	defer resumed.Close()
+	if resumed.Gap || len(resumed.Backlog) != 1 || resumed.Backlog[0].ID != last.ID {
+		t.Errorf("unexpected backlog after overflow: %+v", resumed.Backlog)
+	}
+}
+
+func TestBus_MaxSubscribers(t *testing.T) {
+	bus := NewBus(0)
+	subs := make([]*Subscription, 0, DefaultMaxSubscribers)
+	for i := 0; i < DefaultMaxSubscribers; i++ {
+		sub, err := bus.Subscribe("user1", 0)
+		if err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		subs = append(subs, sub)
+	}
+	if _, err := bus.Subscribe("user1", 0); !errors.Is(err, ErrTooManySubscribers) {
+		t.Errorf("expected ErrTooManySubscribers, got %v", err)
+	}
+	subs[0].Close()
+	if sub, err := bus.Subscribe("user1", 0); err != nil {
+		t.Errorf("expected subscription after close, got %v", err)
+	} else {
+		sub.Close()
+	}
+}
+
+func TestBus_Close(t *testing.T) {
+	bus := NewBus(0)
+	sub1, _ := bus.Subscribe("user1", 0)
+	sub2, _ := bus.Subscribe("user2", 0)
+	bus.Close()
+	for _, sub := range []*Subscription{sub1, sub2} {
+		if _, ok := <-sub.C; ok || sub.Overflowed() {
+			t.Error("expected subscription to be closed")
+		}
+		sub.Close()
+	}
+}
@@ -50,3 +50,34 @@ func TestAPIShortenHandler_InvalidJSON(t *testing.T) {
 		t.Fatalf("expected %d for empty payload, got %d", http.StatusBadRequest, res.Code)
 	}
 }
+
+func TestAPIShortenHandler_Metadata(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	h := setupAPIShortenRouter(cfg, store)
+
+	body := `{"url":"https://foo.bar","title":"Foo","tags":["Docs"],"note":"read later"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	var entries []storage.Entry
+	_ = store.ForEach(func(e storage.Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if len(entries) != 1 || entries[0].Title != "Foo" || len(entries[0].Tags) != 1 || entries[0].Tags[0] != "docs" || entries[0].Note != "read later" {
+		t.Errorf("unexpected stored link: %+v", entries)
+	}
+
+	long := `{"url":"https://foo.baz","title":"` + strings.Repeat("x", 201) + `"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(long))
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	if res.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for too long title, got %d", http.StatusBadRequest, res.Code)
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/utils"
+)
+
+// batchChunkSize количество ссылок, сохраняемых одним вызовом SaveBatch
+// при потоковой обработке больших пакетов
+const batchChunkSize = 1000
+
+// errBatchTooLarge возвращается, если в пакете больше ссылок, чем разрешено настройками
+var errBatchTooLarge = errors.New("batch is too large")
+
+// Ошибки отдельных ссылок пакета
+var (
+	errInvalidItem  = errors.New("invalid JSON")
+	errEmptyURL     = errors.New("empty URL")
+	errAliasTaken   = errors.New("alias is already taken")
+	errSaveFailed   = errors.New("failed to save link")
+	errSaveMetadata = errors.New("failed to save metadata")
+)
+
+// batchItem ссылка пакета, ожидающая сохранения
+type batchItem struct {
+	CorrelationID string
+	OriginalURL   string
+	Alias         string // Пользовательский сокращенный ID (пусто - сгенерировать)
+	Metadata      models.LinkMetadata
+	Options       models.LinkOptions
+	Err           error // Ошибка разбора или проверки; такая ссылка не сохраняется
+}
+
+// batchResult результат сохранения ссылки пакета
+type batchResult struct {
+	CorrelationID string
+	ShortID       string // Сокращенный ID новой ссылки или ссылки, уже сокращающей этот URL
+	Err           error
+}
+
+// saveChunk сохраняет часть пакета одним вызовом SaveBatch и возвращает результаты
+// в порядке items. Ошибки отдельных ссылок не мешают сохранению остальных:
+// URL, который уже сокращен, получает существующий ID и ошибку storage.ErrConflict,
+// занятый alias - errAliasTaken; ссылка, пропущенная SaveBatch, - errSaveFailed. Ссылки с параметрами перенаправления сохраняются по одной
+// вместе с параметрами (SaveLink), чтобы ссылка с паролем не оказалась доступной без него.
+// О сохраненных ссылках сообщается событиями в порядке items, пакетным обработчикам - одним вызовом
+func saveChunk(store storage.Storage, userID string, items []batchItem, o shortenOptions) []batchResult {
+	results := make([]batchResult, len(items))
+	pairs := make(map[string]string, len(items))
+	originals := make(map[string]string, len(items)) // Оригинальный URL -> ID внутри части пакета
+	for i, item := range items {
+		res := &results[i]
+		res.CorrelationID = item.CorrelationID
+		originalURL := strings.TrimSpace(item.OriginalURL)
+		switch {
+		case item.Err != nil:
+			res.Err = item.Err
+			continue
+		case originalURL == "":
+			res.Err = errEmptyURL
+			continue
+		}
+		if existingID, ok := originals[originalURL]; ok {
+			res.ShortID, res.Err = existingID, storage.ErrConflict
+			continue
+		}
+		if existingID, ok := store.FindByOriginal(originalURL); ok {
+			res.ShortID, res.Err = existingID, storage.ErrConflict
+			continue
+		}
+
+		shortID := item.Alias
+		if shortID != "" {
+			if idExists(store, pairs)(shortID) {
+				res.Err = errAliasTaken
+				continue
+			}
+		} else {
+			var err error
+			if shortID, err = utils.NewShortID(originalURL, idExists(store, pairs)); err != nil {
+				res.Err = err
+				continue
+			}
+		}
+		pairs[shortID] = originalURL
+		originals[originalURL] = shortID
+		res.ShortID = shortID
+	}
+	if len(pairs) == 0 {
+		return results
+	}
+
+	batch := make(map[string]string, len(pairs))
+	for i, item := range items {
+		if results[i].Err == nil && item.Options.IsZero() {
+			batch[results[i].ShortID] = pairs[results[i].ShortID]
+		}
+	}
+	var batchErr error
+	if len(batch) > 0 {
+		batchErr = store.SaveBatch(batch, userID)
+	}
+	// Ссылки, ID или URL которых успели занять до записи, не сохранены; остальные сохранены
+	var skipped map[string]bool
+	if be := (*storage.BatchError)(nil); errors.As(batchErr, &be) {
+		skipped = make(map[string]bool, len(be.IDs))
+		for _, id := range be.IDs {
+			skipped[id] = true
+		}
+		batchErr = nil
+	}
+	var created []models.LinkEvent
+	for i, item := range items {
+		res := &results[i]
+		if res.Err != nil {
+			continue
+		}
+		switch {
+		case !item.Options.IsZero():
+			if err := store.SaveLink(res.ShortID, pairs[res.ShortID], userID, item.Metadata, item.Options); err != nil {
+				res.ShortID, res.Err = "", errSaveFailed
+				continue
+			}
+		case batchErr != nil:
+			res.ShortID, res.Err = "", errSaveFailed
+			continue
+		case skipped[res.ShortID]:
+			if existingID, ok := store.FindByOriginal(pairs[res.ShortID]); ok && existingID != res.ShortID {
+				res.ShortID, res.Err = existingID, storage.ErrConflict
+			} else {
+				res.ShortID, res.Err = "", errSaveFailed
+			}
+			continue
+		default:
+			if err := saveMetadata(store, userID, res.ShortID, item.Metadata); err != nil {
+				res.Err = errSaveMetadata
+			}
+		}
+		created = append(created, createdEvent(userID, res.ShortID, pairs[res.ShortID]))
+	}
+	o.createdAll(created)
+	return results
+}
+
+// flushChunk отправляет клиенту результаты сохраненной части пакета и сообщает,
+// стоит ли продолжать: после отключения клиента остальные части не сохраняются.
+// Клиенты, которые не могут принимать ответ по частям, получат его целиком
+func flushChunk(r *http.Request, rc *http.ResponseController) error {
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return r.Context().Err()
+}
+
+// batchDecoder читает JSON массив пакета по одному элементу,
+// не загружая тело запроса в память целиком
+type batchDecoder struct {
+	dec   *json.Decoder
+	limit int // Максимальное количество элементов (0 - без ограничения)
+	read  int // Количество прочитанных элементов
+}
+
+// newBatchDecoder начинает чтение JSON массива пакета из r
+func newBatchDecoder(r io.Reader, limit int) (*batchDecoder, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected JSON array, got %v", tok)
+	}
+	return &batchDecoder{dec: dec, limit: limit}, nil
+}
+
+// next читает следующий элемент пакета; после конца массива возвращает io.EOF
+// Элемент, который не удалось проверить, возвращается с заполненным Err,
+// ошибка же означает, что продолжить чтение пакета нельзя
+func (d *batchDecoder) next() (batchItem, error) {
+	if !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return batchItem{}, err
+		}
+		return batchItem{}, io.EOF
+	}
+	if d.limit > 0 && d.read >= d.limit {
+		return batchItem{}, fmt.Errorf("%w: more than %d links", errBatchTooLarge, d.limit)
+	}
+	d.read++
+
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return batchItem{}, err
+	}
+	var req models.BatchRequest
+	if err := req.UnmarshalJSON(raw); err != nil {
+		return batchItem{Err: errInvalidItem}, nil
+	}
+	item := batchItem{CorrelationID: req.CorrelationID, OriginalURL: req.OriginalURL, Metadata: req.LinkMetadata}
+	if err := item.Metadata.Normalize(); err != nil {
+		item.Err = err
+		return item, nil
+	}
+	item.Options, item.Err = linkOptions(req.Password, models.LinkOptions{
+		Interstitial: req.Interstitial,
+		RedirectCode: req.RedirectCode,
+		QueryPolicy:  req.QueryPolicy,
+	})
+	return item, nil
+}
+
+// nextChunk дочитывает в chunk элементы пакета, пока в нем не станет batchChunkSize
+// Вместе с прочитанными элементами возвращается ошибка, на которой чтение остановилось
+func (d *batchDecoder) nextChunk(chunk []batchItem) ([]batchItem, error) {
+	for len(chunk) < batchChunkSize {
+		item, err := d.next()
+		if err != nil {
+			return chunk, err
+		}
+		chunk = append(chunk, item)
+	}
+	return chunk, nil
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
 	"uno/cmd/shortener/config"
 	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
 	"uno/cmd/shortener/storage"
 
 	"github.com/go-chi/chi/v5"
@@ -32,6 +35,8 @@ func TestBatchShortenHandler(t *testing.T) {
 		{`[{"correlation_id":"1","original_url":"https://a.com"}]`, http.StatusCreated},
 		{`[]`, http.StatusBadRequest},
 		{`not-json`, http.StatusBadRequest},
+		{`{"correlation_id":"1"}`, http.StatusBadRequest},
+		{`[{"correlation_id":"1","original_url":"https://b.com"},{"correlation_id":`, http.StatusBadRequest},
 	}
 
 	for _, c := range cases {
@@ -44,3 +49,176 @@ func TestBatchShortenHandler(t *testing.T) {
 		}
 	}
 }
+
+// batchBody формирует JSON массив из n ссылок, начиная с https://example.com/{from}
+func batchBody(from, n int) string {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := from; i < from+n; i++ {
+		if i > from {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"correlation_id":"%d","original_url":"https://example.com/%d"}`, i, i)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// decodeBatch разбирает ответ пакетного сокращения
+func decodeBatch(t *testing.T, body []byte) models.BatchResponseList {
+	t.Helper()
+	var list models.BatchResponseList
+	if err := list.UnmarshalJSON(body); err != nil {
+		t.Fatalf("invalid batch response %q: %v", body, err)
+	}
+	return list
+}
+
+func TestBatchShortenHandler_Streaming(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	h := BatchShortenHandler(cfg, store)
+
+	n := batchChunkSize*2 + 7
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", batchBody(0, n), "user1")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	list := decodeBatch(t, rec.Body.Bytes())
+	if len(list) != n {
+		t.Fatalf("expected %d results, got %d", n, len(list))
+	}
+	for i, resp := range list {
+		if resp.CorrelationID != strconv.Itoa(i) || resp.Error != "" {
+			t.Fatalf("unexpected result %d: %+v", i, resp)
+		}
+		if original, _, _ := store.Get(strings.TrimPrefix(resp.ShortURL, cfg.BaseURL+"/")); original != "https://example.com/"+resp.CorrelationID {
+			t.Fatalf("result %d points to %q", i, original)
+		}
+	}
+}
+
+func TestBatchShortenHandler_ItemErrors(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("taken", "https://example.com/existing", "user2")
+	h := BatchShortenHandler(cfg, store)
+
+	body := `[
+		{"correlation_id":"1","original_url":"https://example.com/a"},
+		{"correlation_id":"2","original_url":"https://example.com/existing"},
+		{"correlation_id":"3","original_url":"https://example.com/b","redirect_code":303},
+		{"correlation_id":"4","original_url":" "},
+		"not an object",
+		{"correlation_id":"6","original_url":"https://example.com/a"}
+	]`
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", body, "user1")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	list := decodeBatch(t, rec.Body.Bytes())
+	if len(list) != 6 {
+		t.Fatalf("expected 6 results, got %+v", list)
+	}
+	if list[0].Error != "" || list[0].ShortURL == "" {
+		t.Errorf("unexpected result for a new link: %+v", list[0])
+	}
+	if list[1].ShortURL != "http://localhost:8080/taken" || list[1].Error != storage.ErrConflict.Error() {
+		t.Errorf("expected conflict with the existing link, got %+v", list[1])
+	}
+	if list[5].ShortURL != list[0].ShortURL || list[5].Error != storage.ErrConflict.Error() {
+		t.Errorf("expected conflict with a link of the same batch, got %+v", list[5])
+	}
+	for _, i := range []int{2, 3, 4} {
+		if list[i].ShortURL != "" || list[i].Error == "" {
+			t.Errorf("expected error for item %d, got %+v", i, list[i])
+		}
+	}
+}
+
+// skippingBatch хранилище, SaveBatch которого не сохраняет ссылку на URL skip,
+// как если бы ее ID или URL заняли до записи
+type skippingBatch struct {
+	storage.Storage
+	skip string
+}
+
+func (s *skippingBatch) SaveBatch(pairs map[string]string, userID string) error {
+	rest := make(map[string]string, len(pairs))
+	var skipped []string
+	for id, url := range pairs {
+		if url == s.skip {
+			skipped = append(skipped, id)
+			continue
+		}
+		rest[id] = url
+	}
+	if err := s.Storage.SaveBatch(rest, userID); err != nil {
+		return err
+	}
+	return &storage.BatchError{IDs: skipped}
+}
+
+func TestBatchShortenHandler_PartialBatch(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := &skippingBatch{Storage: storage.NewInMemoryStorage(), skip: "https://example.com/1"}
+	h := BatchShortenHandler(cfg, store)
+
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", batchBody(0, 3), "user1")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	list := decodeBatch(t, rec.Body.Bytes())
+	if len(list) != 3 {
+		t.Fatalf("expected 3 results, got %+v", list)
+	}
+	if list[1].ShortURL != "" || list[1].Error != errSaveFailed.Error() {
+		t.Errorf("expected the skipped link to fail, got %+v", list[1])
+	}
+	for _, i := range []int{0, 2} {
+		if list[i].Error != "" {
+			t.Errorf("expected item %d to be saved, got %+v", i, list[i])
+		}
+		if _, _, exists := store.Get(strings.TrimPrefix(list[i].ShortURL, cfg.BaseURL+"/")); !exists {
+			t.Errorf("item %d is not stored", i)
+		}
+	}
+}
+
+func TestBatchShortenHandler_MaxSize(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080", BatchMaxSize: 3}
+	h := BatchShortenHandler(cfg, storage.NewInMemoryStorage())
+	if rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", batchBody(0, 3), "user1"); rec.Code != http.StatusCreated {
+		t.Errorf("expected 201 at the limit, got %d", rec.Code)
+	}
+	if rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", batchBody(3, 4), "user1"); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 over the limit, got %d", rec.Code)
+	}
+
+	// Превышение, обнаруженное после начала ответа, завершает массив ошибкой
+	cfg.BatchMaxSize = batchChunkSize + 1
+	store := storage.NewInMemoryStorage()
+	h = BatchShortenHandler(cfg, store)
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", batchBody(0, batchChunkSize+2), "user1")
+	list := decodeBatch(t, rec.Body.Bytes())
+	if rec.Code != http.StatusCreated || len(list) != batchChunkSize+2 {
+		t.Fatalf("expected %d results, got %d %d", batchChunkSize+2, rec.Code, len(list))
+	}
+	last := list[len(list)-1]
+	if last.CorrelationID != "" || !strings.Contains(last.Error, "batch is too large") || list[batchChunkSize].Error != "" {
+		t.Errorf("expected trailing error after the limit, got %+v", list[batchChunkSize:])
+	}
+	if urls, _ := store.GetUserURLs("user1"); len(urls) != batchChunkSize+1 {
+		t.Errorf("expected %d saved links, got %d", batchChunkSize+1, len(urls))
+	}
+}
+
+func TestBatchShortenHandler_TruncatedBody(t *testing.T) {
+	h := BatchShortenHandler(&config.Config{BaseURL: "http://localhost:8080"}, storage.NewInMemoryStorage())
+	body := strings.TrimSuffix(batchBody(0, batchChunkSize+1), "]") + `,{"correlation_id":`
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch", body, "user1")
+	list := decodeBatch(t, rec.Body.Bytes())
+	if rec.Code != http.StatusCreated || len(list) != batchChunkSize+2 || !strings.HasPrefix(list[len(list)-1].Error, "invalid JSON") {
+		t.Errorf("expected saved items and a trailing error, got %d %d %+v", rec.Code, len(list), list[len(list)-1])
+	}
+}
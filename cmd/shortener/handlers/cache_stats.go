@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"uno/cmd/shortener/storage"
+)
+
+// CacheStatsHandler обрабатывает GET запросы для получения статистики кэша хранилища
+// Возвращает JSON с количеством попаданий, промахов, вытеснений и записей в кэше
+func CacheStatsHandler(cache *storage.CachedStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.Marshal(cache.Stats())
+		if err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"uno/cmd/shortener/storage"
+)
+
+func TestCacheStatsHandler(t *testing.T) {
+	cache := storage.NewCachedStorage(storage.NewInMemoryStorage(), storage.CacheOptions{Size: 10, TTL: time.Minute})
+	cache.Save("abc", "https://example.com", "user")
+	cache.Get("abc")
+	cache.Get("abc")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/internal/cache/stats", nil)
+	res := httptest.NewRecorder()
+	CacheStatsHandler(cache).ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, res.Code)
+	}
+
+	var stats storage.CacheStats
+	if err := json.Unmarshal(res.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"go.uber.org/zap"
+)
+
+// DefaultClickFlushInterval интервал сохранения накопленных переходов по умолчанию
+const DefaultClickFlushInterval = 5 * time.Second
+
+// Click описывает переход по ссылке
+type Click struct {
+	Link    models.Link // Ссылка с параметрами перенаправления
+	Target  string      // Итоговый адрес назначения
+	Variant string      // Имя выбранного варианта (пусто - ссылка без вариантов)
+}
+
+// clickKey ключ счетчика переходов
+type clickKey struct {
+	shortID string
+	variant string
+}
+
+// ClickCounter накапливает переходы по ссылкам в памяти и периодически
+// сохраняет их в хранилище одним пакетом, чтобы перенаправление не ждало записи
+type ClickCounter struct {
+	store      storage.Storage
+	mu         sync.Mutex
+	pending    map[clickKey]int64
+	links      map[string]models.Link // Ссылки с несохраненными переходами (только с порогами)
+	milestones []int64                // Пороги переходов по возрастанию
+	onEvent    []EventFunc            // Обработчики событий о достижении порогов
+}
+
+// ClickCounterOption настраивает ClickCounter
+type ClickCounterOption func(*ClickCounter)
+
+// WithMilestones включает события link.clicks: когда после сохранения переходов
+// общее количество переходов по ссылке достигает одного из порогов thresholds,
+// обработчику fn отправляется событие с этим порогом
+func WithMilestones(thresholds []int64, fn EventFunc) ClickCounterOption {
+	return func(c *ClickCounter) {
+		c.milestones = slices.Sorted(slices.Values(thresholds))
+		c.onEvent = append(c.onEvent, fn)
+	}
+}
+
+// NewClickCounter создает ClickCounter, сохраняющий переходы в store
+func NewClickCounter(store storage.Storage, opts ...ClickCounterOption) *ClickCounter {
+	c := &ClickCounter{
+		store:   store,
+		pending: make(map[clickKey]int64),
+		links:   make(map[string]models.Link),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ParseMilestones разбирает пороги переходов, перечисленные через запятую
+// Пустая строка означает отсутствие порогов
+func ParseMilestones(s string) ([]int64, error) {
+	var milestones []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid click milestone %q", part)
+		}
+		milestones = append(milestones, n)
+	}
+	slices.Sort(milestones)
+	return slices.Compact(milestones), nil
+}
+
+// Record учитывает переход; подходит для WithClickFunc
+func (c *ClickCounter) Record(_ *http.Request, click Click) {
+	c.mu.Lock()
+	c.pending[clickKey{click.Link.ShortURL, click.Variant}]++
+	if len(c.milestones) > 0 {
+		c.links[click.Link.ShortURL] = models.Link{
+			ShortURL:    click.Link.ShortURL,
+			OriginalURL: click.Link.OriginalURL,
+			UserID:      click.Link.UserID,
+		}
+	}
+	c.mu.Unlock()
+}
+
+// Flush сохраняет накопленные переходы в хранилище
+// При ошибке переходы возвращаются в очередь и будут сохранены следующим вызовом
+func (c *ClickCounter) Flush() error {
+	c.mu.Lock()
+	pending, links := c.pending, c.links
+	c.pending = make(map[clickKey]int64)
+	c.links = make(map[string]models.Link)
+	c.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	clicks := make([]models.ClickCount, 0, len(pending))
+	for k, n := range pending {
+		clicks = append(clicks, models.ClickCount{ShortURL: k.shortID, Variant: k.variant, Clicks: n})
+	}
+	if err := c.store.AddClicks(clicks); err != nil {
+		c.mu.Lock()
+		for k, n := range pending {
+			c.pending[k] += n
+		}
+		for id, link := range links {
+			c.links[id] = link
+		}
+		c.mu.Unlock()
+		return err
+	}
+	c.notifyMilestones(pending, links)
+	return nil
+}
+
+// notifyMilestones отправляет события о порогах, пройденных только что сохраненными переходами
+// Ссылки, общее количество переходов которых не удалось получить, пропускаются
+func (c *ClickCounter) notifyMilestones(pending map[clickKey]int64, links map[string]models.Link) {
+	if len(c.milestones) == 0 {
+		return
+	}
+	added := make(map[string]int64)
+	for k, n := range pending {
+		added[k.shortID] += n
+	}
+	for shortID, n := range added {
+		counts, err := c.store.GetClicks(shortID)
+		if err != nil {
+			continue
+		}
+		var total int64
+		for _, v := range counts {
+			total += v
+		}
+		link := links[shortID]
+		for _, m := range c.milestones {
+			if total-n < m && m <= total {
+				emit(c.onEvent, models.LinkEvent{
+					Type:        models.EventLinkClicks,
+					UserID:      link.UserID,
+					ShortID:     shortID,
+					OriginalURL: link.OriginalURL,
+					Clicks:      m,
+				})
+			}
+		}
+	}
+}
+
+// Run сохраняет накопленные переходы каждые interval до завершения контекста,
+// после чего сохраняет оставшиеся
+func (c *ClickCounter) Run(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		interval = DefaultClickFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.Flush(); err != nil {
+				logger.Error("failed to save clicks", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := c.Flush(); err != nil {
+				logger.Error("failed to save clicks", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/utils"
+)
+
+// csvTagSeparator разделяет теги в одной ячейке CSV
+const csvTagSeparator = ";"
+
+// Колонки CSV загрузки и выгрузки ссылок
+var (
+	csvBatchColumns  = []string{"correlation_id", "original_url", "alias", "tags"}
+	csvResultColumns = []string{"correlation_id", "short_url", "error"}
+	csvExportColumns = []string{"short_url", "original_url", "created_at", "title", "tags", "note"}
+)
+
+// errCSVRow возвращается для строки CSV без обязательных колонок
+var errCSVRow = errors.New("expected correlation_id,original_url[,alias,tags]")
+
+// BatchShortenCSVHandler обрабатывает POST запросы для пакетного сокращения URL из CSV
+// Принимает строки correlation_id,original_url[,alias,tags] (необязательная первая строка -
+// заголовок колонок, теги разделяются ";") и возвращает CSV correlation_id,short_url,error
+// в порядке строк запроса. Строки читаются и сохраняются частями по batchChunkSize,
+// а результаты каждой части отправляются сразу, поэтому размер файла не ограничен памятью.
+// Ошибка строки, в том числе ошибка разбора CSV, записывается в колонку error и не прерывает
+// обработку остальных: чтение продолжается со следующей строки. Строки сверх cfg.BatchMaxSize
+// и строки после ошибки чтения тела запроса не обрабатываются, о чем сообщает последняя строка ответа
+func BatchShortenCSVHandler(cfg *config.Config, store storage.Storage, opts ...ShortenOption) http.HandlerFunc {
+	o := newShortenOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Кавычки разбираются строго: с LazyQuotes одна лишняя кавычка
+		// незаметно склеивает все последующие строки в одно поле
+		cr := csv.NewReader(r.Body)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		first, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			http.Error(w, "empty batch", http.StatusBadRequest)
+			return
+		}
+		if err != nil && !isCSVParseError(err) {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		// Таблицы часто сохраняют CSV с BOM в начале файла
+		if err == nil {
+			first[0] = strings.TrimPrefix(first[0], "\ufeff")
+			if strings.EqualFold(strings.TrimSpace(first[0]), csvBatchColumns[0]) {
+				first = nil
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		rc := http.NewResponseController(w)
+		if err := cw.Write(csvResultColumns); err != nil {
+			return
+		}
+
+		chunk := make([]batchItem, 0, batchChunkSize)
+		flush := func() error {
+			for _, res := range saveChunk(store, userID, chunk, o) {
+				if err := cw.Write(csvResult(cfg, res)); err != nil {
+					return err
+				}
+			}
+			chunk = chunk[:0]
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			return flushChunk(r, rc)
+		}
+		// stop сохраняет прочитанное и сообщает, почему остальные строки не обработаны
+		stop := func(reason error) {
+			if err := flush(); err != nil {
+				return
+			}
+			if err := cw.Write([]string{"", "", fmt.Sprintf("remaining rows skipped: %v", reason)}); err != nil {
+				return
+			}
+			cw.Flush()
+		}
+
+		record, rows := first, 0
+		for {
+			if record != nil || err != nil {
+				if rows++; cfg.BatchMaxSize > 0 && rows > cfg.BatchMaxSize {
+					stop(fmt.Errorf("%w: more than %d links", errBatchTooLarge, cfg.BatchMaxSize))
+					return
+				}
+				chunk = append(chunk, csvBatchItem(record, err))
+			}
+			if len(chunk) == batchChunkSize {
+				if err := flush(); err != nil {
+					return
+				}
+			}
+			record, err = cr.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil && !isCSVParseError(err) {
+				// Продолжить чтение после ошибки чтения тела запроса нельзя
+				stop(err)
+				return
+			}
+		}
+		if len(chunk) > 0 {
+			if err := flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// isCSVParseError проверяет, что ошибка относится к разбору одной строки CSV
+// и чтение можно продолжить со следующей строки
+func isCSVParseError(err error) bool {
+	var parseErr *csv.ParseError
+	return errors.As(err, &parseErr)
+}
+
+// csvBatchItem разбирает строку CSV загрузки; parseErr - ошибка разбора этой строки,
+// тогда record содержит только поля, прочитанные до ошибки
+func csvBatchItem(record []string, parseErr error) batchItem {
+	field := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	item := batchItem{CorrelationID: field(0), OriginalURL: field(1), Alias: field(2)}
+	if parseErr != nil {
+		item.Err = parseErr
+		return item
+	}
+	if len(record) < 2 {
+		item.Err = errCSVRow
+		return item
+	}
+	if item.Alias != "" {
+		if err := utils.ValidateAlias(item.Alias); err != nil {
+			item.Err = err
+			return item
+		}
+	}
+	if tags := field(3); tags != "" {
+		item.Metadata.Tags = strings.Split(tags, csvTagSeparator)
+		item.Err = item.Metadata.Normalize()
+	}
+	return item
+}
+
+// csvResult формирует строку CSV результата сохранения ссылки
+func csvResult(cfg *config.Config, res batchResult) []string {
+	var shortURL, errText string
+	if res.ShortID != "" {
+		shortURL = cfg.BaseURL + "/" + res.ShortID
+	}
+	if res.Err != nil {
+		errText = res.Err.Error()
+	}
+	return []string{res.CorrelationID, shortURL, errText}
+}
+
+// UserURLsCSVHandler обрабатывает GET запросы для выгрузки ссылок пользователя в CSV
+// Возвращает файл с колонками short_url,original_url,created_at,title,tags,note;
+// удаленные ссылки не выгружаются, параметры tag фильтруют ссылки как в GET /api/user/urls.
+// Если ссылок нет, возвращается только строка заголовка
+func UserURLsCSVHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		urls, err := store.GetUserURLs(userID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "failed to get user URLs", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="urls.csv"`)
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvExportColumns); err != nil {
+			return
+		}
+		tags := r.URL.Query()["tag"]
+		for _, url := range urls {
+			if url.Deleted || !hasTags(url.LinkMetadata, tags) {
+				continue
+			}
+			if err := cw.Write(csvUserURL(cfg, url)); err != nil {
+				return
+			}
+		}
+		cw.Flush()
+	}
+}
+
+// csvUserURL формирует строку CSV выгрузки ссылки пользователя
+// Ячейки с текстом пользователя защищаются от выполнения как формулы
+func csvUserURL(cfg *config.Config, url models.UserURL) []string {
+	var created string
+	if url.CreatedAt != nil {
+		created = url.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		cfg.BaseURL + "/" + url.ShortURL,
+		csvSafe(url.OriginalURL),
+		created,
+		csvSafe(url.Title),
+		csvSafe(strings.Join(url.Tags, csvTagSeparator)),
+		csvSafe(url.Note),
+	}
+}
+
+// csvSafe защищает ячейку от CSV инъекции: табличные редакторы выполняют ячейку,
+// начинающуюся с =, +, -, @, табуляции или возврата каретки, как формулу.
+// Такая ячейка начинается с апострофа, и редактор показывает ее как текст
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// readCSV разбирает CSV ответа
+func readCSV(t *testing.T, body string) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV response %q: %v", body, err)
+	}
+	return records
+}
+
+func TestBatchShortenCSVHandler(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("taken", "https://example.com/existing", "user2")
+	var log eventLog
+	h := BatchShortenCSVHandler(cfg, store, WithEvents(log.add))
+
+	body := "\ufeffcorrelation_id,original_url,alias,tags\n" +
+		"1,https://example.com/a\n" +
+		"2,https://example.com/b,spring-sale,\"promo; Spring ;promo\"\n" +
+		"3,https://example.com/existing\n" +
+		"4,https://example.com/c,taken\n" +
+		"5,https://example.com/d,bad/alias\n" +
+		"6,\n" +
+		"7\n" +
+		"8,https://example.com/a\n"
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch.csv", body, "user1")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 9 || strings.Join(records[0], ",") != "correlation_id,short_url,error" {
+		t.Fatalf("unexpected result %q", records)
+	}
+	results := make(map[string][]string)
+	for i, r := range records[1:] {
+		if r[0] != fmt.Sprint(i+1) {
+			t.Errorf("expected results in input order, got %q at %d", r[0], i)
+		}
+		results[r[0]] = r
+	}
+
+	if r := results["1"]; r[2] != "" || !strings.HasPrefix(r[1], "http://localhost:8080/") {
+		t.Errorf("unexpected result for a new link: %q", r)
+	}
+	if r := results["2"]; r[1] != "http://localhost:8080/spring-sale" || r[2] != "" {
+		t.Errorf("unexpected result for an alias: %q", r)
+	}
+	urls, _ := store.GetUserURLs("user1")
+	if u, ok := findUserURL(urls, "spring-sale"); !ok || strings.Join(u.Tags, ",") != "promo,spring" {
+		t.Errorf("expected alias with tags to be saved, got %+v", u)
+	}
+	if r := results["3"]; r[1] != "http://localhost:8080/taken" || r[2] != storage.ErrConflict.Error() {
+		t.Errorf("expected conflict with the existing link, got %q", r)
+	}
+	if r := results["8"]; r[1] != results["1"][1] || r[2] != storage.ErrConflict.Error() {
+		t.Errorf("expected conflict with a link of the same file, got %q", r)
+	}
+	for id, want := range map[string]string{"4": "alias is already taken", "5": "invalid alias", "6": "empty URL", "7": "expected correlation_id"} {
+		if r := results[id]; r[1] != "" || !strings.Contains(r[2], want) {
+			t.Errorf("row %s: expected error %q, got %q", id, want, r)
+		}
+	}
+
+	events := log.take()
+	if len(events) != 2 || events[0].OriginalURL != "https://example.com/a" || events[1].ShortID != "spring-sale" {
+		t.Errorf("expected created events in input order, got %+v", events)
+	}
+}
+
+func TestBatchShortenCSVHandler_Chunks(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	h := BatchShortenCSVHandler(cfg, store)
+
+	n := batchChunkSize*2 + 5
+	var body strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&body, "%d,https://example.com/%d\n", i, i)
+	}
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch.csv", body.String(), "user1")
+	records := readCSV(t, rec.Body.String())
+	if len(records) != n+1 {
+		t.Fatalf("expected %d results, got %d", n, len(records)-1)
+	}
+	for i, r := range records[1:] {
+		if r[0] != fmt.Sprint(i) || r[2] != "" {
+			t.Fatalf("unexpected result %d: %q", i, r)
+		}
+	}
+	if urls, _ := store.GetUserURLs("user1"); len(urls) != n {
+		t.Errorf("expected %d saved links, got %d", n, len(urls))
+	}
+}
+
+func TestBatchShortenCSVHandler_Errors(t *testing.T) {
+	h := BatchShortenCSVHandler(&config.Config{}, storage.NewInMemoryStorage())
+	if rec := doAsUser(h, http.MethodPost, "/api/shorten/batch.csv", "1,https://example.com", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+	if rec := doAsUser(h, http.MethodPost, "/api/shorten/batch.csv", "", "user1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an empty file, got %d", rec.Code)
+	}
+}
+
+func TestBatchShortenCSVHandler_ParseErrors(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	h := BatchShortenCSVHandler(&config.Config{BaseURL: "http://localhost:8080"}, store)
+	body := "1,https://example.com/1\n" +
+		"2,\"https://example.com/2\"x\n" +
+		"3,https://example.com/3\n" +
+		"4,https://example.com/\"4\n" +
+		"5,https://example.com/5\n"
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch.csv", body, "user1")
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 6 {
+		t.Fatalf("expected a result for every row, got %q", records)
+	}
+	for i, r := range records[1:] {
+		failed := i == 1 || i == 3
+		if r[0] != fmt.Sprint(i+1) || (r[2] != "") != failed || (r[1] == "") != failed {
+			t.Errorf("unexpected result for row %d: %q", i+1, r)
+		}
+	}
+	if !strings.Contains(records[2][2], "line 2") || !strings.Contains(records[4][2], "line 4") {
+		t.Errorf("expected parse errors to name the line, got %q and %q", records[2][2], records[4][2])
+	}
+	if urls, _ := store.GetUserURLs("user1"); len(urls) != 3 {
+		t.Errorf("expected rows after parse errors to be saved, got %d links", len(urls))
+	}
+}
+
+func TestUserURLsCSVHandler(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://example.com/1", "user1")
+	store.Save("id2", "https://example.com/2", "user1")
+	store.Save("id3", "https://example.com/3", "user1")
+	store.UpdateMetadata("user1", "id1", models.LinkMetadata{Title: "First, \"quoted\"", Tags: []string{"promo", "spring"}})
+	store.DeleteURLs("user1", []string{"id3"})
+	r := chi.NewRouter()
+	r.Get("/api/user/urls.csv", UserURLsCSVHandler(cfg, store))
+
+	rec := doAsUser(r, http.MethodGet, "/api/user/urls.csv", "", "user1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Disposition"), "urls.csv") {
+		t.Fatalf("unexpected response %d %v", rec.Code, rec.Header())
+	}
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 3 || strings.Join(records[0], ",") != "short_url,original_url,created_at,title,tags,note" {
+		t.Fatalf("unexpected export %q", records)
+	}
+	if r := records[1]; r[0] != "http://localhost:8080/id1" || r[3] != "First, \"quoted\"" || r[4] != "promo;spring" || r[2] == "" {
+		t.Errorf("unexpected row %q", r)
+	}
+
+	if records := readCSV(t, doAsUser(r, http.MethodGet, "/api/user/urls.csv?tag=promo", "", "user1").Body.String()); len(records) != 2 {
+		t.Errorf("expected one tagged link, got %q", records)
+	}
+	if records := readCSV(t, doAsUser(r, http.MethodGet, "/api/user/urls.csv", "", "user2").Body.String()); len(records) != 1 {
+		t.Errorf("expected only the header for a user without links, got %q", records)
+	}
+	if rec := doAsUser(r, http.MethodGet, "/api/user/urls.csv", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestUserURLsCSVHandler_FormulaInjection(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://example.com/1", "user1")
+	store.UpdateMetadata("user1", "id1", models.LinkMetadata{Title: "=HYPERLINK(\"http://evil\")", Tags: []string{"+tag"}, Note: "@SUM(A1)"})
+	store.Save("id2", "https://example.com/2", "user1")
+	store.UpdateMetadata("user1", "id2", models.LinkMetadata{Title: "-1+1", Note: "\tcmd"})
+	store.Save("id3", "https://example.com/3", "user1")
+	store.UpdateMetadata("user1", "id3", models.LinkMetadata{Title: "a=b", Note: "\rnote"})
+	h := UserURLsCSVHandler(&config.Config{BaseURL: "http://localhost:8080"}, store)
+
+	records := readCSV(t, doAsUser(h, http.MethodGet, "/api/user/urls.csv", "", "user1").Body.String())
+	if len(records) != 4 {
+		t.Fatalf("unexpected export %q", records)
+	}
+	want := [][3]string{
+		{"'=HYPERLINK(\"http://evil\")", "'+tag", "'@SUM(A1)"},
+		{"'-1+1", "", "'\tcmd"},
+		{"a=b", "", "'\rnote"},
+	}
+	for i, w := range want {
+		if r := records[i+1]; r[3] != w[0] || r[4] != w[1] || r[5] != w[2] {
+			t.Errorf("row %d: expected %q, got %q", i+1, w, r[3:])
+		}
+	}
+}
+
+func TestBatchShortenCSVHandler_MaxSize(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	h := BatchShortenCSVHandler(&config.Config{BatchMaxSize: 2}, store)
+	rec := doAsUser(h, http.MethodPost, "/api/shorten/batch.csv", "1,https://example.com/1\n2,https://example.com/2\n3,https://example.com/3\n", "user1")
+	records := readCSV(t, rec.Body.String())
+	if len(records) != 4 || records[2][2] != "" || !strings.Contains(records[3][2], "batch is too large") {
+		t.Errorf("expected two results and a trailing error, got %q", records)
+	}
+	if urls, _ := store.GetUserURLs("user1"); len(urls) != 2 {
+		t.Errorf("expected 2 saved links, got %d", len(urls))
+	}
+}
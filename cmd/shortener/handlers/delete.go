@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
 	"uno/cmd/shortener/storage"
 
 	"go.uber.org/zap"
@@ -49,11 +50,13 @@ func DeleteUserURLsHandler(store storage.Storage, logger *zap.Logger, deleteQueu
 // RunDeletionWorker запускает воркер для асинхронной обработки запросов на удаление URL
 // Обрабатывает запросы из очереди deleteQueue и выполняет пометку URL как удаленных
 // Каждый запрос обрабатывается в отдельной горутине для параллельности
+// Для каждой удаленной ссылки пользователя обработчикам onEvent отправляется событие link.deleted
 func RunDeletionWorker(
 	ctx context.Context,
 	store storage.Storage,
 	logger *zap.Logger,
 	deleteQueue <-chan DeleteRequest,
+	onEvent ...EventFunc,
 ) {
 	for {
 		select {
@@ -61,10 +64,42 @@ func RunDeletionWorker(
 			return
 		case req := <-deleteQueue:
 			go func(r DeleteRequest) {
+				links := deletableLinks(store, r, len(onEvent) > 0)
 				if err := store.DeleteURLs(r.UserID, r.IDs); err != nil {
 					logger.Error("batch deletion failed", zap.Error(err))
+					return
+				}
+				for _, link := range links {
+					emit(onEvent, models.LinkEvent{
+						Type:        models.EventLinkDeleted,
+						UserID:      link.UserID,
+						ShortID:     link.ShortURL,
+						OriginalURL: link.OriginalURL,
+					})
 				}
 			}(req)
 		}
 	}
 }
+
+// deletableLinks возвращает ссылки запроса, которые принадлежат пользователю
+// и еще не удалены, - именно они будут удалены запросом
+// Если события не нужны (need == false), хранилище не опрашивается
+func deletableLinks(store storage.Storage, r DeleteRequest, need bool) []models.Link {
+	if !need {
+		return nil
+	}
+	var links []models.Link
+	seen := make(map[string]bool, len(r.IDs))
+	for _, id := range r.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		link, err := store.GetLink(id)
+		if err == nil && link.UserID == r.UserID && !link.Deleted {
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// EventFunc получает события жизненного цикла ссылок: создание, удаление
+// и достижение порога переходов. Вызывается синхронно, до ответа на запрос
+type EventFunc func(e models.LinkEvent)
+
+// EventsFunc получает события, произошедшие вместе, например при сохранении части пакета
+type EventsFunc func(events []models.LinkEvent)
+
+// ShortenOption настраивает обработчики сокращения URL
+type ShortenOption func(*shortenOptions)
+
+// shortenOptions параметры обработчиков сокращения URL
+type shortenOptions struct {
+	onEvent  []EventFunc  // Обработчики событий о созданных ссылках
+	onEvents []EventsFunc // Обработчики событий о созданных ссылках, получающие их пакетами
+}
+
+// WithEvents добавляет обработчик событий link.created
+// Событие отправляется только для новых ссылок, ответ 409 событий не порождает
+func WithEvents(fn EventFunc) ShortenOption {
+	return func(o *shortenOptions) {
+		o.onEvent = append(o.onEvent, fn)
+	}
+}
+
+// WithEventBatches добавляет обработчик событий link.created, получающий события
+// всех ссылок, сохраненных вместе, одним вызовом
+func WithEventBatches(fn EventsFunc) ShortenOption {
+	return func(o *shortenOptions) {
+		o.onEvents = append(o.onEvents, fn)
+	}
+}
+
+// newShortenOptions применяет опции обработчика сокращения URL
+func newShortenOptions(opts []ShortenOption) shortenOptions {
+	o := shortenOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// created сообщает о создании ссылки
+func (o shortenOptions) created(userID, shortID, originalURL string) {
+	o.createdAll([]models.LinkEvent{createdEvent(userID, shortID, originalURL)})
+}
+
+// createdAll сообщает о ссылках, сохраненных вместе
+func (o shortenOptions) createdAll(events []models.LinkEvent) {
+	if len(events) == 0 {
+		return
+	}
+	for _, e := range events {
+		emit(o.onEvent, e)
+	}
+	for _, fn := range o.onEvents {
+		fn(events)
+	}
+}
+
+// createdEvent формирует событие link.created
+func createdEvent(userID, shortID, originalURL string) models.LinkEvent {
+	return models.LinkEvent{
+		Type:        models.EventLinkCreated,
+		UserID:      userID,
+		ShortID:     shortID,
+		OriginalURL: originalURL,
+		Time:        time.Now().UTC(),
+	}
+}
+
+// emit передает событие всем обработчикам, проставляя время события
+func emit(handlers []EventFunc, e models.LinkEvent) {
+	if len(handlers) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	for _, fn := range handlers {
+		fn(e)
+	}
+}
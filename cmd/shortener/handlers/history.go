@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RetargetUserURLHandler обрабатывает PUT запросы для изменения адреса перенаправления ссылки
+// Принимает JSON с полем "url"; прежний адрес сохраняется в истории версий
+// Возвращает обновленную ссылку, 404 Not Found, если у пользователя нет такой ссылки,
+// или 409 Conflict, если новый адрес уже сокращен другой ссылкой
+func RetargetUserURLHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var req models.UpdateURLRequest
+		if err := req.UnmarshalJSON(data); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		originalURL := strings.TrimSpace(req.URL)
+		if originalURL == "" {
+			http.Error(w, "empty URL", http.StatusBadRequest)
+			return
+		}
+
+		retarget(w, r, cfg, store, userID, shortID, originalURL)
+	}
+}
+
+// URLHistoryHandler обрабатывает GET запросы для получения истории адресов ссылки
+// Возвращает текущий адрес с номером версии и предыдущие адреса от старых к новым
+func URLHistoryHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		url, history, ok := loadHistory(w, r, store, userID, shortID)
+		if !ok {
+			return
+		}
+
+		resp := models.URLHistory{
+			ShortURL:    cfg.BaseURL + "/" + url.ShortURL,
+			OriginalURL: url.OriginalURL,
+			Version:     len(history) + 1,
+			Versions:    history,
+		}
+		if resp.Versions == nil {
+			resp.Versions = []models.URLVersion{}
+		}
+		data, err := resp.MarshalJSON()
+		if err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// RevertUserURLHandler обрабатывает POST запросы для возврата ссылки к предыдущему адресу
+// Принимает JSON с полем "version" из истории. История не переписывается:
+// возврат создает новую версию с адресом выбранной
+func RevertUserURLHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var req models.RevertRequest
+		if err := req.UnmarshalJSON(data); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		_, history, ok := loadHistory(w, r, store, userID, shortID)
+		if !ok {
+			return
+		}
+		if req.Version < 1 || req.Version > len(history) {
+			http.Error(w, "unknown version", http.StatusBadRequest)
+			return
+		}
+
+		retarget(w, r, cfg, store, userID, shortID, history[req.Version-1].OriginalURL)
+	}
+}
+
+// loadHistory находит ссылку пользователя и ее историю адресов
+// При ошибке отправляет ответ и возвращает false
+func loadHistory(w http.ResponseWriter, r *http.Request, store storage.Storage, userID, shortID string) (models.UserURL, []models.URLVersion, bool) {
+	urls, err := store.GetUserURLs(userID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "failed to get user URLs", http.StatusInternalServerError)
+		return models.UserURL{}, nil, false
+	}
+	url, found := findUserURL(urls, shortID)
+	if !found {
+		http.NotFound(w, r)
+		return models.UserURL{}, nil, false
+	}
+
+	history, err := store.GetHistory(userID, shortID)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.NotFound(w, r)
+		return models.UserURL{}, nil, false
+	}
+	if err != nil {
+		http.Error(w, "failed to get URL history", http.StatusInternalServerError)
+		return models.UserURL{}, nil, false
+	}
+	return url, history, true
+}
+
+// retarget меняет адрес ссылки и отправляет обновленную ссылку
+func retarget(w http.ResponseWriter, r *http.Request, cfg *config.Config, store storage.Storage, userID, shortID, originalURL string) {
+	err := store.UpdateURL(userID, shortID, originalURL)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, storage.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "failed to update URL", http.StatusInternalServerError)
+		return
+	}
+
+	urls, err := store.GetUserURLs(userID)
+	if err != nil {
+		http.Error(w, "failed to get user URLs", http.StatusInternalServerError)
+		return
+	}
+	url, found := findUserURL(urls, shortID)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	writeUserURL(w, cfg, url)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupHistoryRouter(cfg *config.Config, store storage.Storage) http.Handler {
+	r := chi.NewRouter()
+	r.Put("/api/user/urls/{id}", RetargetUserURLHandler(cfg, store))
+	r.Get("/api/user/urls/{id}/history", URLHistoryHandler(cfg, store))
+	r.Post("/api/user/urls/{id}/revert", RevertUserURLHandler(cfg, store))
+	r.Get("/{id}", RedirectHandler(store))
+	return r
+}
+
+func doAsUser(h http.Handler, method, target, body, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, userID))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRetargetAndRevert(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://exmaple.com", "user1")
+	store.Save("id2", "https://taken.com", "user1")
+	h := setupHistoryRouter(cfg, store)
+
+	rec := doAsUser(h, http.MethodPut, "/api/user/urls/id1", `{"url":" https://example.com "}`, "user1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var url models.UserURL
+	if err := url.UnmarshalJSON(rec.Body.Bytes()); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if url.ShortURL != "http://localhost:8080/id1" || url.OriginalURL != "https://example.com" {
+		t.Errorf("unexpected response: %+v", url)
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/id1", "", "")
+	if rec.Code != http.StatusTemporaryRedirect || rec.Header().Get("Location") != "https://example.com" {
+		t.Errorf("expected redirect to new URL, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/api/user/urls/id1/history", "", "user1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var history models.URLHistory
+	if err := history.UnmarshalJSON(rec.Body.Bytes()); err != nil {
+		t.Fatalf("failed to decode history: %v", err)
+	}
+	if history.Version != 2 || history.OriginalURL != "https://example.com" ||
+		len(history.Versions) != 1 || history.Versions[0].OriginalURL != "https://exmaple.com" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	rec = doAsUser(h, http.MethodPost, "/api/user/urls/id1/revert", `{"version":1}`, "user1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on revert, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got, _, _ := store.Get("id1"); got != "https://exmaple.com" {
+		t.Errorf("expected reverted URL, got %q", got)
+	}
+	if versions, _ := store.GetHistory("user1", "id1"); len(versions) != 2 {
+		t.Errorf("expected revert to add a version, got %+v", versions)
+	}
+}
+
+func TestRetargetErrors(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://example.com", "user1")
+	store.Save("id2", "https://taken.com", "user2")
+	h := setupHistoryRouter(cfg, store)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		userID string
+		code   int
+	}{
+		{"no user", http.MethodPut, "/api/user/urls/id1", `{"url":"https://x.com"}`, "", http.StatusUnauthorized},
+		{"invalid JSON", http.MethodPut, "/api/user/urls/id1", `{"url":`, "user1", http.StatusBadRequest},
+		{"empty URL", http.MethodPut, "/api/user/urls/id1", `{"url":" "}`, "user1", http.StatusBadRequest},
+		{"conflict", http.MethodPut, "/api/user/urls/id1", `{"url":"https://taken.com"}`, "user1", http.StatusConflict},
+		{"another owner", http.MethodPut, "/api/user/urls/id2", `{"url":"https://x.com"}`, "user1", http.StatusNotFound},
+		{"history of another owner", http.MethodGet, "/api/user/urls/id2/history", "", "user1", http.StatusNotFound},
+		{"unknown version", http.MethodPost, "/api/user/urls/id1/revert", `{"version":1}`, "user1", http.StatusBadRequest},
+		{"revert of another owner", http.MethodPost, "/api/user/urls/id2/revert", `{"version":1}`, "user1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doAsUser(h, tt.method, tt.target, tt.body, tt.userID)
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if got, _, _ := store.Get("id1"); got != "https://example.com" {
+		t.Errorf("expected failed requests not to change id1, got %q", got)
+	}
+}
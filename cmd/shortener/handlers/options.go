@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LinkOptionsHandler обрабатывает GET запросы параметров перенаправления ссылки пользователя
+// Возвращает код перенаправления, политику query параметров, режим interstitial
+// и признак защиты паролем или 404 Not Found, если у пользователя нет такой ссылки
+func LinkOptionsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		link, ok := loadUserLink(w, r, store, userID, chi.URLParam(r, "id"))
+		if !ok {
+			return
+		}
+		writeOptions(w, link.LinkOptions)
+	}
+}
+
+// UpdateLinkOptionsHandler обрабатывает PATCH запросы для изменения параметров перенаправления
+// Принимает JSON с необязательными "redirect_code", "query_policy" и "interstitial";
+// отсутствующие поля не изменяются. Пароль ссылки, правила и варианты этим запросом
+// не меняются, в том числе если их одновременно изменяет другой запрос
+func UpdateLinkOptionsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var patch models.OptionsPatch
+		if err := patch.UnmarshalJSON(data); err != nil || patch.IsEmpty() {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err := patch.Normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts, err := store.PatchOptions(userID, shortID, patch)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to update link options", http.StatusInternalServerError)
+			return
+		}
+
+		writeOptions(w, opts)
+	}
+}
+
+// loadUserLink загружает не удаленную ссылку пользователя
+// Если ссылки нет или она чужая, отправляет 404 Not Found и возвращает false
+func loadUserLink(w http.ResponseWriter, r *http.Request, store storage.Storage, userID, shortID string) (models.Link, bool) {
+	link, err := store.GetLink(shortID)
+	if errors.Is(err, storage.ErrNotFound) || err == nil && (link.UserID != userID || link.Deleted) {
+		http.NotFound(w, r)
+		return models.Link{}, false
+	}
+	if err != nil {
+		http.Error(w, "failed to get link", http.StatusInternalServerError)
+		return models.Link{}, false
+	}
+	return link, true
+}
+
+// writeOptions отправляет параметры перенаправления ссылки
+func writeOptions(w http.ResponseWriter, opts models.LinkOptions) {
+	data, err := opts.Response().MarshalJSON()
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
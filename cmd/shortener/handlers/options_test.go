@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupOptionsRouter(cfg *config.Config, store storage.Storage, clicks *[]string) http.Handler {
+	redirect := RedirectHandler(store, WithClickFunc(func(r *http.Request, click Click) {
+		*clicks = append(*clicks, click.Link.ShortURL+" "+click.Target)
+	}))
+	r := chi.NewRouter()
+	r.Post("/api/shorten", APIShortenHandler(cfg, store))
+	r.Get("/api/user/urls/{id}/options", LinkOptionsHandler(store))
+	r.Patch("/api/user/urls/{id}/options", UpdateLinkOptionsHandler(store))
+	r.Get("/{id}", redirect)
+	r.Head("/{id}", redirect)
+	r.Post("/{id}", redirect)
+	return r
+}
+
+func TestRedirectHandler_CodeAndQuery(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	var clicks []string
+	h := setupOptionsRouter(cfg, store, &clicks)
+
+	rec := doAsUser(h, http.MethodPost, "/api/shorten",
+		`{"url":"https://example.com/landing?utm_source=site&id=7","redirect_code":308,"query_policy":"append"}`, "user1")
+	var resp models.APIResponse
+	if err := resp.UnmarshalJSON(rec.Body.Bytes()); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	id := strings.TrimPrefix(resp.Result, cfg.BaseURL+"/")
+
+	rec = doAsUser(h, http.MethodGet, "/"+id+"?utm_source=x", "", "")
+	if rec.Code != http.StatusPermanentRedirect {
+		t.Errorf("expected 308, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "https://example.com/landing?utm_source=site&id=7&utm_source=x" {
+		t.Errorf("unexpected Location with append policy: %q", loc)
+	}
+
+	// HEAD отдает тот же Location, но не считается переходом
+	rec = doAsUser(h, http.MethodHead, "/"+id+"?utm_source=x", "", "")
+	if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") == "" {
+		t.Errorf("expected 308 with Location for HEAD, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if len(clicks) != 1 || clicks[0] != id+" https://example.com/landing?utm_source=site&id=7&utm_source=x" {
+		t.Errorf("unexpected clicks: %v", clicks)
+	}
+
+	rec = doAsUser(h, http.MethodPatch, "/api/user/urls/"+id+"/options", `{"redirect_code":301,"query_policy":"override"}`, "user1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	var opts models.OptionsResponse
+	if err := opts.UnmarshalJSON(rec.Body.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if opts != (models.OptionsResponse{RedirectCode: 301, QueryPolicy: models.QueryOverride}) {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/"+id+"?utm_source=x", "", "")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://example.com/landing?id=7&utm_source=x" {
+		t.Errorf("unexpected redirect with override policy: %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	// Политика ignore и код по умолчанию
+	rec = doAsUser(h, http.MethodPatch, "/api/user/urls/"+id+"/options", `{"redirect_code":307,"query_policy":"ignore"}`, "user1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if link, err := store.GetLink(id); err != nil || !link.IsZero() {
+		t.Errorf("default options must not be stored: %+v (%v)", link.LinkOptions, err)
+	}
+	rec = doAsUser(h, http.MethodGet, "/"+id+"?utm_source=x", "", "")
+	if rec.Code != http.StatusTemporaryRedirect || rec.Header().Get("Location") != "https://example.com/landing?utm_source=site&id=7" {
+		t.Errorf("unexpected redirect with ignore policy: %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/api/user/urls/"+id+"/options", "", "user1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"query_policy":"ignore"`) {
+		t.Errorf("unexpected options response: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestLinkOptionsHandler_Errors(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com", "user1")
+	var clicks []string
+	h := setupOptionsRouter(cfg, store, &clicks)
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		userID string
+		status int
+	}{
+		{"unauthorized", http.MethodPatch, "/api/user/urls/abc/options", `{"redirect_code":301}`, "", http.StatusUnauthorized},
+		{"another user", http.MethodPatch, "/api/user/urls/abc/options", `{"redirect_code":301}`, "user2", http.StatusNotFound},
+		{"another user get", http.MethodGet, "/api/user/urls/abc/options", "", "user2", http.StatusNotFound},
+		{"missing link", http.MethodPatch, "/api/user/urls/missing/options", `{"redirect_code":301}`, "user1", http.StatusNotFound},
+		{"empty patch", http.MethodPatch, "/api/user/urls/abc/options", `{}`, "user1", http.StatusBadRequest},
+		{"invalid code", http.MethodPatch, "/api/user/urls/abc/options", `{"redirect_code":303}`, "user1", http.StatusBadRequest},
+		{"invalid policy", http.MethodPatch, "/api/user/urls/abc/options", `{"query_policy":"merge"}`, "user1", http.StatusBadRequest},
+		{"invalid shorten code", http.MethodPost, "/api/shorten", `{"url":"https://x.example.com","redirect_code":200}`, "user1", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec := doAsUser(h, c.method, c.target, c.body, c.userID); rec.Code != c.status {
+				t.Errorf("expected %d, got %d %s", c.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
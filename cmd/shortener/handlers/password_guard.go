@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/utils"
+
+	"go.uber.org/zap"
+)
+
+// Параметры PasswordGuard по умолчанию
+const (
+	DefaultUnlockTTL   = 15 * time.Minute // Время жизни cookie доступа к защищенной ссылке
+	DefaultMaxAttempts = 5                // Неудачных попыток до блокировки ссылки
+	DefaultLockout     = 15 * time.Minute // Окно подсчета неудачных попыток и длительность блокировки
+
+	// unlockCookiePrefix префикс имени cookie доступа; полное имя включает сокращенный ID
+	unlockCookiePrefix = "link_unlock_"
+	// maxFormSize максимальный размер тела формы ввода пароля
+	maxFormSize = 4 << 10
+	// maxTrackedLinks количество ссылок с неудачными попытками, после которого устаревшие записи удаляются
+	maxTrackedLinks = 10000
+)
+
+// PasswordGuardOptions задает параметры PasswordGuard
+type PasswordGuardOptions struct {
+	Secret      []byte        // Ключ подписи cookie доступа (пусто - случайный, cookie не переживают перезапуск)
+	UnlockTTL   time.Duration // Время жизни cookie доступа (0 - DefaultUnlockTTL)
+	MaxAttempts int           // Неудачных попыток до блокировки (0 - DefaultMaxAttempts)
+	Lockout     time.Duration // Окно подсчета неудачных попыток (0 - DefaultLockout)
+	Logger      *zap.Logger   // Логгер ошибок отправки формы (nil - без логирования)
+}
+
+// PasswordGuard открывает доступ к ссылкам, защищенным паролем
+//
+// Вместо перенаправления отдается форма ввода пароля. После верного пароля
+// выставляется подписанная cookie с коротким временем жизни, действующая только
+// для этой ссылки и этого пароля, и выполняется перенаправление. Неудачные попытки
+// считаются для каждой ссылки отдельно: после MaxAttempts попыток за окно Lockout
+// проверка пароля отклоняется до конца окна
+type PasswordGuard struct {
+	secret      []byte
+	ttl         time.Duration
+	maxAttempts int
+	lockout     time.Duration
+	logger      *zap.Logger
+	now         func() time.Time
+
+	mu       sync.Mutex
+	attempts map[string]*failedAttempts // Сокращенный ID -> неудачные попытки
+}
+
+// failedAttempts неудачные попытки ввода пароля в текущем окне
+type failedAttempts struct {
+	count int       // Количество неудачных попыток
+	reset time.Time // Конец окна подсчета
+}
+
+// NewPasswordGuard создает PasswordGuard
+func NewPasswordGuard(opts PasswordGuardOptions) *PasswordGuard {
+	g := &PasswordGuard{
+		secret:      opts.Secret,
+		ttl:         opts.UnlockTTL,
+		maxAttempts: opts.MaxAttempts,
+		lockout:     opts.Lockout,
+		logger:      opts.Logger,
+		now:         time.Now,
+		attempts:    make(map[string]*failedAttempts),
+	}
+	if len(g.secret) == 0 {
+		g.secret = make([]byte, 32)
+		rand.Read(g.secret)
+	}
+	if g.ttl <= 0 {
+		g.ttl = DefaultUnlockTTL
+	}
+	if g.maxAttempts <= 0 {
+		g.maxAttempts = DefaultMaxAttempts
+	}
+	if g.lockout <= 0 {
+		g.lockout = DefaultLockout
+	}
+	if g.logger == nil {
+		g.logger = zap.NewNop()
+	}
+	return g
+}
+
+// Authorize проверяет доступ к защищенной ссылке
+// Возвращает true, если запрос содержит действующую cookie доступа или верный пароль
+// из POST запроса (cookie уже выставлена) и можно выполнить перенаправление.
+// Иначе ответ уже отправлен: форма ввода пароля или результат проверки пароля
+func (g *PasswordGuard) Authorize(w http.ResponseWriter, r *http.Request, link models.Link) bool {
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method != http.MethodPost {
+		if g.validCookie(r, link) {
+			return true
+		}
+		g.renderForm(w, http.StatusOK, "")
+		return false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+	if err := r.ParseForm(); err != nil {
+		g.renderForm(w, http.StatusBadRequest, "Некорректный запрос.")
+		return false
+	}
+	if wait, ok := g.attempt(link.ShortURL); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		g.renderForm(w, http.StatusTooManyRequests, "Слишком много неверных попыток. Попробуйте позже.")
+		return false
+	}
+	if !utils.CheckPassword(link.PasswordHash, r.PostForm.Get("password")) {
+		g.renderForm(w, http.StatusForbidden, "Неверный пароль.")
+		return false
+	}
+
+	g.succeed(link.ShortURL)
+	expires := g.now().Add(g.ttl)
+	// Путь "/", а не "/{id}": иначе cookie не отправлялась бы для предпросмотра /{id}+;
+	// ссылку определяет имя cookie
+	http.SetCookie(w, &http.Cookie{
+		Name:     unlockCookiePrefix + link.ShortURL,
+		Value:    g.sign(link, expires),
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   int(g.ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return true
+}
+
+// sign возвращает значение cookie доступа: время истечения и подпись HMAC-SHA256
+// Подпись включает хэш пароля, поэтому смена пароля отзывает выданные cookie
+func (g *PasswordGuard) sign(link models.Link, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + g.mac(link, exp)
+}
+
+func (g *PasswordGuard) mac(link models.Link, exp string) string {
+	m := hmac.New(sha256.New, g.secret)
+	m.Write([]byte(link.ShortURL + "\x00" + link.PasswordHash + "\x00" + exp))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// validCookie проверяет подпись и срок действия cookie доступа к ссылке
+func (g *PasswordGuard) validCookie(r *http.Request, link models.Link) bool {
+	c, err := r.Cookie(unlockCookiePrefix + link.ShortURL)
+	if err != nil {
+		return false
+	}
+	exp, sig, ok := strings.Cut(c.Value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !g.now().Before(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(g.mac(link, exp)))
+}
+
+// attempt учитывает попытку ввода пароля до ее проверки, чтобы параллельные
+// запросы не обходили ограничение. Попытка считается неудачной, пока succeed
+// не сбросит счетчик. Если лимит попыток в текущем окне исчерпан, возвращает
+// false и время до конца окна
+func (g *PasswordGuard) attempt(shortID string) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	a, ok := g.attempts[shortID]
+	if !ok || !now.Before(a.reset) {
+		if len(g.attempts) >= maxTrackedLinks {
+			g.pruneLocked(now)
+		}
+		a = &failedAttempts{reset: now.Add(g.lockout)}
+		g.attempts[shortID] = a
+	}
+	if a.count >= g.maxAttempts {
+		return a.reset.Sub(now), false
+	}
+	a.count++
+	return 0, true
+}
+
+// succeed сбрасывает счетчик неудачных попыток после верного пароля
+func (g *PasswordGuard) succeed(shortID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.attempts, shortID)
+}
+
+// pruneLocked удаляет счетчики с истекшим окном. Вызывающий должен удерживать g.mu
+func (g *PasswordGuard) pruneLocked(now time.Time) {
+	for id, a := range g.attempts {
+		if !now.Before(a.reset) {
+			delete(g.attempts, id)
+		}
+	}
+}
+
+// passwordForm страница ввода пароля защищенной ссылки
+var passwordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Ссылка защищена паролем</title>
+</head>
+<body>
+<form method="post">
+<p>Ссылка защищена паролем.</p>
+{{if .}}<p role="alert">{{.}}</p>
+{{end}}<input type="password" name="password" autocomplete="current-password" autofocus required>
+<button type="submit">Открыть</button>
+</form>
+</body>
+</html>
+`))
+
+// renderForm отправляет форму ввода пароля с сообщением об ошибке message
+// Ошибка отправки логируется
+func (g *PasswordGuard) renderForm(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	if err := passwordForm.Execute(w, message); err != nil {
+		g.logger.Error("failed to render password form", zap.Error(err))
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/utils"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func setupProtectedLink(t *testing.T, guard *PasswordGuard) (http.Handler, storage.Storage) {
+	t.Helper()
+	store := storage.NewInMemoryStorage()
+	store.Save("secret", "https://internal.example.com/doc", "user1")
+	store.Save("open", "https://example.com", "user1")
+	hash, err := utils.HashPassword("hunter2")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if err := store.UpdateOptions("user1", "secret", models.LinkOptions{PasswordHash: hash}); err != nil {
+		t.Fatalf("failed to protect link: %v", err)
+	}
+
+	h := RedirectHandler(store, WithPasswordGuard(guard))
+	r := chi.NewRouter()
+	r.Get("/{id}", h)
+	r.Post("/{id}", h)
+	return r, store
+}
+
+func postPassword(h http.Handler, id, password string) *httptest.ResponseRecorder {
+	form := url.Values{"password": {password}}
+	req := httptest.NewRequest(http.MethodPost, "/"+id, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRedirectHandler_PasswordProtected(t *testing.T) {
+	guard := NewPasswordGuard(PasswordGuardOptions{Secret: []byte("test-secret"), UnlockTTL: time.Minute})
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+	h, _ := setupProtectedLink(t, guard)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/secret", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Fatalf("expected password form instead of redirect, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if !strings.Contains(rec.Body.String(), `type="password"`) || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("unexpected form response: %v %s", rec.Header(), rec.Body.String())
+	}
+
+	if rec := postPassword(h, "secret", "wrong"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for wrong password, got %d", rec.Code)
+	}
+
+	rec = postPassword(h, "secret", "hunter2")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "https://internal.example.com/doc" {
+		t.Fatalf("expected 303 to original URL, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].Path != "/" {
+		t.Fatalf("unexpected access cookie: %+v", cookies)
+	}
+
+	get := func(id string, c *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/"+id, nil)
+		req.AddCookie(c)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := get("secret", cookies[0]); rec.Code != http.StatusTemporaryRedirect {
+		t.Errorf("expected redirect with access cookie, got %d", rec.Code)
+	}
+
+	forged := *cookies[0]
+	forged.Value = strings.Replace(forged.Value, ".", "0.", 1)
+	if rec := get("secret", &forged); rec.Code != http.StatusOK {
+		t.Errorf("expected forged cookie to be rejected, got %d", rec.Code)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if rec := get("secret", cookies[0]); rec.Code != http.StatusOK {
+		t.Errorf("expected expired cookie to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestRedirectHandler_PasswordPreview(t *testing.T) {
+	guard := NewPasswordGuard(PasswordGuardOptions{Secret: []byte("test-secret"), UnlockTTL: time.Minute})
+	h, _ := setupProtectedLink(t, guard)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	// Браузер отправляет cookie по правилам RFC 6265: cookie с путем /secret
+	// не отправлялась бы для /secret+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.PostForm(srv.URL+"/secret", url.Values{"password": {"hunter2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("expected 303 after unlocking, got %d", resp.StatusCode)
+	}
+
+	resp, err = client.Get(srv.URL + "/secret+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || strings.Contains(string(body), `type="password"`) ||
+		!strings.Contains(string(body), "https://internal.example.com/doc") {
+		t.Errorf("expected preview of unlocked link, got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestRedirectHandler_PasswordThrottling(t *testing.T) {
+	guard := NewPasswordGuard(PasswordGuardOptions{MaxAttempts: 2, Lockout: time.Minute})
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+	h, _ := setupProtectedLink(t, guard)
+
+	for i := 0; i < 2; i++ {
+		if rec := postPassword(h, "secret", "wrong"); rec.Code != http.StatusForbidden {
+			t.Fatalf("attempt %d: expected 403, got %d", i+1, rec.Code)
+		}
+	}
+	rec := postPassword(h, "secret", "hunter2")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected 429 with Retry-After after too many attempts, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(time.Minute)
+	if rec := postPassword(h, "secret", "hunter2"); rec.Code != http.StatusSeeOther {
+		t.Errorf("expected access after lockout window, got %d", rec.Code)
+	}
+}
+
+func TestRedirectHandler_PostToOpenLink(t *testing.T) {
+	h, _ := setupProtectedLink(t, NewPasswordGuard(PasswordGuardOptions{}))
+	if rec := postPassword(h, "open", "x"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestPasswordGuard_RenderError(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	guard := NewPasswordGuard(PasswordGuardOptions{Logger: zap.New(core)})
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	if guard.Authorize(failingWriter{httptest.NewRecorder()}, req, models.Link{ShortURL: "abc", LinkOptions: models.LinkOptions{PasswordHash: "hash"}}) {
+		t.Fatal("expected access to be denied without password")
+	}
+	if logs.FilterMessage("failed to render password form").Len() != 1 {
+		t.Errorf("expected render error to be logged, got %v", logs.All())
+	}
+}
+
+func TestAPIShortenHandler_Password(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	h := setupAPIShortenRouter(cfg, store)
+
+	body := `{"url":"https://internal.example.com","password":"hunter2"}`
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body)))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d", http.StatusCreated, res.Code)
+	}
+
+	id, _ := store.FindByOriginal("https://internal.example.com")
+	link, err := store.GetLink(id)
+	if err != nil {
+		t.Fatalf("failed to get link: %v", err)
+	}
+	if !link.Protected() || strings.Contains(link.PasswordHash, "hunter2") || !utils.CheckPassword(link.PasswordHash, "hunter2") {
+		t.Errorf("expected link to be protected by password hash, got %q", link.PasswordHash)
+	}
+}
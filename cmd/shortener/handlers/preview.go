@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"html/template"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"uno/cmd/shortener/models"
+
+	"go.uber.org/zap"
+)
+
+// DefaultInterstitialDelay обратный отсчет страницы "вы покидаете сайт" по умолчанию
+const DefaultInterstitialDelay = 5 * time.Second
+
+// previewSuffix суффикс сокращенного ID, открывающий страницу предпросмотра (/{id}+)
+const previewSuffix = "+"
+
+// PreviewOptions задает параметры страниц предпросмотра и "вы покидаете сайт"
+type PreviewOptions struct {
+	Interstitial bool          // Показывать страницу "вы покидаете сайт" перед каждым перенаправлением
+	Delay        time.Duration // Обратный отсчет перед перенаправлением (0 - DefaultInterstitialDelay)
+	Logger       *zap.Logger   // Логгер ошибок отправки страниц (nil - без логирования)
+}
+
+// Preview отдает HTML страницы вместо перенаправления
+//
+// Страница предпросмотра (/{id}+) показывает адрес назначения, дату создания
+// и название ссылки и ничего не делает сама. Страница "вы покидаете сайт"
+// показывается для ссылок с параметром interstitial или для всех ссылок,
+// если он включен глобально, и переходит по адресу после обратного отсчета
+type Preview struct {
+	interstitial bool
+	delay        time.Duration
+	logger       *zap.Logger
+}
+
+// NewPreview создает Preview
+func NewPreview(opts PreviewOptions) *Preview {
+	p := &Preview{interstitial: opts.Interstitial, delay: opts.Delay, logger: opts.Logger}
+	if p.delay <= 0 {
+		p.delay = DefaultInterstitialDelay
+	}
+	if p.logger == nil {
+		p.logger = zap.NewNop()
+	}
+	return p
+}
+
+// splitPreview отделяет суффикс предпросмотра от сокращенного ID
+func splitPreview(id string) (string, bool) {
+	if trimmed, ok := strings.CutSuffix(id, previewSuffix); ok && trimmed != "" {
+		return trimmed, true
+	}
+	return id, false
+}
+
+// Interstitial сообщает, нужна ли ссылке страница "вы покидаете сайт"
+func (p *Preview) Interstitial(link models.Link) bool {
+	return p.interstitial || link.Interstitial
+}
+
+// previewPage данные страниц предпросмотра и "вы покидаете сайт"
+type previewPage struct {
+	OriginalURL string
+	Title       string
+	CreatedAt   string
+	Delay       int
+}
+
+// ServePreview отдает страницу предпросмотра ссылки
+// Название и дату создания ссылка уже содержит (GetLink)
+func (p *Preview) ServePreview(w http.ResponseWriter, link models.Link) {
+	page := previewPage{OriginalURL: link.OriginalURL, Title: link.Title}
+	if link.CreatedAt != nil {
+		page.CreatedAt = link.CreatedAt.UTC().Format("02.01.2006 15:04 UTC")
+	}
+	p.render(w, previewTemplate, page)
+}
+
+// ServeInterstitial отдает страницу "вы покидаете сайт" с обратным отсчетом
+// до перехода по адресу target
+func (p *Preview) ServeInterstitial(w http.ResponseWriter, target string) {
+	p.render(w, interstitialTemplate, previewPage{
+		OriginalURL: target,
+		Delay:       int(math.Ceil(p.delay.Seconds())),
+	})
+}
+
+// render отправляет HTML страницу, которую нельзя встраивать и кэшировать:
+// адрес назначения ссылки может измениться. Ошибка отправки логируется
+func (p *Preview) render(w http.ResponseWriter, tmpl *template.Template, page previewPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	if err := tmpl.Execute(w, page); err != nil {
+		p.logger.Error("failed to render page", zap.String("template", tmpl.Name()), zap.Error(err))
+	}
+}
+
+// previewTemplate страница предпросмотра ссылки
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Предпросмотр ссылки</title>
+</head>
+<body>
+{{if .Title}}<h1>{{.Title}}</h1>
+{{end}}<p>Ссылка ведет на:</p>
+<p><a href="{{.OriginalURL}}" rel="noreferrer">{{.OriginalURL}}</a></p>
+{{if .CreatedAt}}<p>Создана {{.CreatedAt}}</p>
+{{end}}</body>
+</html>
+`))
+
+// interstitialTemplate страница "вы покидаете сайт"; переход выполняется
+// meta refresh, сценарий только обновляет счетчик
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="{{.Delay}};url={{.OriginalURL}}">
+<title>Вы покидаете сайт</title>
+</head>
+<body>
+<p>Вы покидаете сайт и переходите по адресу:</p>
+<p><a href="{{.OriginalURL}}" rel="noreferrer">{{.OriginalURL}}</a></p>
+<p>Переход через <span id="countdown">{{.Delay}}</span> с.</p>
+<script>
+(function () {
+  var left = {{.Delay}}, el = document.getElementById("countdown");
+  var timer = setInterval(function () {
+    left = Math.max(left - 1, 0);
+    el.textContent = left;
+    if (left === 0) clearInterval(timer);
+  }, 1000);
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func setupPreviewRouter(store storage.Storage, p *Preview) http.Handler {
+	h := RedirectHandler(store, WithPreview(p))
+	r := chi.NewRouter()
+	r.Get("/{id}", h)
+	r.Post("/{id}", h)
+	return r
+}
+
+func TestRedirectHandler_Preview(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com/page?a=1&b=2", "user1")
+	if err := store.UpdateMetadata("user1", "abc", models.LinkMetadata{Title: "<Docs>"}); err != nil {
+		t.Fatal(err)
+	}
+	store.Save("gone", "https://gone.example.com", "user1")
+	if err := store.DeleteURLs("user1", []string{"gone"}); err != nil {
+		t.Fatal(err)
+	}
+	h := setupPreviewRouter(store, NewPreview(PreviewOptions{}))
+
+	rec := doAsUser(h, http.MethodGet, "/abc+", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Fatalf("expected preview page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`href="https://example.com/page?a=1&amp;b=2"`,
+		"&lt;Docs&gt;",
+		"Создана " + time.Now().UTC().Format("02.01.2006"),
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("preview page does not contain %q: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "http-equiv") {
+		t.Error("preview page must not redirect by itself")
+	}
+
+	// Обычное перенаправление не изменилось
+	if rec := doAsUser(h, http.MethodGet, "/abc", "", ""); rec.Code != http.StatusTemporaryRedirect {
+		t.Errorf("expected 307 without suffix, got %d", rec.Code)
+	}
+
+	cases := []struct {
+		target string
+		status int
+	}{
+		{"/missing+", http.StatusNotFound},
+		{"/gone+", http.StatusGone},
+		{"/+", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		if rec := doAsUser(h, http.MethodGet, c.target, "", ""); rec.Code != c.status {
+			t.Errorf("%s: expected %d, got %d", c.target, c.status, rec.Code)
+		}
+	}
+}
+
+// failingWriter ResponseWriter, запись в который не удается
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestPreview_RenderError(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	p := NewPreview(PreviewOptions{Logger: zap.New(core)})
+
+	p.ServeInterstitial(failingWriter{httptest.NewRecorder()}, "https://example.com")
+	if logs.FilterMessage("failed to render page").Len() != 1 {
+		t.Errorf("expected render error to be logged, got %v", logs.All())
+	}
+}
+
+func TestRedirectHandler_Interstitial(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("open", "https://example.com", "user1")
+	h := setupPreviewRouter(store, NewPreview(PreviewOptions{Delay: 3 * time.Second}))
+
+	// Режим interstitial задается при сокращении
+	api := setupQRRouter(cfg, store)
+	rec := doAsUser(api, http.MethodPost, "/api/shorten", `{"url":"https://leaving.example.com","interstitial":true}`, "user1")
+	var resp models.APIResponse
+	if err := resp.UnmarshalJSON(rec.Body.Bytes()); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	id := strings.TrimPrefix(resp.Result, cfg.BaseURL+"/")
+
+	rec = doAsUser(h, http.MethodGet, "/"+id, "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Fatalf("expected interstitial page, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `content="3;url=https://leaving.example.com"`) {
+		t.Errorf("unexpected interstitial page: %s", body)
+	}
+	if rec := doAsUser(h, http.MethodGet, "/open", "", ""); rec.Code != http.StatusTemporaryRedirect {
+		t.Errorf("expected 307 for link without interstitial, got %d", rec.Code)
+	}
+
+	// Глобальный режим действует на все ссылки
+	global := setupPreviewRouter(store, NewPreview(PreviewOptions{Interstitial: true}))
+	rec = doAsUser(global, http.MethodGet, "/open", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `content="5;url=https://example.com"`) {
+		t.Errorf("expected interstitial page with default delay, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/storage"
+	"uno/pkg/qrcode"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Параметры изображения QR кода
+const (
+	defaultQRSize  = 256           // Сторона изображения по умолчанию в пикселях
+	maxQRSize      = 2048          // Наибольшая сторона изображения в пикселях
+	defaultQRLevel = qrcode.Medium // Уровень коррекции ошибок по умолчанию
+	qrMaxAge       = 24 * 60 * 60  // Время кэширования изображения в секундах
+)
+
+// Форматы изображения QR кода
+const (
+	qrFormatPNG = "png"
+	qrFormatSVG = "svg"
+)
+
+// qrParams разобранные параметры запроса QR кода
+type qrParams struct {
+	format string
+	size   int
+	level  qrcode.Level
+}
+
+// QRCodeHandler обрабатывает GET запросы QR кода сокращенной ссылки
+// Код содержит полный сокращенный URL и не зависит от адреса перенаправления,
+// поэтому изображение кэшируется клиентами и проверяется по ETag
+// Параметры запроса: format (png или svg), size (сторона в пикселях) и level (L, M, Q или H)
+func QRCodeHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		shortID := chi.URLParam(r, "id")
+		params, err := parseQRParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		link, err := store.GetLink(shortID)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if link.Deleted {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+
+		shortURL := cfg.BaseURL + "/" + shortID
+		etag := qrETag(shortURL, params)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", qrMaxAge))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		code, err := qrcode.Encode([]byte(shortURL), params.level)
+		if err != nil {
+			http.Error(w, "failed to encode QR code", http.StatusInternalServerError)
+			return
+		}
+		var buf bytes.Buffer
+		contentType := "image/png"
+		if params.format == qrFormatSVG {
+			contentType = "image/svg+xml"
+			err = code.WriteSVG(&buf, params.size)
+		} else {
+			err = code.WritePNG(&buf, params.size)
+		}
+		if errors.Is(err, qrcode.ErrTooSmall) {
+			http.Error(w, fmt.Sprintf("size must be at least %d", code.MinImageSize()), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to render QR code", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+}
+
+// parseQRParams разбирает параметры запроса QR кода, подставляя значения по умолчанию
+func parseQRParams(r *http.Request) (qrParams, error) {
+	q := r.URL.Query()
+	params := qrParams{format: qrFormatPNG, size: defaultQRSize, level: defaultQRLevel}
+
+	switch f := q.Get("format"); f {
+	case "", qrFormatPNG:
+	case qrFormatSVG:
+		params.format = qrFormatSVG
+	default:
+		return params, fmt.Errorf("unknown format %q", f)
+	}
+
+	if s := q.Get("size"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size <= 0 || size > maxQRSize {
+			return params, fmt.Errorf("size must be between 1 and %d", maxQRSize)
+		}
+		params.size = size
+	}
+
+	if l := q.Get("level"); l != "" {
+		level, err := qrcode.ParseLevel(l)
+		if err != nil {
+			return params, err
+		}
+		params.level = level
+	}
+	return params, nil
+}
+
+// qrETag строгий ETag изображения: оно однозначно определяется URL и параметрами
+func qrETag(shortURL string, params qrParams) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%d\x00%s", shortURL, params.format, params.size, params.level))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// wantQR сообщает, запрошена ли ссылка на QR код в ответе на сокращение (?qr=true)
+func wantQR(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get("qr")
+	if s == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid qr parameter %q", s)
+	}
+	return v, nil
+}
+
+// qrURL возвращает адрес QR кода сокращенной ссылки или пустую строку, если он не запрошен
+func qrURL(cfg *config.Config, shortID string, want bool) string {
+	if !want {
+		return ""
+	}
+	return cfg.BaseURL + "/" + shortID + "/qr"
+}
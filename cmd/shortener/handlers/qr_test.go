@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupQRRouter(cfg *config.Config, store storage.Storage) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{id}/qr", QRCodeHandler(cfg, store))
+	r.Post("/api/shorten", APIShortenHandler(cfg, store))
+	r.Post("/api/shorten/batch", BatchShortenHandler(cfg, store))
+	return r
+}
+
+func TestQRCodeHandler(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com", "user1")
+	h := setupQRRouter(cfg, store)
+
+	rec := doAsUser(h, http.MethodGet, "/abc/qr", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected PNG, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != defaultQRSize || b.Dy() != defaultQRSize {
+		t.Errorf("image bounds = %v, want %d", b, defaultQRSize)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || !strings.Contains(rec.Header().Get("Cache-Control"), "max-age=") {
+		t.Errorf("missing caching headers: %v", rec.Header())
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/abc/qr?format=svg&size=512&level=H", "", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("expected SVG, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), `width="512"`) {
+		t.Errorf("unexpected SVG: %s", rec.Body.String())
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Error("expected different ETag for different parameters")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/abc/qr", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected 304 for matching ETag, got %d", rec.Code)
+	}
+}
+
+func TestQRCodeHandler_Errors(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com", "user1")
+	store.Save("gone", "https://gone.example.com", "user1")
+	if err := store.DeleteURLs("user1", []string{"gone"}); err != nil {
+		t.Fatal(err)
+	}
+	h := setupQRRouter(cfg, store)
+
+	cases := []struct {
+		target string
+		status int
+	}{
+		{"/missing/qr", http.StatusNotFound},
+		{"/gone/qr", http.StatusGone},
+		{"/abc/qr?format=gif", http.StatusBadRequest},
+		{"/abc/qr?size=0", http.StatusBadRequest},
+		{"/abc/qr?size=100000", http.StatusBadRequest},
+		{"/abc/qr?size=20", http.StatusBadRequest},
+		{"/abc/qr?level=X", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		if rec := doAsUser(h, http.MethodGet, c.target, "", ""); rec.Code != c.status {
+			t.Errorf("%s: expected %d, got %d", c.target, c.status, rec.Code)
+		}
+	}
+}
+
+func TestShortenHandlers_QROption(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	h := setupQRRouter(cfg, store)
+
+	rec := doAsUser(h, http.MethodPost, "/api/shorten?qr=true", `{"url":"https://example.com"}`, "user1")
+	var resp models.APIResponse
+	if err := resp.UnmarshalJSON(rec.Body.Bytes()); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	if resp.QR != resp.Result+"/qr" {
+		t.Errorf("qr = %q, want %q", resp.QR, resp.Result+"/qr")
+	}
+
+	rec = doAsUser(h, http.MethodPost, "/api/shorten", `{"url":"https://other.example.com"}`, "user1")
+	if strings.Contains(rec.Body.String(), `"qr"`) {
+		t.Errorf("qr must be omitted unless requested: %s", rec.Body.String())
+	}
+
+	rec = doAsUser(h, http.MethodPost, "/api/shorten/batch?qr=1", `[{"correlation_id":"1","original_url":"https://batch.example.com"}]`, "user1")
+	var list models.BatchResponseList
+	if err := list.UnmarshalJSON(rec.Body.Bytes()); err != nil || len(list) != 1 {
+		t.Fatalf("unexpected batch response %d %s", rec.Code, rec.Body.String())
+	}
+	if list[0].QR != list[0].ShortURL+"/qr" {
+		t.Errorf("batch qr = %q", list[0].QR)
+	}
+
+	if rec := doAsUser(h, http.MethodPost, "/api/shorten?qr=maybe", `{"url":"https://x.example.com"}`, "user1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid qr parameter, got %d", rec.Code)
+	}
+}
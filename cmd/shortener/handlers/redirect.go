@@ -1,30 +1,157 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
+	"uno/cmd/shortener/models"
 	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/utils"
 
 	"github.com/go-chi/chi/v5"
 )
 
-// RedirectHandler обрабатывает GET запросы для перенаправления по сокращенным URL
-// Извлекает shortID из URL параметра и перенаправляет на оригинальный URL
-func RedirectHandler(store storage.Storage) http.HandlerFunc {
+// RedirectOption настраивает обработчик перенаправлений
+type RedirectOption func(*redirectOptions)
+
+// redirectOptions параметры обработчика перенаправлений
+type redirectOptions struct {
+	guard   *PasswordGuard // Проверка паролей защищенных ссылок
+	preview *Preview       // Страницы предпросмотра и "вы покидаете сайт"
+	onClick []ClickFunc    // Обработчики переходов по ссылкам
+}
+
+// ClickFunc вызывается для каждого перехода по ссылке: перенаправления
+// или страницы "вы покидаете сайт"
+type ClickFunc func(r *http.Request, click Click)
+
+// WithPasswordGuard задает проверку паролей защищенных ссылок
+// Без нее используется PasswordGuard со случайным ключом и параметрами по умолчанию
+func WithPasswordGuard(g *PasswordGuard) RedirectOption {
+	return func(o *redirectOptions) {
+		o.guard = g
+	}
+}
+
+// WithPreview задает страницы предпросмотра и "вы покидаете сайт"
+// Без нее страница "вы покидаете сайт" показывается только для ссылок с параметром interstitial
+func WithPreview(p *Preview) RedirectOption {
+	return func(o *redirectOptions) {
+		o.preview = p
+	}
+}
+
+// WithClickFunc добавляет обработчик переходов по ссылкам
+// HEAD запросы, страницы предпросмотра и формы ввода пароля переходами не считаются,
+// перенаправление после верного пароля считается
+func WithClickFunc(fn ClickFunc) RedirectOption {
+	return func(o *redirectOptions) {
+		o.onClick = append(o.onClick, fn)
+	}
+}
+
+// RedirectHandler обрабатывает GET и HEAD запросы для перенаправления по сокращенным URL
+// Извлекает shortID из URL параметра и перенаправляет с кодом ответа ссылки на адрес
+// первого сработавшего правила ссылки (платформа, язык, окно времени), на закрепленный
+// за посетителем вариант ссылки с разделением трафика или на оригинальный URL;
+// query параметры запроса переносятся в адрес по политике ссылки
+// Для ссылок, защищенных паролем, вместо перенаправления отдается форма ввода пароля;
+// тот же обработчик принимает POST запросы с паролем из этой формы
+// Для /{id}+ отдается страница предпросмотра, а для ссылок в режиме interstitial -
+// страница "вы покидаете сайт" с обратным отсчетом
+func RedirectHandler(store storage.Storage, opts ...RedirectOption) http.HandlerFunc {
+	o := redirectOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.guard == nil {
+		o.guard = NewPasswordGuard(PasswordGuardOptions{})
+	}
+	if o.preview == nil {
+		o.preview = NewPreview(PreviewOptions{})
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		shortID := chi.URLParam(r, "id")
-		originalURL, deleted, exists := store.Get(shortID)
+		shortID, preview := splitPreview(chi.URLParam(r, "id"))
+		link, err := store.GetLink(shortID)
 
-		if !exists {
+		if errors.Is(err, storage.ErrNotFound) {
 			http.NotFound(w, r)
 			return
 		}
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
-		if deleted {
+		if link.Deleted {
 			w.WriteHeader(http.StatusGone)
 			return
 		}
 
-		w.Header().Set("Location", originalURL)
-		w.WriteHeader(http.StatusTemporaryRedirect)
+		// Адрес назначения зависит от клиента, общие кэши должны это учитывать
+		if len(link.Rules) > 0 {
+			w.Header().Add("Vary", "User-Agent, Accept-Language")
+		}
+		if len(link.Variants) > 0 {
+			w.Header().Add("Vary", "Cookie")
+		}
+
+		if link.Protected() {
+			if !o.guard.Authorize(w, r, link) {
+				return
+			}
+		} else if r.Method == http.MethodPost {
+			// POST принимает только пароль защищенной ссылки
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if preview {
+			o.preview.ServePreview(w, link)
+			return
+		}
+
+		click := Click{Link: link}
+		destination, ok := link.MatchRule(models.RuleContext{
+			Platform: utils.DetectPlatform(r.UserAgent()),
+			Language: utils.PreferredLanguage(r.Header.Get("Accept-Language")),
+			Now:      time.Now(),
+		})
+		if !ok {
+			destination = link.OriginalURL
+			if len(link.Variants) > 0 {
+				v := chooseVariant(w, r, link)
+				destination, click.Variant = v.URL, v.Name
+			}
+		}
+		target := utils.MergeQuery(destination, r.URL.RawQuery, link.QueryPolicy)
+		click.Target = target
+
+		if o.preview.Interstitial(link) {
+			o.click(r, click)
+			o.preview.ServeInterstitial(w, target)
+			return
+		}
+
+		status := link.RedirectStatus()
+		if r.Method == http.MethodPost {
+			// После верного пароля: повторять POST с паролем по адресу назначения нельзя
+			status = http.StatusSeeOther
+		}
+		o.click(r, click)
+		w.Header().Set("Location", target)
+		w.WriteHeader(status)
+	}
+}
+
+// click передает переход обработчикам; HEAD запросы не учитываются
+func (o *redirectOptions) click(r *http.Request, click Click) {
+	if r.Method == http.MethodHead {
+		return
+	}
+	for _, fn := range o.onClick {
+		fn(r, click)
 	}
 }
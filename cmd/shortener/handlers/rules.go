@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LinkRulesHandler обрабатывает GET запросы правил перенаправления ссылки пользователя
+// Возвращает правила в порядке проверки (пустой массив, если правил нет)
+// или 404 Not Found, если у пользователя нет такой ссылки
+func LinkRulesHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		link, ok := loadUserLink(w, r, store, userID, chi.URLParam(r, "id"))
+		if !ok {
+			return
+		}
+		writeRules(w, link.Rules)
+	}
+}
+
+// UpdateLinkRulesHandler обрабатывает PUT запросы для замены правил перенаправления
+// Принимает JSON массив правил в порядке проверки; пустой массив удаляет все правила.
+// Остальные параметры перенаправления и пароль ссылки не изменяются, в том числе
+// если их одновременно изменяет другой запрос
+func UpdateLinkRulesHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var rules models.RedirectRuleList
+		if err := rules.UnmarshalJSON(data); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		opts := models.LinkOptions{Rules: rules}
+		if err := opts.Normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = store.UpdateRules(userID, shortID, opts.Rules)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to update link rules", http.StatusInternalServerError)
+			return
+		}
+
+		writeRules(w, opts.Rules)
+	}
+}
+
+// writeRules отправляет правила перенаправления ссылки
+func writeRules(w http.ResponseWriter, rules []models.RedirectRule) {
+	if rules == nil {
+		rules = []models.RedirectRule{}
+	}
+	data, err := models.RedirectRuleList(rules).MarshalJSON()
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupRulesRouter(store storage.Storage) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/api/user/urls/{id}/rules", LinkRulesHandler(store))
+	r.Put("/api/user/urls/{id}/rules", UpdateLinkRulesHandler(store))
+	r.Patch("/api/user/urls/{id}/options", UpdateLinkOptionsHandler(store))
+	r.Get("/{id}", RedirectHandler(store))
+	return r
+}
+
+func TestRedirectHandler_Rules(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("app", "https://example.com/app", "user1")
+	h := setupRulesRouter(store)
+
+	rec := doAsUser(h, http.MethodPut, "/api/user/urls/app/rules", `[
+		{"platform":"ios","url":"https://apps.apple.com/app"},
+		{"platform":"Android","url":"https://play.google.com/app"},
+		{"languages":["DE"],"url":"https://example.com/de/app"},
+		{"languages":["fr"],"until":"2000-01-01T00:00:00Z","url":"https://example.com/fr/expired"}
+	]`, "user1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"platform":"android"`) || !strings.Contains(body, `"languages":["de"]`) {
+		t.Errorf("expected normalized rules, got %s", body)
+	}
+
+	// Политика query параметров применяется и к адресам правил
+	if rec := doAsUser(h, http.MethodPatch, "/api/user/urls/app/options", `{"query_policy":"append"}`, "user1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	cases := []struct {
+		name      string
+		userAgent string
+		language  string
+		want      string
+	}{
+		{"ios", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "de-DE", "https://apps.apple.com/app?ref=x"},
+		{"android", "Mozilla/5.0 (Linux; Android 14; Pixel 8)", "", "https://play.google.com/app?ref=x"},
+		{"german desktop", "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", "de-AT,de;q=0.9,en;q=0.5", "https://example.com/de/app?ref=x"},
+		{"english prefers over german", "Mozilla/5.0 (X11; Linux x86_64)", "en-US,de;q=0.9", "https://example.com/app?ref=x"},
+		{"expired window", "", "fr", "https://example.com/app?ref=x"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/app?ref=x", nil)
+			req.Header.Set("User-Agent", c.userAgent)
+			req.Header.Set("Accept-Language", c.language)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusTemporaryRedirect || rec.Header().Get("Location") != c.want {
+				t.Errorf("expected redirect to %q, got %d %q", c.want, rec.Code, rec.Header().Get("Location"))
+			}
+			if vary := rec.Header().Get("Vary"); vary != "User-Agent, Accept-Language" {
+				t.Errorf("unexpected Vary header %q", vary)
+			}
+		})
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/api/user/urls/app/rules", "", "user1")
+	if rec.Code != http.StatusOK || strings.Count(rec.Body.String(), `"url"`) != 4 {
+		t.Errorf("unexpected rules response: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Пустой массив удаляет правила, остальные параметры сохраняются
+	if rec := doAsUser(h, http.MethodPut, "/api/user/urls/app/rules", `[]`, "user1"); rec.Code != http.StatusOK || rec.Body.String() != "[]" {
+		t.Fatalf("expected empty rules, got %d %s", rec.Code, rec.Body.String())
+	}
+	link, err := store.GetLink("app")
+	if err != nil || link.Rules != nil || link.QueryPolicy != "append" {
+		t.Errorf("unexpected link after clearing rules: %+v (%v)", link, err)
+	}
+	rec = doAsUser(h, http.MethodGet, "/app", "", "")
+	if rec.Header().Get("Location") != "https://example.com/app" || rec.Header().Get("Vary") != "" {
+		t.Errorf("expected plain redirect without rules, got %q %q", rec.Header().Get("Location"), rec.Header().Get("Vary"))
+	}
+}
+
+func TestUpdateLinkRulesHandler_Errors(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("app", "https://example.com/app", "user1")
+	h := setupRulesRouter(store)
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		userID string
+		status int
+	}{
+		{"unauthorized", http.MethodPut, "/api/user/urls/app/rules", `[]`, "", http.StatusUnauthorized},
+		{"another user", http.MethodPut, "/api/user/urls/app/rules", `[]`, "user2", http.StatusNotFound},
+		{"another user get", http.MethodGet, "/api/user/urls/app/rules", "", "user2", http.StatusNotFound},
+		{"missing link", http.MethodGet, "/api/user/urls/missing/rules", "", "user1", http.StatusNotFound},
+		{"not an array", http.MethodPut, "/api/user/urls/app/rules", `{"platform":"ios"}`, "user1", http.StatusBadRequest},
+		{"unknown platform", http.MethodPut, "/api/user/urls/app/rules", `[{"platform":"symbian","url":"https://x.example.com"}]`, "user1", http.StatusBadRequest},
+		{"relative url", http.MethodPut, "/api/user/urls/app/rules", `[{"platform":"ios","url":"/app"}]`, "user1", http.StatusBadRequest},
+		{"no conditions", http.MethodPut, "/api/user/urls/app/rules", `[{"url":"https://x.example.com"}]`, "user1", http.StatusBadRequest},
+		{"empty window", http.MethodPut, "/api/user/urls/app/rules",
+			`[{"from":"2027-01-01T00:00:00Z","until":"2026-01-01T00:00:00Z","url":"https://x.example.com"}]`, "user1", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec := doAsUser(h, c.method, c.target, c.body, c.userID); rec.Code != c.status {
+				t.Errorf("expected %d, got %d %s", c.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,7 +16,8 @@ import (
 
 // ShortenURLHandler обрабатывает POST запросы для сокращения URL в текстовом формате
 // Принимает URL в теле запроса и возвращает сокращенную ссылку
-func ShortenURLHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+func ShortenURLHandler(cfg *config.Config, store storage.Storage, opts ...ShortenOption) http.HandlerFunc {
+	o := newShortenOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := middleware.FromContext(r.Context())
 		if !ok || userID == "" {
@@ -39,12 +42,16 @@ func ShortenURLHandler(cfg *config.Config, store storage.Storage) http.HandlerFu
 			return
 		}
 
-		shortID, err := utils.GenerateShortID()
+		shortID, err := utils.NewShortID(originalURL, idExists(store, nil))
 		if err != nil {
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
-		store.Save(shortID, originalURL, userID)
+		if err := store.Save(shortID, originalURL, userID); err != nil {
+			http.Error(w, "failed to save link", http.StatusInternalServerError)
+			return
+		}
+		o.created(userID, shortID, originalURL)
 
 		w.WriteHeader(http.StatusCreated)
 		fmt.Fprint(w, cfg.BaseURL+"/"+shortID)
@@ -52,16 +59,28 @@ func ShortenURLHandler(cfg *config.Config, store storage.Storage) http.HandlerFu
 }
 
 // APIShortenHandler обрабатывает POST запросы для сокращения URL через JSON API
-// Принимает JSON с полем "url" и возвращает JSON с полем "result"
-func APIShortenHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+// Принимает JSON с полем "url", необязательными метаданными "title", "tags", "note"
+// и параметрами перенаправления "password", "interstitial", "redirect_code", "query_policy"
+// и возвращает JSON с полем "result"; с параметром ?qr=true еще и адрес QR кода "qr"
+func APIShortenHandler(cfg *config.Config, store storage.Storage, opts ...ShortenOption) http.HandlerFunc {
+	o := newShortenOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := middleware.FromContext(r.Context())
 		if !ok || userID == "" {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
+		withQR, err := wantQR(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		data, _ := io.ReadAll(r.Body)
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
 		var req models.APIRequest
 		if err := req.UnmarshalJSON(data); err != nil {
 			http.Error(w, "invalid JSON", http.StatusBadRequest)
@@ -73,99 +92,195 @@ func APIShortenHandler(cfg *config.Config, store storage.Storage) http.HandlerFu
 			http.Error(w, "empty URL", http.StatusBadRequest)
 			return
 		}
+		if err := req.LinkMetadata.Normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts, err := linkOptions(req.Password, models.LinkOptions{
+			Interstitial: req.Interstitial,
+			RedirectCode: req.RedirectCode,
+			QueryPolicy:  req.QueryPolicy,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		if existingID, ok := store.FindByOriginal(originalURL); ok {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			resp := models.APIResponse{Result: cfg.BaseURL + "/" + existingID}
+		conflict := func(existingID string) {
+			resp := models.APIResponse{Result: cfg.BaseURL + "/" + existingID, QR: qrURL(cfg, existingID, withQR)}
 			data, err := resp.MarshalJSON()
 			if err != nil {
 				http.Error(w, "failed to encode response", http.StatusInternalServerError)
 				return
 			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
 			w.Write(data)
+		}
+		if existingID, ok := store.FindByOriginal(originalURL); ok {
+			conflict(existingID)
 			return
 		}
 
-		shortID, err := utils.GenerateShortID()
+		shortID, err := utils.NewShortID(originalURL, idExists(store, nil))
 		if err != nil {
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
-		store.Save(shortID, originalURL, userID)
+		// Ссылка сохраняется вместе с паролем и остальными параметрами одной операцией
+		if err := store.SaveLink(shortID, originalURL, userID, req.LinkMetadata, opts); err != nil {
+			// Тот же URL мог быть сокращен параллельным запросом
+			if existingID, ok := store.FindByOriginal(originalURL); ok && errors.Is(err, storage.ErrConflict) {
+				conflict(existingID)
+				return
+			}
+			http.Error(w, "failed to save link", http.StatusInternalServerError)
+			return
+		}
+
+		o.created(userID, shortID, originalURL)
 
 		resp := models.APIResponse{
 			Result: cfg.BaseURL + "/" + shortID,
+			QR:     qrURL(cfg, shortID, withQR),
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		if data, err := resp.MarshalJSON(); err != nil {
+		respData, err := resp.MarshalJSON()
+		if err != nil {
 			http.Error(w, "failed to encode response", http.StatusInternalServerError)
 			return
-		} else {
-			w.Write(data)
 		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(respData)
 	}
 }
 
 // BatchShortenHandler обрабатывает POST запросы для пакетного сокращения URL
-// Принимает массив URL с correlation_id и возвращает массив сокращенных ссылок
-func BatchShortenHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+// Принимает массив URL с correlation_id, необязательными метаданными и параметрами перенаправления
+// и возвращает массив результатов в порядке запроса; с параметром ?qr=true - вместе с адресами QR кодов.
+// Массив читается и сохраняется частями по batchChunkSize, а результаты каждой части отправляются
+// сразу, поэтому память не растет с размером пакета. Элемент, который не удалось сохранить,
+// получает поле "error" и не мешает остальным. Ошибки чтения первой части (неверный JSON,
+// пустой пакет, больше cfg.BatchMaxSize ссылок) возвращают 400 или 413; после начала ответа
+// такая ошибка завершает массив элементом с пустыми "correlation_id" и "short_url" и полем "error"
+func BatchShortenHandler(cfg *config.Config, store storage.Storage, opts ...ShortenOption) http.HandlerFunc {
+	o := newShortenOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := middleware.FromContext(r.Context())
 		if !ok || userID == "" {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
-
-		data, err := io.ReadAll(r.Body)
+		withQR, err := wantQR(r)
 		if err != nil {
-			http.Error(w, "failed to read body", http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		var requests []models.BatchRequest
-		if err := models.UnmarshalBatchRequest(data, &requests); err != nil {
+		dec, err := newBatchDecoder(r.Body, cfg.BatchMaxSize)
+		if err != nil {
 			http.Error(w, "invalid JSON", http.StatusBadRequest)
 			return
 		}
-
-		if len(requests) == 0 {
+		chunk, err := dec.nextChunk(make([]batchItem, 0, batchChunkSize))
+		switch {
+		case errors.Is(err, errBatchTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil && !errors.Is(err, io.EOF):
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		case len(chunk) == 0:
 			http.Error(w, "empty batch", http.StatusBadRequest)
 			return
 		}
 
-		pairs := make(map[string]string, len(requests))
-		responses := make([]models.BatchResponse, 0, len(requests))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		bw := bufio.NewWriter(w)
+		rc := http.NewResponseController(w)
+		sep := "["
+		write := func(resp models.BatchResponse) {
+			data, _ := resp.MarshalJSON()
+			bw.WriteString(sep)
+			bw.Write(data)
+			sep = ","
+		}
 
-		for _, req := range requests {
-			shortID, err := utils.GenerateShortID()
+		for {
+			for _, res := range saveChunk(store, userID, chunk, o) {
+				resp := models.BatchResponse{CorrelationID: res.CorrelationID}
+				if res.ShortID != "" {
+					resp.ShortURL = cfg.BaseURL + "/" + res.ShortID
+					resp.QR = qrURL(cfg, res.ShortID, withQR)
+				}
+				if res.Err != nil {
+					resp.Error = res.Err.Error()
+				}
+				write(resp)
+			}
 			if err != nil {
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				break
+			}
+			if bw.Flush() != nil || flushChunk(r, rc) != nil {
 				return
 			}
-			pairs[shortID] = req.OriginalURL
-
-			responses = append(responses, models.BatchResponse{
-				CorrelationID: req.CorrelationID,
-				ShortURL:      cfg.BaseURL + "/" + shortID,
-			})
+			chunk, err = dec.nextChunk(chunk[:0])
 		}
 
-		if err := store.SaveBatch(pairs, userID); err != nil {
-			http.Error(w, "failed to save batch", http.StatusInternalServerError)
-			return
+		if !errors.Is(err, io.EOF) {
+			msg := err.Error()
+			if !errors.Is(err, errBatchTooLarge) {
+				msg = "invalid JSON: " + msg
+			}
+			write(models.BatchResponse{Error: msg})
 		}
+		bw.WriteString("]")
+		bw.Flush()
+	}
+}
 
-		respData, err := models.MarshalBatchResponse(responses)
-		if err != nil {
-			http.Error(w, "failed to encode response", http.StatusInternalServerError)
-			return
+// idExists возвращает функцию проверки занятости сокращенного ID в хранилище
+// и среди еще не сохраненных ID пакета pending
+func idExists(store storage.Storage, pending map[string]string) func(string) bool {
+	return func(id string) bool {
+		if _, ok := pending[id]; ok {
+			return true
 		}
+		_, _, exists := store.Get(id)
+		return exists
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		w.Write(respData)
+// saveMetadata сохраняет метаданные только что созданной ссылки
+// Пустые метаданные не записываются. Ссылка, которую хранилище молча
+// не сохранило (например, из-за уже занятого оригинального URL), пропускается
+func saveMetadata(store storage.Storage, userID, shortID string, meta models.LinkMetadata) error {
+	if meta.IsZero() {
+		return nil
+	}
+	err := store.UpdateMetadata(userID, shortID, meta)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// linkOptions проверяет параметры перенаправления новой ссылки
+// и дополняет их паролем: непустой пароль заменяется медленным хэшем
+func linkOptions(password string, opts models.LinkOptions) (models.LinkOptions, error) {
+	if err := opts.Normalize(); err != nil {
+		return models.LinkOptions{}, err
+	}
+	if password == "" {
+		return opts, nil
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return models.LinkOptions{}, err
 	}
+	opts.PasswordHash = hash
+	return opts, nil
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/events"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+)
+
+// DefaultEventsHeartbeat период комментариев, поддерживающих поток событий открытым
+const DefaultEventsHeartbeat = 15 * time.Second
+
+// eventsRetry задержка переподключения клиента в миллисекундах
+const eventsRetry = 3000
+
+// EventsHandler обрабатывает GET запросы потока событий ссылок пользователя (Server-Sent Events)
+// Передает события link.created, link.deleted и link.clicked по мере их появления.
+// Идентификатор последнего полученного события из заголовка Last-Event-ID (или параметра
+// last_event_id) возобновляет поток: сначала отправляются пропущенные события из буфера шины.
+// Если часть событий уже недоступна, первым отправляется событие resync: клиенту нужно
+// перечитать список ссылок. Клиент, не успевающий получать события, отключается
+func EventsHandler(cfg *config.Config, bus *events.Bus) http.HandlerFunc {
+	heartbeat := cfg.EventsHeartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultEventsHeartbeat
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		since, err := lastEventID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sub, err := bus.Subscribe(userID, since)
+		if errors.Is(err, events.ErrTooManySubscribers) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to subscribe to events", http.StatusInternalServerError)
+			return
+		}
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+		if sub.Gap {
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+		for _, e := range sub.Backlog {
+			if err := writeEvent(w, cfg, e); err != nil {
+				return
+			}
+		}
+		rc := http.NewResponseController(w)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					// Подписка отключена шиной: клиент переподключится с Last-Event-ID
+					return
+				}
+				if err := writeEvent(w, cfg, e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lastEventID возвращает идентификатор последнего полученного клиентом события
+// (0 - поток начинается с новых событий)
+func lastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid last event id %q", value)
+	}
+	return id, nil
+}
+
+// writeEvent отправляет событие в формате text/event-stream
+func writeEvent(w io.Writer, cfg *config.Config, e events.Event) error {
+	data, err := models.StreamEvent{
+		Type:        e.Type,
+		ShortID:     e.ShortID,
+		ShortURL:    cfg.BaseURL + "/" + e.ShortID,
+		OriginalURL: e.OriginalURL,
+		Target:      e.Target,
+		Variant:     e.Variant,
+		Time:        e.Time,
+	}.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// ClickEvents возвращает обработчик переходов, сообщающий о каждом переходе
+// событием link.clicked; подходит для WithClickFunc
+func ClickEvents(fn EventFunc) ClickFunc {
+	return func(_ *http.Request, click Click) {
+		emit([]EventFunc{fn}, models.LinkEvent{
+			Type:        models.EventLinkClicked,
+			UserID:      click.Link.UserID,
+			ShortID:     click.Link.ShortURL,
+			OriginalURL: click.Link.OriginalURL,
+			Target:      click.Target,
+			Variant:     click.Variant,
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/events"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sseEvent событие, прочитанное из потока text/event-stream
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvent читает следующее событие потока, пропуская комментарии и поле retry
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && e.event != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// openStream подключается к потоку событий пользователя userID
+func openStream(t *testing.T, ctx context.Context, url, userID, lastEventID string) *bufio.Reader {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("X-Test-User", userID)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open event stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestEventsHandler(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080", EventsHeartbeat: time.Hour}
+	bus := events.NewBus(100)
+	h := EventsHandler(cfg, bus)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), middleware.ContextUserIDKey, r.Header.Get("X-Test-User"))
+		h(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := openStream(t, ctx, srv.URL, "user1", "")
+
+	// Подписка создается до ответа, поэтому события после подключения не теряются
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com", "user1")
+	router := chi.NewRouter()
+	router.Get("/{id}", RedirectHandler(store, WithClickFunc(ClickEvents(bus.Publish))))
+	bus.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user2", ShortID: "other"})
+	bus.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user1", ShortID: "abc", OriginalURL: "https://example.com"})
+	doAsUser(router, http.MethodGet, "/abc", "", "")
+
+	created := readEvent(t, stream)
+	var data models.StreamEvent
+	if err := data.UnmarshalJSON([]byte(created.data)); err != nil {
+		t.Fatalf("invalid event data %q: %v", created.data, err)
+	}
+	if created.event != models.EventLinkCreated || data.ShortURL != "http://localhost:8080/abc" || data.OriginalURL != "https://example.com" {
+		t.Errorf("unexpected event %+v", created)
+	}
+	clicked := readEvent(t, stream)
+	if clicked.event != models.EventLinkClicked || !strings.Contains(clicked.data, `"target":"https://example.com"`) {
+		t.Errorf("unexpected click event %+v", clicked)
+	}
+
+	// Переподключение с Last-Event-ID возвращает пропущенные события
+	bus.Publish(models.LinkEvent{Type: models.EventLinkDeleted, UserID: "user1", ShortID: "abc"})
+	resumed := openStream(t, ctx, srv.URL, "user1", created.id)
+	if e := readEvent(t, resumed); e.id != clicked.id {
+		t.Errorf("expected replay to start with %s, got %+v", clicked.id, e)
+	}
+	if e := readEvent(t, resumed); e.event != models.EventLinkDeleted {
+		t.Errorf("expected replayed deletion, got %+v", e)
+	}
+
+	// Неизвестный идентификатор требует перечитать состояние
+	id, _ := strconv.ParseUint(created.id, 10, 64)
+	stale := openStream(t, ctx, srv.URL, "user1", strconv.FormatUint(id-1000, 10))
+	if e := readEvent(t, stale); e.event != "resync" {
+		t.Errorf("expected resync event, got %+v", e)
+	}
+}
+
+func TestEventsHandler_Errors(t *testing.T) {
+	h := EventsHandler(&config.Config{}, events.NewBus(10))
+	if rec := doAsUser(h, http.MethodGet, "/api/user/events", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+	if rec := doAsUser(h, http.MethodGet, "/api/user/events?last_event_id=abc", "", "user1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
@@ -1,18 +1,22 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"uno/cmd/shortener/config"
 	"uno/cmd/shortener/middleware"
 	"uno/cmd/shortener/models"
 	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // UserURLsHandler обрабатывает GET запросы для получения всех URL пользователя
 // Возвращает JSON массив с информацией о сокращенных URL пользователя
 // Если у пользователя нет URL, возвращает статус 204 No Content
 // Удаленные URL исключаются из результата
+// Параметры tag оставляют только ссылки, помеченные всеми указанными тегами
 func UserURLsHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := middleware.FromContext(r.Context())
@@ -27,9 +31,10 @@ func UserURLsHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc
 			return
 		}
 
+		tags := r.URL.Query()["tag"]
 		var filtered []models.UserURL
 		for _, url := range urls {
-			if !url.Deleted {
+			if !url.Deleted && hasTags(url.LinkMetadata, tags) {
 				url.ShortURL = cfg.BaseURL + "/" + url.ShortURL
 				filtered = append(filtered, url)
 			}
@@ -40,7 +45,7 @@ func UserURLsHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc
 			return
 		}
 
-		data, err := json.Marshal(filtered)
+		data, err := models.UserURLList(filtered).MarshalJSON()
 		if err != nil {
 			http.Error(w, "failed to encode response", http.StatusInternalServerError)
 			return
@@ -50,3 +55,88 @@ func UserURLsHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc
 		w.Write(data)
 	}
 }
+
+// UpdateUserURLHandler обрабатывает PATCH запросы для изменения метаданных ссылки пользователя
+// Принимает JSON с полями "title", "tags" и "note"; отсутствующие поля не изменяются
+// Возвращает обновленную ссылку или 404 Not Found, если у пользователя нет такой ссылки
+func UpdateUserURLHandler(cfg *config.Config, store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var patch models.MetadataPatch
+		if err := patch.UnmarshalJSON(data); err != nil || patch.IsEmpty() {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		urls, err := store.GetUserURLs(userID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "failed to get user URLs", http.StatusInternalServerError)
+			return
+		}
+		url, found := findUserURL(urls, shortID)
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+
+		url.LinkMetadata = patch.Apply(url.LinkMetadata)
+		if err := url.LinkMetadata.Normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = store.UpdateMetadata(userID, shortID, url.LinkMetadata)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to update metadata", http.StatusInternalServerError)
+			return
+		}
+
+		writeUserURL(w, cfg, url)
+	}
+}
+
+// writeUserURL отправляет ссылку пользователя в формате GET /api/user/urls
+func writeUserURL(w http.ResponseWriter, cfg *config.Config, url models.UserURL) {
+	url.ShortURL = cfg.BaseURL + "/" + url.ShortURL
+	data, err := url.MarshalJSON()
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// findUserURL ищет не удаленную ссылку shortID среди ссылок пользователя
+func findUserURL(urls []models.UserURL, shortID string) (models.UserURL, bool) {
+	for _, u := range urls {
+		if u.ShortURL == shortID && !u.Deleted {
+			return u, true
+		}
+	}
+	return models.UserURL{}, false
+}
+
+// hasTags проверяет, что ссылка помечена всеми тегами из tags
+func hasTags(meta models.LinkMetadata, tags []string) bool {
+	for _, tag := range tags {
+		if !meta.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
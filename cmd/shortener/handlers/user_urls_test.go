@@ -4,10 +4,14 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"uno/cmd/shortener/config"
 	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
 	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func TestUserURLsHandler(t *testing.T) {
@@ -164,3 +168,102 @@ func contains(s, substr string) bool {
 			s[len(s)-len(substr):] == substr ||
 			contains(s[1:len(s)-1], substr))))
 }
+
+func TestUserURLsHandler_TagFilter(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://example1.com", "user1")
+	store.Save("id2", "https://example2.com", "user1")
+	store.Save("id3", "https://example3.com", "user1")
+	_ = store.UpdateMetadata("user1", "id1", models.LinkMetadata{Tags: []string{"work", "go"}})
+	_ = store.UpdateMetadata("user1", "id2", models.LinkMetadata{Tags: []string{"work"}})
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	handler := UserURLsHandler(cfg, store)
+
+	tests := []struct {
+		query string
+		code  int
+		want  []string
+	}{
+		{"", http.StatusOK, []string{"id1", "id2", "id3"}},
+		{"?tag=work", http.StatusOK, []string{"id1", "id2"}},
+		{"?tag=WORK&tag=go", http.StatusOK, []string{"id1"}},
+		{"?tag=missing", http.StatusNoContent, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, "user1"))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Fatalf("expected status %d, got %d", tt.code, rec.Code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var got models.UserURLList
+			if err := got.UnmarshalJSON(rec.Body.Bytes()); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d URLs, got %+v", len(tt.want), got)
+			}
+			for i, id := range tt.want {
+				if got[i].ShortURL != cfg.BaseURL+"/"+id {
+					t.Errorf("expected %s at position %d, got %s", id, i, got[i].ShortURL)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateUserURLHandler(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://example1.com", "user1")
+	store.Save("id2", "https://example2.com", "user1")
+	store.Save("id3", "https://example3.com", "user2")
+	_ = store.UpdateMetadata("user1", "id1", models.LinkMetadata{Title: "Old", Note: "keep"})
+	_ = store.DeleteURLs("user1", []string{"id2"})
+
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	r := chi.NewRouter()
+	r.Patch("/api/user/urls/{id}", UpdateUserURLHandler(cfg, store))
+
+	tests := []struct {
+		name   string
+		userID string
+		id     string
+		body   string
+		code   int
+	}{
+		{"update", "user1", "id1", `{"title":" New ","tags":["Work","work"]}`, http.StatusOK},
+		{"empty patch", "user1", "id1", `{}`, http.StatusBadRequest},
+		{"invalid JSON", "user1", "id1", `{"title":`, http.StatusBadRequest},
+		{"too many tags", "user1", "id1", `{"tags":["1","2","3","4","5","6","7","8","9","10","11","12","13","14","15","16","17","18","19","20","21"]}`, http.StatusBadRequest},
+		{"deleted", "user1", "id2", `{"title":"x"}`, http.StatusNotFound},
+		{"another user", "user1", "id3", `{"title":"x"}`, http.StatusNotFound},
+		{"unknown user", "user3", "id1", `{"title":"x"}`, http.StatusNotFound},
+		{"no user", "", "id1", `{"title":"x"}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/"+tt.id, strings.NewReader(tt.body))
+			if tt.userID != "" {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserIDKey, tt.userID))
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Errorf("expected status %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	urls, _ := store.GetUserURLs("user1")
+	got := urls[0]
+	if got.Title != "New" || got.Note != "keep" || len(got.Tags) != 1 || got.Tags[0] != "work" {
+		t.Errorf("unexpected metadata after update: %+v", got.LinkMetadata)
+	}
+}
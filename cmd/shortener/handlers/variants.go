@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// variantCookiePrefix префикс cookie с вариантом ссылки, назначенным посетителю
+const variantCookiePrefix = "link_variant_"
+
+// variantCookieTTL время жизни cookie с вариантом ссылки
+const variantCookieTTL = 30 * 24 * time.Hour
+
+// chooseVariant выбирает вариант ссылки для посетителя
+// Вариант из cookie сохраняется, пока он есть у ссылки; иначе вариант выбирается
+// по хэшу ссылки, адреса и User-Agent клиента, чтобы посетитель без cookie тоже
+// попадал в один и тот же вариант, и запоминается в cookie
+func chooseVariant(w http.ResponseWriter, r *http.Request, link models.Link) models.Variant {
+	name := variantCookiePrefix + link.ShortURL
+	if c, err := r.Cookie(name); err == nil {
+		if v, ok := link.Variant(c.Value); ok {
+			return v
+		}
+	}
+
+	v := link.PickVariant(clientFingerprint(r, link.ShortURL))
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    v.Name,
+		Path:     "/", // Ссылку определяет имя cookie; путь "/{id}" не покрывает /{id}+
+		MaxAge:   int(variantCookieTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return v
+}
+
+// clientFingerprint возвращает хэш адреса и User-Agent клиента для ссылки shortID
+func clientFingerprint(r *http.Request, shortID string) uint64 {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	h := fnv.New64a()
+	for _, part := range []string{shortID, host, r.UserAgent()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// LinkVariantsHandler обрабатывает GET запросы вариантов ссылки пользователя
+// Возвращает варианты с весами (пустой массив, если трафик не разделяется)
+// или 404 Not Found, если у пользователя нет такой ссылки
+func LinkVariantsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		link, ok := loadUserLink(w, r, store, userID, chi.URLParam(r, "id"))
+		if !ok {
+			return
+		}
+		writeVariants(w, link.Variants)
+	}
+}
+
+// UpdateLinkVariantsHandler обрабатывает PUT запросы для замены вариантов ссылки
+// Принимает JSON массив вариантов (не меньше двух); пустой массив отключает
+// разделение трафика. Счетчики переходов по вариантам и остальные параметры ссылки
+// сохраняются, в том числе если их одновременно изменяет другой запрос
+func UpdateLinkVariantsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var variants models.VariantList
+		if err := variants.UnmarshalJSON(data); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		opts := models.LinkOptions{Variants: variants}
+		if err := opts.Normalize(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = store.UpdateVariants(userID, shortID, opts.Variants)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to update link variants", http.StatusInternalServerError)
+			return
+		}
+
+		writeVariants(w, opts.Variants)
+	}
+}
+
+// LinkStatsHandler обрабатывает GET запросы статистики переходов по ссылке пользователя
+// Возвращает общее количество переходов и переходы по каждому варианту:
+// сначала текущие варианты по порядку, затем удаленные по имени
+func LinkStatsHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		shortID := chi.URLParam(r, "id")
+
+		link, ok := loadUserLink(w, r, store, userID, shortID)
+		if !ok {
+			return
+		}
+		clicks, err := store.GetClicks(shortID)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to get link stats", http.StatusInternalServerError)
+			return
+		}
+
+		data, err := clickStats(link, clicks).MarshalJSON()
+		if err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// clickStats собирает статистику переходов ссылки из счетчиков по вариантам
+func clickStats(link models.Link, clicks map[string]int64) models.ClickStats {
+	var stats models.ClickStats
+	for _, n := range clicks {
+		stats.Clicks += n
+	}
+
+	seen := make(map[string]bool, len(link.Variants))
+	for _, v := range link.Variants {
+		seen[v.Name] = true
+		stats.Variants = append(stats.Variants, models.VariantStats{
+			Name: v.Name, URL: v.URL, Weight: v.Weight, Clicks: clicks[v.Name],
+		})
+	}
+	var removed []string
+	for name := range clicks {
+		if name != "" && !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		stats.Variants = append(stats.Variants, models.VariantStats{Name: name, Clicks: clicks[name]})
+	}
+	return stats
+}
+
+// writeVariants отправляет варианты ссылки
+func writeVariants(w http.ResponseWriter, variants []models.Variant) {
+	if variants == nil {
+		variants = []models.Variant{}
+	}
+	data, err := models.VariantList(variants).MarshalJSON()
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
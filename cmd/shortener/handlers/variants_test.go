@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap/zaptest"
+)
+
+func setupVariantsRouter(store storage.Storage, clicks *ClickCounter) http.Handler {
+	redirect := RedirectHandler(store, WithClickFunc(clicks.Record))
+	r := chi.NewRouter()
+	r.Get("/api/user/urls/{id}/variants", LinkVariantsHandler(store))
+	r.Put("/api/user/urls/{id}/variants", UpdateLinkVariantsHandler(store))
+	r.Get("/api/user/urls/{id}/stats", LinkStatsHandler(store))
+	r.Put("/api/user/urls/{id}/rules", UpdateLinkRulesHandler(store))
+	r.Patch("/api/user/urls/{id}/options", UpdateLinkOptionsHandler(store))
+	r.Get("/{id}", redirect)
+	r.Head("/{id}", redirect)
+	return r
+}
+
+// visit выполняет GET /{id} от клиента с адресом remoteAddr и cookie
+func visit(h http.Handler, id, remoteAddr string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/"+id, nil)
+	req.RemoteAddr = remoteAddr
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRedirectHandler_Variants(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("ab", "https://example.com", "user1")
+	clicks := NewClickCounter(store)
+	h := setupVariantsRouter(store, clicks)
+
+	rec := doAsUser(h, http.MethodPut, "/api/user/urls/ab/variants",
+		`[{"url":"https://example.com/a","weight":70},{"name":"B","url":"https://example.com/b","weight":30}]`, "user1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"b"`) {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Разные посетители распределяются примерно по весам
+	targets := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		rec := visit(h, "ab", fmt.Sprintf("10.0.%d.%d:1234", i/256, i%256))
+		if rec.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("expected 307, got %d", rec.Code)
+		}
+		targets[rec.Header().Get("Location")]++
+	}
+	if a := targets["https://example.com/a"]; a < 620 || a > 780 || a+targets["https://example.com/b"] != 1000 {
+		t.Errorf("expected about 70/30 split, got %v", targets)
+	}
+
+	// Без cookie посетитель попадает в тот же вариант по адресу и User-Agent
+	first := visit(h, "ab", "192.0.2.1:1000")
+	if again := visit(h, "ab", "192.0.2.1:2000"); again.Header().Get("Location") != first.Header().Get("Location") {
+		t.Error("expected fingerprint assignment to be sticky")
+	}
+	cookies := first.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "link_variant_ab" || cookies[0].Path != "/" {
+		t.Fatalf("unexpected variant cookie: %v", cookies)
+	}
+	if vary := first.Header().Get("Vary"); vary != "Cookie" {
+		t.Errorf("unexpected Vary header %q", vary)
+	}
+
+	// Cookie важнее адреса клиента
+	other := "a"
+	if cookies[0].Value == "a" {
+		other = "b"
+	}
+	for i := 0; i < 20; i++ {
+		rec := visit(h, "ab", fmt.Sprintf("198.51.100.%d:1", i), &http.Cookie{Name: "link_variant_ab", Value: other})
+		if loc := rec.Header().Get("Location"); loc != "https://example.com/"+other {
+			t.Fatalf("expected cookie variant %s, got %q", other, loc)
+		}
+		if len(rec.Result().Cookies()) != 0 {
+			t.Fatal("cookie must not be reissued for a valid variant")
+		}
+	}
+	// Неизвестный вариант из cookie заменяется
+	rec = visit(h, "ab", "192.0.2.1:1000", &http.Cookie{Name: "link_variant_ab", Value: "zzz"})
+	if rec.Header().Get("Location") != first.Header().Get("Location") || len(rec.Result().Cookies()) != 1 {
+		t.Error("expected unknown cookie variant to be reassigned")
+	}
+
+	// Правила важнее вариантов
+	if rec := doAsUser(h, http.MethodPut, "/api/user/urls/ab/rules", `[{"platform":"ios","url":"https://apps.apple.com/app"}]`, "user1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/ab", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Location") != "https://apps.apple.com/app" || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("expected rule to win over variants, got %q", rec.Header().Get("Location"))
+	}
+
+	// HEAD не считается переходом
+	if rec := doAsUser(h, http.MethodHead, "/ab", "", ""); rec.Code != http.StatusTemporaryRedirect {
+		t.Errorf("expected 307 for HEAD, got %d", rec.Code)
+	}
+
+	if err := clicks.Flush(); err != nil {
+		t.Fatalf("failed to flush clicks: %v", err)
+	}
+	rec = doAsUser(h, http.MethodGet, "/api/user/urls/ab/stats", "", "user1")
+	var stats models.ClickStats
+	if err := stats.UnmarshalJSON(rec.Body.Bytes()); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected stats response %d %s", rec.Code, rec.Body.String())
+	}
+	// 1000 посетителей, 2 повтора, 20 с cookie, 1 с неизвестной cookie, 1 по правилу
+	if stats.Clicks != 1024 || len(stats.Variants) != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	a, b := stats.Variants[0], stats.Variants[1]
+	if a.Name != "a" || a.Weight != 70 || a.URL != "https://example.com/a" || b.Name != "b" || a.Clicks+b.Clicks != 1023 {
+		t.Errorf("unexpected variant stats: %+v", stats.Variants)
+	}
+
+	// Удаленный вариант остается в статистике; пустой массив отключает разделение
+	if rec := doAsUser(h, http.MethodPut, "/api/user/urls/ab/variants", `[]`, "user1"); rec.Code != http.StatusOK || rec.Body.String() != "[]" {
+		t.Fatalf("expected empty variants, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = doAsUser(h, http.MethodGet, "/api/user/urls/ab/stats", "", "user1")
+	if body := rec.Body.String(); !strings.Contains(body, `{"name":"a","clicks":`) || strings.Contains(body, `"weight"`) {
+		t.Errorf("expected removed variants in stats, got %s", body)
+	}
+	if rec := visit(h, "ab", "192.0.2.1:1000"); rec.Header().Get("Location") != "https://example.com" || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("expected original URL without variants, got %q", rec.Header().Get("Location"))
+	}
+}
+
+func TestLinkVariantsHandler_Errors(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("ab", "https://example.com", "user1")
+	h := setupVariantsRouter(store, NewClickCounter(store))
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		userID string
+		status int
+	}{
+		{"unauthorized", http.MethodPut, "/api/user/urls/ab/variants", `[]`, "", http.StatusUnauthorized},
+		{"another user", http.MethodPut, "/api/user/urls/ab/variants", `[]`, "user2", http.StatusNotFound},
+		{"another user get", http.MethodGet, "/api/user/urls/ab/variants", "", "user2", http.StatusNotFound},
+		{"another user stats", http.MethodGet, "/api/user/urls/ab/stats", "", "user2", http.StatusNotFound},
+		{"missing stats", http.MethodGet, "/api/user/urls/missing/stats", "", "user1", http.StatusNotFound},
+		{"not an array", http.MethodPut, "/api/user/urls/ab/variants", `{}`, "user1", http.StatusBadRequest},
+		{"single variant", http.MethodPut, "/api/user/urls/ab/variants", `[{"url":"https://example.com/a","weight":1}]`, "user1", http.StatusBadRequest},
+		{"zero weight", http.MethodPut, "/api/user/urls/ab/variants",
+			`[{"url":"https://example.com/a","weight":0},{"url":"https://example.com/b","weight":1}]`, "user1", http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec := doAsUser(h, c.method, c.target, c.body, c.userID); rec.Code != c.status {
+				t.Errorf("expected %d, got %d %s", c.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+// failingClicks хранилище, в котором не удается сохранить переходы
+type failingClicks struct {
+	storage.Storage
+	fail bool
+}
+
+func (s *failingClicks) AddClicks(clicks []models.ClickCount) error {
+	if s.fail {
+		return errors.New("unavailable")
+	}
+	return s.Storage.AddClicks(clicks)
+}
+
+func TestClickCounter_FlushRetry(t *testing.T) {
+	inner := storage.NewInMemoryStorage()
+	inner.Save("abc", "https://example.com", "user1")
+	store := &failingClicks{Storage: inner, fail: true}
+	clicks := NewClickCounter(store)
+
+	link := models.Link{ShortURL: "abc"}
+	clicks.Record(nil, Click{Link: link})
+	clicks.Record(nil, Click{Link: link})
+	if err := clicks.Flush(); err == nil {
+		t.Fatal("expected flush error")
+	}
+	clicks.Record(nil, Click{Link: link})
+
+	store.fail = false
+	if err := clicks.Flush(); err != nil {
+		t.Fatalf("unexpected flush error: %v", err)
+	}
+	if counts, _ := inner.GetClicks("abc"); counts[""] != 3 {
+		t.Errorf("expected failed clicks to be retried, got %v", counts)
+	}
+}
+
+func TestClickCounter_RunFlushesOnStop(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com", "user1")
+	clicks := NewClickCounter(store)
+	clicks.Record(nil, Click{Link: models.Link{ShortURL: "abc"}, Variant: "a"})
+
+	// Интервал больше времени теста: переходы сохраняются только при остановке
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clicks.Run(ctx, time.Hour, zaptest.NewLogger(t))
+	}()
+	cancel()
+	<-done
+	if counts, _ := store.GetClicks("abc"); counts["a"] != 1 {
+		t.Errorf("expected clicks to be saved on stop, got %v", counts)
+	}
+}
+
+func TestLinkOptions_ConcurrentUpdates(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("ab", "https://example.com", "user1")
+	h := setupVariantsRouter(store, NewClickCounter(store))
+
+	// Одновременные изменения разных частей параметров не затирают друг друга
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			doAsUser(h, http.MethodPut, "/api/user/urls/ab/variants",
+				`[{"url":"https://example.com/a","weight":1},{"url":"https://example.com/b","weight":1}]`, "user1")
+		}()
+		go func() {
+			defer wg.Done()
+			doAsUser(h, http.MethodPut, "/api/user/urls/ab/rules", `[{"platform":"ios","url":"https://apps.apple.com/app"}]`, "user1")
+		}()
+		go func() {
+			defer wg.Done()
+			doAsUser(h, http.MethodPatch, "/api/user/urls/ab/options", fmt.Sprintf(`{"interstitial":%v}`, i%2 == 0), "user1")
+		}()
+	}
+	wg.Wait()
+
+	link, err := store.GetLink("ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(link.Variants) != 2 || len(link.Rules) != 1 {
+		t.Errorf("expected variants and rules to be kept, got %+v", link.LinkOptions)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/webhook"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateWebhookHandler обрабатывает POST запросы для регистрации вебхука пользователя
+// Принимает JSON с адресом получателя "url", необязательными ключом подписи "secret"
+// и типами событий "events" (пусто - все события). Возвращает 201 Created с вебхуком;
+// ключ подписи возвращается только в этом ответе. Адреса внутренней сети отклоняются guard
+func CreateWebhookHandler(store webhook.Store, guard *webhook.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		var req models.WebhookRequest
+		if err := req.UnmarshalJSON(data); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		hook, err := webhook.NewWebhook(r.Context(), userID, req, guard)
+		if err == nil {
+			err = store.CreateWebhook(hook)
+		}
+		if errors.Is(err, webhook.ErrInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to save webhook", http.StatusInternalServerError)
+			return
+		}
+
+		if hook.Events == nil {
+			hook.Events = []string{}
+		}
+		respData, err := hook.MarshalJSON()
+		if err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(respData)
+	}
+}
+
+// WebhooksHandler обрабатывает GET запросы списка вебхуков пользователя
+// Возвращает вебхуки в порядке регистрации без ключей подписи
+func WebhooksHandler(store webhook.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		hooks, err := store.Webhooks(userID)
+		if err != nil {
+			http.Error(w, "failed to get webhooks", http.StatusInternalServerError)
+			return
+		}
+		list := make(models.WebhookList, 0, len(hooks))
+		for _, hook := range hooks {
+			hook.Secret = ""
+			if hook.Events == nil {
+				hook.Events = []string{}
+			}
+			list = append(list, hook)
+		}
+
+		data, err := list.MarshalJSON()
+		if err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// DeleteWebhookHandler обрабатывает DELETE запросы для удаления вебхука пользователя
+// Недоставленные события вебхука отбрасываются. Возвращает 204 No Content
+// или 404 Not Found, если у пользователя нет такого вебхука
+func DeleteWebhookHandler(store webhook.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		err := store.DeleteWebhook(userID, chi.URLParam(r, "id"))
+		if errors.Is(err, webhook.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to delete webhook", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// WebhookDeliveriesHandler обрабатывает GET запросы журнала доставок вебхука
+// Возвращает последние webhook.DeliveryLogSize доставок, от новых к старым,
+// или 404 Not Found, если у пользователя нет такого вебхука
+func WebhookDeliveriesHandler(store webhook.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.FromContext(r.Context())
+		if !ok || userID == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		deliveries, err := store.Deliveries(userID, chi.URLParam(r, "id"), webhook.DeliveryLogSize)
+		if errors.Is(err, webhook.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to get webhook deliveries", http.StatusInternalServerError)
+			return
+		}
+		if deliveries == nil {
+			deliveries = []models.WebhookDelivery{}
+		}
+
+		data, err := models.WebhookDeliveryList(deliveries).MarshalJSON()
+		if err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
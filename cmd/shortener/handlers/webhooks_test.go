@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/webhook"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap/zaptest"
+)
+
+// testGuard проверяет адреса вебхуков, разрешая example.com без обращения к DNS
+var testGuard = &webhook.Guard{LookupIP: func(context.Context, string) ([]netip.Addr, error) {
+	return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, nil
+}}
+
+func setupWebhooksRouter(store webhook.Store) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/api/user/webhooks", CreateWebhookHandler(store, testGuard))
+	r.Get("/api/user/webhooks", WebhooksHandler(store))
+	r.Delete("/api/user/webhooks/{id}", DeleteWebhookHandler(store))
+	r.Get("/api/user/webhooks/{id}/deliveries", WebhookDeliveriesHandler(store))
+	return r
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	store := webhook.NewMemoryStore()
+	h := setupWebhooksRouter(store)
+
+	rec := doAsUser(h, http.MethodPost, "/api/user/webhooks", `{"url":"https://example.com/hook","events":["link.created"]}`, "user1")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+	var created models.Webhook
+	if err := created.UnmarshalJSON(rec.Body.Bytes()); err != nil || created.ID == "" || len(created.Secret) != 64 {
+		t.Fatalf("unexpected webhook %s: %v", rec.Body.String(), err)
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/api/user/webhooks", "", "user1")
+	if body := rec.Body.String(); rec.Code != http.StatusOK || strings.Contains(body, "secret") || !strings.Contains(body, created.ID) {
+		t.Errorf("expected webhook list without secrets, got %d %s", rec.Code, body)
+	}
+	if rec := doAsUser(h, http.MethodGet, "/api/user/webhooks", "", "user2"); rec.Body.String() != "[]" {
+		t.Errorf("expected empty list for another user, got %s", rec.Body.String())
+	}
+
+	rec = doAsUser(h, http.MethodGet, "/api/user/webhooks/"+created.ID+"/deliveries", "", "user1")
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]" {
+		t.Errorf("expected empty delivery log, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		userID string
+		status int
+	}{
+		{"unauthorized", http.MethodPost, "/api/user/webhooks", `{"url":"https://example.com"}`, "", http.StatusUnauthorized},
+		{"invalid JSON", http.MethodPost, "/api/user/webhooks", `[]`, "user1", http.StatusBadRequest},
+		{"invalid url", http.MethodPost, "/api/user/webhooks", `{"url":"example.com"}`, "user1", http.StatusBadRequest},
+		{"internal address", http.MethodPost, "/api/user/webhooks", `{"url":"http://169.254.169.254/"}`, "user1", http.StatusBadRequest},
+		{"unknown event", http.MethodPost, "/api/user/webhooks", `{"url":"https://example.com","events":["link.renamed"]}`, "user1", http.StatusBadRequest},
+		{"another user deliveries", http.MethodGet, "/api/user/webhooks/" + created.ID + "/deliveries", "", "user2", http.StatusNotFound},
+		{"another user delete", http.MethodDelete, "/api/user/webhooks/" + created.ID, "", "user2", http.StatusNotFound},
+		{"delete", http.MethodDelete, "/api/user/webhooks/" + created.ID, "", "user1", http.StatusNoContent},
+		{"delete again", http.MethodDelete, "/api/user/webhooks/" + created.ID, "", "user1", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if rec := doAsUser(h, c.method, c.target, c.body, c.userID); rec.Code != c.status {
+				t.Errorf("expected %d, got %d %s", c.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	for i := 0; i < webhook.MaxWebhooks; i++ {
+		doAsUser(h, http.MethodPost, "/api/user/webhooks", `{"url":"https://example.com"}`, "user3")
+	}
+	if rec := doAsUser(h, http.MethodPost, "/api/user/webhooks", `{"url":"https://example.com"}`, "user3"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 over the webhook limit, got %d", rec.Code)
+	}
+}
+
+// eventLog собирает события ссылок
+type eventLog struct {
+	mu     sync.Mutex
+	events []models.LinkEvent
+}
+
+func (l *eventLog) add(e models.LinkEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+// take возвращает собранные события и очищает журнал
+func (l *eventLog) take() []models.LinkEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := l.events
+	l.events = nil
+	return events
+}
+
+func TestLinkEvents_Created(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	var log eventLog
+	r := chi.NewRouter()
+	r.Post("/", ShortenURLHandler(cfg, store, WithEvents(log.add)))
+	r.Post("/api/shorten", APIShortenHandler(cfg, store, WithEvents(log.add)))
+	r.Post("/api/shorten/batch", BatchShortenHandler(cfg, store, WithEvents(log.add)))
+
+	doAsUser(r, http.MethodPost, "/", "https://example.com/text", "user1")
+	doAsUser(r, http.MethodPost, "/api/shorten", `{"url":"https://example.com/json"}`, "user1")
+	if rec := doAsUser(r, http.MethodPost, "/api/shorten", `{"url":"https://example.com/json"}`, "user1"); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	doAsUser(r, http.MethodPost, "/api/shorten/batch",
+		`[{"correlation_id":"1","original_url":"https://example.com/b1"},{"correlation_id":"2","original_url":"https://example.com/b2"}]`, "user1")
+
+	events := log.take()
+	want := []string{"https://example.com/text", "https://example.com/json", "https://example.com/b1", "https://example.com/b2"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, e := range events {
+		if e.Type != models.EventLinkCreated || e.UserID != "user1" || e.OriginalURL != want[i] || e.ShortID == "" || e.Time.IsZero() {
+			t.Errorf("unexpected event %d: %+v", i, e)
+		}
+	}
+}
+
+func TestLinkEvents_CreatedBatches(t *testing.T) {
+	cfg := &config.Config{BaseURL: "http://localhost:8080"}
+	store := storage.NewInMemoryStorage()
+	var batches [][]models.LinkEvent
+	collect := func(events []models.LinkEvent) { batches = append(batches, events) }
+	r := chi.NewRouter()
+	r.Post("/api/shorten", APIShortenHandler(cfg, store, WithEventBatches(collect)))
+	r.Post("/api/shorten/batch", BatchShortenHandler(cfg, store, WithEventBatches(collect)))
+
+	doAsUser(r, http.MethodPost, "/api/shorten", `{"url":"https://example.com/json"}`, "user1")
+	doAsUser(r, http.MethodPost, "/api/shorten/batch",
+		`[{"correlation_id":"1","original_url":"https://example.com/b1"},{"correlation_id":"2","original_url":"https://example.com/b2"},{"correlation_id":"3","original_url":""}]`, "user1")
+
+	if len(batches) != 2 || len(batches[0]) != 1 || len(batches[1]) != 2 {
+		t.Fatalf("expected batches of 1 and 2 events, got %+v", batches)
+	}
+	if batches[1][0].OriginalURL != "https://example.com/b1" || batches[1][1].OriginalURL != "https://example.com/b2" {
+		t.Errorf("unexpected batch events: %+v", batches[1])
+	}
+}
+
+func TestLinkEvents_Deleted(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("id1", "https://example1.com", "user1")
+	store.Save("id2", "https://example2.com", "user1")
+	store.Save("id3", "https://example3.com", "user2")
+	store.DeleteURLs("user1", []string{"id2"})
+
+	var log eventLog
+	queue := make(chan DeleteRequest, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunDeletionWorker(ctx, store, zaptest.NewLogger(t), queue, log.add)
+
+	queue <- DeleteRequest{UserID: "user1", IDs: []string{"id1", "id1", "id2", "id3", "missing"}}
+	var events []models.LinkEvent
+	for deadline := time.Now().Add(time.Second); len(events) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		events = log.take()
+	}
+	if len(events) != 1 || events[0].Type != models.EventLinkDeleted || events[0].ShortID != "id1" || events[0].OriginalURL != "https://example1.com" {
+		t.Errorf("expected one link.deleted event for id1, got %+v", events)
+	}
+}
+
+func TestLinkEvents_ClickMilestones(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	store.Save("abc", "https://example.com", "user1")
+	var log eventLog
+	clicks := NewClickCounter(store, WithMilestones([]int64{5, 1, 3}, log.add))
+	link := models.Link{ShortURL: "abc", OriginalURL: "https://example.com", UserID: "user1"}
+
+	record := func(n int) []models.LinkEvent {
+		for i := 0; i < n; i++ {
+			clicks.Record(nil, Click{Link: link, Variant: []string{"a", "b"}[i%2]})
+		}
+		if err := clicks.Flush(); err != nil {
+			t.Fatalf("failed to flush clicks: %v", err)
+		}
+		return log.take()
+	}
+
+	if events := record(2); len(events) != 1 || events[0].Clicks != 1 || events[0].Type != models.EventLinkClicks || events[0].UserID != "user1" {
+		t.Errorf("expected milestone 1, got %+v", events)
+	}
+	if events := record(4); len(events) != 2 || events[0].Clicks != 3 || events[1].Clicks != 5 {
+		t.Errorf("expected milestones 3 and 5, got %+v", events)
+	}
+	if events := record(10); len(events) != 0 {
+		t.Errorf("expected no events after the last milestone, got %+v", events)
+	}
+}
+
+func TestParseMilestones(t *testing.T) {
+	got, err := ParseMilestones(" 1000, 100,,1000 ")
+	if err != nil || len(got) != 2 || got[0] != 100 || got[1] != 1000 {
+		t.Errorf("unexpected milestones %v %v", got, err)
+	}
+	if got, err := ParseMilestones(""); err != nil || len(got) != 0 {
+		t.Errorf("expected no milestones, got %v %v", got, err)
+	}
+	for _, s := range []string{"0", "-5", "10k"} {
+		if _, err := ParseMilestones(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
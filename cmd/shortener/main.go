@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/events"
 	"uno/cmd/shortener/handlers"
 	"uno/cmd/shortener/middleware"
 	"uno/cmd/shortener/storage"
+	"uno/cmd/shortener/utils"
+	"uno/cmd/shortener/webhook"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -26,25 +35,51 @@ var (
 	buildCommit  string = "N/A"
 )
 
+// shutdownTimeout время, за которое сервер завершает начатые запросы после сигнала остановки
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	cfg := config.NewConfig()
+
+	if err := run(cfg, flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run выполняет служебную команду из args или запускает сервер
+func run(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		if err := runCommand(cfg, args); err != nil {
+			return fmt.Errorf("command %s failed: %w", args[0], err)
+		}
+		return nil
+	}
+
 	// Выводим информацию о сборке
+	// Служебные команды ее не выводят, чтобы не смешивать с выгрузкой в stdout
 	printBuildInfo()
 
-	cfg := config.NewConfig()
+	return runServer(cfg)
+}
 
+// runServer запускает HTTP сервер и возвращает ошибку вместо завершения программы,
+// чтобы отложенные закрытия пула соединений, журнала трафика и логгера выполнялись.
+// По SIGINT или SIGTERM сервер завершает начатые запросы, затем останавливает фоновые
+// задачи и только после этого закрывает хранилища
+func runServer(cfg *config.Config) error {
 	var pool *pgxpool.Pool
 	if cfg.DatabaseDSN != "" {
 		var err error
 		pool, err = pgxpool.New(context.Background(), cfg.DatabaseDSN)
 		if err != nil {
-			log.Fatalf("DB connection failed: %v", err)
+			return fmt.Errorf("DB connection failed: %w", err)
 		}
 		defer pool.Close()
 	}
 
 	logger, err := zap.NewProduction()
 	if err != nil {
-		log.Fatalf("Could not initialize zap logger: %v", err)
+		return fmt.Errorf("could not initialize zap logger: %w", err)
 	}
 	defer logger.Sync()
 
@@ -64,49 +99,229 @@ func main() {
 
 	r.Use(middleware.GzipMiddleware)
 	r.Use(middleware.WithUserID)
+	if cfg.CaptureFile != "" {
+		capture, err := middleware.NewCapture(middleware.CaptureOptions{
+			Path:        cfg.CaptureFile,
+			SampleRate:  cfg.CaptureSample,
+			MaxFileSize: cfg.CaptureMaxSize,
+			MaxBodySize: cfg.CaptureMaxBody,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize traffic capture: %w", err)
+		}
+		defer capture.Close()
+		r.Use(capture.Middleware)
+	}
 	r.Use(middleware.LoggingMiddleware(logger))
 
 	var store storage.Storage
+	runDeletionWorker := false
 	if pool != nil {
 		store, err = storage.NewPostgresStorage(pool)
 		if err != nil {
-			log.Fatalf("failed to initialize PostgreSQL storage: %v", err)
+			return fmt.Errorf("failed to initialize PostgreSQL storage: %w", err)
 		}
 		if _, ok := store.(*storage.PostgresStorage); ok {
-			go handlers.RunDeletionWorker(context.Background(), store, logger, deleteQueue)
+			runDeletionWorker = true
+		}
+	} else if cfg.FileStoragePath != "" {
+		policy := storage.DefaultCompactionPolicy
+		policy.MaxFileSize = cfg.FileCompactSize
+		policy.GarbageRatio = cfg.FileCompactRatio
+		syncMode, err := storage.ParseSyncMode(cfg.FileSyncMode)
+		if err != nil {
+			return fmt.Errorf("invalid file storage sync mode: %w", err)
+		}
+		// Без файла хранилища сервер не запускается: молча перейти на хранилище
+		// в памяти значило бы потерять все ссылки при следующем перезапуске
+		store, err = storage.NewFileStorage(cfg.FileStoragePath,
+			storage.WithCompactionPolicy(policy),
+			storage.WithSync(syncMode, cfg.FileSyncInterval),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to initialize file storage: %w", err)
+		}
+		if fileStore, ok := store.(*storage.FileStorage); ok {
+			defer fileStore.Close()
+			runDeletionWorker = true
 		}
 	} else {
-		if cfg.FileStoragePath != "" {
-			s, err := storage.NewFileStorage(cfg.FileStoragePath)
-			if err == nil {
-				store = s
-				if _, ok := store.(*storage.FileStorage); ok {
-					go handlers.RunDeletionWorker(context.Background(), store, logger, deleteQueue)
-				}
-			}
+		store = storage.NewInMemoryStorage()
+	}
+
+	if err := setupIDGenerator(cfg, store); err != nil {
+		return fmt.Errorf("failed to initialize short ID generator: %w", err)
+	}
+
+	// Кэш оборачивает выбранное хранилище; удаление тоже идет через него,
+	// чтобы удаленные ссылки сразу переставали отдаваться из кэша
+	if cfg.CacheSize > 0 {
+		cache := storage.NewCachedStorage(store, storage.CacheOptions{
+			Size:        cfg.CacheSize,
+			TTL:         cfg.CacheTTL,
+			NegativeTTL: cfg.CacheNegativeTTL,
+		})
+		store = cache
+		// Статистика не требует авторизации, поэтому отдается только на внутреннем
+		// сервере pprof (:6060), а не на публичном адресе сервиса
+		if cfg.EnablePprof {
+			http.Handle("/api/internal/cache/stats", handlers.CacheStatsHandler(cache))
 		}
-		if store == nil {
-			store = storage.NewInMemoryStorage()
+	}
+
+	// Журнал вебхуков хранится там же, где ссылки: в PostgreSQL, рядом с файлом хранилища или в памяти
+	var hooks webhook.Store
+	switch {
+	case pool != nil:
+		hooks, err = webhook.NewPostgresStore(pool)
+	case cfg.FileStoragePath != "":
+		var fileHooks *webhook.FileStore
+		fileHooks, err = webhook.NewFileStore(cfg.FileStoragePath + ".webhooks")
+		if err == nil {
+			defer fileHooks.Close()
+			hooks = fileHooks
 		}
+	default:
+		hooks = webhook.NewMemoryStore()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize webhook store: %w", err)
 	}
+	milestones, err := handlers.ParseMilestones(cfg.WebhookMilestones)
+	if err != nil {
+		return fmt.Errorf("invalid webhook milestones: %w", err)
+	}
+	guard := &webhook.Guard{AllowPrivate: cfg.WebhookPrivate}
+	dispatcher := webhook.NewDispatcher(hooks, webhook.Options{
+		BaseURL:     cfg.BaseURL,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Timeout:     cfg.WebhookTimeout,
+		Guard:       guard,
+		Logger:      logger,
+	})
+	// Фоновые задачи останавливаются после HTTP сервера и до закрытия хранилищ:
+	// отложенные вызовы выполняются в обратном порядке
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer stopWorkers()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(workerCtx)
+	}()
+	// События ссылок уходят вебхукам и в потоки GET /api/user/events
+	bus := events.NewBus(cfg.EventsHistory)
+	shortenEvents := []handlers.ShortenOption{handlers.WithEventBatches(dispatcher.PublishAll), handlers.WithEvents(bus.Publish)}
+
+	if runDeletionWorker {
+		go handlers.RunDeletionWorker(context.Background(), store, logger, deleteQueue, dispatcher.Publish, bus.Publish)
+	}
+
+	r.Post("/", handlers.ShortenURLHandler(cfg, store, shortenEvents...))
+	r.Post("/api/shorten", handlers.APIShortenHandler(cfg, store, shortenEvents...))
+	r.Post("/api/shorten/batch", handlers.BatchShortenHandler(cfg, store, shortenEvents...))
+	r.Post("/api/shorten/batch.csv", handlers.BatchShortenCSVHandler(cfg, store, shortenEvents...))
+	clicks := handlers.NewClickCounter(store, handlers.WithMilestones(milestones, dispatcher.Publish))
+	// Последние переходы сохраняются при остановке, до закрытия хранилища
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		clicks.Run(workerCtx, cfg.ClickFlushInterval, logger)
+	}()
 
-	r.Post("/", handlers.ShortenURLHandler(cfg, store))
-	r.Post("/api/shorten", handlers.APIShortenHandler(cfg, store))
-	r.Post("/api/shorten/batch", handlers.BatchShortenHandler(cfg, store))
-	r.Get("/{id}", handlers.RedirectHandler(store))
+	redirect := handlers.RedirectHandler(store, handlers.WithPasswordGuard(handlers.NewPasswordGuard(handlers.PasswordGuardOptions{
+		Secret:      []byte(cfg.LinkSecret),
+		UnlockTTL:   cfg.LinkUnlockTTL,
+		MaxAttempts: cfg.LinkMaxAttempts,
+		Lockout:     cfg.LinkLockout,
+		Logger:      logger,
+	})), handlers.WithPreview(handlers.NewPreview(handlers.PreviewOptions{
+		Interstitial: cfg.Interstitial,
+		Delay:        cfg.InterstitialDelay,
+		Logger:       logger,
+	})), handlers.WithClickFunc(clicks.Record), handlers.WithClickFunc(handlers.ClickEvents(bus.Publish)))
+	r.Get("/{id}", redirect)
+	r.Head("/{id}", redirect)
+	r.Post("/{id}", redirect)
+	r.Get("/{id}/qr", handlers.QRCodeHandler(cfg, store))
 	r.Get("/ping", handlers.PingHandler(pool))
 	r.Get("/api/user/urls", handlers.UserURLsHandler(cfg, store))
+	r.Get("/api/user/urls.csv", handlers.UserURLsCSVHandler(cfg, store))
+	r.Patch("/api/user/urls/{id}", handlers.UpdateUserURLHandler(cfg, store))
+	r.Get("/api/user/urls/{id}/options", handlers.LinkOptionsHandler(store))
+	r.Patch("/api/user/urls/{id}/options", handlers.UpdateLinkOptionsHandler(store))
+	r.Get("/api/user/urls/{id}/rules", handlers.LinkRulesHandler(store))
+	r.Put("/api/user/urls/{id}/rules", handlers.UpdateLinkRulesHandler(store))
+	r.Get("/api/user/urls/{id}/variants", handlers.LinkVariantsHandler(store))
+	r.Put("/api/user/urls/{id}/variants", handlers.UpdateLinkVariantsHandler(store))
+	r.Get("/api/user/urls/{id}/stats", handlers.LinkStatsHandler(store))
+	r.Put("/api/user/urls/{id}", handlers.RetargetUserURLHandler(cfg, store))
+	r.Get("/api/user/urls/{id}/history", handlers.URLHistoryHandler(cfg, store))
+	r.Post("/api/user/urls/{id}/revert", handlers.RevertUserURLHandler(cfg, store))
 	r.Delete("/api/user/urls", handlers.DeleteUserURLsHandler(store, logger, deleteQueue))
+	r.Get("/api/user/events", handlers.EventsHandler(cfg, bus))
+	r.Post("/api/user/webhooks", handlers.CreateWebhookHandler(hooks, guard))
+	r.Get("/api/user/webhooks", handlers.WebhooksHandler(hooks))
+	r.Delete("/api/user/webhooks/{id}", handlers.DeleteWebhookHandler(hooks))
+	r.Get("/api/user/webhooks/{id}/deliveries", handlers.WebhookDeliveriesHandler(hooks))
 
 	srv := &http.Server{
 		Addr:    cfg.Address,
 		Handler: r,
 	}
+	// Потоки событий не завершаются сами, поэтому при остановке отключаются шиной
+	srv.RegisterOnShutdown(bus.Close)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	log.Println("Starting server on", cfg.Address)
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	return serve(ctx, srv)
+}
+
+// serve обслуживает запросы до ошибки сервера или завершения ctx,
+// после которого ждет завершения начатых запросов не дольше shutdownTimeout
+func serve(ctx context.Context, srv *http.Server) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+	return nil
+}
+
+// setupIDGenerator настраивает генератор сокращенных ID, используемый обработчиками
+// Счетчик стратегии sequential продолжается после количества уже сохраненных ссылок,
+// чтобы после перезапуска не перебирать занятые ID
+func setupIDGenerator(cfg *config.Config, store storage.Storage) error {
+	var start uint64
+	if cfg.IDStrategy == utils.StrategySequential {
+		if err := store.ForEach(func(storage.Entry) error {
+			start++
+			return nil
+		}); err != nil {
+			return err
+		}
+		start++
+	}
+
+	strategy, err := utils.NewStrategy(cfg.IDStrategy, cfg.IDSalt, start)
+	if err != nil {
+		return err
 	}
+	utils.SetDefaultGenerator(utils.NewIDGenerator(strategy, cfg.IDLength))
+	return nil
 }
 
 // printBuildInfo выводит информацию о сборке приложения
@@ -2,9 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/storage"
 )
 
 func TestGetBuildValue(t *testing.T) {
@@ -161,3 +169,132 @@ func TestGlobalVariablesDefaultValues(t *testing.T) {
 		t.Error("getBuildValue should return 'N/A' for empty string")
 	}
 }
+
+func TestRunCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.json")
+	cfg := &config.Config{FileStoragePath: path}
+
+	if err := runCommand(cfg, []string{"unknown"}); err == nil {
+		t.Error("expected error for unknown command")
+	}
+
+	content := `{"uuid":"1","short_url":"a","original_url":"https://a.example","user_id":"u","deleted_flag":false}
+{"uuid":"2","short_url":"a","original_url":"https://a.example","user_id":"u","deleted_flag":true}
+`
+	if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatalf("failed to write storage file: %v", err)
+	}
+
+	if err := runCommand(cfg, []string{"compact"}); err != nil {
+		t.Fatalf("compact command failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read storage file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("expected header and 1 record after compaction, got %d lines", lines)
+	}
+
+	// Уплотнение рядом с работающим сервером не выполняется
+	live, err := storage.NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("failed to open storage: %v", err)
+	}
+	defer live.(*storage.FileStorage).Close()
+	if err := runCommand(cfg, []string{"compact"}); !errors.Is(err, storage.ErrLocked) {
+		t.Errorf("expected compact to fail while the storage is open, got %v", err)
+	}
+}
+
+func TestRunServer_FileStorageError(t *testing.T) {
+	// Путь к директории нельзя открыть как файл хранилища
+	dir := t.TempDir()
+	err := runServer(&config.Config{FileStoragePath: dir})
+	if err == nil || !strings.Contains(err.Error(), "file storage") {
+		t.Fatalf("expected file storage error, got %v", err)
+	}
+	if _, err := os.Stat(dir + ".webhooks"); !os.IsNotExist(err) {
+		t.Errorf("expected webhook store not to be opened, got %v", err)
+	}
+}
+
+func TestServe_Shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		// Начатый запрос завершается, несмотря на остановку сервера
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv.Addr = ln.Addr().String()
+	ln.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- serve(ctx, srv)
+	}()
+	var resp *http.Response
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = http.Get("http://" + srv.Addr); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected in-flight request to complete, got %d", resp.StatusCode)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("expected graceful shutdown, got %v", err)
+	}
+}
+
+func TestRunExportImport(t *testing.T) {
+	dir := t.TempDir()
+	srcCfg := &config.Config{FileStoragePath: filepath.Join(dir, "src.json")}
+	dstCfg := &config.Config{FileStoragePath: filepath.Join(dir, "dst.json")}
+	dump := filepath.Join(dir, "dump.csv")
+
+	content := `{"uuid":"1","short_url":"a","original_url":"https://a.example","user_id":"u","deleted_flag":false}
+{"uuid":"2","short_url":"b","original_url":"https://b.example","user_id":"u","deleted_flag":false}
+{"uuid":"3","short_url":"b","original_url":"https://b.example","user_id":"u","deleted_flag":true}
+`
+	if err := os.WriteFile(srcCfg.FileStoragePath, []byte(content), 0666); err != nil {
+		t.Fatalf("failed to write storage file: %v", err)
+	}
+
+	if err := runCommand(srcCfg, []string{"export", "-format", "csv", "-o", dump}); err != nil {
+		t.Fatalf("export command failed: %v", err)
+	}
+	if err := runCommand(dstCfg, []string{"import", "-format", "csv", "-i", dump, "-dry-run"}); err != nil {
+		t.Fatalf("dry run import failed: %v", err)
+	}
+	if _, err := os.Stat(dstCfg.FileStoragePath); err == nil {
+		if data, _ := os.ReadFile(dstCfg.FileStoragePath); len(data) > 0 {
+			t.Fatal("dry run must not write records")
+		}
+	}
+	if err := runCommand(dstCfg, []string{"import", "-format", "csv", "-i", dump}); err != nil {
+		t.Fatalf("import command failed: %v", err)
+	}
+
+	dst, cleanup, err := openStorage(dstCfg)
+	if err != nil {
+		t.Fatalf("failed to open imported storage: %v", err)
+	}
+	defer cleanup()
+	if _, deleted, exists := dst.Get("b"); !exists || !deleted {
+		t.Errorf("expected deleted link to be imported, exists=%v deleted=%v", exists, deleted)
+	}
+	if url, _, exists := dst.Get("a"); !exists || url != "https://a.example" {
+		t.Errorf("expected link a to be imported, got %q", url)
+	}
+}
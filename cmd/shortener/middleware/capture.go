@@ -0,0 +1,305 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"uno/pkg/traffic"
+)
+
+// Параметры записи трафика по умолчанию
+const (
+	DefaultCaptureMaxFileSize = 100 << 20 // Размер файла журнала, после которого он ротируется
+	DefaultCaptureMaxFiles    = 5         // Количество хранимых ротированных файлов
+	DefaultCaptureMaxBody     = 4096      // Максимальная длина сохраняемого тела запроса и ответа
+	captureQueueSize          = 1024      // Размер очереди записей на запись в файл
+)
+
+// redacted значение, которым заменяются идентификаторы пользователей и пароли
+const redacted = "<redacted>"
+
+// Поля паролей ссылок в телах запросов: JSON поле "password" (в том числе
+// оборванное усечением тела) и поле password формы ввода пароля
+var (
+	jsonPasswordField = regexp.MustCompile(`(?i)("password"\s*:\s*)"(?:[^"\\]|\\.)*(?:"|\\?$)`)
+	formPasswordField = regexp.MustCompile(`(?i)(^|&)(password=)[^&]*`)
+)
+
+// CaptureOptions задает параметры записи трафика
+type CaptureOptions struct {
+	Path        string  // Путь к файлу журнала; ротированные файлы получают суффиксы .1, .2, ...
+	SampleRate  float64 // Доля записываемых запросов от 0 до 1
+	MaxFileSize int64   // Размер файла, после которого он ротируется
+	MaxFiles    int     // Количество хранимых ротированных файлов
+	MaxBodySize int     // Максимальная длина сохраняемого тела запроса и ответа
+}
+
+// Capture записывает пары запрос-ответ в ротируемый JSONL журнал формата pkg/traffic
+// Заголовки не сохраняются, идентификатор пользователя вырезается из тел запроса
+// и ответа, а пароли ссылок - из тел запроса. Запись в файл выполняется в фоне; если очередь переполнена, запись
+// отбрасывается, чтобы не замедлять обработку запросов
+type Capture struct {
+	opts    CaptureOptions
+	queue   chan traffic.Record
+	done    chan struct{}
+	dropped atomic.Uint64
+	once    sync.Once
+
+	file *os.File
+	buf  *bufio.Writer
+	size int64
+}
+
+// NewCapture создает журнал трафика и запускает фоновую запись
+func NewCapture(opts CaptureOptions) (*Capture, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("capture file path is not set")
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = DefaultCaptureMaxFileSize
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultCaptureMaxFiles
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultCaptureMaxBody
+	}
+
+	c := &Capture{
+		opts:  opts,
+		queue: make(chan traffic.Record, captureQueueSize),
+		done:  make(chan struct{}),
+	}
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+	go c.run()
+	return c, nil
+}
+
+// Dropped возвращает количество записей, отброшенных из-за переполнения очереди
+func (c *Capture) Dropped() uint64 {
+	return c.dropped.Load()
+}
+
+// Close дописывает оставшиеся записи и закрывает журнал
+// Middleware нельзя использовать после Close
+func (c *Capture) Close() error {
+	c.once.Do(func() {
+		close(c.queue)
+		<-c.done
+	})
+	return nil
+}
+
+// Middleware записывает выбранные запросы в журнал
+// Должен стоять после GzipMiddleware и WithUserID, чтобы видеть распакованные тела
+// и идентификатор пользователя
+func (c *Capture) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.opts.SampleRate <= 0 || (c.opts.SampleRate < 1 && rand.Float64() >= c.opts.SampleRate) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		reqBody := &limitedBuffer{limit: c.opts.MaxBodySize}
+		if r.Body != nil {
+			r.Body = &teeReadCloser{ReadCloser: r.Body, w: reqBody}
+		}
+		cw := &captureResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+			body:           &limitedBuffer{limit: c.opts.MaxBodySize},
+		}
+
+		next.ServeHTTP(cw, r)
+
+		userID, _ := FromContext(r.Context())
+		rec := traffic.Record{
+			Time:          start.UTC(),
+			Method:        r.Method,
+			Path:          r.URL.RequestURI(),
+			ContentType:   r.Header.Get("Content-Type"),
+			Body:          redact(redactPasswords(reqBody.String(), r.Header.Get("Content-Type")), userID),
+			BodyTruncated: reqBody.truncated,
+			Status:        cw.status,
+			Response:      redact(cw.body.String(), userID),
+			RespTruncated: cw.body.truncated,
+			LatencyMS:     float64(time.Since(start)) / float64(time.Millisecond),
+		}
+
+		select {
+		case c.queue <- rec:
+		default:
+			c.dropped.Add(1)
+		}
+	})
+}
+
+// redact вырезает идентификатор пользователя из текста
+func redact(s, userID string) string {
+	if userID == "" || s == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, userID, redacted)
+}
+
+// redactPasswords заменяет значения паролей в теле запроса с типом contentType
+func redactPasswords(body, contentType string) string {
+	if body == "" {
+		return body
+	}
+	body = jsonPasswordField.ReplaceAllString(body, `${1}"`+redacted+`"`)
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		body = formPasswordField.ReplaceAllString(body, "${1}${2}"+redacted)
+	}
+	return body
+}
+
+// run записывает записи из очереди в файл
+// Буфер сбрасывается, когда очередь пуста, поэтому журнал отстает не больше чем на пачку записей
+func (c *Capture) run() {
+	defer close(c.done)
+	w := traffic.NewWriter(c)
+	for rec := range c.queue {
+		if err := w.Write(rec); err != nil {
+			log.Printf("Capture: failed to write record: %v", err)
+		}
+		if len(c.queue) == 0 {
+			if err := c.buf.Flush(); err != nil {
+				log.Printf("Capture: failed to flush: %v", err)
+			}
+		}
+	}
+	if err := c.buf.Flush(); err != nil {
+		log.Printf("Capture: failed to flush: %v", err)
+	}
+	if err := c.file.Close(); err != nil {
+		log.Printf("Capture: failed to close file: %v", err)
+	}
+}
+
+// Write реализует io.Writer для traffic.Writer: пишет одну строку журнала,
+// предварительно ротируя файл, если строка не помещается в лимит размера
+func (c *Capture) Write(p []byte) (int, error) {
+	if c.size > 0 && c.size+int64(len(p)) > c.opts.MaxFileSize {
+		if err := c.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := c.buf.Write(p)
+	c.size += int64(n)
+	return n, err
+}
+
+// open открывает файл журнала для дозаписи
+func (c *Capture) open() error {
+	f, err := os.OpenFile(c.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open capture file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat capture file: %w", err)
+	}
+	c.file = f
+	c.buf = bufio.NewWriter(f)
+	c.size = info.Size()
+	return nil
+}
+
+// rotate сдвигает ротированные файлы (path.1 -> path.2, ...), переименовывает
+// текущий файл в path.1 и открывает новый; самый старый файл удаляется
+func (c *Capture) rotate() error {
+	if err := c.buf.Flush(); err != nil {
+		return err
+	}
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+
+	path := c.opts.Path
+	os.Remove(fmt.Sprintf("%s.%d", path, c.opts.MaxFiles))
+	for i := c.opts.MaxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate capture file: %w", err)
+	}
+	return c.open()
+}
+
+// limitedBuffer накапливает не больше limit байт и отмечает усечение
+type limitedBuffer struct {
+	data      []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room < len(p) {
+		b.data = append(b.data, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.data = append(b.data, p...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.data)
+}
+
+// teeReadCloser копирует прочитанное обработчиком тело запроса в w
+type teeReadCloser struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		t.w.Write(p[:n])
+	}
+	return n, err
+}
+
+// captureResponseWriter запоминает статус и начало тела ответа
+type captureResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	body        *limitedBuffer
+	wroteHeader bool
+}
+
+// WriteHeader запоминает статус ответа
+func (w *captureResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.status = statusCode
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write копирует тело ответа в буфер
+func (w *captureResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap возвращает исходный ResponseWriter для http.ResponseController
+func (w *captureResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uno/pkg/traffic"
+)
+
+func readCapture(t *testing.T, path string) []traffic.Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open capture file: %v", err)
+	}
+	defer f.Close()
+	records, err := traffic.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read capture file: %v", err)
+	}
+	return records
+}
+
+func TestCapture_RecordsAndRedacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.jsonl")
+	capture, err := NewCapture(CaptureOptions{Path: path, SampleRate: 1, MaxBodySize: 16})
+	if err != nil {
+		t.Fatalf("NewCapture failed: %v", err)
+	}
+
+	handler := WithUserID(capture.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		userID, _ := FromContext(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("user " + userID + " sent " + string(body)))
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten?x=1", strings.NewReader(`{"url":"https://example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: userIDCookieName, Value: "u1"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := capture.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	records := readCapture(t, path)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Method != http.MethodPost || rec.Path != "/api/shorten?x=1" || rec.Status != http.StatusCreated {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if rec.ContentType != "application/json" {
+		t.Errorf("expected content type to be recorded, got %q", rec.ContentType)
+	}
+	if rec.Body != `{"url":"https://` || !rec.BodyTruncated {
+		t.Errorf("expected truncated body, got %q (%v)", rec.Body, rec.BodyTruncated)
+	}
+	if strings.Contains(rec.Response, "u1") || !strings.HasPrefix(rec.Response, "user "+redacted) {
+		t.Errorf("expected user ID to be redacted, got %q", rec.Response)
+	}
+}
+
+func TestCapture_RedactsPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.jsonl")
+	capture, err := NewCapture(CaptureOptions{Path: path, SampleRate: 1})
+	if err != nil {
+		t.Fatalf("NewCapture failed: %v", err)
+	}
+	handler := capture.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+		}
+	}))
+
+	requests := []struct{ contentType, body string }{
+		{"application/x-www-form-urlencoded", "password=s3cret&next=1"},
+		{"application/json", `{"url":"https://example.com","password":"s3cret"}`},
+		{"application/json", `[{"correlation_id":"1","original_url":"https://example.com","Password" : "s3\"cret"}]`},
+	}
+	for _, req := range requests {
+		r := httptest.NewRequest(http.MethodPost, "/abc", strings.NewReader(req.body))
+		r.Header.Set("Content-Type", req.contentType)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if err := capture.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	records := readCapture(t, path)
+	if len(records) != len(requests) {
+		t.Fatalf("expected %d records, got %d", len(requests), len(records))
+	}
+	for _, rec := range records {
+		if strings.Contains(rec.Body, "s3") || !strings.Contains(rec.Body, redacted) {
+			t.Errorf("expected password to be redacted, got %q", rec.Body)
+		}
+	}
+}
+
+func TestRedactPasswords(t *testing.T) {
+	tests := []struct{ body, contentType, want string }{
+		{`{"password":"abc"}`, "application/json", `{"password":"<redacted>"}`},
+		{`{"password":"ab`, "application/json", `{"password":"<redacted>"`},
+		{`{"password":"ab\`, "application/json", `{"password":"<redacted>"`},
+		{`{"url":"https://example.com/?password=abc"}`, "application/json", `{"url":"https://example.com/?password=abc"}`},
+		{"a=1&password=abc", "application/x-www-form-urlencoded", "a=1&password=<redacted>"},
+		{"password=ab", "application/x-www-form-urlencoded; charset=utf-8", "password=<redacted>"},
+		{"notpassword=abc", "application/x-www-form-urlencoded", "notpassword=abc"},
+	}
+	for _, tt := range tests {
+		if got := redactPasswords(tt.body, tt.contentType); got != tt.want {
+			t.Errorf("redactPasswords(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestCapture_SamplingAndRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.jsonl")
+	capture, err := NewCapture(CaptureOptions{Path: path, SampleRate: 1, MaxFileSize: 300, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("NewCapture failed: %v", err)
+	}
+	handler := capture.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 20; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	}
+	capture.Close()
+
+	total := 0
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected rotated file %s: %v", p, err)
+		}
+		if info.Size() > 300 {
+			t.Errorf("file %s exceeds max size: %d", p, info.Size())
+		}
+		total += len(readCapture(t, p))
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("expected old files beyond MaxFiles to be removed")
+	}
+	if total == 0 || total >= 20 {
+		t.Errorf("expected some but not all records to be kept, got %d", total)
+	}
+
+	// Нулевая доля сэмплирования отключает запись
+	offPath := filepath.Join(t.TempDir(), "off.jsonl")
+	off, err := NewCapture(CaptureOptions{Path: offPath, SampleRate: 0})
+	if err != nil {
+		t.Fatalf("NewCapture failed: %v", err)
+	}
+	off.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+	off.Close()
+	if records := readCapture(t, offPath); len(records) != 0 {
+		t.Errorf("expected no records with zero sample rate, got %d", len(records))
+	}
+}
@@ -19,6 +19,14 @@ func (w *gzipResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush отправляет клиенту уже сжатые данные; нужен потоковым ответам (Server-Sent Events)
+func (w *gzipResponseWriter) Flush() {
+	if gw, ok := w.Writer.(*gzip.Writer); ok {
+		gw.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
 // GzipMiddleware middleware для сжатия HTTP ответов в gzip и декодирования gzip запросов
 // Автоматически сжимает ответы, если клиент поддерживает gzip (Accept-Encoding: gzip)
 // Декодирует входящие gzip запросы (Content-Encoding: gzip)
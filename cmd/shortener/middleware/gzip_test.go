@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"go.uber.org/zap"
 )
 
 func TestGzipMiddleware(t *testing.T) {
@@ -175,3 +177,34 @@ func TestGzipMiddleware_WithGzippedRequest(t *testing.T) {
 		t.Errorf("Expected body 'Hello, Gzipped Request!', got '%s'", rec.Body.String())
 	}
 }
+
+func TestGzipMiddleware_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var flushed []byte
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: hello\n\n"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush failed: %v", err)
+		}
+		flushed = bytes.Clone(rec.Body.Bytes())
+		w.Write([]byte("data: bye\n\n"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	LoggingMiddleware(zap.NewNop())(GzipMiddleware(handler)).ServeHTTP(rec, req)
+
+	// Данные, отправленные до Flush, читаются до окончания ответа
+	reader, err := gzip.NewReader(bytes.NewReader(flushed))
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	buf := make([]byte, len("data: hello\n\n"))
+	if _, err := io.ReadFull(reader, buf); err != nil || string(buf) != "data: hello\n\n" {
+		t.Errorf("Expected flushed event, got %q: %v", buf, err)
+	}
+	if !rec.Flushed {
+		t.Error("Expected underlying writer to be flushed")
+	}
+}
@@ -34,6 +34,11 @@ func (l *loggingResponseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
+// Unwrap возвращает исходный ResponseWriter для http.ResponseController
+func (l *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return l.ResponseWriter
+}
+
 // LoggingMiddleware middleware для логирования HTTP запросов и ответов
 // Логирует метод, URI, статус код, размер ответа, тип содержимого и время выполнения
 // Использует структурированное логирование через zap.Logger
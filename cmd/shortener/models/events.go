@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Типы событий жизненного цикла ссылки
+// События об истечении ссылки нет: срока жизни у ссылок пока нет
+const (
+	EventLinkCreated = "link.created" // Ссылка создана
+	EventLinkDeleted = "link.deleted" // Ссылка удалена
+	EventLinkClicks  = "link.clicks"  // Количество переходов по ссылке достигло порога
+	EventLinkClicked = "link.clicked" // Переход по ссылке (только в потоке событий)
+)
+
+// LinkEvent представляет событие жизненного цикла ссылки
+type LinkEvent struct {
+	Type        string    // Тип события
+	UserID      string    // Владелец ссылки
+	ShortID     string    // Сокращенный ID
+	OriginalURL string    // Оригинальный URL
+	Clicks      int64     // Достигнутое количество переходов (для link.clicks)
+	Target      string    // Адрес, на который перенаправлен посетитель (для link.clicked)
+	Variant     string    // Вариант ссылки, выбранный посетителю (для link.clicked)
+	Time        time.Time // Момент события
+}
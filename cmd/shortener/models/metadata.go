@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ограничения метаданных ссылки
+const (
+	MaxTitleLength = 200  // Максимальная длина названия в символах
+	MaxNoteLength  = 2000 // Максимальная длина заметки в символах
+	MaxTags        = 20   // Максимальное количество тегов
+	MaxTagLength   = 50   // Максимальная длина тега в символах
+)
+
+// ErrInvalidMetadata возвращается, если метаданные ссылки не проходят проверку
+var ErrInvalidMetadata = errors.New("invalid link metadata")
+
+// IsZero проверяет, что метаданные не заданы
+func (m LinkMetadata) IsZero() bool {
+	return m.Title == "" && len(m.Tags) == 0 && m.Note == ""
+}
+
+// HasTag проверяет, что ссылка помечена тегом tag (без учета регистра)
+func (m LinkMetadata) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTag приводит тег к каноническому виду: без пробелов по краям, в нижнем регистре
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Normalize приводит метаданные к каноническому виду и проверяет ограничения
+// Пробелы по краям убираются, теги приводятся к нижнему регистру,
+// пустые и повторяющиеся теги отбрасываются
+func (m *LinkMetadata) Normalize() error {
+	m.Title = strings.TrimSpace(m.Title)
+	m.Note = strings.TrimSpace(m.Note)
+	if utf8.RuneCountInString(m.Title) > MaxTitleLength {
+		return fmt.Errorf("%w: title is longer than %d characters", ErrInvalidMetadata, MaxTitleLength)
+	}
+	if utf8.RuneCountInString(m.Note) > MaxNoteLength {
+		return fmt.Errorf("%w: note is longer than %d characters", ErrInvalidMetadata, MaxNoteLength)
+	}
+
+	var tags []string
+	seen := make(map[string]bool, len(m.Tags))
+	for _, t := range m.Tags {
+		t = NormalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > MaxTagLength {
+			return fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidMetadata, t, MaxTagLength)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	if len(tags) > MaxTags {
+		return fmt.Errorf("%w: more than %d tags", ErrInvalidMetadata, MaxTags)
+	}
+	m.Tags = tags
+	return nil
+}
+
+// Apply применяет изменения к метаданным m и возвращает результат
+// Результат не нормализуется
+func (p MetadataPatch) Apply(m LinkMetadata) LinkMetadata {
+	if p.Title != nil {
+		m.Title = *p.Title
+	}
+	if p.Tags != nil {
+		m.Tags = *p.Tags
+	}
+	if p.Note != nil {
+		m.Note = *p.Note
+	}
+	return m
+}
+
+// IsEmpty проверяет, что запрос не изменяет ни одного поля
+func (p MetadataPatch) IsEmpty() bool {
+	return p.Title == nil && p.Tags == nil && p.Note == nil
+}
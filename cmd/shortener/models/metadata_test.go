@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLinkMetadata_Normalize(t *testing.T) {
+	m := LinkMetadata{
+		Title: "  Docs  ",
+		Tags:  []string{" Work", "work", "", "Go "},
+		Note:  "note ",
+	}
+	if err := m.Normalize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Title != "Docs" || m.Note != "note" {
+		t.Errorf("expected trimmed title and note, got %q and %q", m.Title, m.Note)
+	}
+	if strings.Join(m.Tags, ",") != "work,go" {
+		t.Errorf("expected tags [work go], got %v", m.Tags)
+	}
+	if !m.HasTag("WORK") || m.HasTag("docs") {
+		t.Errorf("unexpected HasTag result for %v", m.Tags)
+	}
+
+	many := make([]string, MaxTags+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("tag%d", i)
+	}
+	invalid := []LinkMetadata{
+		{Title: strings.Repeat("я", MaxTitleLength+1)},
+		{Note: strings.Repeat("x", MaxNoteLength+1)},
+		{Tags: []string{strings.Repeat("t", MaxTagLength+1)}},
+		{Tags: many},
+	}
+	for i, m := range invalid {
+		if err := m.Normalize(); !errors.Is(err, ErrInvalidMetadata) {
+			t.Errorf("case %d: expected ErrInvalidMetadata, got %v", i, err)
+		}
+	}
+}
+
+func TestMetadataPatch_Apply(t *testing.T) {
+	var patch MetadataPatch
+	if err := patch.UnmarshalJSON([]byte(`{"title":"New","tags":[]}`)); err != nil {
+		t.Fatalf("failed to unmarshal patch: %v", err)
+	}
+	if patch.IsEmpty() || patch.Note != nil {
+		t.Fatalf("unexpected patch: %+v", patch)
+	}
+
+	got := patch.Apply(LinkMetadata{Title: "Old", Tags: []string{"a"}, Note: "keep"})
+	if got.Title != "New" || len(got.Tags) != 0 || got.Note != "keep" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestAPIRequest_UnmarshalMetadata(t *testing.T) {
+	var req APIRequest
+	data := `{"url":"https://example.com","title":"Example","tags":["a","b"],"note":"n"}`
+	if err := req.UnmarshalJSON([]byte(data)); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if req.URL != "https://example.com" || req.Title != "Example" || len(req.Tags) != 2 || req.Note != "n" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+
+	out, err := UserURL{ShortURL: "id", OriginalURL: "https://example.com"}.MarshalJSON()
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if strings.Contains(string(out), "title") || strings.Contains(string(out), "tags") {
+		t.Errorf("expected empty metadata to be omitted, got %s", out)
+	}
+}
@@ -2,36 +2,51 @@
 // включая модели для запросов, ответов и внутреннего представления данных.
 package models
 
+import "time"
+
 //go:generate easyjson
 
 // APIRequest представляет запрос на сокращение URL через API
 //
 //easyjson:json
 type APIRequest struct {
-	URL string `json:"url"` // Оригинальный URL для сокращения
+	URL          string `json:"url"`                     // Оригинальный URL для сокращения
+	Password     string `json:"password,omitempty"`      // Пароль для открытия ссылки (необязательный)
+	Interstitial bool   `json:"interstitial,omitempty"`  // Показывать страницу перед перенаправлением
+	RedirectCode int    `json:"redirect_code,omitempty"` // Код ответа перенаправления (301, 302, 307 или 308)
+	QueryPolicy  string `json:"query_policy,omitempty"`  // Обработка query параметров: ignore, append или override
+	LinkMetadata
 }
 
 // APIResponse представляет ответ API с сокращенным URL
 //
 //easyjson:json
 type APIResponse struct {
-	Result string `json:"result"` // Сокращенный URL
+	Result string `json:"result"`       // Сокращенный URL
+	QR     string `json:"qr,omitempty"` // Адрес QR кода ссылки, если запрошен
 }
 
 // BatchRequest представляет запрос на пакетное сокращение URL
 //
 //easyjson:json
 type BatchRequest struct {
-	CorrelationID string `json:"correlation_id"` // Идентификатор корреляции для связи запроса и ответа
-	OriginalURL   string `json:"original_url"`   // Оригинальный URL для сокращения
+	CorrelationID string `json:"correlation_id"`          // Идентификатор корреляции для связи запроса и ответа
+	OriginalURL   string `json:"original_url"`            // Оригинальный URL для сокращения
+	Password      string `json:"password,omitempty"`      // Пароль для открытия ссылки (необязательный)
+	Interstitial  bool   `json:"interstitial,omitempty"`  // Показывать страницу перед перенаправлением
+	RedirectCode  int    `json:"redirect_code,omitempty"` // Код ответа перенаправления (301, 302, 307 или 308)
+	QueryPolicy   string `json:"query_policy,omitempty"`  // Обработка query параметров: ignore, append или override
+	LinkMetadata
 }
 
 // BatchResponse представляет ответ на пакетное сокращение URL
 //
 //easyjson:json
 type BatchResponse struct {
-	CorrelationID string `json:"correlation_id"` // Идентификатор корреляции из запроса
-	ShortURL      string `json:"short_url"`      // Сокращенный URL
+	CorrelationID string `json:"correlation_id"`  // Идентификатор корреляции из запроса
+	ShortURL      string `json:"short_url"`       // Сокращенный URL
+	QR            string `json:"qr,omitempty"`    // Адрес QR кода ссылки, если запрошен
+	Error         string `json:"error,omitempty"` // Причина, по которой ссылка не сохранена
 }
 
 // BatchRequestList представляет список запросов на пакетное сокращение
@@ -63,7 +78,251 @@ func MarshalBatchResponse(v []BatchResponse) ([]byte, error) {
 //
 //easyjson:json
 type UserURL struct {
-	ShortURL    string `json:"short_url"`    // Сокращенный URL
-	OriginalURL string `json:"original_url"` // Оригинальный URL
-	Deleted     bool   `json:"deleted"`      // Флаг удаления URL
+	ShortURL    string     `json:"short_url"`            // Сокращенный URL
+	OriginalURL string     `json:"original_url"`         // Оригинальный URL
+	Deleted     bool       `json:"deleted"`              // Флаг удаления URL
+	CreatedAt   *time.Time `json:"created_at,omitempty"` // Момент создания (nil у ссылок, созданных до его учета)
+	LinkMetadata
+}
+
+// UserURLList представляет список URL пользователя
+//
+//easyjson:json
+type UserURLList []UserURL
+
+// LinkMetadata содержит необязательные пользовательские данные ссылки:
+// название, теги и заметку
+//
+//easyjson:json
+type LinkMetadata struct {
+	Title string   `json:"title,omitempty"` // Название ссылки
+	Tags  []string `json:"tags,omitempty"`  // Теги для группировки и фильтрации
+	Note  string   `json:"note,omitempty"`  // Произвольная заметка
+}
+
+// MetadataPatch представляет запрос на изменение метаданных ссылки
+// Поля, отсутствующие в запросе, не изменяются; пустое значение очищает поле
+//
+//easyjson:json
+type MetadataPatch struct {
+	Title *string   `json:"title"` // Новое название
+	Tags  *[]string `json:"tags"`  // Новый набор тегов
+	Note  *string   `json:"note"`  // Новая заметка
+}
+
+// UpdateURLRequest представляет запрос на изменение адреса перенаправления ссылки
+//
+//easyjson:json
+type UpdateURLRequest struct {
+	URL string `json:"url"` // Новый оригинальный URL
+}
+
+// RevertRequest представляет запрос на возврат ссылки к предыдущей версии адреса
+//
+//easyjson:json
+type RevertRequest struct {
+	Version int `json:"version"` // Номер версии из истории
+}
+
+// URLVersion представляет предыдущий адрес перенаправления ссылки
+//
+//easyjson:json
+type URLVersion struct {
+	Version     int       `json:"version"`      // Номер версии, начиная с 1
+	OriginalURL string    `json:"original_url"` // Оригинальный URL этой версии
+	ReplacedAt  time.Time `json:"replaced_at"`  // Момент замены следующей версией
+}
+
+// URLHistory представляет текущий адрес ссылки и историю его изменений
+//
+//easyjson:json
+type URLHistory struct {
+	ShortURL    string       `json:"short_url"`    // Сокращенный URL
+	OriginalURL string       `json:"original_url"` // Текущий оригинальный URL
+	Version     int          `json:"version"`      // Номер текущей версии
+	Versions    []URLVersion `json:"versions"`     // Предыдущие версии, от старых к новым
+}
+
+// Link представляет ссылку вместе с владельцем и параметрами перенаправления
+type Link struct {
+	ShortURL    string     // Сокращенный ID
+	OriginalURL string     // Оригинальный URL
+	UserID      string     // Идентификатор владельца
+	Deleted     bool       // Флаг удаления
+	Title       string     // Название ссылки
+	CreatedAt   *time.Time // Момент создания ссылки
+	LinkOptions
+}
+
+// LinkOptions содержит параметры перенаправления ссылки
+//
+//easyjson:json
+type LinkOptions struct {
+	PasswordHash string         `json:"password_hash,omitempty"` // Хэш пароля ссылки (пусто - ссылка открыта)
+	Interstitial bool           `json:"interstitial,omitempty"`  // Страница "вы покидаете сайт" перед перенаправлением
+	RedirectCode int            `json:"redirect_code,omitempty"` // Код ответа перенаправления (0 - DefaultRedirectCode)
+	QueryPolicy  string         `json:"query_policy,omitempty"`  // Обработка query параметров короткой ссылки (пусто - QueryIgnore)
+	Rules        []RedirectRule `json:"rules,omitempty"`         // Правила выбора адреса назначения, по порядку
+	Variants     []Variant      `json:"variants,omitempty"`      // Варианты A/B теста (пусто - один адрес)
+}
+
+// Variant представляет один из адресов назначения ссылки с разделением трафика
+//
+//easyjson:json
+type Variant struct {
+	Name   string `json:"name"`   // Имя варианта, уникальное в пределах ссылки
+	URL    string `json:"url"`    // Адрес назначения варианта
+	Weight int    `json:"weight"` // Относительная доля трафика варианта
+}
+
+// VariantList представляет варианты ссылки в порядке задания
+//
+//easyjson:json
+type VariantList []Variant
+
+// ClickCount представляет приращение счетчика переходов по ссылке
+type ClickCount struct {
+	ShortURL string // Сокращенный ID
+	Variant  string // Имя варианта (пусто - ссылка без вариантов)
+	Clicks   int64  // Количество переходов
+}
+
+// VariantStats представляет переходы по одному варианту ссылки
+//
+//easyjson:json
+type VariantStats struct {
+	Name   string `json:"name"`             // Имя варианта
+	URL    string `json:"url,omitempty"`    // Адрес варианта (пусто - вариант уже удален)
+	Weight int    `json:"weight,omitempty"` // Текущая доля трафика варианта
+	Clicks int64  `json:"clicks"`           // Количество переходов
+}
+
+// ClickStats представляет статистику переходов по ссылке
+//
+//easyjson:json
+type ClickStats struct {
+	Clicks   int64          `json:"clicks"`             // Всего переходов
+	Variants []VariantStats `json:"variants,omitempty"` // Переходы по вариантам
+}
+
+// RedirectRule представляет правило выбора адреса назначения ссылки
+// Правило срабатывает, если выполнены все заданные условия
+//
+//easyjson:json
+type RedirectRule struct {
+	Platform  string     `json:"platform,omitempty"`  // Платформа из User-Agent (ios, android, windows, macos, linux)
+	Languages []string   `json:"languages,omitempty"` // Языковые теги Accept-Language ("de" совпадает и с "de-AT")
+	From      *time.Time `json:"from,omitempty"`      // Начало окна действия правила
+	Until     *time.Time `json:"until,omitempty"`     // Конец окна действия правила (не включая)
+	URL       string     `json:"url"`                 // Адрес назначения при срабатывании правила
+}
+
+// RedirectRuleList представляет упорядоченный список правил перенаправления
+//
+//easyjson:json
+type RedirectRuleList []RedirectRule
+
+// OptionsPatch представляет частичное изменение параметров перенаправления
+// Поля со значением nil не изменяются
+//
+//easyjson:json
+type OptionsPatch struct {
+	RedirectCode *int    `json:"redirect_code"` // Новый код ответа перенаправления
+	QueryPolicy  *string `json:"query_policy"`  // Новая политика query параметров
+	Interstitial *bool   `json:"interstitial"`  // Новый режим страницы "вы покидаете сайт"
+}
+
+// OptionsResponse представляет параметры перенаправления ссылки в ответе API
+// Хэш пароля не выводится, только признак защиты
+//
+//easyjson:json
+type OptionsResponse struct {
+	RedirectCode int    `json:"redirect_code"` // Код ответа перенаправления
+	QueryPolicy  string `json:"query_policy"`  // Политика query параметров
+	Interstitial bool   `json:"interstitial"`  // Страница "вы покидаете сайт"
+	Protected    bool   `json:"protected"`     // Ссылка защищена паролем
+}
+
+// StreamEvent представляет событие ссылки в потоке GET /api/user/events
+//
+//easyjson:json
+type StreamEvent struct {
+	Type        string    `json:"type"`                   // Тип события
+	ShortID     string    `json:"short_id"`               // Сокращенный ID
+	ShortURL    string    `json:"short_url"`              // Сокращенный URL
+	OriginalURL string    `json:"original_url,omitempty"` // Оригинальный URL
+	Target      string    `json:"target,omitempty"`       // Адрес перенаправления (для link.clicked)
+	Variant     string    `json:"variant,omitempty"`      // Выбранный вариант ссылки (для link.clicked)
+	Time        time.Time `json:"time"`                   // Момент события
+}
+
+// WebhookRequest представляет запрос на регистрацию вебхука
+//
+//easyjson:json
+type WebhookRequest struct {
+	URL    string   `json:"url"`    // Адрес получателя событий
+	Secret string   `json:"secret"` // Ключ подписи (пусто - сгенерировать)
+	Events []string `json:"events"` // Типы событий (пусто - все)
+}
+
+// Webhook представляет зарегистрированный получатель событий пользователя
+// Ключ подписи выводится только в ответе на регистрацию
+//
+//easyjson:json
+type Webhook struct {
+	ID        string    `json:"id"`               // Идентификатор вебхука
+	UserID    string    `json:"-"`                // Владелец вебхука
+	URL       string    `json:"url"`              // Адрес получателя событий
+	Secret    string    `json:"secret,omitempty"` // Ключ подписи HMAC-SHA256
+	Events    []string  `json:"events"`           // Типы событий (пусто - все)
+	CreatedAt time.Time `json:"created_at"`       // Момент регистрации
+}
+
+// WebhookList представляет список вебхуков пользователя
+//
+//easyjson:json
+type WebhookList []Webhook
+
+// WebhookEventData представляет данные события в теле запроса вебхука
+//
+//easyjson:json
+type WebhookEventData struct {
+	ShortID     string `json:"short_id"`               // Сокращенный ID
+	ShortURL    string `json:"short_url"`              // Сокращенный URL
+	OriginalURL string `json:"original_url,omitempty"` // Оригинальный URL
+	Clicks      int64  `json:"clicks,omitempty"`       // Достигнутое количество переходов
+}
+
+// WebhookEvent представляет тело запроса вебхука
+//
+//easyjson:json
+type WebhookEvent struct {
+	ID        string           `json:"id"`         // Идентификатор события, общий для всех получателей
+	Type      string           `json:"type"`       // Тип события
+	CreatedAt time.Time        `json:"created_at"` // Момент события
+	Data      WebhookEventData `json:"data"`       // Данные события
+}
+
+// WebhookDelivery представляет доставку события одному вебхуку
+//
+//easyjson:json
+type WebhookDelivery struct {
+	ID            string     `json:"id"`                        // Идентификатор доставки
+	WebhookID     string     `json:"webhook_id"`                // Идентификатор вебхука
+	UserID        string     `json:"-"`                         // Владелец вебхука
+	EventID       string     `json:"event_id"`                  // Идентификатор события
+	EventType     string     `json:"event_type"`                // Тип события
+	Payload       []byte     `json:"-"`                         // Тело запроса, одинаковое во всех попытках
+	Status        string     `json:"status"`                    // Состояние: pending, delivered, failed
+	Attempts      int        `json:"attempts"`                  // Выполнено попыток
+	ResponseCode  int        `json:"response_code,omitempty"`   // Код ответа последней попытки
+	Error         string     `json:"error,omitempty"`           // Ошибка последней попытки
+	CreatedAt     time.Time  `json:"created_at"`                // Момент постановки в очередь
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // Момент следующей попытки (только pending)
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`    // Момент успешной доставки
 }
+
+// WebhookDeliveryList представляет журнал доставок вебхука
+//
+//easyjson:json
+type WebhookDeliveryList []WebhookDelivery
@@ -4,21 +4,2199 @@ package models
 
 import (
 	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels(in *jlexer.Lexer, out *WebhookRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "url":
+			out.URL = string(in.String())
+		case "secret":
+			out.Secret = string(in.String())
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]string, 0, 4)
+					} else {
+						out.Events = []string{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 string
+					v1 = string(in.String())
+					out.Events = append(out.Events, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels(out *jwriter.Writer, in WebhookRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"url\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.URL))
+	}
+	{
+		const prefix string = ",\"secret\":"
+		out.RawString(prefix)
+		out.String(string(in.Secret))
+	}
+	{
+		const prefix string = ",\"events\":"
+		out.RawString(prefix)
+		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Events {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v3))
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels1(in *jlexer.Lexer, out *WebhookList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(WebhookList, 0, 0)
+			} else {
+				*out = WebhookList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v4 Webhook
+			(v4).UnmarshalEasyJSON(in)
+			*out = append(*out, v4)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels1(out *jwriter.Writer, in WebhookList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v5, v6 := range in {
+			if v5 > 0 {
+				out.RawByte(',')
+			}
+			(v6).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels1(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels2(in *jlexer.Lexer, out *WebhookEventData) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "short_id":
+			out.ShortID = string(in.String())
+		case "short_url":
+			out.ShortURL = string(in.String())
+		case "original_url":
+			out.OriginalURL = string(in.String())
+		case "clicks":
+			out.Clicks = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels2(out *jwriter.Writer, in WebhookEventData) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"short_id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ShortID))
+	}
+	{
+		const prefix string = ",\"short_url\":"
+		out.RawString(prefix)
+		out.String(string(in.ShortURL))
+	}
+	if in.OriginalURL != "" {
+		const prefix string = ",\"original_url\":"
+		out.RawString(prefix)
+		out.String(string(in.OriginalURL))
+	}
+	if in.Clicks != 0 {
+		const prefix string = ",\"clicks\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Clicks))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookEventData) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookEventData) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookEventData) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookEventData) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels2(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels3(in *jlexer.Lexer, out *WebhookEvent) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "type":
+			out.Type = string(in.String())
+		case "created_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		case "data":
+			(out.Data).UnmarshalEasyJSON(in)
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels3(out *jwriter.Writer, in WebhookEvent) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	{
+		const prefix string = ",\"type\":"
+		out.RawString(prefix)
+		out.String(string(in.Type))
+	}
+	{
+		const prefix string = ",\"created_at\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"data\":"
+		out.RawString(prefix)
+		(in.Data).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookEvent) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookEvent) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookEvent) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookEvent) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels3(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels4(in *jlexer.Lexer, out *WebhookDeliveryList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(WebhookDeliveryList, 0, 0)
+			} else {
+				*out = WebhookDeliveryList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v7 WebhookDelivery
+			(v7).UnmarshalEasyJSON(in)
+			*out = append(*out, v7)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels4(out *jwriter.Writer, in WebhookDeliveryList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v8, v9 := range in {
+			if v8 > 0 {
+				out.RawByte(',')
+			}
+			(v9).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookDeliveryList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels4(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookDeliveryList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels4(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookDeliveryList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels4(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookDeliveryList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels4(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels5(in *jlexer.Lexer, out *WebhookDelivery) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "webhook_id":
+			out.WebhookID = string(in.String())
+		case "event_id":
+			out.EventID = string(in.String())
+		case "event_type":
+			out.EventType = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "attempts":
+			out.Attempts = int(in.Int())
+		case "response_code":
+			out.ResponseCode = int(in.Int())
+		case "error":
+			out.Error = string(in.String())
+		case "created_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		case "next_attempt_at":
+			if in.IsNull() {
+				in.Skip()
+				out.NextAttemptAt = nil
+			} else {
+				if out.NextAttemptAt == nil {
+					out.NextAttemptAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.NextAttemptAt).UnmarshalJSON(data))
+				}
+			}
+		case "delivered_at":
+			if in.IsNull() {
+				in.Skip()
+				out.DeliveredAt = nil
+			} else {
+				if out.DeliveredAt == nil {
+					out.DeliveredAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.DeliveredAt).UnmarshalJSON(data))
+				}
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels5(out *jwriter.Writer, in WebhookDelivery) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	{
+		const prefix string = ",\"webhook_id\":"
+		out.RawString(prefix)
+		out.String(string(in.WebhookID))
+	}
+	{
+		const prefix string = ",\"event_id\":"
+		out.RawString(prefix)
+		out.String(string(in.EventID))
+	}
+	{
+		const prefix string = ",\"event_type\":"
+		out.RawString(prefix)
+		out.String(string(in.EventType))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"attempts\":"
+		out.RawString(prefix)
+		out.Int(int(in.Attempts))
+	}
+	if in.ResponseCode != 0 {
+		const prefix string = ",\"response_code\":"
+		out.RawString(prefix)
+		out.Int(int(in.ResponseCode))
+	}
+	if in.Error != "" {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		out.String(string(in.Error))
+	}
+	{
+		const prefix string = ",\"created_at\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	if in.NextAttemptAt != nil {
+		const prefix string = ",\"next_attempt_at\":"
+		out.RawString(prefix)
+		out.Raw((*in.NextAttemptAt).MarshalJSON())
+	}
+	if in.DeliveredAt != nil {
+		const prefix string = ",\"delivered_at\":"
+		out.RawString(prefix)
+		out.Raw((*in.DeliveredAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookDelivery) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels5(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookDelivery) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels5(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookDelivery) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels5(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookDelivery) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels5(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels6(in *jlexer.Lexer, out *Webhook) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "url":
+			out.URL = string(in.String())
+		case "secret":
+			out.Secret = string(in.String())
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]string, 0, 4)
+					} else {
+						out.Events = []string{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v10 string
+					v10 = string(in.String())
+					out.Events = append(out.Events, v10)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "created_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels6(out *jwriter.Writer, in Webhook) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	{
+		const prefix string = ",\"url\":"
+		out.RawString(prefix)
+		out.String(string(in.URL))
+	}
+	if in.Secret != "" {
+		const prefix string = ",\"secret\":"
+		out.RawString(prefix)
+		out.String(string(in.Secret))
+	}
+	{
+		const prefix string = ",\"events\":"
+		out.RawString(prefix)
+		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v11, v12 := range in.Events {
+				if v11 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v12))
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"created_at\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Webhook) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Webhook) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Webhook) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Webhook) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels6(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels7(in *jlexer.Lexer, out *VariantStats) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "name":
+			out.Name = string(in.String())
+		case "url":
+			out.URL = string(in.String())
+		case "weight":
+			out.Weight = int(in.Int())
+		case "clicks":
+			out.Clicks = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels7(out *jwriter.Writer, in VariantStats) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"name\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Name))
+	}
+	if in.URL != "" {
+		const prefix string = ",\"url\":"
+		out.RawString(prefix)
+		out.String(string(in.URL))
+	}
+	if in.Weight != 0 {
+		const prefix string = ",\"weight\":"
+		out.RawString(prefix)
+		out.Int(int(in.Weight))
+	}
+	{
+		const prefix string = ",\"clicks\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.Clicks))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v VariantStats) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels7(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v VariantStats) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels7(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *VariantStats) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels7(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *VariantStats) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels7(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels8(in *jlexer.Lexer, out *VariantList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(VariantList, 0, 1)
+			} else {
+				*out = VariantList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v13 Variant
+			(v13).UnmarshalEasyJSON(in)
+			*out = append(*out, v13)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels8(out *jwriter.Writer, in VariantList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v14, v15 := range in {
+			if v14 > 0 {
+				out.RawByte(',')
+			}
+			(v15).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v VariantList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels8(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v VariantList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels8(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *VariantList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels8(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *VariantList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels8(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels9(in *jlexer.Lexer, out *Variant) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "name":
+			out.Name = string(in.String())
+		case "url":
+			out.URL = string(in.String())
+		case "weight":
+			out.Weight = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels9(out *jwriter.Writer, in Variant) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"name\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Name))
+	}
+	{
+		const prefix string = ",\"url\":"
+		out.RawString(prefix)
+		out.String(string(in.URL))
+	}
+	{
+		const prefix string = ",\"weight\":"
+		out.RawString(prefix)
+		out.Int(int(in.Weight))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Variant) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Variant) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Variant) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Variant) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels9(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels10(in *jlexer.Lexer, out *UserURLList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(UserURLList, 0, 0)
+			} else {
+				*out = UserURLList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v16 UserURL
+			(v16).UnmarshalEasyJSON(in)
+			*out = append(*out, v16)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels10(out *jwriter.Writer, in UserURLList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v17, v18 := range in {
+			if v17 > 0 {
+				out.RawByte(',')
+			}
+			(v18).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserURLList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels10(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserURLList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels10(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserURLList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels10(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserURLList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels10(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels11(in *jlexer.Lexer, out *UserURL) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "short_url":
+			out.ShortURL = string(in.String())
+		case "original_url":
+			out.OriginalURL = string(in.String())
+		case "deleted":
+			out.Deleted = bool(in.Bool())
+		case "created_at":
+			if in.IsNull() {
+				in.Skip()
+				out.CreatedAt = nil
+			} else {
+				if out.CreatedAt == nil {
+					out.CreatedAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.CreatedAt).UnmarshalJSON(data))
+				}
+			}
+		case "title":
+			out.Title = string(in.String())
+		case "tags":
+			if in.IsNull() {
+				in.Skip()
+				out.Tags = nil
+			} else {
+				in.Delim('[')
+				if out.Tags == nil {
+					if !in.IsDelim(']') {
+						out.Tags = make([]string, 0, 4)
+					} else {
+						out.Tags = []string{}
+					}
+				} else {
+					out.Tags = (out.Tags)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v19 string
+					v19 = string(in.String())
+					out.Tags = append(out.Tags, v19)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "note":
+			out.Note = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels11(out *jwriter.Writer, in UserURL) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"short_url\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ShortURL))
+	}
+	{
+		const prefix string = ",\"original_url\":"
+		out.RawString(prefix)
+		out.String(string(in.OriginalURL))
+	}
+	{
+		const prefix string = ",\"deleted\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Deleted))
+	}
+	if in.CreatedAt != nil {
+		const prefix string = ",\"created_at\":"
+		out.RawString(prefix)
+		out.Raw((*in.CreatedAt).MarshalJSON())
+	}
+	if in.Title != "" {
+		const prefix string = ",\"title\":"
+		out.RawString(prefix)
+		out.String(string(in.Title))
+	}
+	if len(in.Tags) != 0 {
+		const prefix string = ",\"tags\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v20, v21 := range in.Tags {
+				if v20 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v21))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Note != "" {
+		const prefix string = ",\"note\":"
+		out.RawString(prefix)
+		out.String(string(in.Note))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UserURL) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels11(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UserURL) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels11(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UserURL) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels11(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UserURL) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels11(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels12(in *jlexer.Lexer, out *UpdateURLRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "url":
+			out.URL = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels12(out *jwriter.Writer, in UpdateURLRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"url\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.URL))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UpdateURLRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels12(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UpdateURLRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels12(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UpdateURLRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels12(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UpdateURLRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels12(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels13(in *jlexer.Lexer, out *URLVersion) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "version":
+			out.Version = int(in.Int())
+		case "original_url":
+			out.OriginalURL = string(in.String())
+		case "replaced_at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ReplacedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels13(out *jwriter.Writer, in URLVersion) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"version\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Version))
+	}
+	{
+		const prefix string = ",\"original_url\":"
+		out.RawString(prefix)
+		out.String(string(in.OriginalURL))
+	}
+	{
+		const prefix string = ",\"replaced_at\":"
+		out.RawString(prefix)
+		out.Raw((in.ReplacedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v URLVersion) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels13(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v URLVersion) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels13(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *URLVersion) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels13(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *URLVersion) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels13(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels14(in *jlexer.Lexer, out *URLHistory) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "short_url":
+			out.ShortURL = string(in.String())
+		case "original_url":
+			out.OriginalURL = string(in.String())
+		case "version":
+			out.Version = int(in.Int())
+		case "versions":
+			if in.IsNull() {
+				in.Skip()
+				out.Versions = nil
+			} else {
+				in.Delim('[')
+				if out.Versions == nil {
+					if !in.IsDelim(']') {
+						out.Versions = make([]URLVersion, 0, 1)
+					} else {
+						out.Versions = []URLVersion{}
+					}
+				} else {
+					out.Versions = (out.Versions)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v22 URLVersion
+					(v22).UnmarshalEasyJSON(in)
+					out.Versions = append(out.Versions, v22)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels14(out *jwriter.Writer, in URLHistory) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"short_url\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ShortURL))
+	}
+	{
+		const prefix string = ",\"original_url\":"
+		out.RawString(prefix)
+		out.String(string(in.OriginalURL))
+	}
+	{
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
+	{
+		const prefix string = ",\"versions\":"
+		out.RawString(prefix)
+		if in.Versions == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v23, v24 := range in.Versions {
+				if v23 > 0 {
+					out.RawByte(',')
+				}
+				(v24).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v URLHistory) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels14(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v URLHistory) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels14(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *URLHistory) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels14(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *URLHistory) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels14(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels15(in *jlexer.Lexer, out *StreamEvent) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "type":
+			out.Type = string(in.String())
+		case "short_id":
+			out.ShortID = string(in.String())
+		case "short_url":
+			out.ShortURL = string(in.String())
+		case "original_url":
+			out.OriginalURL = string(in.String())
+		case "target":
+			out.Target = string(in.String())
+		case "variant":
+			out.Variant = string(in.String())
+		case "time":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Time).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels15(out *jwriter.Writer, in StreamEvent) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"type\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Type))
+	}
+	{
+		const prefix string = ",\"short_id\":"
+		out.RawString(prefix)
+		out.String(string(in.ShortID))
+	}
+	{
+		const prefix string = ",\"short_url\":"
+		out.RawString(prefix)
+		out.String(string(in.ShortURL))
+	}
+	if in.OriginalURL != "" {
+		const prefix string = ",\"original_url\":"
+		out.RawString(prefix)
+		out.String(string(in.OriginalURL))
+	}
+	if in.Target != "" {
+		const prefix string = ",\"target\":"
+		out.RawString(prefix)
+		out.String(string(in.Target))
+	}
+	if in.Variant != "" {
+		const prefix string = ",\"variant\":"
+		out.RawString(prefix)
+		out.String(string(in.Variant))
+	}
+	{
+		const prefix string = ",\"time\":"
+		out.RawString(prefix)
+		out.Raw((in.Time).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v StreamEvent) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels15(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v StreamEvent) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels15(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *StreamEvent) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels15(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *StreamEvent) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels15(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels16(in *jlexer.Lexer, out *RevertRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "version":
+			out.Version = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels16(out *jwriter.Writer, in RevertRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"version\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Version))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RevertRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels16(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RevertRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels16(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RevertRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels16(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RevertRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels16(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels17(in *jlexer.Lexer, out *RedirectRuleList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(RedirectRuleList, 0, 0)
+			} else {
+				*out = RedirectRuleList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v25 RedirectRule
+			(v25).UnmarshalEasyJSON(in)
+			*out = append(*out, v25)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels17(out *jwriter.Writer, in RedirectRuleList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v26, v27 := range in {
+			if v26 > 0 {
+				out.RawByte(',')
+			}
+			(v27).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RedirectRuleList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels17(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RedirectRuleList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels17(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RedirectRuleList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels17(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RedirectRuleList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels17(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels18(in *jlexer.Lexer, out *RedirectRule) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "platform":
+			out.Platform = string(in.String())
+		case "languages":
+			if in.IsNull() {
+				in.Skip()
+				out.Languages = nil
+			} else {
+				in.Delim('[')
+				if out.Languages == nil {
+					if !in.IsDelim(']') {
+						out.Languages = make([]string, 0, 4)
+					} else {
+						out.Languages = []string{}
+					}
+				} else {
+					out.Languages = (out.Languages)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v28 string
+					v28 = string(in.String())
+					out.Languages = append(out.Languages, v28)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "from":
+			if in.IsNull() {
+				in.Skip()
+				out.From = nil
+			} else {
+				if out.From == nil {
+					out.From = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.From).UnmarshalJSON(data))
+				}
+			}
+		case "until":
+			if in.IsNull() {
+				in.Skip()
+				out.Until = nil
+			} else {
+				if out.Until == nil {
+					out.Until = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.Until).UnmarshalJSON(data))
+				}
+			}
+		case "url":
+			out.URL = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels18(out *jwriter.Writer, in RedirectRule) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Platform != "" {
+		const prefix string = ",\"platform\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Platform))
+	}
+	if len(in.Languages) != 0 {
+		const prefix string = ",\"languages\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v29, v30 := range in.Languages {
+				if v29 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v30))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.From != nil {
+		const prefix string = ",\"from\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((*in.From).MarshalJSON())
+	}
+	if in.Until != nil {
+		const prefix string = ",\"until\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Raw((*in.Until).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"url\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.URL))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RedirectRule) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels18(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RedirectRule) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels18(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RedirectRule) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels18(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RedirectRule) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels18(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels19(in *jlexer.Lexer, out *OptionsResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "redirect_code":
+			out.RedirectCode = int(in.Int())
+		case "query_policy":
+			out.QueryPolicy = string(in.String())
+		case "interstitial":
+			out.Interstitial = bool(in.Bool())
+		case "protected":
+			out.Protected = bool(in.Bool())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels19(out *jwriter.Writer, in OptionsResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"redirect_code\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.RedirectCode))
+	}
+	{
+		const prefix string = ",\"query_policy\":"
+		out.RawString(prefix)
+		out.String(string(in.QueryPolicy))
+	}
+	{
+		const prefix string = ",\"interstitial\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Interstitial))
+	}
+	{
+		const prefix string = ",\"protected\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Protected))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v OptionsResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels19(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v OptionsResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels19(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *OptionsResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels19(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *OptionsResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels19(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels20(in *jlexer.Lexer, out *OptionsPatch) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "redirect_code":
+			if in.IsNull() {
+				in.Skip()
+				out.RedirectCode = nil
+			} else {
+				if out.RedirectCode == nil {
+					out.RedirectCode = new(int)
+				}
+				*out.RedirectCode = int(in.Int())
+			}
+		case "query_policy":
+			if in.IsNull() {
+				in.Skip()
+				out.QueryPolicy = nil
+			} else {
+				if out.QueryPolicy == nil {
+					out.QueryPolicy = new(string)
+				}
+				*out.QueryPolicy = string(in.String())
+			}
+		case "interstitial":
+			if in.IsNull() {
+				in.Skip()
+				out.Interstitial = nil
+			} else {
+				if out.Interstitial == nil {
+					out.Interstitial = new(bool)
+				}
+				*out.Interstitial = bool(in.Bool())
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels20(out *jwriter.Writer, in OptionsPatch) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"redirect_code\":"
+		out.RawString(prefix[1:])
+		if in.RedirectCode == nil {
+			out.RawString("null")
+		} else {
+			out.Int(int(*in.RedirectCode))
+		}
+	}
+	{
+		const prefix string = ",\"query_policy\":"
+		out.RawString(prefix)
+		if in.QueryPolicy == nil {
+			out.RawString("null")
+		} else {
+			out.String(string(*in.QueryPolicy))
+		}
+	}
+	{
+		const prefix string = ",\"interstitial\":"
+		out.RawString(prefix)
+		if in.Interstitial == nil {
+			out.RawString("null")
+		} else {
+			out.Bool(bool(*in.Interstitial))
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v OptionsPatch) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels20(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v OptionsPatch) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels20(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *OptionsPatch) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels20(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *OptionsPatch) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels20(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels21(in *jlexer.Lexer, out *MetadataPatch) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "title":
+			if in.IsNull() {
+				in.Skip()
+				out.Title = nil
+			} else {
+				if out.Title == nil {
+					out.Title = new(string)
+				}
+				*out.Title = string(in.String())
+			}
+		case "tags":
+			if in.IsNull() {
+				in.Skip()
+				out.Tags = nil
+			} else {
+				if out.Tags == nil {
+					out.Tags = new([]string)
+				}
+				if in.IsNull() {
+					in.Skip()
+					*out.Tags = nil
+				} else {
+					in.Delim('[')
+					if *out.Tags == nil {
+						if !in.IsDelim(']') {
+							*out.Tags = make([]string, 0, 4)
+						} else {
+							*out.Tags = []string{}
+						}
+					} else {
+						*out.Tags = (*out.Tags)[:0]
+					}
+					for !in.IsDelim(']') {
+						var v31 string
+						v31 = string(in.String())
+						*out.Tags = append(*out.Tags, v31)
+						in.WantComma()
+					}
+					in.Delim(']')
+				}
+			}
+		case "note":
+			if in.IsNull() {
+				in.Skip()
+				out.Note = nil
+			} else {
+				if out.Note == nil {
+					out.Note = new(string)
+				}
+				*out.Note = string(in.String())
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels21(out *jwriter.Writer, in MetadataPatch) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"title\":"
+		out.RawString(prefix[1:])
+		if in.Title == nil {
+			out.RawString("null")
+		} else {
+			out.String(string(*in.Title))
+		}
+	}
+	{
+		const prefix string = ",\"tags\":"
+		out.RawString(prefix)
+		if in.Tags == nil {
+			out.RawString("null")
+		} else {
+			if *in.Tags == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+				out.RawString("null")
+			} else {
+				out.RawByte('[')
+				for v32, v33 := range *in.Tags {
+					if v32 > 0 {
+						out.RawByte(',')
+					}
+					out.String(string(v33))
+				}
+				out.RawByte(']')
+			}
+		}
+	}
+	{
+		const prefix string = ",\"note\":"
+		out.RawString(prefix)
+		if in.Note == nil {
+			out.RawString("null")
+		} else {
+			out.String(string(*in.Note))
+		}
+	}
+	out.RawByte('}')
+}
 
-	easyjson "github.com/mailru/easyjson"
-	jlexer "github.com/mailru/easyjson/jlexer"
-	jwriter "github.com/mailru/easyjson/jwriter"
-)
+// MarshalJSON supports json.Marshaler interface
+func (v MetadataPatch) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels21(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
 
-// suppress unused package warning
-var (
-	_ *json.RawMessage
-	_ *jlexer.Lexer
-	_ *jwriter.Writer
-	_ easyjson.Marshaler
-)
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v MetadataPatch) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels21(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *MetadataPatch) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels21(&r, v)
+	return r.Error()
+}
 
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels(in *jlexer.Lexer, out *UserURL) {
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *MetadataPatch) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels21(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels22(in *jlexer.Lexer, out *LinkOptions) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -37,10 +2215,60 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels(in *jlexer.Lexer, out *UserURL)
 			continue
 		}
 		switch key {
-		case "short_url":
-			out.ShortURL = string(in.String())
-		case "original_url":
-			out.OriginalURL = string(in.String())
+		case "password_hash":
+			out.PasswordHash = string(in.String())
+		case "interstitial":
+			out.Interstitial = bool(in.Bool())
+		case "redirect_code":
+			out.RedirectCode = int(in.Int())
+		case "query_policy":
+			out.QueryPolicy = string(in.String())
+		case "rules":
+			if in.IsNull() {
+				in.Skip()
+				out.Rules = nil
+			} else {
+				in.Delim('[')
+				if out.Rules == nil {
+					if !in.IsDelim(']') {
+						out.Rules = make([]RedirectRule, 0, 0)
+					} else {
+						out.Rules = []RedirectRule{}
+					}
+				} else {
+					out.Rules = (out.Rules)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v34 RedirectRule
+					(v34).UnmarshalEasyJSON(in)
+					out.Rules = append(out.Rules, v34)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "variants":
+			if in.IsNull() {
+				in.Skip()
+				out.Variants = nil
+			} else {
+				in.Delim('[')
+				if out.Variants == nil {
+					if !in.IsDelim(']') {
+						out.Variants = make([]Variant, 0, 1)
+					} else {
+						out.Variants = []Variant{}
+					}
+				} else {
+					out.Variants = (out.Variants)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v35 Variant
+					(v35).UnmarshalEasyJSON(in)
+					out.Variants = append(out.Variants, v35)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -51,47 +2279,335 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels(in *jlexer.Lexer, out *UserURL)
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels(out *jwriter.Writer, in UserURL) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels22(out *jwriter.Writer, in LinkOptions) {
 	out.RawByte('{')
 	first := true
 	_ = first
-	{
-		const prefix string = ",\"short_url\":"
+	if in.PasswordHash != "" {
+		const prefix string = ",\"password_hash\":"
+		first = false
 		out.RawString(prefix[1:])
-		out.String(string(in.ShortURL))
+		out.String(string(in.PasswordHash))
+	}
+	if in.Interstitial {
+		const prefix string = ",\"interstitial\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(in.Interstitial))
+	}
+	if in.RedirectCode != 0 {
+		const prefix string = ",\"redirect_code\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.RedirectCode))
+	}
+	if in.QueryPolicy != "" {
+		const prefix string = ",\"query_policy\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.QueryPolicy))
+	}
+	if len(in.Rules) != 0 {
+		const prefix string = ",\"rules\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v36, v37 := range in.Rules {
+				if v36 > 0 {
+					out.RawByte(',')
+				}
+				(v37).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.Variants) != 0 {
+		const prefix string = ",\"variants\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v38, v39 := range in.Variants {
+				if v38 > 0 {
+					out.RawByte(',')
+				}
+				(v39).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v LinkOptions) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels22(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v LinkOptions) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels22(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *LinkOptions) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels22(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *LinkOptions) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels22(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels23(in *jlexer.Lexer, out *LinkMetadata) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "title":
+			out.Title = string(in.String())
+		case "tags":
+			if in.IsNull() {
+				in.Skip()
+				out.Tags = nil
+			} else {
+				in.Delim('[')
+				if out.Tags == nil {
+					if !in.IsDelim(']') {
+						out.Tags = make([]string, 0, 4)
+					} else {
+						out.Tags = []string{}
+					}
+				} else {
+					out.Tags = (out.Tags)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v40 string
+					v40 = string(in.String())
+					out.Tags = append(out.Tags, v40)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "note":
+			out.Note = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels23(out *jwriter.Writer, in LinkMetadata) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Title != "" {
+		const prefix string = ",\"title\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Title))
+	}
+	if len(in.Tags) != 0 {
+		const prefix string = ",\"tags\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v41, v42 := range in.Tags {
+				if v41 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v42))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Note != "" {
+		const prefix string = ",\"note\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Note))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v LinkMetadata) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels23(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v LinkMetadata) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels23(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *LinkMetadata) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels23(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *LinkMetadata) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels23(l, v)
+}
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels24(in *jlexer.Lexer, out *ClickStats) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "clicks":
+			out.Clicks = int64(in.Int64())
+		case "variants":
+			if in.IsNull() {
+				in.Skip()
+				out.Variants = nil
+			} else {
+				in.Delim('[')
+				if out.Variants == nil {
+					if !in.IsDelim(']') {
+						out.Variants = make([]VariantStats, 0, 1)
+					} else {
+						out.Variants = []VariantStats{}
+					}
+				} else {
+					out.Variants = (out.Variants)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v43 VariantStats
+					(v43).UnmarshalEasyJSON(in)
+					out.Variants = append(out.Variants, v43)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
 	}
+}
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels24(out *jwriter.Writer, in ClickStats) {
+	out.RawByte('{')
+	first := true
+	_ = first
 	{
-		const prefix string = ",\"original_url\":"
+		const prefix string = ",\"clicks\":"
+		out.RawString(prefix[1:])
+		out.Int64(int64(in.Clicks))
+	}
+	if len(in.Variants) != 0 {
+		const prefix string = ",\"variants\":"
 		out.RawString(prefix)
-		out.String(string(in.OriginalURL))
+		{
+			out.RawByte('[')
+			for v44, v45 := range in.Variants {
+				if v44 > 0 {
+					out.RawByte(',')
+				}
+				(v45).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
 	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v UserURL) MarshalJSON() ([]byte, error) {
+func (v ClickStats) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels24(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v UserURL) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels(w, v)
+func (v ClickStats) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels24(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *UserURL) UnmarshalJSON(data []byte) error {
+func (v *ClickStats) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels24(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *UserURL) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels(l, v)
+func (v *ClickStats) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels24(l, v)
 }
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels1(in *jlexer.Lexer, out *BatchResponseList) {
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels25(in *jlexer.Lexer, out *BatchResponseList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -100,7 +2616,7 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels1(in *jlexer.Lexer, out *BatchRe
 		in.Delim('[')
 		if *out == nil {
 			if !in.IsDelim(']') {
-				*out = make(BatchResponseList, 0, 2)
+				*out = make(BatchResponseList, 0, 1)
 			} else {
 				*out = BatchResponseList{}
 			}
@@ -108,9 +2624,9 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels1(in *jlexer.Lexer, out *BatchRe
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v1 BatchResponse
-			(v1).UnmarshalEasyJSON(in)
-			*out = append(*out, v1)
+			var v46 BatchResponse
+			(v46).UnmarshalEasyJSON(in)
+			*out = append(*out, v46)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -119,16 +2635,16 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels1(in *jlexer.Lexer, out *BatchRe
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels1(out *jwriter.Writer, in BatchResponseList) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels25(out *jwriter.Writer, in BatchResponseList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v2, v3 := range in {
-			if v2 > 0 {
+		for v47, v48 := range in {
+			if v47 > 0 {
 				out.RawByte(',')
 			}
-			(v3).MarshalEasyJSON(out)
+			(v48).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -137,27 +2653,27 @@ func easyjsonD2b7633eEncodeUnoCmdShortenerModels1(out *jwriter.Writer, in BatchR
 // MarshalJSON supports json.Marshaler interface
 func (v BatchResponseList) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels1(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels25(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v BatchResponseList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels1(w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels25(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *BatchResponseList) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels1(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels25(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *BatchResponseList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels1(l, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels25(l, v)
 }
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels2(in *jlexer.Lexer, out *BatchResponse) {
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels26(in *jlexer.Lexer, out *BatchResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -180,6 +2696,10 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels2(in *jlexer.Lexer, out *BatchRe
 			out.CorrelationID = string(in.String())
 		case "short_url":
 			out.ShortURL = string(in.String())
+		case "qr":
+			out.QR = string(in.String())
+		case "error":
+			out.Error = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -190,7 +2710,7 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels2(in *jlexer.Lexer, out *BatchRe
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels2(out *jwriter.Writer, in BatchResponse) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels26(out *jwriter.Writer, in BatchResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -204,33 +2724,43 @@ func easyjsonD2b7633eEncodeUnoCmdShortenerModels2(out *jwriter.Writer, in BatchR
 		out.RawString(prefix)
 		out.String(string(in.ShortURL))
 	}
+	if in.QR != "" {
+		const prefix string = ",\"qr\":"
+		out.RawString(prefix)
+		out.String(string(in.QR))
+	}
+	if in.Error != "" {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		out.String(string(in.Error))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v BatchResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels2(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels26(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v BatchResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels2(w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels26(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *BatchResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels2(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels26(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *BatchResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels2(l, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels26(l, v)
 }
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels3(in *jlexer.Lexer, out *BatchRequestList) {
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels27(in *jlexer.Lexer, out *BatchRequestList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -239,7 +2769,7 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels3(in *jlexer.Lexer, out *BatchRe
 		in.Delim('[')
 		if *out == nil {
 			if !in.IsDelim(']') {
-				*out = make(BatchRequestList, 0, 2)
+				*out = make(BatchRequestList, 0, 0)
 			} else {
 				*out = BatchRequestList{}
 			}
@@ -247,9 +2777,9 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels3(in *jlexer.Lexer, out *BatchRe
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v4 BatchRequest
-			(v4).UnmarshalEasyJSON(in)
-			*out = append(*out, v4)
+			var v49 BatchRequest
+			(v49).UnmarshalEasyJSON(in)
+			*out = append(*out, v49)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -258,16 +2788,16 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels3(in *jlexer.Lexer, out *BatchRe
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels3(out *jwriter.Writer, in BatchRequestList) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels27(out *jwriter.Writer, in BatchRequestList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v5, v6 := range in {
-			if v5 > 0 {
+		for v50, v51 := range in {
+			if v50 > 0 {
 				out.RawByte(',')
 			}
-			(v6).MarshalEasyJSON(out)
+			(v51).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -276,27 +2806,27 @@ func easyjsonD2b7633eEncodeUnoCmdShortenerModels3(out *jwriter.Writer, in BatchR
 // MarshalJSON supports json.Marshaler interface
 func (v BatchRequestList) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels3(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels27(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v BatchRequestList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels3(w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels27(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *BatchRequestList) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels3(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels27(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *BatchRequestList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels3(l, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels27(l, v)
 }
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels4(in *jlexer.Lexer, out *BatchRequest) {
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels28(in *jlexer.Lexer, out *BatchRequest) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -319,6 +2849,41 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels4(in *jlexer.Lexer, out *BatchRe
 			out.CorrelationID = string(in.String())
 		case "original_url":
 			out.OriginalURL = string(in.String())
+		case "password":
+			out.Password = string(in.String())
+		case "interstitial":
+			out.Interstitial = bool(in.Bool())
+		case "redirect_code":
+			out.RedirectCode = int(in.Int())
+		case "query_policy":
+			out.QueryPolicy = string(in.String())
+		case "title":
+			out.Title = string(in.String())
+		case "tags":
+			if in.IsNull() {
+				in.Skip()
+				out.Tags = nil
+			} else {
+				in.Delim('[')
+				if out.Tags == nil {
+					if !in.IsDelim(']') {
+						out.Tags = make([]string, 0, 4)
+					} else {
+						out.Tags = []string{}
+					}
+				} else {
+					out.Tags = (out.Tags)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v52 string
+					v52 = string(in.String())
+					out.Tags = append(out.Tags, v52)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "note":
+			out.Note = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -329,7 +2894,7 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels4(in *jlexer.Lexer, out *BatchRe
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels4(out *jwriter.Writer, in BatchRequest) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels28(out *jwriter.Writer, in BatchRequest) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -343,33 +2908,77 @@ func easyjsonD2b7633eEncodeUnoCmdShortenerModels4(out *jwriter.Writer, in BatchR
 		out.RawString(prefix)
 		out.String(string(in.OriginalURL))
 	}
+	if in.Password != "" {
+		const prefix string = ",\"password\":"
+		out.RawString(prefix)
+		out.String(string(in.Password))
+	}
+	if in.Interstitial {
+		const prefix string = ",\"interstitial\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Interstitial))
+	}
+	if in.RedirectCode != 0 {
+		const prefix string = ",\"redirect_code\":"
+		out.RawString(prefix)
+		out.Int(int(in.RedirectCode))
+	}
+	if in.QueryPolicy != "" {
+		const prefix string = ",\"query_policy\":"
+		out.RawString(prefix)
+		out.String(string(in.QueryPolicy))
+	}
+	if in.Title != "" {
+		const prefix string = ",\"title\":"
+		out.RawString(prefix)
+		out.String(string(in.Title))
+	}
+	if len(in.Tags) != 0 {
+		const prefix string = ",\"tags\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v53, v54 := range in.Tags {
+				if v53 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v54))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Note != "" {
+		const prefix string = ",\"note\":"
+		out.RawString(prefix)
+		out.String(string(in.Note))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v BatchRequest) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels4(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels28(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v BatchRequest) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels4(w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels28(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *BatchRequest) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels4(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels28(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *BatchRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels4(l, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels28(l, v)
 }
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels5(in *jlexer.Lexer, out *APIResponse) {
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels29(in *jlexer.Lexer, out *APIResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -390,6 +2999,8 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels5(in *jlexer.Lexer, out *APIResp
 		switch key {
 		case "result":
 			out.Result = string(in.String())
+		case "qr":
+			out.QR = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -400,7 +3011,7 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels5(in *jlexer.Lexer, out *APIResp
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels5(out *jwriter.Writer, in APIResponse) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels29(out *jwriter.Writer, in APIResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -409,33 +3020,38 @@ func easyjsonD2b7633eEncodeUnoCmdShortenerModels5(out *jwriter.Writer, in APIRes
 		out.RawString(prefix[1:])
 		out.String(string(in.Result))
 	}
+	if in.QR != "" {
+		const prefix string = ",\"qr\":"
+		out.RawString(prefix)
+		out.String(string(in.QR))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v APIResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels5(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels29(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v APIResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels5(w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels29(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *APIResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels5(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels29(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *APIResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels5(l, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels29(l, v)
 }
-func easyjsonD2b7633eDecodeUnoCmdShortenerModels6(in *jlexer.Lexer, out *APIRequest) {
+func easyjsonD2b7633eDecodeUnoCmdShortenerModels30(in *jlexer.Lexer, out *APIRequest) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -456,6 +3072,41 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels6(in *jlexer.Lexer, out *APIRequ
 		switch key {
 		case "url":
 			out.URL = string(in.String())
+		case "password":
+			out.Password = string(in.String())
+		case "interstitial":
+			out.Interstitial = bool(in.Bool())
+		case "redirect_code":
+			out.RedirectCode = int(in.Int())
+		case "query_policy":
+			out.QueryPolicy = string(in.String())
+		case "title":
+			out.Title = string(in.String())
+		case "tags":
+			if in.IsNull() {
+				in.Skip()
+				out.Tags = nil
+			} else {
+				in.Delim('[')
+				if out.Tags == nil {
+					if !in.IsDelim(']') {
+						out.Tags = make([]string, 0, 4)
+					} else {
+						out.Tags = []string{}
+					}
+				} else {
+					out.Tags = (out.Tags)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v55 string
+					v55 = string(in.String())
+					out.Tags = append(out.Tags, v55)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "note":
+			out.Note = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -466,7 +3117,7 @@ func easyjsonD2b7633eDecodeUnoCmdShortenerModels6(in *jlexer.Lexer, out *APIRequ
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeUnoCmdShortenerModels6(out *jwriter.Writer, in APIRequest) {
+func easyjsonD2b7633eEncodeUnoCmdShortenerModels30(out *jwriter.Writer, in APIRequest) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -475,29 +3126,73 @@ func easyjsonD2b7633eEncodeUnoCmdShortenerModels6(out *jwriter.Writer, in APIReq
 		out.RawString(prefix[1:])
 		out.String(string(in.URL))
 	}
+	if in.Password != "" {
+		const prefix string = ",\"password\":"
+		out.RawString(prefix)
+		out.String(string(in.Password))
+	}
+	if in.Interstitial {
+		const prefix string = ",\"interstitial\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Interstitial))
+	}
+	if in.RedirectCode != 0 {
+		const prefix string = ",\"redirect_code\":"
+		out.RawString(prefix)
+		out.Int(int(in.RedirectCode))
+	}
+	if in.QueryPolicy != "" {
+		const prefix string = ",\"query_policy\":"
+		out.RawString(prefix)
+		out.String(string(in.QueryPolicy))
+	}
+	if in.Title != "" {
+		const prefix string = ",\"title\":"
+		out.RawString(prefix)
+		out.String(string(in.Title))
+	}
+	if len(in.Tags) != 0 {
+		const prefix string = ",\"tags\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v56, v57 := range in.Tags {
+				if v56 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v57))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Note != "" {
+		const prefix string = ",\"note\":"
+		out.RawString(prefix)
+		out.String(string(in.Note))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v APIRequest) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels6(&w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels30(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v APIRequest) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeUnoCmdShortenerModels6(w, v)
+	easyjsonD2b7633eEncodeUnoCmdShortenerModels30(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *APIRequest) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels6(&r, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels30(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *APIRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeUnoCmdShortenerModels6(l, v)
+	easyjsonD2b7633eDecodeUnoCmdShortenerModels30(l, v)
 }
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultRedirectCode код ответа перенаправления по умолчанию
+const DefaultRedirectCode = http.StatusTemporaryRedirect
+
+// Политики обработки query параметров короткой ссылки
+const (
+	QueryIgnore   = "ignore"   // Параметры отбрасываются
+	QueryAppend   = "append"   // Параметры добавляются к параметрам адреса назначения
+	QueryOverride = "override" // Параметры заменяют одноименные параметры адреса назначения
+)
+
+// ErrInvalidOptions возвращается, если параметры перенаправления не проходят проверку
+var ErrInvalidOptions = errors.New("invalid link options")
+
+// IsZero проверяет, что параметры не заданы
+func (o LinkOptions) IsZero() bool {
+	return o.PasswordHash == "" && !o.Interstitial && o.RedirectCode == 0 && o.QueryPolicy == "" && len(o.Rules) == 0 && len(o.Variants) == 0
+}
+
+// Protected проверяет, что ссылка защищена паролем
+func (o LinkOptions) Protected() bool {
+	return o.PasswordHash != ""
+}
+
+// RedirectStatus возвращает код ответа перенаправления ссылки
+func (o LinkOptions) RedirectStatus() int {
+	if o.RedirectCode == 0 {
+		return DefaultRedirectCode
+	}
+	return o.RedirectCode
+}
+
+// Normalize проверяет код перенаправления, политику query параметров, правила и варианты
+// Значения по умолчанию заменяются пустыми, чтобы не хранить их
+func (o *LinkOptions) Normalize() error {
+	switch o.RedirectCode {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("%w: redirect code must be 301, 302, 307 or 308", ErrInvalidOptions)
+	}
+	if o.RedirectCode == DefaultRedirectCode {
+		o.RedirectCode = 0
+	}
+
+	switch o.QueryPolicy {
+	case "", QueryAppend, QueryOverride:
+	case QueryIgnore:
+		o.QueryPolicy = ""
+	default:
+		return fmt.Errorf("%w: query policy must be ignore, append or override", ErrInvalidOptions)
+	}
+
+	rules, err := normalizeRules(o.Rules)
+	if err != nil {
+		return err
+	}
+	o.Rules = rules
+
+	variants, err := normalizeVariants(o.Variants)
+	if err != nil {
+		return err
+	}
+	o.Variants = variants
+	return nil
+}
+
+// Response возвращает параметры для ответа API без хэша пароля
+func (o LinkOptions) Response() OptionsResponse {
+	policy := o.QueryPolicy
+	if policy == "" {
+		policy = QueryIgnore
+	}
+	return OptionsResponse{
+		RedirectCode: o.RedirectStatus(),
+		QueryPolicy:  policy,
+		Interstitial: o.Interstitial,
+		Protected:    o.Protected(),
+	}
+}
+
+// Apply применяет изменение к параметрам и возвращает результат
+func (p OptionsPatch) Apply(o LinkOptions) LinkOptions {
+	if p.RedirectCode != nil {
+		o.RedirectCode = *p.RedirectCode
+	}
+	if p.QueryPolicy != nil {
+		o.QueryPolicy = *p.QueryPolicy
+	}
+	if p.Interstitial != nil {
+		o.Interstitial = *p.Interstitial
+	}
+	return o
+}
+
+// Normalize проверяет и нормализует заданные поля так же, как LinkOptions.Normalize
+func (p *OptionsPatch) Normalize() error {
+	opts := p.Apply(LinkOptions{})
+	if err := opts.Normalize(); err != nil {
+		return err
+	}
+	if p.RedirectCode != nil {
+		p.RedirectCode = &opts.RedirectCode
+	}
+	if p.QueryPolicy != nil {
+		p.QueryPolicy = &opts.QueryPolicy
+	}
+	return nil
+}
+
+// IsEmpty проверяет, что запрос не изменяет ни одного поля
+func (p OptionsPatch) IsEmpty() bool {
+	return p.RedirectCode == nil && p.QueryPolicy == nil && p.Interstitial == nil
+}
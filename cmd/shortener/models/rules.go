@@ -0,0 +1,157 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Платформы, которые различают правила перенаправления
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+)
+
+// Ограничения правил перенаправления
+const (
+	MaxRules         = 20 // Максимальное количество правил ссылки
+	MaxRuleLanguages = 20 // Максимальное количество языков в правиле
+	MaxLanguageTag   = 35 // Максимальная длина языкового тега
+)
+
+// RuleContext описывает запрос, для которого выбирается адрес назначения
+type RuleContext struct {
+	Platform string    // Платформа клиента (пусто - не определена)
+	Language string    // Предпочтительный язык клиента в нижнем регистре (пусто - не указан)
+	Now      time.Time // Момент запроса
+}
+
+// Match проверяет, что правило срабатывает для запроса c
+func (r RedirectRule) Match(c RuleContext) bool {
+	if r.Platform != "" && r.Platform != c.Platform {
+		return false
+	}
+	if len(r.Languages) > 0 && !matchLanguage(r.Languages, c.Language) {
+		return false
+	}
+	if r.From != nil && c.Now.Before(*r.From) {
+		return false
+	}
+	if r.Until != nil && !c.Now.Before(*r.Until) {
+		return false
+	}
+	return true
+}
+
+// matchLanguage проверяет, что язык lang совпадает с одним из тегов или уточняет его
+func matchLanguage(tags []string, lang string) bool {
+	if lang == "" {
+		return false
+	}
+	for _, tag := range tags {
+		if lang == tag || strings.HasPrefix(lang, tag+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchRule возвращает адрес назначения первого сработавшего правила
+// Если ни одно правило не сработало, возвращает false
+func (o LinkOptions) MatchRule(c RuleContext) (string, bool) {
+	for _, r := range o.Rules {
+		if r.Match(c) {
+			return r.URL, true
+		}
+	}
+	return "", false
+}
+
+// normalizeRules приводит правила к каноническому виду и проверяет ограничения
+// Платформы и языки приводятся к нижнему регистру, повторяющиеся языки отбрасываются
+func normalizeRules(rules []RedirectRule) ([]RedirectRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if len(rules) > MaxRules {
+		return nil, fmt.Errorf("%w: more than %d rules", ErrInvalidOptions, MaxRules)
+	}
+
+	normalized := make([]RedirectRule, 0, len(rules))
+	for i, r := range rules {
+		if err := r.normalize(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		normalized = append(normalized, r)
+	}
+	return normalized, nil
+}
+
+// normalize проверяет одно правило; срез языков копируется
+func (r *RedirectRule) normalize() error {
+	r.URL = strings.TrimSpace(r.URL)
+	if !absoluteURL(r.URL) {
+		return fmt.Errorf("%w: url must be absolute", ErrInvalidOptions)
+	}
+
+	r.Platform = strings.ToLower(strings.TrimSpace(r.Platform))
+	switch r.Platform {
+	case "", PlatformIOS, PlatformAndroid, PlatformWindows, PlatformMacOS, PlatformLinux:
+	default:
+		return fmt.Errorf("%w: unknown platform %q", ErrInvalidOptions, r.Platform)
+	}
+
+	var languages []string
+	seen := make(map[string]bool, len(r.Languages))
+	for _, tag := range r.Languages {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !validLanguageTag(tag) {
+			return fmt.Errorf("%w: invalid language %q", ErrInvalidOptions, tag)
+		}
+		seen[tag] = true
+		languages = append(languages, tag)
+	}
+	if len(languages) > MaxRuleLanguages {
+		return fmt.Errorf("%w: more than %d languages", ErrInvalidOptions, MaxRuleLanguages)
+	}
+	r.Languages = languages
+
+	if r.From != nil && r.Until != nil && !r.From.Before(*r.Until) {
+		return fmt.Errorf("%w: from must be before until", ErrInvalidOptions)
+	}
+	if r.Platform == "" && len(r.Languages) == 0 && r.From == nil && r.Until == nil {
+		return fmt.Errorf("%w: rule has no conditions", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// absoluteURL проверяет, что адрес назначения содержит схему и хост
+func absoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// validLanguageTag проверяет языковой тег вида "pt" или "pt-br"
+func validLanguageTag(tag string) bool {
+	if len(tag) > MaxLanguageTag {
+		return false
+	}
+	for _, part := range strings.Split(tag, "-") {
+		if part == "" || len(part) > 8 {
+			return false
+		}
+		for _, c := range part {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
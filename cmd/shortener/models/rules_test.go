@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLinkOptions_MatchRule(t *testing.T) {
+	from := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	link := Link{
+		OriginalURL: "https://example.com",
+		LinkOptions: LinkOptions{Rules: []RedirectRule{
+			{Platform: PlatformIOS, URL: "https://apps.apple.com/app"},
+			{Platform: PlatformAndroid, URL: "https://play.google.com/app"},
+			{Languages: []string{"de"}, From: &from, Until: &until, URL: "https://example.com/de/sale"},
+			{Languages: []string{"de", "pt-br"}, URL: "https://example.com/localized"},
+		}},
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		ctx  RuleContext
+		want string
+	}{
+		{"ios", RuleContext{Platform: PlatformIOS, Language: "de", Now: now}, "https://apps.apple.com/app"},
+		{"android", RuleContext{Platform: PlatformAndroid, Now: now}, "https://play.google.com/app"},
+		{"language prefix", RuleContext{Language: "de-at", Now: now}, "https://example.com/localized"},
+		{"exact region", RuleContext{Language: "pt-br", Now: now}, "https://example.com/localized"},
+		{"other region", RuleContext{Language: "pt-pt", Now: now}, "https://example.com"},
+		{"inside window", RuleContext{Language: "de", Now: from}, "https://example.com/de/sale"},
+		{"window end excluded", RuleContext{Language: "de", Now: until}, "https://example.com/localized"},
+		{"fallback", RuleContext{Platform: PlatformWindows, Language: "en-us", Now: now}, "https://example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := link.MatchRule(tt.ctx)
+			if !ok {
+				got = link.OriginalURL
+			}
+			if got != tt.want {
+				t.Errorf("MatchRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkOptions_NormalizeRules(t *testing.T) {
+	opts := LinkOptions{Rules: []RedirectRule{
+		{Platform: " iOS ", Languages: []string{"DE", "de", " "}, URL: " https://apps.apple.com/app "},
+	}}
+	if err := opts.Normalize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := opts.Rules[0]
+	if r.Platform != PlatformIOS || len(r.Languages) != 1 || r.Languages[0] != "de" || r.URL != "https://apps.apple.com/app" {
+		t.Errorf("unexpected normalized rule: %+v", r)
+	}
+
+	empty := LinkOptions{Rules: []RedirectRule{}}
+	if err := empty.Normalize(); err != nil || empty.Rules != nil || !empty.IsZero() {
+		t.Errorf("expected empty rules to be cleared, got %+v (%v)", empty, err)
+	}
+
+	from := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	invalid := []RedirectRule{
+		{Platform: "symbian", URL: "https://example.com"},
+		{Platform: PlatformIOS, URL: "/relative"},
+		{Platform: PlatformIOS, URL: ""},
+		{Languages: []string{"en_US"}, URL: "https://example.com"},
+		{From: &from, Until: &from, URL: "https://example.com"},
+		{URL: "https://example.com"},
+	}
+	for _, rule := range invalid {
+		opts := LinkOptions{Rules: []RedirectRule{rule}}
+		if err := opts.Normalize(); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("expected ErrInvalidOptions for %+v, got %v", rule, err)
+		}
+	}
+
+	tooMany := LinkOptions{Rules: make([]RedirectRule, MaxRules+1)}
+	for i := range tooMany.Rules {
+		tooMany.Rules[i] = RedirectRule{Platform: PlatformIOS, URL: "https://example.com"}
+	}
+	if err := tooMany.Normalize(); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("expected ErrInvalidOptions for too many rules, got %v", err)
+	}
+}
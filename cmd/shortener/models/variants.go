@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ограничения вариантов A/B теста
+const (
+	MaxVariants       = 10   // Максимальное количество вариантов ссылки
+	MaxVariantWeight  = 1000 // Максимальный вес варианта
+	MaxVariantNameLen = 32   // Максимальная длина имени варианта
+)
+
+// Variant возвращает вариант ссылки с именем name
+func (o LinkOptions) Variant(name string) (Variant, bool) {
+	for _, v := range o.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// PickVariant выбирает вариант по числу bucket пропорционально весам
+// Одинаковый bucket при неизменных вариантах всегда дает один и тот же вариант
+func (o LinkOptions) PickVariant(bucket uint64) Variant {
+	var total uint64
+	for _, v := range o.Variants {
+		total += uint64(v.Weight)
+	}
+	if total == 0 {
+		return Variant{}
+	}
+	point := bucket % total
+	for _, v := range o.Variants {
+		if point < uint64(v.Weight) {
+			return v
+		}
+		point -= uint64(v.Weight)
+	}
+	return o.Variants[len(o.Variants)-1]
+}
+
+// normalizeVariants проверяет варианты ссылки
+// Вариантов должно быть не меньше двух; вариантам без имени назначаются имена "a", "b", ...
+func normalizeVariants(variants []Variant) ([]Variant, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	if len(variants) < 2 {
+		return nil, fmt.Errorf("%w: at least 2 variants are required", ErrInvalidOptions)
+	}
+	if len(variants) > MaxVariants {
+		return nil, fmt.Errorf("%w: more than %d variants", ErrInvalidOptions, MaxVariants)
+	}
+
+	normalized := make([]Variant, 0, len(variants))
+	seen := make(map[string]bool, len(variants))
+	for i, v := range variants {
+		v.Name = strings.ToLower(strings.TrimSpace(v.Name))
+		if v.Name == "" {
+			v.Name = string(rune('a' + i))
+		}
+		if !validVariantName(v.Name) {
+			return nil, fmt.Errorf("%w: invalid variant name %q", ErrInvalidOptions, v.Name)
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("%w: duplicate variant name %q", ErrInvalidOptions, v.Name)
+		}
+		seen[v.Name] = true
+
+		v.URL = strings.TrimSpace(v.URL)
+		if !absoluteURL(v.URL) {
+			return nil, fmt.Errorf("%w: variant %q url must be absolute", ErrInvalidOptions, v.Name)
+		}
+		if v.Weight < 1 || v.Weight > MaxVariantWeight {
+			return nil, fmt.Errorf("%w: variant %q weight must be from 1 to %d", ErrInvalidOptions, v.Name, MaxVariantWeight)
+		}
+		normalized = append(normalized, v)
+	}
+	return normalized, nil
+}
+
+// validVariantName проверяет имя варианта: латинские буквы, цифры, "-" и "_"
+// Имя попадает в cookie посетителя, поэтому другие символы не допускаются
+func validVariantName(name string) bool {
+	if len(name) > MaxVariantNameLen {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
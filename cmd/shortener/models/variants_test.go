@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLinkOptions_PickVariant(t *testing.T) {
+	opts := LinkOptions{Variants: []Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 70},
+		{Name: "b", URL: "https://example.com/b", Weight: 30},
+	}}
+
+	counts := make(map[string]int)
+	for bucket := uint64(0); bucket < 1000; bucket++ {
+		counts[opts.PickVariant(bucket).Name]++
+	}
+	if counts["a"] != 700 || counts["b"] != 300 {
+		t.Errorf("expected 70/30 split, got %v", counts)
+	}
+	if opts.PickVariant(69).Name != "a" || opts.PickVariant(70).Name != "b" || opts.PickVariant(169).Name != "a" {
+		t.Error("unexpected variant boundaries")
+	}
+	if v, ok := opts.Variant("b"); !ok || v.Weight != 30 {
+		t.Errorf("unexpected variant lookup: %+v %v", v, ok)
+	}
+	if _, ok := opts.Variant("c"); ok {
+		t.Error("expected unknown variant to be missing")
+	}
+}
+
+func TestLinkOptions_NormalizeVariants(t *testing.T) {
+	opts := LinkOptions{Variants: []Variant{
+		{URL: " https://example.com/a ", Weight: 7},
+		{Name: " Blue ", URL: "https://example.com/b", Weight: 3},
+	}}
+	if err := opts.Normalize(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.Variants[0].Name != "a" || opts.Variants[0].URL != "https://example.com/a" || opts.Variants[1].Name != "blue" {
+		t.Errorf("unexpected normalized variants: %+v", opts.Variants)
+	}
+
+	invalid := [][]Variant{
+		{{Name: "a", URL: "https://example.com/a", Weight: 1}},
+		{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "a", URL: "https://example.com/b", Weight: 1}},
+		{{Name: "a", URL: "https://example.com/a", Weight: 0}, {Name: "b", URL: "https://example.com/b", Weight: 1}},
+		{{Name: "a", URL: "https://example.com/a", Weight: MaxVariantWeight + 1}, {Name: "b", URL: "https://example.com/b", Weight: 1}},
+		{{Name: "a", URL: "/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 1}},
+		{{Name: "a;b", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 1}},
+	}
+	for _, variants := range invalid {
+		opts := LinkOptions{Variants: variants}
+		if err := opts.Normalize(); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("expected ErrInvalidOptions for %+v, got %v", variants, err)
+		}
+	}
+}
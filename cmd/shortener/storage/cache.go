@@ -0,0 +1,419 @@
+package storage
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// CacheOptions задает параметры кэширующей обертки хранилища
+type CacheOptions struct {
+	Size        int           // Максимальное количество записей в каждом из кэшей
+	TTL         time.Duration // Время жизни найденных записей
+	NegativeTTL time.Duration // Время жизни промахов (несуществующих ссылок); 0 - промахи не кэшируются
+}
+
+// CacheStats содержит статистику работы кэша
+type CacheStats struct {
+	Hits         uint64 `json:"hits"`          // Запросы, обслуженные из кэша
+	NegativeHits uint64 `json:"negative_hits"` // Из них попадания в закэшированные промахи
+	Misses       uint64 `json:"misses"`        // Запросы, переданные в хранилище
+	Evictions    uint64 `json:"evictions"`     // Записи, вытесненные из-за ограничения размера
+	Entries      int    `json:"entries"`       // Текущее количество записей в кэше
+}
+
+// getEntry результат метода Get, хранимый в кэше
+type getEntry struct {
+	originalURL string
+	deleted     bool
+	exists      bool
+}
+
+// linkEntry результат метода GetLink, хранимый в кэше
+type linkEntry struct {
+	link  models.Link
+	found bool
+}
+
+// findEntry результат метода FindByOriginal, хранимый в кэше
+type findEntry struct {
+	shortID string
+	found   bool
+}
+
+// CachedStorage реализует интерфейс Storage как кэширующую обертку над другим хранилищем
+// Кэширует результаты Get, GetLink и FindByOriginal в ограниченных LRU кэшах с временем жизни
+// записей, включая промахи. Все изменяющие операции проходят через обертку и
+// сбрасывают затронутые записи, поэтому удаленная ссылка сразу отдает 410
+type CachedStorage struct {
+	inner Storage      // Оборачиваемое хранилище
+	opts  CacheOptions // Параметры кэша
+
+	mu    sync.Mutex           // Мьютекс для доступа к кэшам
+	gets  *lruCache[getEntry]  // Сокращенный ID -> результат Get
+	links *lruCache[linkEntry] // Сокращенный ID -> результат GetLink
+	finds *lruCache[findEntry] // Оригинальный URL -> результат FindByOriginal
+	byID  map[string]string    // Сокращенный ID -> оригинальный URL в finds, для инвалидации
+	gen   uint64               // Счетчик инвалидаций, защищает от записи устаревших данных
+
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+}
+
+// NewCachedStorage создает кэширующую обертку над хранилищем inner
+func NewCachedStorage(inner Storage, opts CacheOptions) *CachedStorage {
+	c := &CachedStorage{
+		inner: inner,
+		opts:  opts,
+		byID:  make(map[string]string),
+	}
+	c.gets = newLRUCache[getEntry](opts.Size, nil)
+	c.links = newLRUCache[linkEntry](opts.Size, nil)
+	c.finds = newLRUCache(opts.Size, func(originalURL string, e findEntry) {
+		if e.found && c.byID[e.shortID] == originalURL {
+			delete(c.byID, e.shortID)
+		}
+	})
+	return c
+}
+
+// Unwrap возвращает оборачиваемое хранилище
+func (c *CachedStorage) Unwrap() Storage {
+	return c.inner
+}
+
+// Stats возвращает статистику работы кэша
+func (c *CachedStorage) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Misses:       c.misses.Load(),
+		Evictions:    c.gets.evictions + c.links.evictions + c.finds.evictions,
+		Entries:      c.gets.len() + c.links.len() + c.finds.len(),
+	}
+}
+
+// Save сохраняет ссылку в хранилище и сбрасывает связанные с ней записи кэша
+func (c *CachedStorage) Save(shortID, originalURL, userID string) error {
+	err := c.inner.Save(shortID, originalURL, userID)
+	c.invalidate([]string{shortID}, []string{originalURL})
+	return err
+}
+
+// SaveLink сохраняет ссылку с параметрами и сбрасывает связанные с ней записи кэша
+func (c *CachedStorage) SaveLink(shortID, originalURL, userID string, meta models.LinkMetadata, opts models.LinkOptions) error {
+	err := c.inner.SaveLink(shortID, originalURL, userID, meta, opts)
+	c.invalidate([]string{shortID}, []string{originalURL})
+	return err
+}
+
+// Restore сохраняет ссылку из выгрузки и сбрасывает связанные с ней записи кэша
+func (c *CachedStorage) Restore(e Entry) error {
+	err := c.inner.Restore(e)
+	c.invalidate([]string{e.ShortURL}, []string{e.OriginalURL})
+	return err
+}
+
+// Get возвращает оригинальный URL по сокращенному ID, используя кэш
+func (c *CachedStorage) Get(shortID string) (string, bool, bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.gets.get(shortID, now)
+	gen := c.gen
+	c.mu.Unlock()
+	if ok {
+		c.hits.Add(1)
+		if !e.exists {
+			c.negativeHits.Add(1)
+		}
+		return e.originalURL, e.deleted, e.exists
+	}
+
+	c.misses.Add(1)
+	originalURL, deleted, exists := c.inner.Get(shortID)
+
+	ttl := c.opts.TTL
+	if !exists {
+		ttl = c.opts.NegativeTTL
+	}
+	if ttl > 0 {
+		c.mu.Lock()
+		if c.gen == gen {
+			c.gets.put(shortID, getEntry{originalURL: originalURL, deleted: deleted, exists: exists}, now.Add(ttl))
+		}
+		c.mu.Unlock()
+	}
+	return originalURL, deleted, exists
+}
+
+// GetLink возвращает ссылку с параметрами перенаправления, используя кэш
+// Ошибки хранилища, кроме ErrNotFound, не кэшируются
+func (c *CachedStorage) GetLink(shortID string) (models.Link, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.links.get(shortID, now)
+	gen := c.gen
+	c.mu.Unlock()
+	if ok {
+		c.hits.Add(1)
+		if !e.found {
+			c.negativeHits.Add(1)
+			return models.Link{}, ErrNotFound
+		}
+		return e.link, nil
+	}
+
+	c.misses.Add(1)
+	link, err := c.inner.GetLink(shortID)
+	found := err == nil
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return link, err
+	}
+
+	ttl := c.opts.TTL
+	if !found {
+		ttl = c.opts.NegativeTTL
+	}
+	if ttl > 0 {
+		c.mu.Lock()
+		if c.gen == gen {
+			c.links.put(shortID, linkEntry{link: link, found: found}, now.Add(ttl))
+		}
+		c.mu.Unlock()
+	}
+	return link, err
+}
+
+// UpdateOptions изменяет параметры перенаправления ссылки и сбрасывает ее записи в кэше
+func (c *CachedStorage) UpdateOptions(userID, shortID string, opts models.LinkOptions) error {
+	err := c.inner.UpdateOptions(userID, shortID, opts)
+	c.invalidate([]string{shortID}, nil)
+	return err
+}
+
+// PatchOptions изменяет поля параметров перенаправления ссылки и сбрасывает ее записи в кэше
+func (c *CachedStorage) PatchOptions(userID, shortID string, patch models.OptionsPatch) (models.LinkOptions, error) {
+	opts, err := c.inner.PatchOptions(userID, shortID, patch)
+	c.invalidate([]string{shortID}, nil)
+	return opts, err
+}
+
+// UpdateRules заменяет правила перенаправления ссылки и сбрасывает ее записи в кэше
+func (c *CachedStorage) UpdateRules(userID, shortID string, rules []models.RedirectRule) error {
+	err := c.inner.UpdateRules(userID, shortID, rules)
+	c.invalidate([]string{shortID}, nil)
+	return err
+}
+
+// UpdateVariants заменяет варианты ссылки и сбрасывает ее записи в кэше
+func (c *CachedStorage) UpdateVariants(userID, shortID string, variants []models.Variant) error {
+	err := c.inner.UpdateVariants(userID, shortID, variants)
+	c.invalidate([]string{shortID}, nil)
+	return err
+}
+
+// FindByOriginal ищет сокращенный ID для оригинального URL, используя кэш
+func (c *CachedStorage) FindByOriginal(originalURL string) (string, bool) {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.finds.get(originalURL, now)
+	gen := c.gen
+	c.mu.Unlock()
+	if ok {
+		c.hits.Add(1)
+		if !e.found {
+			c.negativeHits.Add(1)
+		}
+		return e.shortID, e.found
+	}
+
+	c.misses.Add(1)
+	shortID, found := c.inner.FindByOriginal(originalURL)
+
+	ttl := c.opts.TTL
+	if !found {
+		ttl = c.opts.NegativeTTL
+	}
+	if ttl > 0 {
+		c.mu.Lock()
+		if c.gen == gen {
+			c.finds.put(originalURL, findEntry{shortID: shortID, found: found}, now.Add(ttl))
+			if found {
+				c.byID[shortID] = originalURL
+			}
+		}
+		c.mu.Unlock()
+	}
+	return shortID, found
+}
+
+// SaveBatch сохраняет пакет ссылок и сбрасывает связанные с ними записи кэша
+func (c *CachedStorage) SaveBatch(pairs map[string]string, userID string) error {
+	err := c.inner.SaveBatch(pairs, userID)
+
+	ids := make([]string, 0, len(pairs))
+	urls := make([]string, 0, len(pairs))
+	for shortID, originalURL := range pairs {
+		ids = append(ids, shortID)
+		urls = append(urls, originalURL)
+	}
+	c.invalidate(ids, urls)
+	return err
+}
+
+// GetUserURLs возвращает все URL пользователя напрямую из хранилища
+func (c *CachedStorage) GetUserURLs(userID string) ([]models.UserURL, error) {
+	return c.inner.GetUserURLs(userID)
+}
+
+// DeleteURLs помечает ссылки удаленными и сбрасывает их записи в кэше
+func (c *CachedStorage) DeleteURLs(userID string, ids []string) error {
+	err := c.inner.DeleteURLs(userID, ids)
+	c.invalidate(ids, nil)
+	return err
+}
+
+// ForEach обходит все ссылки напрямую в хранилище, минуя кэш
+func (c *CachedStorage) ForEach(fn func(Entry) error) error {
+	return c.inner.ForEach(fn)
+}
+
+// UpdateMetadata изменяет метаданные ссылки и сбрасывает запись кэша:
+// ссылка в кэше содержит ее название
+func (c *CachedStorage) UpdateMetadata(userID, shortID string, meta models.LinkMetadata) error {
+	err := c.inner.UpdateMetadata(userID, shortID, meta)
+	c.invalidate([]string{shortID}, nil)
+	return err
+}
+
+// UpdateURL изменяет адрес ссылки и сбрасывает записи кэша для ссылки,
+// ее прежнего и нового оригинального URL
+func (c *CachedStorage) UpdateURL(userID, shortID, originalURL string) error {
+	err := c.inner.UpdateURL(userID, shortID, originalURL)
+	c.invalidate([]string{shortID}, []string{originalURL})
+	return err
+}
+
+// GetHistory возвращает историю адресов ссылки напрямую из хранилища
+func (c *CachedStorage) GetHistory(userID, shortID string) ([]models.URLVersion, error) {
+	return c.inner.GetHistory(userID, shortID)
+}
+
+// AddClicks увеличивает счетчики переходов напрямую в хранилище
+// Кэш не хранит счетчики, поэтому сброс не требуется
+func (c *CachedStorage) AddClicks(clicks []models.ClickCount) error {
+	return c.inner.AddClicks(clicks)
+}
+
+// GetClicks возвращает счетчики переходов напрямую из хранилища
+func (c *CachedStorage) GetClicks(shortID string) (map[string]int64, error) {
+	return c.inner.GetClicks(shortID)
+}
+
+// invalidate сбрасывает записи кэша для указанных сокращенных ID и оригинальных URL
+// Для сокращенных ID также сбрасываются записи FindByOriginal, указывающие на них
+func (c *CachedStorage) invalidate(ids []string, urls []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, id := range ids {
+		c.gets.remove(id)
+		c.links.remove(id)
+		if originalURL, ok := c.byID[id]; ok {
+			c.finds.remove(originalURL)
+		}
+	}
+	for _, originalURL := range urls {
+		c.finds.remove(originalURL)
+	}
+}
+
+// lruEntry элемент LRU кэша
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// lruCache ограниченный по размеру кэш с вытеснением давно не использованных записей
+// и временем жизни записей. Не потокобезопасен
+type lruCache[V any] struct {
+	capacity  int
+	order     *list.List
+	items     map[string]*list.Element
+	onRemove  func(key string, value V)
+	evictions uint64
+}
+
+// newLRUCache создает LRU кэш вместимостью capacity записей
+// onRemove вызывается при удалении любой записи из кэша
+func newLRUCache[V any](capacity int, onRemove func(key string, value V)) *lruCache[V] {
+	return &lruCache[V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		onRemove: onRemove,
+	}
+}
+
+// get возвращает значение по ключу, если оно есть в кэше и не устарело
+func (l *lruCache[V]) get(key string, now time.Time) (V, bool) {
+	var zero V
+	el, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*lruEntry[V])
+	if now.After(e.expires) {
+		l.removeElement(el)
+		return zero, false
+	}
+	l.order.MoveToFront(el)
+	return e.value, true
+}
+
+// put добавляет или обновляет значение, вытесняя самую старую запись при переполнении
+func (l *lruCache[V]) put(key string, value V, expires time.Time) {
+	if l.capacity <= 0 {
+		return
+	}
+	if el, ok := l.items[key]; ok {
+		l.removeElement(el)
+	}
+	l.items[key] = l.order.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	for l.order.Len() > l.capacity {
+		l.removeElement(l.order.Back())
+		l.evictions++
+	}
+}
+
+// remove удаляет значение по ключу
+func (l *lruCache[V]) remove(key string) {
+	if el, ok := l.items[key]; ok {
+		l.removeElement(el)
+	}
+}
+
+// len возвращает количество записей в кэше
+func (l *lruCache[V]) len() int {
+	return l.order.Len()
+}
+
+// removeElement удаляет элемент из кэша
+func (l *lruCache[V]) removeElement(el *list.Element) {
+	e := l.order.Remove(el).(*lruEntry[V])
+	delete(l.items, e.key)
+	if l.onRemove != nil {
+		l.onRemove(e.key, e.value)
+	}
+}
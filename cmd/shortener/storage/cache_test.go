@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"testing"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// countingStorage считает обращения к методам чтения оборачиваемого хранилища
+type countingStorage struct {
+	Storage
+	gets  int
+	finds int
+}
+
+func (s *countingStorage) Get(shortID string) (string, bool, bool) {
+	s.gets++
+	return s.Storage.Get(shortID)
+}
+
+func (s *countingStorage) FindByOriginal(originalURL string) (string, bool) {
+	s.finds++
+	return s.Storage.FindByOriginal(originalURL)
+}
+
+func newTestCache(size int) (*CachedStorage, *countingStorage) {
+	inner := &countingStorage{Storage: NewInMemoryStorage()}
+	return NewCachedStorage(inner, CacheOptions{Size: size, TTL: time.Minute, NegativeTTL: time.Minute}), inner
+}
+
+func TestCachedStorage_GetHitsCache(t *testing.T) {
+	cache, inner := newTestCache(10)
+	cache.Save("id1", "https://example.com", "user")
+
+	for i := 0; i < 3; i++ {
+		url, deleted, exists := cache.Get("id1")
+		if !exists || deleted || url != "https://example.com" {
+			t.Fatalf("unexpected result: %q %v %v", url, deleted, exists)
+		}
+	}
+	if inner.gets != 1 {
+		t.Errorf("expected 1 call to inner storage, got %d", inner.gets)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCachedStorage_NegativeCaching(t *testing.T) {
+	cache, inner := newTestCache(10)
+
+	cache.Get("missing")
+	cache.Get("missing")
+	if inner.gets != 1 {
+		t.Errorf("expected miss to be cached, got %d inner calls", inner.gets)
+	}
+	if stats := cache.Stats(); stats.NegativeHits != 1 {
+		t.Errorf("expected 1 negative hit, got %+v", stats)
+	}
+
+	// Сохранение должно сбросить закэшированный промах
+	cache.Save("missing", "https://example.com/new", "user")
+	if _, _, exists := cache.Get("missing"); !exists {
+		t.Error("expected saved link to be visible after negative caching")
+	}
+
+	if _, found := cache.FindByOriginal("https://example.com/other"); found {
+		t.Fatal("expected URL not to be found")
+	}
+	cache.Save("other", "https://example.com/other", "user")
+	if id, found := cache.FindByOriginal("https://example.com/other"); !found || id != "other" {
+		t.Errorf("expected saved URL to be found, got %q %v", id, found)
+	}
+}
+
+func TestCachedStorage_DeleteInvalidates(t *testing.T) {
+	cache, _ := newTestCache(10)
+	cache.Save("id1", "https://example.com", "user")
+
+	cache.Get("id1")
+	if id, found := cache.FindByOriginal("https://example.com"); !found || id != "id1" {
+		t.Fatalf("expected URL to be found, got %q %v", id, found)
+	}
+
+	if err := cache.DeleteURLs("user", []string{"id1"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+
+	if _, deleted, exists := cache.Get("id1"); !exists || !deleted {
+		t.Errorf("expected deleted link right after deletion, got exists=%v deleted=%v", exists, deleted)
+	}
+	if _, found := cache.FindByOriginal("https://example.com"); found {
+		t.Error("expected deleted URL not to be found")
+	}
+}
+
+func TestCachedStorage_EvictionAndTTL(t *testing.T) {
+	inner := &countingStorage{Storage: NewInMemoryStorage()}
+	cache := NewCachedStorage(inner, CacheOptions{Size: 2, TTL: 20 * time.Millisecond})
+	cache.Save("a", "https://a.example", "user")
+	cache.Save("b", "https://b.example", "user")
+	cache.Save("c", "https://c.example", "user")
+
+	cache.Get("a")
+	cache.Get("b")
+	cache.Get("c")
+	if stats := cache.Stats(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("expected 1 eviction and 2 entries, got %+v", stats)
+	}
+
+	cache.Get("a")
+	if inner.gets != 4 {
+		t.Errorf("expected evicted entry to be reloaded, got %d inner calls", inner.gets)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	cache.Get("a")
+	if inner.gets != 5 {
+		t.Errorf("expected expired entry to be reloaded, got %d inner calls", inner.gets)
+	}
+}
+
+func TestCachedStorage_UpdateURL(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testUpdateURL(t, cache)
+}
+
+func TestCachedStorage_UpdateURLInvalidates(t *testing.T) {
+	cache, _ := newTestCache(10)
+	cache.Save("id1", "https://example.com/old", "user")
+
+	cache.Get("id1")
+	cache.FindByOriginal("https://example.com/old")
+	cache.FindByOriginal("https://example.com/new")
+
+	if err := cache.UpdateURL("user", "id1", "https://example.com/new"); err != nil {
+		t.Fatalf("failed to update URL: %v", err)
+	}
+	if url, _, _ := cache.Get("id1"); url != "https://example.com/new" {
+		t.Errorf("expected new destination right after update, got %q", url)
+	}
+	if _, found := cache.FindByOriginal("https://example.com/old"); found {
+		t.Error("expected previous URL not to be found")
+	}
+	if id, found := cache.FindByOriginal("https://example.com/new"); !found || id != "id1" {
+		t.Errorf("expected new URL to be found, got %q %v", id, found)
+	}
+}
+
+func TestCachedStorage_LinkOptions(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testLinkOptions(t, cache)
+
+	// Изменение параметров сразу видно через кэш
+	cache.Save("id2", "https://example.com/2", "user1")
+	if link, err := cache.GetLink("id2"); err != nil || link.Protected() {
+		t.Fatalf("unexpected link: %+v (%v)", link, err)
+	}
+	if err := cache.UpdateOptions("user1", "id2", models.LinkOptions{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("failed to update options: %v", err)
+	}
+	if link, _ := cache.GetLink("id2"); !link.Protected() {
+		t.Error("expected cached link to be invalidated after options update")
+	}
+}
+
+func TestCachedStorage_UpdateMetadataInvalidates(t *testing.T) {
+	cache, _ := newTestCache(10)
+	cache.Save("id1", "https://example.com/1", "user")
+	if link, err := cache.GetLink("id1"); err != nil || link.Title != "" || link.CreatedAt == nil {
+		t.Fatalf("unexpected link: %+v (%v)", link, err)
+	}
+	if err := cache.UpdateMetadata("user", "id1", models.LinkMetadata{Title: "Docs"}); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	if link, _ := cache.GetLink("id1"); link.Title != "Docs" {
+		t.Errorf("expected cached link to be invalidated after metadata update, got %+v", link)
+	}
+}
+
+func TestCachedStorage_PatchOptions(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testPatchOptions(t, cache)
+
+	// Изменение сразу видно через кэш
+	interstitial := true
+	if _, err := cache.PatchOptions("user1", "id1", models.OptionsPatch{Interstitial: &interstitial}); err != nil {
+		t.Fatalf("failed to patch options: %v", err)
+	}
+	if link, _ := cache.GetLink("id1"); !link.Interstitial {
+		t.Error("expected cached link to be invalidated after options patch")
+	}
+}
+
+func TestCachedStorage_UpdateRules(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testUpdateRules(t, cache)
+}
+
+func TestCachedStorage_UpdateVariants(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testUpdateVariants(t, cache)
+}
+
+func TestCachedStorage_SaveBatchConflicts(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testSaveBatchConflicts(t, cache)
+}
+
+func TestCachedStorage_Clicks(t *testing.T) {
+	cache, _ := newTestCache(10)
+	testClicks(t, cache)
+}
@@ -2,12 +2,21 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 	"uno/cmd/shortener/models"
 
 	"github.com/google/uuid"
@@ -16,56 +25,297 @@ import (
 // FileStorage реализует интерфейс Storage с хранением данных в файле
 // Поддерживает персистентность данных и асинхронные операции
 type FileStorage struct {
-	filePath        string                      // Путь к файлу хранилища
-	mu              sync.RWMutex                // Мьютекс для безопасного доступа к данным
-	originalToShort map[string]string           // Оригинальный URL -> сокращенный ID
-	shortToOriginal map[string]string           // Сокращенный ID -> оригинальный URL
-	file            *os.File                    // Файл для записи данных
-	userURLs        map[string][]models.UserURL // Пользователь -> список его URL
-	deleted         map[string]bool             // Сокращенный ID -> флаг удаления
+	filePath        string                         // Путь к файлу хранилища
+	lock            *os.File                       // Файл эксклюзивной блокировки хранилища (<путь>.lock)
+	mu              sync.RWMutex                   // Мьютекс для безопасного доступа к данным
+	originalToShort map[string]string              // Оригинальный URL -> сокращенный ID
+	shortToOriginal map[string]string              // Сокращенный ID -> оригинальный URL
+	file            *os.File                       // Файл для записи данных
+	userURLs        map[string][]models.UserURL    // Пользователь -> список его URL
+	deleted         map[string]bool                // Сокращенный ID -> флаг удаления
+	owners          map[string]string              // Сокращенный ID -> идентификатор владельца
+	history         map[string][]models.URLVersion // Сокращенный ID -> предыдущие адреса
+	options         map[string]models.LinkOptions  // Сокращенный ID -> параметры перенаправления
+	clicks          map[string]map[string]int64    // Сокращенный ID -> вариант -> количество переходов
+
+	policy        CompactionPolicy // Пороги автоматического уплотнения файла
+	lines         int              // Количество записей в файле
+	size          int64            // Текущий размер файла в байтах
+	baseSize      int64            // Размер файла после последнего уплотнения или загрузки
+	compacting    bool             // Идет уплотнение: новые записи дублируются в pending
+	compactQueued bool             // Автоматическое уплотнение уже запланировано
+	pending       [][]byte         // Записи, добавленные во время уплотнения
+	compactMu     sync.Mutex       // Исключает параллельные уплотнения
+
+	syncMode     SyncMode      // Режим синхронизации записей с диском
+	syncInterval time.Duration // Интервал групповой синхронизации для SyncGroup
+	dirty        bool          // Есть записи, еще не синхронизированные с диском
+	stop         chan struct{} // Сигнал остановки фоновой синхронизации
+	closeOnce    sync.Once     // Гарантирует однократное закрытие
+	closed       bool          // Хранилище закрыто: уплотнение больше не выполняется
+	loadStats    LoadStats     // Результат восстановления данных при загрузке
+	legacy       bool          // Файл старого формата без заголовка fileHeader
+}
+
+// SyncMode определяет, когда записи файлового хранилища синхронизируются с диском
+type SyncMode int
+
+const (
+	// SyncNone не вызывает fsync: данные сбрасываются на диск операционной системой
+	SyncNone SyncMode = iota
+	// SyncAlways вызывает fsync после каждой операции записи
+	SyncAlways
+	// SyncGroup вызывает fsync периодически, объединяя записи за интервал
+	SyncGroup
+)
+
+// DefaultSyncInterval интервал групповой синхронизации по умолчанию
+const DefaultSyncInterval = 100 * time.Millisecond
+
+// ParseSyncMode разбирает режим синхронизации из строки: none, always или group
+func ParseSyncMode(s string) (SyncMode, error) {
+	switch s {
+	case "", "none":
+		return SyncNone, nil
+	case "always":
+		return SyncAlways, nil
+	case "group":
+		return SyncGroup, nil
+	default:
+		return SyncNone, fmt.Errorf("unknown sync mode %q", s)
+	}
+}
+
+// WithSync задает режим синхронизации записей с диском
+// Интервал используется только в режиме SyncGroup
+func WithSync(mode SyncMode, interval time.Duration) FileOption {
+	return func(fs *FileStorage) {
+		fs.syncMode = mode
+		fs.syncInterval = interval
+	}
+}
+
+// LoadStats содержит результат восстановления данных при загрузке файла
+type LoadStats struct {
+	Recovered int   // Количество успешно загруженных записей
+	Dropped   int   // Количество поврежденных записей, перенесенных в карантин
+	TornBytes int64 // Размер отброшенного недописанного хвоста файла в байтах
+}
+
+// CompactionPolicy задает пороги автоматического уплотнения файла хранилища.
+// Уплотнение переписывает файл, оставляя по одной записи на каждую ссылку
+type CompactionPolicy struct {
+	MaxFileSize  int64   // Размер файла в байтах, после которого запускается уплотнение (0 - не учитывать)
+	GarbageRatio float64 // Доля устаревших записей, после которой запускается уплотнение (0 - не учитывать)
+	MinRecords   int     // Минимальное количество записей в файле для автоматического уплотнения
+}
+
+// DefaultCompactionPolicy политика уплотнения, используемая по умолчанию
+var DefaultCompactionPolicy = CompactionPolicy{
+	MaxFileSize:  64 << 20,
+	GarbageRatio: 0.5,
+	MinRecords:   1000,
+}
+
+// FileOption настраивает FileStorage при создании
+type FileOption func(*FileStorage)
+
+// WithCompactionPolicy задает пороги автоматического уплотнения файла
+func WithCompactionPolicy(p CompactionPolicy) FileOption {
+	return func(fs *FileStorage) {
+		fs.policy = p
+	}
 }
 
 // record представляет запись в файле хранилища
+// Каждая запись содержит полное состояние ссылки: последняя запись о ссылке
+// при загрузке заменяет предыдущие
 type record struct {
-	UUID        string `json:"uuid"`         // Уникальный идентификатор записи
-	ShortURL    string `json:"short_url"`    // Сокращенный URL
-	OriginalURL string `json:"original_url"` // Оригинальный URL
-	UserID      string `json:"user_id"`      // Идентификатор пользователя
-	DeletedFlag bool   `json:"deleted_flag"` // Флаг удаления
+	UUID        string              `json:"uuid"`                 // Уникальный идентификатор записи
+	ShortURL    string              `json:"short_url"`            // Сокращенный URL
+	OriginalURL string              `json:"original_url"`         // Оригинальный URL
+	UserID      string              `json:"user_id"`              // Идентификатор пользователя
+	DeletedFlag bool                `json:"deleted_flag"`         // Флаг удаления
+	Title       string              `json:"title,omitempty"`      // Название ссылки
+	Tags        []string            `json:"tags,omitempty"`       // Теги ссылки
+	Note        string              `json:"note,omitempty"`       // Заметка к ссылке
+	History     []models.URLVersion `json:"history,omitempty"`    // Предыдущие адреса ссылки
+	Options     models.LinkOptions  `json:"options,omitzero"`     // Параметры перенаправления
+	CreatedAt   *time.Time          `json:"created_at,omitempty"` // Момент создания ссылки
+	Clicks      map[string]int64    `json:"clicks,omitempty"`     // Переходы по вариантам ссылки
+}
+
+// recordLocked создает запись о текущем состоянии ссылки пользователя
+// Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) recordLocked(userID string, u models.UserURL) record {
+	return record{
+		UUID:        uuid.NewString(),
+		ShortURL:    u.ShortURL,
+		OriginalURL: u.OriginalURL,
+		UserID:      userID,
+		DeletedFlag: u.Deleted,
+		Title:       u.Title,
+		Tags:        u.Tags,
+		Note:        u.Note,
+		History:     fs.history[u.ShortURL],
+		Options:     fs.options[u.ShortURL],
+		CreatedAt:   u.CreatedAt,
+		Clicks:      fs.clicks[u.ShortURL],
+	}
+}
+
+// metadata возвращает метаданные ссылки из записи
+func (r record) metadata() models.LinkMetadata {
+	return models.LinkMetadata{Title: r.Title, Tags: r.Tags, Note: r.Note}
+}
+
+// fileHeader первая строка файла хранилища. В файле с заголовком каждая запись
+// хранится как "<crc32>\t<json>", где CRC32 считается по байтам JSON из файла
+const fileHeader = "#uno-storage v2"
+
+// errChecksumMismatch возвращается, если контрольная сумма записи не совпала
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// errMissingChecksum возвращается для записи без контрольной суммы в файле с заголовком
+var errMissingChecksum = errors.New("missing checksum")
+
+// ErrClosed возвращается при уплотнении закрытого файлового хранилища
+var ErrClosed = errors.New("file storage is closed")
+
+// ErrLocked возвращается NewFileStorage, если файл хранилища уже открыт
+// другим процессом (например, работающим сервером) или другим FileStorage
+var ErrLocked = errors.New("file storage is locked by another process")
+
+// encodeRecord сериализует запись в строку файла "<crc32>\t<json>"
+func encodeRecord(rec record) ([]byte, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	line := fmt.Appendf(make([]byte, 0, len(data)+10), "%08x\t", crc32.ChecksumIEEE(data))
+	line = append(line, data...)
+	return append(line, '\n'), nil
+}
+
+// decodeRecord разбирает строку файла и проверяет контрольную сумму по байтам JSON
+// Строка без контрольной суммы принимается как есть только в файле старого формата (legacy)
+func decodeRecord(line []byte, legacy bool) (record, error) {
+	payload := line
+	if sum, data, framed := bytes.Cut(line, []byte{'\t'}); framed {
+		want, err := strconv.ParseUint(string(sum), 16, 32)
+		if err != nil {
+			return record{}, fmt.Errorf("invalid checksum: %w", err)
+		}
+		if crc32.ChecksumIEEE(data) != uint32(want) {
+			return record{}, errChecksumMismatch
+		}
+		payload = data
+	} else if !legacy {
+		return record{}, errMissingChecksum
+	}
+
+	var r record
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return record{}, err
+	}
+	return r, nil
+}
+
+// writeHeader записывает в w заголовок файла хранилища
+func writeHeader(w io.Writer) (int, error) {
+	return io.WriteString(w, fileHeader+"\n")
 }
 
 // NewFileStorage создает новый экземпляр FileStorage
 // Создает директорию для файла, если она не существует
 // Загружает существующие данные из файла при инициализации
-func NewFileStorage(path string) (Storage, error) {
+func NewFileStorage(path string, opts ...FileOption) (Storage, error) {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create storage directory: %w", err)
 	}
 
+	// Блокируется отдельный файл: уплотнение заменяет файл хранилища переименованием,
+	// и блокировка самого файла осталась бы на старой копии
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage lock file: %w", err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
 	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
 	if err != nil {
+		lock.Close()
 		return nil, fmt.Errorf("failed to open file storage: %w", err)
 	}
 
 	fs := &FileStorage{
 		filePath:        path,
+		lock:            lock,
 		originalToShort: make(map[string]string),
 		shortToOriginal: make(map[string]string),
 		file:            file,
 		userURLs:        make(map[string][]models.UserURL),
 		deleted:         make(map[string]bool),
+		owners:          make(map[string]string),
+		history:         make(map[string][]models.URLVersion),
+		options:         make(map[string]models.LinkOptions),
+		clicks:          make(map[string]map[string]int64),
+		policy:          DefaultCompactionPolicy,
+		syncInterval:    DefaultSyncInterval,
+		stop:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(fs)
 	}
 
 	if err := fs.load(); err != nil {
+		file.Close()
+		lock.Close()
 		return nil, err
 	}
 
+	stats := fs.loadStats
+	log.Printf("FileStorage: loaded %s: recovered %d records, dropped %d corrupt records, truncated %d bytes of torn tail",
+		path, stats.Recovered, stats.Dropped, stats.TornBytes)
+
+	// Поврежденные строки уже в карантине: переписываем файл без них,
+	// чтобы не переносить их повторно при следующем запуске.
+	// Файл старого формата переписывается с заголовком тем же уплотнением
+	if stats.Dropped > 0 || fs.legacy && fs.size > 0 {
+		if err := fs.Compact(); err != nil {
+			log.Printf("FileStorage: failed to rewrite storage file: %v", err)
+		}
+	}
+
+	fs.mu.Lock()
+	fs.maybeCompactLocked()
+	fs.mu.Unlock()
+
+	if fs.syncMode == SyncGroup {
+		if fs.syncInterval <= 0 {
+			fs.syncInterval = DefaultSyncInterval
+		}
+		go fs.runGroupSync()
+	}
+
 	return fs, nil
 }
 
+// LoadStats возвращает результат восстановления данных при загрузке файла
+func (fs *FileStorage) LoadStats() LoadStats {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.loadStats
+}
+
 // load загружает данные из файла в память
-// Читает файл построчно и восстанавливает состояние хранилища
+// Читает файл построчно и восстанавливает состояние хранилища.
+// Повторные записи об одной ссылке (например, об удалении) обновляют
+// уже загруженную запись, а не добавляют новую
 func (fs *FileStorage) load() error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
@@ -76,67 +326,321 @@ func (fs *FileStorage) load() error {
 	if fs.deleted == nil {
 		fs.deleted = make(map[string]bool)
 	}
+	if fs.owners == nil {
+		fs.owners = make(map[string]string)
+	}
+	if fs.history == nil {
+		fs.history = make(map[string][]models.URLVersion)
+	}
+	if fs.options == nil {
+		fs.options = make(map[string]models.LinkOptions)
+	}
+	if fs.clicks == nil {
+		fs.clicks = make(map[string]map[string]int64)
+	}
 
-	_, err := fs.file.Seek(0, 0)
-	if err != nil {
+	if _, err := fs.file.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
 
-	scanner := bufio.NewScanner(fs.file)
-	for scanner.Scan() {
-		var r record
-		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+	var quarantine *os.File
+	defer func() {
+		if quarantine != nil {
+			quarantine.Close()
+		}
+	}()
+
+	var offset int64
+	reader := bufio.NewReader(fs.file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if len(line) == 0 {
+			break
+		}
+
+		complete := line[len(line)-1] == '\n'
+		body := bytes.TrimSpace(line)
+		if offset == 0 {
+			fs.legacy = !complete || string(body) != fileHeader
+			if !fs.legacy {
+				offset += int64(len(line))
+				fs.size += int64(len(line))
+				continue
+			}
+		}
+		if len(body) == 0 {
+			offset += int64(len(line))
+			fs.size += int64(len(line))
 			continue
 		}
-		u := models.UserURL{
-			ShortURL:    r.ShortURL,
-			OriginalURL: r.OriginalURL,
-			Deleted:     r.DeletedFlag,
+
+		r, err := decodeRecord(body, fs.legacy)
+		switch {
+		case err != nil && !complete:
+			// Недописанная последняя строка: запись прервалась на середине
+			if err := fs.file.Truncate(offset); err != nil {
+				return fmt.Errorf("failed to truncate torn tail: %w", err)
+			}
+			fs.loadStats.TornBytes = int64(len(line))
+		case err != nil:
+			if quarantine == nil {
+				quarantine, err = os.OpenFile(fs.filePath+".corrupt", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+				if err != nil {
+					return fmt.Errorf("failed to open quarantine file: %w", err)
+				}
+			}
+			if _, err := quarantine.Write(append(body, '\n')); err != nil {
+				return fmt.Errorf("failed to quarantine corrupt record: %w", err)
+			}
+			fs.loadStats.Dropped++
+			fs.lines++
+			fs.size += int64(len(line))
+		default:
+			if !complete {
+				// Запись цела, но перевод строки не успел попасть на диск
+				if _, err := fs.file.Write([]byte{'\n'}); err != nil {
+					return fmt.Errorf("failed to repair last record: %w", err)
+				}
+				fs.size++
+			}
+			fs.applyLocked(r)
+			fs.loadStats.Recovered++
+			fs.lines++
+			fs.size += int64(len(line))
 		}
+		offset += int64(len(line))
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+	fs.baseSize = fs.size
+	return nil
+}
+
+// applyLocked применяет запись из файла к состоянию в памяти
+// Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) applyLocked(r record) {
+	u := models.UserURL{
+		ShortURL:     r.ShortURL,
+		OriginalURL:  r.OriginalURL,
+		Deleted:      r.DeletedFlag,
+		CreatedAt:    r.CreatedAt,
+		LinkMetadata: r.metadata(),
+	}
+	if owner, ok := fs.owners[r.ShortURL]; ok && owner == r.UserID {
+		for i, existing := range fs.userURLs[owner] {
+			if existing.ShortURL == r.ShortURL {
+				fs.userURLs[owner][i] = u
+				break
+			}
+		}
+	} else {
+		fs.owners[r.ShortURL] = r.UserID
 		fs.userURLs[r.UserID] = append(fs.userURLs[r.UserID], u)
-		if r.DeletedFlag {
-			fs.deleted[r.ShortURL] = true
-			continue
+	}
+	if len(r.History) > 0 {
+		fs.history[r.ShortURL] = r.History
+	}
+	if r.Options.IsZero() {
+		delete(fs.options, r.ShortURL)
+	} else {
+		fs.options[r.ShortURL] = r.Options
+	}
+	if len(r.Clicks) > 0 {
+		fs.clicks[r.ShortURL] = r.Clicks
+	} else {
+		delete(fs.clicks, r.ShortURL)
+	}
+	// Ссылка сменила адрес: прежний оригинальный URL освобождается
+	if prev, ok := fs.shortToOriginal[r.ShortURL]; ok && prev != r.OriginalURL {
+		if fs.originalToShort[prev] == r.ShortURL {
+			delete(fs.originalToShort, prev)
 		}
-		fs.originalToShort[r.OriginalURL] = r.ShortURL
 		fs.shortToOriginal[r.ShortURL] = r.OriginalURL
-		fs.deleted[r.ShortURL] = false
 	}
-	return scanner.Err()
+
+	if r.DeletedFlag {
+		fs.deleted[r.ShortURL] = true
+		if _, ok := fs.shortToOriginal[r.ShortURL]; !ok {
+			fs.shortToOriginal[r.ShortURL] = r.OriginalURL
+		}
+		if _, ok := fs.originalToShort[r.OriginalURL]; !ok {
+			fs.originalToShort[r.OriginalURL] = r.ShortURL
+		}
+		return
+	}
+	fs.originalToShort[r.OriginalURL] = r.ShortURL
+	fs.shortToOriginal[r.ShortURL] = r.OriginalURL
+	fs.deleted[r.ShortURL] = false
+}
+
+// writeRecordLocked дописывает запись в конец файла
+// Во время уплотнения запись дополнительно сохраняется в pending,
+// чтобы попасть в новый файл. Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) writeRecordLocked(rec record) error {
+	line, err := encodeRecord(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	// Пустой файл начинается с заголовка формата
+	if fs.size == 0 {
+		n, err := writeHeader(fs.file)
+		fs.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write storage file header: %w", err)
+		}
+		fs.legacy = false
+	}
+	n, err := fs.file.Write(line)
+	fs.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write record to file: %w", err)
+	}
+	fs.lines++
+	fs.dirty = true
+	if fs.compacting {
+		fs.pending = append(fs.pending, line)
+	}
+	return nil
+}
+
+// commitLocked завершает операцию записи в соответствии с режимом синхронизации
+// В режиме SyncAlways вызывает fsync. Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) commitLocked() error {
+	if fs.syncMode != SyncAlways || !fs.dirty {
+		return nil
+	}
+	if err := fs.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	fs.dirty = false
+	return nil
+}
+
+// runGroupSync периодически синхронизирует файл с диском в режиме SyncGroup
+// fsync выполняется без удержания блокировки, чтобы не задерживать запись
+func (fs *FileStorage) runGroupSync() {
+	ticker := time.NewTicker(fs.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fs.stop:
+			return
+		case <-ticker.C:
+			fs.mu.Lock()
+			file, dirty := fs.file, fs.dirty
+			fs.dirty = false
+			fs.mu.Unlock()
+			if !dirty {
+				continue
+			}
+			// Файл мог быть заменен уплотнением, которое само выполняет fsync
+			if err := file.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+				log.Printf("FileStorage: group sync failed: %v", err)
+				fs.mu.Lock()
+				fs.dirty = true
+				fs.mu.Unlock()
+			}
+		}
+	}
 }
 
 // Save сохраняет связь между сокращенным ID и оригинальным URL для конкретного пользователя
 // Записывает данные в файл для персистентности
-// Если URL уже существует, операция игнорируется
-func (fs *FileStorage) Save(shortID, originalURL, userID string) {
+// Если URL уже существует, операция игнорируется. Ошибка записи или, в режиме
+// SyncAlways, синхронизации с диском возвращается вызывающему
+func (fs *FileStorage) Save(shortID, originalURL, userID string) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
 	if _, exists := fs.originalToShort[originalURL]; exists {
-		return
+		return nil
 	}
 
-	fs.originalToShort[originalURL] = shortID
-	fs.shortToOriginal[shortID] = originalURL
-
 	rec := record{
 		UUID:        uuid.NewString(),
 		ShortURL:    shortID,
 		OriginalURL: originalURL,
 		UserID:      userID,
+		CreatedAt:   createdNow(),
+	}
+	if err := fs.writeRecordLocked(rec); err != nil {
+		return errors.Join(err, fs.commitLocked())
 	}
-	fs.userURLs[userID] = append(fs.userURLs[userID], models.UserURL{
+	fs.addLocked(rec)
+	err := fs.commitLocked()
+	fs.maybeCompactLocked()
+	return err
+}
+
+// SaveLink сохраняет новую ссылку вместе с метаданными и параметрами перенаправления
+// Ссылка записывается в файл одной записью и становится видна только после записи
+func (fs *FileStorage) SaveLink(shortID, originalURL, userID string, meta models.LinkMetadata, opts models.LinkOptions) error {
+	return fs.Restore(Entry{
 		ShortURL:    shortID,
 		OriginalURL: originalURL,
+		UserID:      userID,
+		Title:       meta.Title,
+		Tags:        meta.Tags,
+		Note:        meta.Note,
+		Options:     opts,
+		CreatedAt:   createdNow(),
 	})
-	fs.deleted[shortID] = false
-	jsonData, err := json.Marshal(rec)
-	if err != nil {
-		return
+}
+
+// Restore сохраняет ссылку из выгрузки вместе со всем ее состоянием
+// одной записью файла
+func (fs *FileStorage) Restore(e Entry) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.shortToOriginal[e.ShortURL]; exists {
+		return ErrConflict
+	}
+	if _, exists := fs.originalToShort[e.OriginalURL]; exists {
+		return ErrConflict
+	}
+
+	rec := record{
+		UUID:        uuid.NewString(),
+		ShortURL:    e.ShortURL,
+		OriginalURL: e.OriginalURL,
+		UserID:      e.UserID,
+		DeletedFlag: e.Deleted,
+		Title:       e.Title,
+		Tags:        e.Tags,
+		Note:        e.Note,
+		History:     slices.Clone(e.History),
+		Options:     e.Options,
+		CreatedAt:   e.CreatedAt,
+		Clicks:      maps.Clone(e.Clicks),
 	}
-	if _, err := fs.file.Write(append(jsonData, '\n')); err != nil {
-		log.Printf("FileStorage: failed to write record to file: %v", err)
+	if err := fs.writeRecordLocked(rec); err != nil {
+		return errors.Join(err, fs.commitLocked())
 	}
+	fs.applyLocked(rec)
+	err := fs.commitLocked()
+	fs.maybeCompactLocked()
+	return err
+}
+
+// addLocked добавляет новую ссылку из записи rec в состояние в памяти
+// Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) addLocked(rec record) {
+	fs.originalToShort[rec.OriginalURL] = rec.ShortURL
+	fs.shortToOriginal[rec.ShortURL] = rec.OriginalURL
+	fs.userURLs[rec.UserID] = append(fs.userURLs[rec.UserID], models.UserURL{
+		ShortURL:    rec.ShortURL,
+		OriginalURL: rec.OriginalURL,
+		CreatedAt:   rec.CreatedAt,
+	})
+	fs.owners[rec.ShortURL] = rec.UserID
+	fs.deleted[rec.ShortURL] = false
 }
 
 // Get возвращает оригинальный URL по сокращенному ID, флаг удаления и существования
@@ -163,36 +667,41 @@ func (fs *FileStorage) FindByOriginal(originalURL string) (string, bool) {
 
 // SaveBatch сохраняет пакет URL для конкретного пользователя
 // Обрабатывает каждый URL аналогично методу Save
+// Возвращает ошибку, если запись в файл не удалась; ссылки, записанные
+// до ошибки, остаются сохраненными. Уже занятые ID и URL перечисляются в *BatchError
 func (fs *FileStorage) SaveBatch(pairs map[string]string, userID string) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
+	defer fs.maybeCompactLocked()
 
+	created := createdNow()
+	var skipped []string
 	for shortID, originalURL := range pairs {
 		if _, exists := fs.originalToShort[originalURL]; exists {
+			skipped = append(skipped, shortID)
+			continue
+		}
+		if _, exists := fs.shortToOriginal[shortID]; exists {
+			skipped = append(skipped, shortID)
 			continue
 		}
-
-		fs.originalToShort[originalURL] = shortID
-		fs.shortToOriginal[shortID] = originalURL
 
 		rec := record{
 			UUID:        uuid.NewString(),
 			ShortURL:    shortID,
 			OriginalURL: originalURL,
 			UserID:      userID,
+			CreatedAt:   created,
 		}
-		fs.userURLs[userID] = append(fs.userURLs[userID], models.UserURL{
-			ShortURL:    shortID,
-			OriginalURL: originalURL,
-		})
-		fs.deleted[shortID] = false
-		jsonData, err := json.Marshal(rec)
-		if err != nil {
-			continue
+		if err := fs.writeRecordLocked(rec); err != nil {
+			return errors.Join(err, fs.commitLocked())
 		}
-		_, _ = fs.file.Write(append(jsonData, '\n'))
+		fs.addLocked(rec)
 	}
-	return nil
+	if err := fs.commitLocked(); err != nil {
+		return err
+	}
+	return batchError(skipped)
 }
 
 // DeleteURLs помечает указанные URL как удаленные для конкретного пользователя
@@ -206,23 +715,21 @@ func (fs *FileStorage) DeleteURLs(userID string, ids []string) error {
 		toDelete[id] = struct{}{}
 	}
 
+	var errs []error
 	for i, u := range fs.userURLs[userID] {
 		if _, del := toDelete[u.ShortURL]; del && !fs.deleted[u.ShortURL] {
+			u.Deleted = true
+			if err := fs.writeRecordLocked(fs.recordLocked(userID, u)); err != nil {
+				errs = append(errs, err)
+				continue
+			}
 			fs.userURLs[userID][i].Deleted = true
 			fs.deleted[u.ShortURL] = true
-			rec := record{
-				UUID:        uuid.NewString(),
-				ShortURL:    u.ShortURL,
-				OriginalURL: u.OriginalURL,
-				UserID:      userID,
-				DeletedFlag: true,
-			}
-			if b, err := json.Marshal(rec); err == nil {
-				fs.file.Write(append(b, '\n'))
-			}
 		}
 	}
-	return nil
+	errs = append(errs, fs.commitLocked())
+	fs.maybeCompactLocked()
+	return errors.Join(errs...)
 }
 
 // GetUserURLs возвращает все не удаленные URL для конкретного пользователя
@@ -243,3 +750,446 @@ func (fs *FileStorage) GetUserURLs(userID string) ([]models.UserURL, error) {
 	}
 	return filtered, nil
 }
+
+// UpdateMetadata заменяет метаданные не удаленной ссылки пользователя
+// Записывает в файл полное новое состояние ссылки
+func (fs *FileStorage) UpdateMetadata(userID, shortID string, meta models.LinkMetadata) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	i := fs.userURLIndexLocked(userID, shortID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	u := fs.userURLs[userID][i]
+	u.LinkMetadata = meta
+	if err := fs.writeRecordLocked(fs.recordLocked(userID, u)); err != nil {
+		return errors.Join(err, fs.commitLocked())
+	}
+	fs.userURLs[userID][i] = u
+	err := fs.commitLocked()
+	fs.maybeCompactLocked()
+	return err
+}
+
+// UpdateURL заменяет оригинальный URL не удаленной ссылки пользователя
+// Прежний адрес добавляется в историю версий, а запись с новым состоянием
+// ссылки и всей ее историей дописывается в файл
+func (fs *FileStorage) UpdateURL(userID, shortID, originalURL string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	i := fs.userURLIndexLocked(userID, shortID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	u := fs.userURLs[userID][i]
+	previous := u.OriginalURL
+	if previous == originalURL {
+		return nil
+	}
+	if id, ok := fs.originalToShort[originalURL]; ok && id != shortID {
+		return ErrConflict
+	}
+
+	history := appendVersion(fs.history[shortID], previous, time.Now())
+	u.OriginalURL = originalURL
+	rec := fs.recordLocked(userID, u)
+	rec.History = history
+	if err := fs.writeRecordLocked(rec); err != nil {
+		return errors.Join(err, fs.commitLocked())
+	}
+
+	if fs.originalToShort[previous] == shortID {
+		delete(fs.originalToShort, previous)
+	}
+	fs.originalToShort[originalURL] = shortID
+	fs.shortToOriginal[shortID] = originalURL
+	fs.history[shortID] = history
+	fs.userURLs[userID][i] = u
+
+	err := fs.commitLocked()
+	fs.maybeCompactLocked()
+	return err
+}
+
+// GetHistory возвращает предыдущие адреса не удаленной ссылки пользователя
+func (fs *FileStorage) GetHistory(userID, shortID string) ([]models.URLVersion, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if fs.userURLIndexLocked(userID, shortID) < 0 {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(fs.history[shortID]), nil
+}
+
+// GetLink возвращает ссылку с владельцем, названием, датой создания и параметрами перенаправления
+func (fs *FileStorage) GetLink(shortID string) (models.Link, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	originalURL, ok := fs.shortToOriginal[shortID]
+	if !ok {
+		return models.Link{}, ErrNotFound
+	}
+	link := models.Link{
+		ShortURL:    shortID,
+		OriginalURL: originalURL,
+		UserID:      fs.owners[shortID],
+		Deleted:     fs.deleted[shortID],
+		LinkOptions: fs.options[shortID],
+	}
+	if i := fs.userURLIndexLocked(link.UserID, shortID); i >= 0 {
+		u := fs.userURLs[link.UserID][i]
+		link.Title, link.CreatedAt = u.Title, u.CreatedAt
+	}
+	return link, nil
+}
+
+// UpdateOptions заменяет параметры перенаправления не удаленной ссылки пользователя
+// Записывает в файл полное новое состояние ссылки
+func (fs *FileStorage) UpdateOptions(userID, shortID string, opts models.LinkOptions) error {
+	_, err := fs.modifyOptions(userID, shortID, func(models.LinkOptions) models.LinkOptions { return opts })
+	return err
+}
+
+// PatchOptions изменяет заданные поля параметров перенаправления
+func (fs *FileStorage) PatchOptions(userID, shortID string, patch models.OptionsPatch) (models.LinkOptions, error) {
+	return fs.modifyOptions(userID, shortID, patch.Apply)
+}
+
+// UpdateRules заменяет правила перенаправления ссылки
+func (fs *FileStorage) UpdateRules(userID, shortID string, rules []models.RedirectRule) error {
+	_, err := fs.modifyOptions(userID, shortID, func(opts models.LinkOptions) models.LinkOptions {
+		opts.Rules = rules
+		return opts
+	})
+	return err
+}
+
+// UpdateVariants заменяет варианты ссылки
+func (fs *FileStorage) UpdateVariants(userID, shortID string, variants []models.Variant) error {
+	_, err := fs.modifyOptions(userID, shortID, func(opts models.LinkOptions) models.LinkOptions {
+		opts.Variants = variants
+		return opts
+	})
+	return err
+}
+
+// modifyOptions заменяет параметры не удаленной ссылки пользователя результатом fn
+// от текущих параметров, не отпуская блокировку между чтением и записью
+func (fs *FileStorage) modifyOptions(userID, shortID string, fn func(models.LinkOptions) models.LinkOptions) (models.LinkOptions, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	i := fs.userURLIndexLocked(userID, shortID)
+	if i < 0 {
+		return models.LinkOptions{}, ErrNotFound
+	}
+	opts := fn(fs.options[shortID])
+	rec := fs.recordLocked(userID, fs.userURLs[userID][i])
+	rec.Options = opts
+	if err := fs.writeRecordLocked(rec); err != nil {
+		return models.LinkOptions{}, errors.Join(err, fs.commitLocked())
+	}
+	if opts.IsZero() {
+		delete(fs.options, shortID)
+	} else {
+		fs.options[shortID] = opts
+	}
+	err := fs.commitLocked()
+	fs.maybeCompactLocked()
+	return opts, err
+}
+
+// AddClicks увеличивает счетчики переходов по ссылкам
+// Для каждой ссылки из пакета записывает в файл одну запись с ее полным состоянием.
+// Счетчики ссылки заменяются новой картой, а не изменяются на месте: снимок
+// для уплотнения кодируется без блокировки и может ссылаться на прежнюю карту
+func (fs *FileStorage) AddClicks(clicks []models.ClickCount) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	updated := make(map[string]map[string]int64)
+	var order []string
+	for _, c := range clicks {
+		if _, ok := fs.owners[c.ShortURL]; !ok {
+			continue
+		}
+		counts, ok := updated[c.ShortURL]
+		if !ok {
+			counts = maps.Clone(fs.clicks[c.ShortURL])
+			if counts == nil {
+				counts = make(map[string]int64)
+			}
+			updated[c.ShortURL] = counts
+			order = append(order, c.ShortURL)
+		}
+		counts[c.Variant] += c.Clicks
+	}
+
+	for _, shortID := range order {
+		userID := fs.owners[shortID]
+		for _, u := range fs.userURLs[userID] {
+			if u.ShortURL != shortID {
+				continue
+			}
+			rec := fs.recordLocked(userID, u)
+			rec.Clicks = updated[shortID]
+			if err := fs.writeRecordLocked(rec); err != nil {
+				return errors.Join(err, fs.commitLocked())
+			}
+			fs.clicks[shortID] = updated[shortID]
+			break
+		}
+	}
+	err := fs.commitLocked()
+	fs.maybeCompactLocked()
+	return err
+}
+
+// GetClicks возвращает счетчики переходов ссылки по вариантам
+func (fs *FileStorage) GetClicks(shortID string) (map[string]int64, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if _, ok := fs.owners[shortID]; !ok {
+		return nil, ErrNotFound
+	}
+	return maps.Clone(fs.clicks[shortID]), nil
+}
+
+// userURLIndexLocked возвращает индекс не удаленной ссылки в списке пользователя или -1
+// Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) userURLIndexLocked(userID, shortID string) int {
+	for i, u := range fs.userURLs[userID] {
+		if u.ShortURL == shortID && !u.Deleted {
+			return i
+		}
+	}
+	return -1
+}
+
+// needsCompactionLocked проверяет, превышены ли пороги политики уплотнения
+// Порог по размеру срабатывает только если файл вырос хотя бы вдвое с момента
+// последнего уплотнения, иначе большой файл без мусора уплотнялся бы постоянно
+func (fs *FileStorage) needsCompactionLocked() bool {
+	p := fs.policy
+	if fs.lines == 0 || fs.lines < p.MinRecords {
+		return false
+	}
+	garbage := fs.lines - fs.liveRecordsLocked()
+	if garbage <= 0 {
+		return false
+	}
+	if p.GarbageRatio > 0 && float64(garbage)/float64(fs.lines) >= p.GarbageRatio {
+		return true
+	}
+	return p.MaxFileSize > 0 && fs.size >= p.MaxFileSize && fs.size >= 2*fs.baseSize
+}
+
+// liveRecordsLocked возвращает количество актуальных записей (по одной на ссылку)
+func (fs *FileStorage) liveRecordsLocked() int {
+	return len(fs.owners)
+}
+
+// maybeCompactLocked запускает фоновое уплотнение, если превышены пороги политики
+// Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) maybeCompactLocked() {
+	if fs.closed || fs.compacting || fs.compactQueued || !fs.needsCompactionLocked() {
+		return
+	}
+	fs.compactQueued = true
+	go func() {
+		// Хранилище могло быть закрыто, пока уплотнение ждало своей очереди
+		if err := fs.Compact(); err != nil && !errors.Is(err, ErrClosed) {
+			log.Printf("FileStorage: compaction failed: %v", err)
+		}
+	}()
+}
+
+// snapshotLocked возвращает актуальное состояние хранилища в виде записей файла,
+// по одной записи на каждую ссылку. Вызывающий должен удерживать fs.mu
+func (fs *FileStorage) snapshotLocked() []record {
+	users := make([]string, 0, len(fs.userURLs))
+	for userID := range fs.userURLs {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+
+	snapshot := make([]record, 0, len(fs.owners))
+	for _, userID := range users {
+		for _, u := range fs.userURLs[userID] {
+			snapshot = append(snapshot, fs.recordLocked(userID, u))
+		}
+	}
+	return snapshot
+}
+
+// Compact уплотняет файл хранилища: записывает снимок актуального состояния
+// во временный файл, синхронизирует его с диском и атомарно подменяет им
+// основной файл. Чтение и запись блокируются только на время снятия снимка
+// и финальной подмены файла; записи, сделанные во время уплотнения,
+// дописываются в новый файл перед подменой. После Close возвращает ErrClosed
+func (fs *FileStorage) Compact() error {
+	fs.compactMu.Lock()
+	defer fs.compactMu.Unlock()
+
+	fs.mu.Lock()
+	if fs.closed {
+		fs.compactQueued = false
+		fs.mu.Unlock()
+		return ErrClosed
+	}
+	snapshot := fs.snapshotLocked()
+	fs.compacting = true
+	fs.compactQueued = false
+	fs.pending = nil
+	fs.mu.Unlock()
+
+	tmpPath := fs.filePath + ".compact"
+	tmp, size, err := writeSnapshot(tmpPath, snapshot)
+	if err != nil {
+		fs.mu.Lock()
+		fs.compacting = false
+		fs.pending = nil
+		fs.mu.Unlock()
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	pending := fs.pending
+	fs.compacting = false
+	fs.pending = nil
+
+	abort := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	for _, line := range pending {
+		n, err := tmp.Write(line)
+		if err != nil {
+			return abort(fmt.Errorf("failed to write compacted file: %w", err))
+		}
+		size += int64(n)
+	}
+	if err := tmp.Sync(); err != nil {
+		return abort(fmt.Errorf("failed to sync compacted file: %w", err))
+	}
+	if err := os.Rename(tmpPath, fs.filePath); err != nil {
+		return abort(fmt.Errorf("failed to replace storage file: %w", err))
+	}
+	if err := syncDir(filepath.Dir(fs.filePath)); err != nil {
+		log.Printf("FileStorage: failed to sync storage directory: %v", err)
+	}
+
+	if err := fs.file.Close(); err != nil {
+		log.Printf("FileStorage: failed to close old storage file: %v", err)
+	}
+	fs.file = tmp
+	fs.legacy = false
+	fs.lines = len(snapshot) + len(pending)
+	fs.size = size
+	fs.baseSize = size
+	return nil
+}
+
+// writeSnapshot записывает снимок во временный файл и возвращает открытый на дозапись файл
+// вместе с количеством записанных байт
+func writeSnapshot(path string, snapshot []record) (*os.File, int64, error) {
+	tmp, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create compacted file: %w", err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	n, err := writeHeader(w)
+	if err != nil {
+		tmp.Close()
+		os.Remove(path)
+		return nil, 0, fmt.Errorf("failed to write compacted file: %w", err)
+	}
+	size := int64(n)
+	for _, rec := range snapshot {
+		line, err := encodeRecord(rec)
+		if err == nil {
+			var n int
+			n, err = w.Write(line)
+			size += int64(n)
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(path)
+			return nil, 0, fmt.Errorf("failed to write compacted file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(path)
+		return nil, 0, fmt.Errorf("failed to write compacted file: %w", err)
+	}
+	return tmp, size, nil
+}
+
+// syncDir синхронизирует с диском содержимое директории,
+// чтобы переименование файла пережило сбой питания
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// ForEach последовательно передает в fn все ссылки хранилища, включая удаленные
+// Обход выполняется по снимку состояния и не блокирует хранилище на время вызовов fn
+func (fs *FileStorage) ForEach(fn func(Entry) error) error {
+	fs.mu.RLock()
+	snapshot := fs.snapshotLocked()
+	fs.mu.RUnlock()
+
+	for _, r := range snapshot {
+		e := Entry{
+			ShortURL:    r.ShortURL,
+			OriginalURL: r.OriginalURL,
+			UserID:      r.UserID,
+			Deleted:     r.DeletedFlag,
+			Title:       r.Title,
+			Tags:        r.Tags,
+			Note:        r.Note,
+			Options:     r.Options,
+			CreatedAt:   r.CreatedAt,
+			History:     slices.Clone(r.History),
+			Clicks:      maps.Clone(r.Clicks),
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close синхронизирует данные с диском и закрывает файл хранилища
+// Дожидается завершения уплотнения, если оно выполняется
+func (fs *FileStorage) Close() error {
+	var err error
+	fs.closeOnce.Do(func() {
+		close(fs.stop)
+		fs.compactMu.Lock()
+		defer fs.compactMu.Unlock()
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		fs.closed = true
+		// Блокировка снимается закрытием файла блокировки
+		err = errors.Join(fs.file.Sync(), fs.file.Close(), fs.lock.Close())
+	})
+	return err
+}
+
+// Stats возвращает количество записей в файле и количество актуальных ссылок
+func (fs *FileStorage) Stats() (records int, live int) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.lines, fs.liveRecordsLocked()
+}
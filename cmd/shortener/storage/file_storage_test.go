@@ -1,9 +1,15 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	"uno/cmd/shortener/models"
 )
 
 func TestFileStorage_SaveAndGet(t *testing.T) {
@@ -163,3 +169,676 @@ func TestFileStorage_SaveBatch(t *testing.T) {
 		}
 	}
 }
+
+func TestFileStorage_Compact(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "compact.json")
+
+	s, err := NewFileStorage(testFile, WithCompactionPolicy(CompactionPolicy{}))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	store := s.(*FileStorage)
+
+	store.Save("id1", "https://example.com/1", "user1")
+	store.Save("id2", "https://example.com/2", "user1")
+	store.Save("id3", "https://example.com/3", "user2")
+	if err := store.DeleteURLs("user1", []string{"id1"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+
+	if records, live := store.Stats(); records != 4 || live != 3 {
+		t.Fatalf("expected 4 records and 3 live links before compaction, got %d and %d", records, live)
+	}
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if records, _ := store.Stats(); records != 3 {
+		t.Errorf("expected 3 records after compaction, got %d", records)
+	}
+
+	// Запись после уплотнения должна попасть в новый файл
+	store.Save("id4", "https://example.com/4", "user2")
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 5 {
+		t.Errorf("expected header and 4 records in compacted file, got %d lines", lines)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+
+	if _, deleted, exists := reopened.Get("id1"); !exists || !deleted {
+		t.Errorf("expected id1 to survive compaction as deleted, got exists=%v deleted=%v", exists, deleted)
+	}
+	if got, _, _ := reopened.Get("id4"); got != "https://example.com/4" {
+		t.Errorf("expected id4 to be stored after compaction, got %q", got)
+	}
+	urls, err := reopened.GetUserURLs("user1")
+	if err != nil {
+		t.Fatalf("failed to get user URLs: %v", err)
+	}
+	if len(urls) != 1 || urls[0].ShortURL != "id2" {
+		t.Errorf("unexpected user URLs after compaction: %+v", urls)
+	}
+}
+
+func TestFileStorage_CompactAfterClose(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "closed.json")
+
+	s, err := NewFileStorage(testFile, WithCompactionPolicy(CompactionPolicy{}))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	store := s.(*FileStorage)
+	store.Save("id1", "https://example.com/1", "user1")
+	store.Save("id2", "https://example.com/2", "user1")
+	if err := store.DeleteURLs("user1", []string{"id1"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	if err := store.Compact(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if _, err := os.Stat(testFile + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("expected no compacted file after close, got %v", err)
+	}
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	if records, _ := reopened.(*FileStorage).Stats(); records != 3 {
+		t.Errorf("expected file to stay uncompacted, got %d records", records)
+	}
+}
+
+func TestFileStorage_SaveError(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "save.json")
+
+	s, err := NewFileStorage(testFile, WithSync(SyncAlways, 0))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if err := s.Save("id1", "https://example.com/1", "user1"); err != nil {
+		t.Fatalf("failed to save link: %v", err)
+	}
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+	if err := s.Save("id2", "https://example.com/2", "user1"); err == nil {
+		t.Error("expected write error to be returned")
+	}
+	if err := s.Save("id3", "https://example.com/1", "user1"); err != nil {
+		t.Errorf("expected already shortened URL to be skipped, got %v", err)
+	}
+}
+
+func TestFileStorage_Metadata(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "metadata.json")
+
+	s, err := NewFileStorage(testFile, WithCompactionPolicy(CompactionPolicy{}))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	store := s.(*FileStorage)
+
+	store.Save("id1", "https://example.com/1", "user1")
+	store.Save("id2", "https://example.com/2", "user1")
+	meta := models.LinkMetadata{Title: "First", Tags: []string{"work", "go"}, Note: "note"}
+	if err := store.UpdateMetadata("user1", "id1", meta); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	if err := store.UpdateMetadata("user1", "id2", models.LinkMetadata{Title: "Second"}); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	if err := store.UpdateMetadata("user2", "id1", meta); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	// Запись об удалении не должна терять метаданные
+	if err := store.DeleteURLs("user1", []string{"id2"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+
+	check := func(stage string, st Storage) {
+		t.Helper()
+		var got []Entry
+		if err := st.ForEach(func(e Entry) error {
+			got = append(got, e)
+			return nil
+		}); err != nil {
+			t.Fatalf("%s: ForEach failed: %v", stage, err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("%s: expected 2 entries, got %+v", stage, got)
+		}
+		first := got[0].Metadata()
+		if first.Title != "First" || !first.HasTag("go") || first.Note != "note" {
+			t.Errorf("%s: unexpected metadata of id1: %+v", stage, first)
+		}
+		if !got[1].Deleted || got[1].Title != "Second" {
+			t.Errorf("%s: unexpected id2: %+v", stage, got[1])
+		}
+	}
+
+	check("before reload", store)
+	if err := store.Compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	check("after compaction", store)
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	check("after reload", reopened)
+}
+
+func TestFileStorage_CreatedAt(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "created.json")
+
+	s, err := NewFileStorage(testFile, WithCompactionPolicy(CompactionPolicy{}))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	store := s.(*FileStorage)
+	store.Save("id1", "https://example.com/1", "user1")
+	if err := store.SaveBatch(map[string]string{"id2": "https://example.com/2"}, "user1"); err != nil {
+		t.Fatalf("failed to save batch: %v", err)
+	}
+
+	created := func(st Storage) map[string]time.Time {
+		t.Helper()
+		urls, err := st.GetUserURLs("user1")
+		if err != nil {
+			t.Fatalf("failed to get user URLs: %v", err)
+		}
+		result := make(map[string]time.Time)
+		for _, u := range urls {
+			if u.CreatedAt == nil {
+				t.Fatalf("missing creation time of %s", u.ShortURL)
+			}
+			result[u.ShortURL] = *u.CreatedAt
+		}
+		return result
+	}
+	want := created(store)
+	if len(want) != 2 {
+		t.Fatalf("expected 2 links, got %v", want)
+	}
+
+	// Изменения ссылки и уплотнение не меняют момент создания
+	if err := store.UpdateMetadata("user1", "id1", models.LinkMetadata{Title: "First"}); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	if err := store.UpdateURL("user1", "id2", "https://example.com/2b"); err != nil {
+		t.Fatalf("failed to update URL: %v", err)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	got := created(reopened)
+	for id, ts := range want {
+		if !got[id].Equal(ts) {
+			t.Errorf("creation time of %s = %v, want %v", id, got[id], ts)
+		}
+	}
+}
+
+func TestFileStorage_UpdateURL(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "retarget.json")
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	testUpdateURL(t, s)
+	if err := s.UpdateMetadata("user1", "id1", models.LinkMetadata{Title: "kept"}); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+
+	check := func(stage string, st Storage) {
+		t.Helper()
+		if got, _, _ := st.Get("id1"); got != "https://example.com/typo" {
+			t.Errorf("%s: unexpected destination %q", stage, got)
+		}
+		if id, found := st.FindByOriginal("https://example.com/1"); found {
+			t.Errorf("%s: expected replaced URL to be released, got %q", stage, id)
+		}
+		if err := st.UpdateURL("user1", "id2", "https://example.com/1"); err != nil {
+			t.Errorf("%s: expected released URL to be available: %v", stage, err)
+		}
+		if err := st.UpdateURL("user1", "id2", "https://example.com/2"); err != nil {
+			t.Errorf("%s: failed to restore id2: %v", stage, err)
+		}
+		history, err := st.GetHistory("user1", "id1")
+		if err != nil || len(history) != 2 || history[1].OriginalURL != "https://example.com/1" {
+			t.Errorf("%s: unexpected history %+v (%v)", stage, history, err)
+		}
+	}
+
+	store := s.(*FileStorage)
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	check("after reload", reopened)
+
+	store = reopened.(*FileStorage)
+	if err := store.Compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+	compacted, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer compacted.(*FileStorage).Close()
+	check("after compaction", compacted)
+}
+
+func TestFileStorage_LinkOptions(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "options.json")
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	testLinkOptions(t, s)
+	if err := s.(*FileStorage).Compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	if link, err := reopened.GetLink("id1"); err != nil || !link.Deleted || link.PasswordHash != "hash" || link.RedirectCode != 308 || link.QueryPolicy != models.QueryOverride || len(link.Rules) != 2 || link.Rules[1].Until == nil {
+		t.Errorf("expected options to survive reload, got %+v (%v)", link, err)
+	}
+}
+
+func TestFileStorage_PatchOptions(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "patch.json")
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	testPatchOptions(t, s)
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	if link, err := reopened.GetLink("id1"); err != nil || link.RedirectCode != 301 || link.QueryPolicy != models.QueryAppend || link.PasswordHash != "hash" || len(link.Rules) != 1 {
+		t.Errorf("expected patched options to survive reload, got %+v (%v)", link, err)
+	}
+}
+
+func TestFileStorage_UpdateRules(t *testing.T) {
+	s, err := NewFileStorage(filepath.Join(t.TempDir(), "rules.json"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	defer s.(*FileStorage).Close()
+	testUpdateRules(t, s)
+}
+
+func TestFileStorage_UpdateVariants(t *testing.T) {
+	s, err := NewFileStorage(filepath.Join(t.TempDir(), "variants.json"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	defer s.(*FileStorage).Close()
+	testUpdateVariants(t, s)
+}
+
+func TestFileStorage_SaveBatchConflicts(t *testing.T) {
+	s, err := NewFileStorage(filepath.Join(t.TempDir(), "batch.json"))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	defer s.(*FileStorage).Close()
+	testSaveBatchConflicts(t, s)
+}
+
+func TestFileStorage_SaveLink(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "links.json")
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	testSaveLink(t, s)
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	if link, err := reopened.GetLink("id1"); err != nil || !link.Deleted || link.PasswordHash != "hash" || !link.Interstitial {
+		t.Errorf("expected options to survive reload, got %+v (%v)", link, err)
+	}
+	var entries []Entry
+	if err := reopened.ForEach(func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to iterate links: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Title != "Docs" || entries[0].Note != "note" {
+		t.Errorf("expected metadata to survive reload, got %+v", entries)
+	}
+}
+
+func TestFileStorage_Restore(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "restore.json")
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	testRestore(t, s)
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	defer reopened.(*FileStorage).Close()
+	if history, err := reopened.GetHistory("user1", "id1"); err != nil || len(history) != 2 {
+		t.Errorf("expected history to survive reload, got %+v (%v)", history, err)
+	}
+	if _, deleted, exists := reopened.Get("id2"); !exists || !deleted {
+		t.Errorf("expected deleted link to survive reload")
+	}
+	if clicks, err := reopened.GetClicks("id1"); err != nil || clicks["a"] != 7 {
+		t.Errorf("expected clicks to survive reload, got %v (%v)", clicks, err)
+	}
+}
+
+func TestFileStorage_Clicks(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "clicks.json")
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	testClicks(t, s)
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+
+	check := func(name string, s Storage) {
+		t.Helper()
+		if clicks, err := s.GetClicks("ab"); err != nil || clicks["a"] != 5 || clicks["b"] != 1 {
+			t.Errorf("%s: unexpected clicks %v (%v)", name, clicks, err)
+		}
+	}
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen file storage: %v", err)
+	}
+	check("after reload", reopened)
+	if err := reopened.(*FileStorage).Compact(); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	reopened.(*FileStorage).Close()
+
+	compacted, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to reopen compacted storage: %v", err)
+	}
+	defer compacted.(*FileStorage).Close()
+	check("after compaction", compacted)
+}
+
+func TestFileStorage_AutoCompact(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "auto_compact.json")
+
+	s, err := NewFileStorage(testFile, WithCompactionPolicy(CompactionPolicy{GarbageRatio: 0.5, MinRecords: 4}))
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	store := s.(*FileStorage)
+	defer store.Close()
+
+	store.Save("id1", "https://example.com/1", "user")
+	store.Save("id2", "https://example.com/2", "user")
+	_ = store.DeleteURLs("user", []string{"id1"})
+	_ = store.DeleteURLs("user", []string{"id2"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if records, _ := store.Stats(); records == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			records, live := store.Stats()
+			t.Fatalf("expected automatic compaction to 2 records, got %d records (%d live)", records, live)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileStorage_LoadRecovery(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "recovery.json")
+
+	good, err := encodeRecord(record{UUID: "1", ShortURL: "good", OriginalURL: "https://good.example", UserID: "user"})
+	if err != nil {
+		t.Fatalf("failed to encode record: %v", err)
+	}
+	tampered, err := encodeRecord(record{UUID: "2", ShortURL: "bad", OriginalURL: "https://bad.example", UserID: "user"})
+	if err != nil {
+		t.Fatalf("failed to encode record: %v", err)
+	}
+	tampered = []byte(strings.Replace(string(tampered), "bad.example", "evil.example", 1))
+	legacy := `{"uuid":"3","short_url":"legacy","original_url":"https://legacy.example","user_id":"user","deleted_flag":false}` + "\n"
+	garbage := "not a json line\n"
+	torn := `{"uuid":"4","short_url":"torn","orig`
+
+	content := string(good) + string(tampered) + legacy + garbage + torn
+	if err := os.WriteFile(testFile, []byte(content), 0666); err != nil {
+		t.Fatalf("failed to write storage file: %v", err)
+	}
+
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	store := s.(*FileStorage)
+	defer store.Close()
+
+	stats := store.LoadStats()
+	if stats.Recovered != 2 || stats.Dropped != 2 || stats.TornBytes != int64(len(torn)) {
+		t.Errorf("unexpected load stats: %+v", stats)
+	}
+
+	if _, _, exists := store.Get("good"); !exists {
+		t.Error("expected record with valid checksum to be loaded")
+	}
+	if _, _, exists := store.Get("legacy"); !exists {
+		t.Error("expected legacy record without checksum to be loaded")
+	}
+	if _, _, exists := store.Get("bad"); exists {
+		t.Error("expected record with checksum mismatch to be dropped")
+	}
+
+	quarantined, err := os.ReadFile(testFile + ".corrupt")
+	if err != nil {
+		t.Fatalf("failed to read quarantine file: %v", err)
+	}
+	if !strings.Contains(string(quarantined), "evil.example") || !strings.Contains(string(quarantined), garbage) {
+		t.Errorf("unexpected quarantine content: %s", quarantined)
+	}
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read storage file: %v", err)
+	}
+	if strings.Contains(string(data), "torn") || strings.Contains(string(data), "evil.example") {
+		t.Errorf("expected corrupt records to be removed from storage file, got: %s", data)
+	}
+}
+
+func TestFileStorage_Format(t *testing.T) {
+	dir := t.TempDir()
+
+	// Контрольная сумма проверяется по байтам из файла, а не по повторной сериализации
+	payload := `{"short_url":"raw","uuid":"1","original_url":"https://raw.example","user_id":"user","unknown":1}`
+	framed := fmt.Sprintf("%08x\t%s\n", crc32.ChecksumIEEE([]byte(payload)), payload)
+	bare := `{"uuid":"2","short_url":"bare","original_url":"https://bare.example","user_id":"user","deleted_flag":false}` + "\n"
+
+	headered := filepath.Join(dir, "headered.json")
+	if err := os.WriteFile(headered, []byte(fileHeader+"\n"+framed+bare), 0666); err != nil {
+		t.Fatalf("failed to write storage file: %v", err)
+	}
+	s, err := NewFileStorage(headered)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if _, _, exists := s.Get("raw"); !exists {
+		t.Error("expected record with valid checksum of raw bytes to be loaded")
+	}
+	if _, _, exists := s.Get("bare"); exists {
+		t.Error("expected record without checksum to be dropped from a file with header")
+	}
+	if stats := s.(*FileStorage).LoadStats(); stats.Recovered != 1 || stats.Dropped != 1 {
+		t.Errorf("unexpected load stats: %+v", stats)
+	}
+	s.(*FileStorage).Close()
+
+	// Файл старого формата загружается целиком и переписывается с заголовком
+	legacy := filepath.Join(dir, "legacy.json")
+	if err := os.WriteFile(legacy, []byte(bare), 0666); err != nil {
+		t.Fatalf("failed to write storage file: %v", err)
+	}
+	s, err = NewFileStorage(legacy)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	if _, _, exists := s.Get("bare"); !exists {
+		t.Error("expected legacy record to be loaded")
+	}
+	s.(*FileStorage).Close()
+	data, err := os.ReadFile(legacy)
+	if err != nil {
+		t.Fatalf("failed to read storage file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), fileHeader+"\n") || strings.Contains(string(data), "\n{") {
+		t.Errorf("expected legacy file to be rewritten in the current format, got: %s", data)
+	}
+}
+
+func TestFileStorage_Lock(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "locked.json")
+	s, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	if _, err := NewFileStorage(testFile); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked while the storage is open, got %v", err)
+	}
+
+	// После уплотнения файл заменен, но блокировка по-прежнему действует
+	if err := s.(*FileStorage).Compact(); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+	if _, err := NewFileStorage(testFile); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked after compaction, got %v", err)
+	}
+
+	if err := s.(*FileStorage).Close(); err != nil {
+		t.Fatalf("failed to close storage: %v", err)
+	}
+	reopened, err := NewFileStorage(testFile)
+	if err != nil {
+		t.Fatalf("expected storage to open after close, got %v", err)
+	}
+	reopened.(*FileStorage).Close()
+}
+
+func TestFileStorage_SyncModes(t *testing.T) {
+	for _, mode := range []string{"none", "always", "group"} {
+		t.Run(mode, func(t *testing.T) {
+			syncMode, err := ParseSyncMode(mode)
+			if err != nil {
+				t.Fatalf("failed to parse sync mode: %v", err)
+			}
+
+			testFile := filepath.Join(t.TempDir(), "sync.json")
+			s, err := NewFileStorage(testFile, WithSync(syncMode, 5*time.Millisecond))
+			if err != nil {
+				t.Fatalf("failed to create file storage: %v", err)
+			}
+			store := s.(*FileStorage)
+
+			store.Save("id1", "https://example.com/1", "user")
+			if err := store.SaveBatch(map[string]string{"id2": "https://example.com/2"}, "user"); err != nil {
+				t.Errorf("failed to save batch: %v", err)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if err := store.Close(); err != nil {
+				t.Errorf("failed to close storage: %v", err)
+			}
+
+			reopened, err := NewFileStorage(testFile)
+			if err != nil {
+				t.Fatalf("failed to reopen file storage: %v", err)
+			}
+			defer reopened.(*FileStorage).Close()
+			if stats := reopened.(*FileStorage).LoadStats(); stats.Recovered != 2 {
+				t.Errorf("expected 2 recovered records, got %+v", stats)
+			}
+		})
+	}
+
+	if _, err := ParseSyncMode("sometimes"); err == nil {
+		t.Error("expected error for unknown sync mode")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockFile ничего не делает: на этой платформе блокировка файла хранилища не поддерживается
+func lockFile(*os.File) error {
+	return nil
+}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 	"uno/cmd/shortener/models"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -41,17 +43,90 @@ func NewPostgresStorage(conn *pgxpool.Pool) (Storage, error) {
 
 // Save сохраняет связь между сокращенным ID и оригинальным URL для конкретного пользователя
 // Игнорирует ошибки уникальности (URL уже существует)
-func (s *PostgresStorage) Save(shortID, originalURL, userID string) {
+func (s *PostgresStorage) Save(shortID, originalURL, userID string) error {
 	_, err := s.pool.Exec(context.Background(),
 		`INSERT INTO public.short_urls (id, original_url, user_id) VALUES ($1, $2, $3)`,
 		shortID, originalURL, userID,
 	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return nil
+	}
+	return err
+}
+
+// SaveLink сохраняет новую ссылку вместе с метаданными и параметрами перенаправления
+// одним запросом INSERT; нарушение уникальности ID или оригинального URL - ErrConflict
+func (s *PostgresStorage) SaveLink(shortID, originalURL, userID string, meta models.LinkMetadata, opts models.LinkOptions) error {
+	options, err := opts.MarshalJSON()
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			return
+		return err
+	}
+	// NULL в tags не допускается схемой, поэтому отсутствие тегов - пустой массив
+	tags := meta.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	_, err = s.pool.Exec(context.Background(),
+		`INSERT INTO public.short_urls (id, original_url, user_id, title, tags, note, options)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		shortID, originalURL, userID, meta.Title, tags, meta.Note, options,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return ErrConflict
+	}
+	return err
+}
+
+// Restore сохраняет ссылку из выгрузки вместе со всем ее состоянием в одной транзакции:
+// ссылка, ее история адресов и счетчики переходов появляются вместе
+func (s *PostgresStorage) Restore(e Entry) error {
+	options, err := e.Options.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	ctx := context.Background()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO public.short_urls (id, original_url, user_id, is_deleted, title, tags, note, options, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.ShortURL, e.OriginalURL, e.UserID, e.Deleted, e.Title, tags, e.Note, options, e.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	for _, v := range e.History {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO public.short_url_versions (short_id, version, original_url, replaced_at) VALUES ($1, $2, $3, $4)`,
+			e.ShortURL, v.Version, v.OriginalURL, v.ReplacedAt)
+		if err != nil {
+			return err
 		}
 	}
+	for variant, clicks := range e.Clicks {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO public.short_url_clicks (short_id, variant, clicks) VALUES ($1, $2, $3)`,
+			e.ShortURL, variant, clicks)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
 }
 
 // FindByOriginal ищет существующий сокращенный ID для оригинального URL
@@ -71,24 +146,33 @@ func (s *PostgresStorage) FindByOriginal(originalURL string) (string, bool) {
 }
 
 // SaveBatch сохраняет пакет URL для конкретного пользователя
-// Использует batch операции для оптимизации производительности
+// Использует batch операции для оптимизации производительности. Строка, нарушающая
+// любое ограничение уникальности (в том числе оригинальный URL удаленной ссылки),
+// пропускается без отката остальных и попадает в *BatchError
 func (s *PostgresStorage) SaveBatch(pairs map[string]string, userID string) error {
 	batch := &pgx.Batch{}
+	ids := make([]string, 0, len(pairs))
 	for shortID, originalURL := range pairs {
 		batch.Queue(`INSERT INTO public.short_urls (id, original_url, user_id) VALUES ($1, $2, $3)
-                     ON CONFLICT (id) DO NOTHING`, shortID, originalURL, userID)
+                     ON CONFLICT DO NOTHING`, shortID, originalURL, userID)
+		ids = append(ids, shortID)
 	}
 
 	br := s.pool.SendBatch(context.Background(), batch)
 	defer br.Close()
 
-	for range pairs {
-		if _, err := br.Exec(); err != nil {
+	var skipped []string
+	for _, shortID := range ids {
+		tag, err := br.Exec()
+		if err != nil {
 			return err
 		}
+		if tag.RowsAffected() == 0 {
+			skipped = append(skipped, shortID)
+		}
 	}
 
-	return nil
+	return batchError(skipped)
 }
 
 // Get возвращает оригинальный URL по сокращенному ID, флаг удаления и существования
@@ -113,7 +197,7 @@ func (s *PostgresStorage) Get(shortID string) (string, bool, bool) {
 // GetUserURLs возвращает все не удаленные URL для конкретного пользователя
 func (s *PostgresStorage) GetUserURLs(userID string) ([]models.UserURL, error) {
 	rows, err := s.pool.Query(context.Background(),
-		`SELECT id, original_url FROM public.short_urls WHERE user_id = $1 AND is_deleted = false`, userID)
+		`SELECT id, original_url, title, tags, note, created_at FROM public.short_urls WHERE user_id = $1 AND is_deleted = false`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -121,14 +205,11 @@ func (s *PostgresStorage) GetUserURLs(userID string) ([]models.UserURL, error) {
 
 	var result []models.UserURL
 	for rows.Next() {
-		var id, original string
-		if err := rows.Scan(&id, &original); err != nil {
+		var u models.UserURL
+		if err := rows.Scan(&u.ShortURL, &u.OriginalURL, &u.Title, &u.Tags, &u.Note, &u.CreatedAt); err != nil {
 			continue
 		}
-		result = append(result, models.UserURL{
-			ShortURL:    id,
-			OriginalURL: original,
-		})
+		result = append(result, u)
 	}
 	return result, nil
 }
@@ -147,6 +228,299 @@ func (s *PostgresStorage) DeleteURLs(userID string, shortIDs []string) error {
 	return nil
 }
 
+// ForEach последовательно передает в fn все ссылки хранилища, включая удаленные
+// Строки читаются из базы потоком в порядке сокращенных ID; история адресов
+// и счетчики переходов каждой ссылки собираются в JSON тем же запросом
+func (s *PostgresStorage) ForEach(fn func(Entry) error) error {
+	rows, err := s.pool.Query(context.Background(),
+		`SELECT u.id, u.original_url, u.user_id, u.is_deleted, u.title, u.tags, u.note, u.options, u.created_at,
+                (SELECT json_agg(json_build_object('version', v.version, 'original_url', v.original_url, 'replaced_at', v.replaced_at)
+                        ORDER BY v.version)
+                 FROM public.short_url_versions v WHERE v.short_id = u.id),
+                (SELECT json_object_agg(c.variant, c.clicks) FROM public.short_url_clicks c WHERE c.short_id = u.id)
+         FROM public.short_urls u ORDER BY u.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entry
+		var options, history, clicks []byte
+		if err := rows.Scan(&e.ShortURL, &e.OriginalURL, &e.UserID, &e.Deleted, &e.Title, &e.Tags, &e.Note, &options, &e.CreatedAt, &history, &clicks); err != nil {
+			return err
+		}
+		if err := e.Options.UnmarshalJSON(options); err != nil {
+			return fmt.Errorf("invalid options of %s: %w", e.ShortURL, err)
+		}
+		if history != nil {
+			if err := json.Unmarshal(history, &e.History); err != nil {
+				return fmt.Errorf("invalid history of %s: %w", e.ShortURL, err)
+			}
+			for i := range e.History {
+				e.History[i].ReplacedAt = e.History[i].ReplacedAt.UTC()
+			}
+		}
+		if clicks != nil {
+			if err := json.Unmarshal(clicks, &e.Clicks); err != nil {
+				return fmt.Errorf("invalid clicks of %s: %w", e.ShortURL, err)
+			}
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateMetadata заменяет метаданные не удаленной ссылки пользователя
+func (s *PostgresStorage) UpdateMetadata(userID, shortID string, meta models.LinkMetadata) error {
+	// NULL в tags не допускается схемой, поэтому отсутствие тегов - пустой массив
+	tags := meta.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	commandTag, err := s.pool.Exec(context.Background(),
+		`UPDATE public.short_urls SET title = $3, tags = $4, note = $5
+         WHERE user_id = $1 AND id = $2 AND is_deleted = false`,
+		userID, shortID, meta.Title, tags, meta.Note)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateURL заменяет оригинальный URL не удаленной ссылки пользователя
+// В одной транзакции прежний адрес записывается в short_url_versions, а новый -
+// в short_urls; уникальность оригинальных URL обеспечивает ограничение UNIQUE
+func (s *PostgresStorage) UpdateURL(userID, shortID, originalURL string) error {
+	ctx := context.Background()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous string
+	err = tx.QueryRow(ctx,
+		`SELECT original_url FROM public.short_urls
+         WHERE id = $1 AND user_id = $2 AND is_deleted = false FOR UPDATE`, shortID, userID,
+	).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if previous == originalURL {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO public.short_url_versions (short_id, version, original_url, replaced_at)
+         SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3 FROM public.short_url_versions WHERE short_id = $1`,
+		shortID, previous, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE public.short_urls SET original_url = $2 WHERE id = $1`, shortID, originalURL)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrConflict
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetHistory возвращает предыдущие адреса не удаленной ссылки пользователя
+func (s *PostgresStorage) GetHistory(userID, shortID string) ([]models.URLVersion, error) {
+	var owned bool
+	err := s.pool.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM public.short_urls WHERE id = $1 AND user_id = $2 AND is_deleted = false)`,
+		shortID, userID,
+	).Scan(&owned)
+	if err != nil {
+		return nil, err
+	}
+	if !owned {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.pool.Query(context.Background(),
+		`SELECT version, original_url, replaced_at FROM public.short_url_versions
+         WHERE short_id = $1 ORDER BY version`, shortID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.URLVersion
+	for rows.Next() {
+		var v models.URLVersion
+		if err := rows.Scan(&v.Version, &v.OriginalURL, &v.ReplacedAt); err != nil {
+			return nil, err
+		}
+		v.ReplacedAt = v.ReplacedAt.UTC()
+		history = append(history, v)
+	}
+	return history, rows.Err()
+}
+
+// GetLink возвращает ссылку с владельцем, названием, датой создания и параметрами перенаправления
+func (s *PostgresStorage) GetLink(shortID string) (models.Link, error) {
+	link := models.Link{ShortURL: shortID}
+	var options []byte
+	err := s.pool.QueryRow(context.Background(),
+		`SELECT original_url, user_id, is_deleted, title, created_at, options FROM public.short_urls WHERE id = $1`, shortID,
+	).Scan(&link.OriginalURL, &link.UserID, &link.Deleted, &link.Title, &link.CreatedAt, &options)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Link{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Link{}, err
+	}
+	if err := link.LinkOptions.UnmarshalJSON(options); err != nil {
+		return models.Link{}, fmt.Errorf("invalid options of %s: %w", shortID, err)
+	}
+	return link, nil
+}
+
+// UpdateOptions заменяет параметры перенаправления не удаленной ссылки пользователя
+// Параметры хранятся в колонке options типа jsonb
+func (s *PostgresStorage) UpdateOptions(userID, shortID string, opts models.LinkOptions) error {
+	options, err := opts.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	commandTag, err := s.pool.Exec(context.Background(),
+		`UPDATE public.short_urls SET options = $3 WHERE user_id = $1 AND id = $2 AND is_deleted = false`,
+		userID, shortID, options)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PatchOptions изменяет заданные поля параметров перенаправления одним запросом UPDATE:
+// ключи jsonb, которые затрагивает patch, заменяются, остальные не изменяются
+func (s *PostgresStorage) PatchOptions(userID, shortID string, patch models.OptionsPatch) (models.LinkOptions, error) {
+	var keys []string
+	if patch.RedirectCode != nil {
+		keys = append(keys, "redirect_code")
+	}
+	if patch.QueryPolicy != nil {
+		keys = append(keys, "query_policy")
+	}
+	if patch.Interstitial != nil {
+		keys = append(keys, "interstitial")
+	}
+	return s.updateOptionKeys(userID, shortID, patch.Apply(models.LinkOptions{}), keys)
+}
+
+// UpdateRules заменяет правила перенаправления ссылки, не затрагивая остальные ключи options
+func (s *PostgresStorage) UpdateRules(userID, shortID string, rules []models.RedirectRule) error {
+	_, err := s.updateOptionKeys(userID, shortID, models.LinkOptions{Rules: rules}, []string{"rules"})
+	return err
+}
+
+// UpdateVariants заменяет варианты ссылки, не затрагивая остальные ключи options
+func (s *PostgresStorage) UpdateVariants(userID, shortID string, variants []models.Variant) error {
+	_, err := s.updateOptionKeys(userID, shortID, models.LinkOptions{Variants: variants}, []string{"variants"})
+	return err
+}
+
+// updateOptionKeys заменяет ключи keys колонки options ссылки значениями из opts
+// (пустое значение удаляет ключ) и возвращает параметры после изменения
+func (s *PostgresStorage) updateOptionKeys(userID, shortID string, opts models.LinkOptions, keys []string) (models.LinkOptions, error) {
+	value, err := opts.MarshalJSON()
+	if err != nil {
+		return models.LinkOptions{}, err
+	}
+	var options []byte
+	err = s.pool.QueryRow(context.Background(),
+		`UPDATE public.short_urls SET options = (options - $3::text[]) || $4::jsonb
+         WHERE user_id = $1 AND id = $2 AND is_deleted = false
+         RETURNING options`,
+		userID, shortID, keys, value,
+	).Scan(&options)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.LinkOptions{}, ErrNotFound
+	}
+	if err != nil {
+		return models.LinkOptions{}, err
+	}
+	var result models.LinkOptions
+	if err := result.UnmarshalJSON(options); err != nil {
+		return models.LinkOptions{}, fmt.Errorf("invalid options of %s: %w", shortID, err)
+	}
+	return result, nil
+}
+
+// AddClicks увеличивает счетчики переходов по ссылкам одним пакетом запросов
+// Счетчики хранятся в таблице short_url_clicks по строке на вариант ссылки
+func (s *PostgresStorage) AddClicks(clicks []models.ClickCount) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, c := range clicks {
+		batch.Queue(`INSERT INTO public.short_url_clicks (short_id, variant, clicks)
+                     SELECT id, $2, $3 FROM public.short_urls WHERE id = $1
+                     ON CONFLICT (short_id, variant) DO UPDATE
+                     SET clicks = short_url_clicks.clicks + EXCLUDED.clicks`, c.ShortURL, c.Variant, c.Clicks)
+	}
+
+	br := s.pool.SendBatch(context.Background(), batch)
+	defer br.Close()
+
+	for range clicks {
+		if _, err := br.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetClicks возвращает счетчики переходов ссылки по вариантам
+func (s *PostgresStorage) GetClicks(shortID string) (map[string]int64, error) {
+	var exists bool
+	err := s.pool.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM public.short_urls WHERE id = $1)`, shortID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.pool.Query(context.Background(),
+		`SELECT variant, clicks FROM public.short_url_clicks WHERE short_id = $1`, shortID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clicks := make(map[string]int64)
+	for rows.Next() {
+		var variant string
+		var n int64
+		if err := rows.Scan(&variant, &n); err != nil {
+			return nil, err
+		}
+		clicks[variant] = n
+	}
+	return clicks, rows.Err()
+}
+
 // RunDeletionWorker запускает воркер для асинхронной обработки задач на удаление URL
 // Обрабатывает задачи из очереди deleteQueue до завершения контекста
 func (s *PostgresStorage) RunDeletionWorker(ctx context.Context) {
@@ -167,7 +541,9 @@ func (s *PostgresStorage) RunDeletionWorker(ctx context.Context) {
 }
 
 // initSchema инициализирует схему базы данных
-// Создает таблицу short_urls, если она не существует
+// Создает таблицу short_urls, если она не существует, и добавляет
+// колонки метаданных и параметров перенаправления в таблицы, созданные до их появления.
+// Таблица short_url_versions хранит предыдущие адреса ссылок
 func (s *PostgresStorage) initSchema() error {
 	_, err := s.pool.Exec(context.Background(), `
         CREATE TABLE IF NOT EXISTS public.short_urls (
@@ -175,6 +551,27 @@ func (s *PostgresStorage) initSchema() error {
             original_url varchar UNIQUE NOT NULL,
             user_id varchar NOT NULL,
             is_deleted boolean DEFAULT false
+        );
+        ALTER TABLE public.short_urls
+            ADD COLUMN IF NOT EXISTS title varchar NOT NULL DEFAULT '',
+            ADD COLUMN IF NOT EXISTS tags text[] NOT NULL DEFAULT '{}',
+            ADD COLUMN IF NOT EXISTS note text NOT NULL DEFAULT '',
+            ADD COLUMN IF NOT EXISTS options jsonb NOT NULL DEFAULT '{}',
+            ADD COLUMN IF NOT EXISTS created_at timestamptz;
+        -- Значение по умолчанию задается отдельно, чтобы у существующих ссылок осталось NULL
+        ALTER TABLE public.short_urls ALTER COLUMN created_at SET DEFAULT now();
+        CREATE TABLE IF NOT EXISTS public.short_url_versions (
+            short_id varchar NOT NULL REFERENCES public.short_urls (id),
+            version integer NOT NULL,
+            original_url varchar NOT NULL,
+            replaced_at timestamptz NOT NULL,
+            PRIMARY KEY (short_id, version)
+        );
+        CREATE TABLE IF NOT EXISTS public.short_url_clicks (
+            short_id varchar NOT NULL REFERENCES public.short_urls (id),
+            variant varchar NOT NULL DEFAULT '',
+            clicks bigint NOT NULL DEFAULT 0,
+            PRIMARY KEY (short_id, variant)
         )
     `)
 	return err
@@ -1,23 +1,44 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 	"uno/cmd/shortener/models"
 )
 
 // Storage определяет интерфейс для хранения и управления сокращенными URL
 type Storage interface {
 	// Save сохраняет связь между сокращенным ID и оригинальным URL для конкретного пользователя
-	Save(shortID, originalURL, userID string)
+	// Уже сокращенный оригинальный URL пропускается без ошибки; ошибка означает,
+	// что ссылку не удалось надежно сохранить (например, не удалась запись или fsync)
+	Save(shortID, originalURL, userID string) error
 
 	// Get возвращает оригинальный URL по сокращенному ID, флаг удаления и существования
 	Get(shortID string) (originalURL string, deleted bool, exists bool)
 
+	// SaveLink сохраняет новую ссылку пользователя вместе с метаданными и параметрами
+	// перенаправления одной операцией, поэтому ссылка не бывает доступна без своих
+	// параметров (например, без пароля). Возвращает ErrConflict, если сокращенный ID
+	// или оригинальный URL уже заняты другой ссылкой (в том числе удаленной)
+	SaveLink(shortID, originalURL, userID string, meta models.LinkMetadata, opts models.LinkOptions) error
+
+	// Restore сохраняет ссылку из выгрузки вместе со всем ее состоянием одной операцией:
+	// флагом удаления, метаданными, параметрами, моментом создания, историей адресов
+	// и счетчиками переходов.
+	// Возвращает ErrConflict, если сокращенный ID или оригинальный URL уже заняты
+	Restore(e Entry) error
+
 	// FindByOriginal ищет существующий сокращенный ID для оригинального URL
 	FindByOriginal(originalURL string) (string, bool)
 
 	// SaveBatch сохраняет пакет URL для конкретного пользователя
+	// Ссылки, сокращенный ID или оригинальный URL которых уже заняты, пропускаются:
+	// их ID перечисляются в ошибке *BatchError, остальные ссылки пакета сохранены
 	SaveBatch(pairs map[string]string, userID string) error
 
 	// GetUserURLs возвращает все URL для конкретного пользователя
@@ -25,14 +46,118 @@ type Storage interface {
 
 	// DeleteURLs помечает указанные URL как удаленные для конкретного пользователя
 	DeleteURLs(userID string, ids []string) error
+
+	// ForEach последовательно передает в fn все ссылки хранилища, включая удаленные
+	// Обход прекращается при первой ошибке fn, и эта ошибка возвращается
+	ForEach(fn func(Entry) error) error
+
+	// UpdateMetadata заменяет метаданные не удаленной ссылки пользователя
+	// Возвращает ErrNotFound, если у пользователя нет такой ссылки
+	UpdateMetadata(userID, shortID string, meta models.LinkMetadata) error
+
+	// UpdateURL заменяет оригинальный URL не удаленной ссылки пользователя,
+	// сохраняя прежний адрес в истории версий
+	// Возвращает ErrNotFound, если у пользователя нет такой ссылки, и ErrConflict,
+	// если originalURL уже принадлежит другой ссылке (в том числе удаленной)
+	UpdateURL(userID, shortID, originalURL string) error
+
+	// GetHistory возвращает предыдущие адреса не удаленной ссылки пользователя
+	// от старых к новым. Возвращает ErrNotFound, если у пользователя нет такой ссылки
+	GetHistory(userID, shortID string) ([]models.URLVersion, error)
+
+	// GetLink возвращает ссылку с владельцем, названием, датой создания и параметрами
+	// перенаправления, в том числе удаленную. Возвращает ErrNotFound, если ссылки нет
+	GetLink(shortID string) (models.Link, error)
+
+	// UpdateOptions заменяет параметры перенаправления не удаленной ссылки пользователя
+	// Возвращает ErrNotFound, если у пользователя нет такой ссылки
+	UpdateOptions(userID, shortID string, opts models.LinkOptions) error
+
+	// PatchOptions изменяет поля параметров перенаправления, заданные в patch, одной
+	// операцией: остальные параметры, в том числе записанные одновременно другим запросом,
+	// не затираются. Возвращает параметры после изменения или ErrNotFound,
+	// если у пользователя нет такой ссылки
+	PatchOptions(userID, shortID string, patch models.OptionsPatch) (models.LinkOptions, error)
+
+	// UpdateRules заменяет правила перенаправления не удаленной ссылки пользователя,
+	// не затрагивая остальные параметры. Возвращает ErrNotFound, если у пользователя нет такой ссылки
+	UpdateRules(userID, shortID string, rules []models.RedirectRule) error
+
+	// UpdateVariants заменяет варианты не удаленной ссылки пользователя,
+	// не затрагивая остальные параметры. Возвращает ErrNotFound, если у пользователя нет такой ссылки
+	UpdateVariants(userID, shortID string, variants []models.Variant) error
+
+	// AddClicks увеличивает счетчики переходов по ссылкам на указанные значения
+	// Приращения для ссылок, которых нет в хранилище, пропускаются
+	AddClicks(clicks []models.ClickCount) error
+
+	// GetClicks возвращает счетчики переходов ссылки по именам вариантов;
+	// переходы по ссылке без вариантов учитываются под пустым именем
+	GetClicks(shortID string) (map[string]int64, error)
+}
+
+// ErrNotFound возвращается, если ссылка не найдена или принадлежит другому пользователю
+var ErrNotFound = errors.New("link not found")
+
+// ErrConflict возвращается, если оригинальный URL уже принадлежит другой ссылке
+var ErrConflict = errors.New("original URL is already shortened")
+
+// BatchError возвращается SaveBatch, если часть ссылок пакета не сохранена,
+// потому что их сокращенный ID или оригинальный URL уже заняты
+type BatchError struct {
+	IDs []string // Сокращенные ID несохраненных ссылок
+}
+
+// Error возвращает описание ошибки
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d links of the batch are already taken", len(e.IDs))
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrConflict
+func (e *BatchError) Unwrap() error {
+	return ErrConflict
+}
+
+// batchError возвращает *BatchError для непустого списка несохраненных ID и nil иначе
+func batchError(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return &BatchError{IDs: ids}
+}
+
+// Entry представляет ссылку вместе с владельцем и флагом удаления
+// Используется для выгрузки и загрузки данных между хранилищами
+type Entry struct {
+	ShortURL    string   `json:"short_url"`       // Сокращенный ID
+	OriginalURL string   `json:"original_url"`    // Оригинальный URL
+	UserID      string   `json:"user_id"`         // Идентификатор владельца
+	Deleted     bool     `json:"deleted"`         // Флаг удаления
+	Title       string   `json:"title,omitempty"` // Название ссылки
+	Tags        []string `json:"tags,omitempty"`  // Теги ссылки
+	Note        string   `json:"note,omitempty"`  // Заметка к ссылке
+
+	Options   models.LinkOptions  `json:"options,omitzero"`     // Параметры перенаправления
+	CreatedAt *time.Time          `json:"created_at,omitempty"` // Момент создания (nil - неизвестен)
+	History   []models.URLVersion `json:"history,omitempty"`    // Предыдущие адреса от старых к новым
+	Clicks    map[string]int64    `json:"clicks,omitempty"`     // Переходы по вариантам ("" - без вариантов)
+}
+
+// Metadata возвращает метаданные ссылки
+func (e Entry) Metadata() models.LinkMetadata {
+	return models.LinkMetadata{Title: e.Title, Tags: e.Tags, Note: e.Note}
 }
 
 // InMemoryStorage реализует интерфейс Storage с хранением данных в памяти
 type InMemoryStorage struct {
-	data    map[string]string           // Сокращенный ID -> оригинальный URL
-	users   map[string][]models.UserURL // Пользователь -> список его URL
-	deleted map[string]bool             // Сокращенный ID -> флаг удаления
-	mu      sync.RWMutex                // Мьютекс для безопасного доступа к данным
+	data    map[string]string              // Сокращенный ID -> оригинальный URL
+	users   map[string][]models.UserURL    // Пользователь -> список его URL
+	deleted map[string]bool                // Сокращенный ID -> флаг удаления
+	history map[string][]models.URLVersion // Сокращенный ID -> предыдущие адреса
+	owners  map[string]string              // Сокращенный ID -> идентификатор владельца
+	options map[string]models.LinkOptions  // Сокращенный ID -> параметры перенаправления
+	clicks  map[string]map[string]int64    // Сокращенный ID -> вариант -> количество переходов
+	mu      sync.RWMutex                   // Мьютекс для безопасного доступа к данным
 }
 
 // NewInMemoryStorage создает новый экземпляр InMemoryStorage
@@ -41,19 +166,75 @@ func NewInMemoryStorage() *InMemoryStorage {
 		data:    make(map[string]string),
 		users:   make(map[string][]models.UserURL),
 		deleted: make(map[string]bool),
+		history: make(map[string][]models.URLVersion),
+		owners:  make(map[string]string),
+		options: make(map[string]models.LinkOptions),
+		clicks:  make(map[string]map[string]int64),
 	}
 }
 
 // Save сохраняет связь между сокращенным ID и оригинальным URL для конкретного пользователя
-func (s *InMemoryStorage) Save(shortID, originalURL, userID string) {
+func (s *InMemoryStorage) Save(shortID, originalURL, userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.data[shortID] = originalURL
 	s.users[userID] = append(s.users[userID], models.UserURL{
 		ShortURL:    shortID,
 		OriginalURL: originalURL,
+		CreatedAt:   createdNow(),
 	})
 	s.deleted[shortID] = false
+	s.owners[shortID] = userID
+	return nil
+}
+
+// SaveLink сохраняет новую ссылку вместе с метаданными и параметрами перенаправления
+func (s *InMemoryStorage) SaveLink(shortID, originalURL, userID string, meta models.LinkMetadata, opts models.LinkOptions) error {
+	return s.Restore(Entry{
+		ShortURL:    shortID,
+		OriginalURL: originalURL,
+		UserID:      userID,
+		Title:       meta.Title,
+		Tags:        meta.Tags,
+		Note:        meta.Note,
+		Options:     opts,
+		CreatedAt:   createdNow(),
+	})
+}
+
+// Restore сохраняет ссылку из выгрузки вместе со всем ее состоянием
+func (s *InMemoryStorage) Restore(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[e.ShortURL]; ok {
+		return ErrConflict
+	}
+	for _, url := range s.data {
+		if url == e.OriginalURL {
+			return ErrConflict
+		}
+	}
+
+	s.data[e.ShortURL] = e.OriginalURL
+	s.users[e.UserID] = append(s.users[e.UserID], models.UserURL{
+		ShortURL:     e.ShortURL,
+		OriginalURL:  e.OriginalURL,
+		Deleted:      e.Deleted,
+		CreatedAt:    e.CreatedAt,
+		LinkMetadata: e.Metadata(),
+	})
+	s.deleted[e.ShortURL] = e.Deleted
+	s.owners[e.ShortURL] = e.UserID
+	if !e.Options.IsZero() {
+		s.options[e.ShortURL] = e.Options
+	}
+	if len(e.History) > 0 {
+		s.history[e.ShortURL] = slices.Clone(e.History)
+	}
+	if len(e.Clicks) > 0 {
+		s.clicks[e.ShortURL] = maps.Clone(e.Clicks)
+	}
+	return nil
 }
 
 // Get возвращает оригинальный URL по сокращенному ID, флаг удаления и существования
@@ -87,31 +268,39 @@ func (s *InMemoryStorage) FindByOriginal(originalURL string) (string, bool) {
 }
 
 // SaveBatch сохраняет пакет URL для конкретного пользователя
-// Обрабатывает каждый URL аналогично методу Save
+// Обрабатывает каждый URL аналогично методу Save; уже занятые ID перечисляются в *BatchError
 func (s *InMemoryStorage) SaveBatch(pairs map[string]string, userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	created := createdNow()
+	var skipped []string
 	for shortID, originalURL := range pairs {
+		if _, exists := s.data[shortID]; exists {
+			skipped = append(skipped, shortID)
+			continue
+		}
 		s.data[shortID] = originalURL
 		s.users[userID] = append(s.users[userID], models.UserURL{
 			ShortURL:    shortID,
 			OriginalURL: originalURL,
+			CreatedAt:   created,
 		})
 		s.deleted[shortID] = false
+		s.owners[shortID] = userID
 	}
-	return nil
+	return batchError(skipped)
 }
 
 // GetUserURLs возвращает все URL для конкретного пользователя
-// Возвращает ошибку, если пользователь не найден
+// Возвращает ошибку, если пользователь не найден; список - копия, которую можно изменять
 func (s *InMemoryStorage) GetUserURLs(userID string) ([]models.UserURL, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	urls, ok := s.users[userID]
 	if !ok {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("user not found: %w", ErrNotFound)
 	}
-	return urls, nil
+	return slices.Clone(urls), nil
 }
 
 // DeleteURLs помечает указанные URL как удаленные для конкретного пользователя
@@ -131,3 +320,215 @@ func (s *InMemoryStorage) DeleteURLs(userID string, ids []string) error {
 	}
 	return nil
 }
+
+// ForEach последовательно передает в fn все ссылки хранилища, включая удаленные
+// Ссылки выдаются по пользователям в порядке сортировки их идентификаторов
+func (s *InMemoryStorage) ForEach(fn func(Entry) error) error {
+	s.mu.RLock()
+	entries := make([]Entry, 0, len(s.data))
+	users := make([]string, 0, len(s.users))
+	for userID := range s.users {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+	for _, userID := range users {
+		for _, u := range s.users[userID] {
+			entries = append(entries, Entry{
+				ShortURL:    u.ShortURL,
+				OriginalURL: u.OriginalURL,
+				UserID:      userID,
+				Deleted:     u.Deleted,
+				Title:       u.Title,
+				Tags:        u.Tags,
+				Note:        u.Note,
+				Options:     s.options[u.ShortURL],
+				CreatedAt:   u.CreatedAt,
+				History:     slices.Clone(s.history[u.ShortURL]),
+				Clicks:      maps.Clone(s.clicks[u.ShortURL]),
+			})
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateMetadata заменяет метаданные не удаленной ссылки пользователя
+func (s *InMemoryStorage) UpdateMetadata(userID, shortID string, meta models.LinkMetadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.userURLIndexLocked(userID, shortID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.users[userID][i].LinkMetadata = meta
+	return nil
+}
+
+// UpdateURL заменяет оригинальный URL не удаленной ссылки пользователя
+// Прежний адрес добавляется в историю версий; замена на тот же адрес ничего не меняет
+func (s *InMemoryStorage) UpdateURL(userID, shortID, originalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.userURLIndexLocked(userID, shortID)
+	if i < 0 {
+		return ErrNotFound
+	}
+	current := s.users[userID][i].OriginalURL
+	if current == originalURL {
+		return nil
+	}
+	for id, url := range s.data {
+		if url == originalURL && id != shortID {
+			return ErrConflict
+		}
+	}
+
+	s.history[shortID] = appendVersion(s.history[shortID], current, time.Now())
+	s.data[shortID] = originalURL
+	s.users[userID][i].OriginalURL = originalURL
+	return nil
+}
+
+// GetHistory возвращает предыдущие адреса не удаленной ссылки пользователя
+func (s *InMemoryStorage) GetHistory(userID, shortID string) ([]models.URLVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.userURLIndexLocked(userID, shortID) < 0 {
+		return nil, ErrNotFound
+	}
+	return slices.Clone(s.history[shortID]), nil
+}
+
+// userURLIndexLocked возвращает индекс не удаленной ссылки в списке пользователя или -1
+// Вызывающий должен удерживать s.mu
+func (s *InMemoryStorage) userURLIndexLocked(userID, shortID string) int {
+	for i, u := range s.users[userID] {
+		if u.ShortURL == shortID && !u.Deleted {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendVersion добавляет замененный адрес в конец истории версий
+func appendVersion(history []models.URLVersion, originalURL string, replacedAt time.Time) []models.URLVersion {
+	return append(history, models.URLVersion{
+		Version:     len(history) + 1,
+		OriginalURL: originalURL,
+		ReplacedAt:  replacedAt.UTC(),
+	})
+}
+
+// createdNow возвращает момент создания новой ссылки
+func createdNow() *time.Time {
+	now := time.Now().UTC()
+	return &now
+}
+
+// GetLink возвращает ссылку с владельцем, названием, датой создания и параметрами перенаправления
+func (s *InMemoryStorage) GetLink(shortID string) (models.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	url, ok := s.data[shortID]
+	if !ok {
+		return models.Link{}, ErrNotFound
+	}
+	link := models.Link{
+		ShortURL:    shortID,
+		OriginalURL: url,
+		UserID:      s.owners[shortID],
+		Deleted:     s.deleted[shortID],
+		LinkOptions: s.options[shortID],
+	}
+	if i := s.userURLIndexLocked(link.UserID, shortID); i >= 0 {
+		u := s.users[link.UserID][i]
+		link.Title, link.CreatedAt = u.Title, u.CreatedAt
+	}
+	return link, nil
+}
+
+// UpdateOptions заменяет параметры перенаправления не удаленной ссылки пользователя
+func (s *InMemoryStorage) UpdateOptions(userID, shortID string, opts models.LinkOptions) error {
+	_, err := s.modifyOptions(userID, shortID, func(models.LinkOptions) models.LinkOptions { return opts })
+	return err
+}
+
+// PatchOptions изменяет заданные поля параметров перенаправления
+func (s *InMemoryStorage) PatchOptions(userID, shortID string, patch models.OptionsPatch) (models.LinkOptions, error) {
+	return s.modifyOptions(userID, shortID, patch.Apply)
+}
+
+// UpdateRules заменяет правила перенаправления ссылки
+func (s *InMemoryStorage) UpdateRules(userID, shortID string, rules []models.RedirectRule) error {
+	_, err := s.modifyOptions(userID, shortID, func(opts models.LinkOptions) models.LinkOptions {
+		opts.Rules = rules
+		return opts
+	})
+	return err
+}
+
+// UpdateVariants заменяет варианты ссылки
+func (s *InMemoryStorage) UpdateVariants(userID, shortID string, variants []models.Variant) error {
+	_, err := s.modifyOptions(userID, shortID, func(opts models.LinkOptions) models.LinkOptions {
+		opts.Variants = variants
+		return opts
+	})
+	return err
+}
+
+// modifyOptions заменяет параметры не удаленной ссылки пользователя результатом fn
+// от текущих параметров, не отпуская блокировку между чтением и записью
+func (s *InMemoryStorage) modifyOptions(userID, shortID string, fn func(models.LinkOptions) models.LinkOptions) (models.LinkOptions, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.userURLIndexLocked(userID, shortID) < 0 {
+		return models.LinkOptions{}, ErrNotFound
+	}
+	opts := fn(s.options[shortID])
+	if opts.IsZero() {
+		delete(s.options, shortID)
+	} else {
+		s.options[shortID] = opts
+	}
+	return opts, nil
+}
+
+// AddClicks увеличивает счетчики переходов по ссылкам
+func (s *InMemoryStorage) AddClicks(clicks []models.ClickCount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range clicks {
+		if _, ok := s.data[c.ShortURL]; !ok {
+			continue
+		}
+		addClick(s.clicks, c)
+	}
+	return nil
+}
+
+// GetClicks возвращает счетчики переходов ссылки по вариантам
+func (s *InMemoryStorage) GetClicks(shortID string) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.data[shortID]; !ok {
+		return nil, ErrNotFound
+	}
+	return maps.Clone(s.clicks[shortID]), nil
+}
+
+// addClick прибавляет приращение c к счетчикам clicks
+func addClick(clicks map[string]map[string]int64, c models.ClickCount) {
+	counts, ok := clicks[c.ShortURL]
+	if !ok {
+		counts = make(map[string]int64)
+		clicks[c.ShortURL] = counts
+	}
+	counts[c.Variant] += c.Clicks
+}
@@ -1,7 +1,11 @@
 package storage
 
 import (
+	"errors"
+	"reflect"
 	"testing"
+	"time"
+	"uno/cmd/shortener/models"
 )
 
 func TestNewInMemoryStorage(t *testing.T) {
@@ -114,6 +118,17 @@ func TestInMemoryStorage_GetUserURLs(t *testing.T) {
 	if len(urls) != 2 {
 		t.Errorf("Expected 2 URLs for user1, got %d", len(urls))
 	}
+	for _, u := range urls {
+		if u.CreatedAt == nil || u.CreatedAt.IsZero() {
+			t.Errorf("Expected creation time for %s", u.ShortURL)
+		}
+	}
+
+	// Изменение результата не затрагивает хранилище
+	urls[0].OriginalURL = "https://changed.com"
+	if again, _ := store.GetUserURLs("user1"); again[0].OriginalURL == "https://changed.com" {
+		t.Error("GetUserURLs should return a copy of the stored list")
+	}
 
 	// Test getting URLs for user2
 	urls, err = store.GetUserURLs("user2")
@@ -175,3 +190,372 @@ func TestInMemoryStorage_DeleteURLs(t *testing.T) {
 		t.Error("URL with ID id3 should not be deleted")
 	}
 }
+
+func TestInMemoryStorage_UpdateMetadata(t *testing.T) {
+	s := NewInMemoryStorage()
+	s.Save("id1", "https://example.com/1", "user1")
+
+	meta := models.LinkMetadata{Title: "Example", Tags: []string{"work"}}
+	if err := s.UpdateMetadata("user1", "id1", meta); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	urls, _ := s.GetUserURLs("user1")
+	if len(urls) != 1 || urls[0].Title != "Example" || !urls[0].HasTag("work") {
+		t.Errorf("unexpected user URLs: %+v", urls)
+	}
+
+	if err := s.UpdateMetadata("user2", "id1", meta); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	_ = s.DeleteURLs("user1", []string{"id1"})
+	if err := s.UpdateMetadata("user1", "id1", meta); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for deleted URL, got %v", err)
+	}
+}
+
+// testUpdateURL проверяет смену адреса ссылки и историю версий на хранилище s
+func testUpdateURL(t *testing.T, s Storage) {
+	t.Helper()
+	s.Save("id1", "https://example.com/typo", "user1")
+	s.Save("id2", "https://example.com/2", "user1")
+	s.Save("id3", "https://example.com/3", "user2")
+	if err := s.DeleteURLs("user2", []string{"id3"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+
+	if err := s.UpdateURL("user1", "id1", "https://example.com/1"); err != nil {
+		t.Fatalf("failed to update URL: %v", err)
+	}
+	if got, _, _ := s.Get("id1"); got != "https://example.com/1" {
+		t.Errorf("expected new destination, got %q", got)
+	}
+	if id, found := s.FindByOriginal("https://example.com/1"); !found || id != "id1" {
+		t.Errorf("expected new URL to be found as id1, got %q %v", id, found)
+	}
+	if id, found := s.FindByOriginal("https://example.com/typo"); found {
+		t.Errorf("expected previous URL to be released, got %q", id)
+	}
+
+	// Адрес другой ссылки, в том числе удаленной, занять нельзя
+	if err := s.UpdateURL("user1", "id1", "https://example.com/2"); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+	if err := s.UpdateURL("user1", "id1", "https://example.com/3"); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for deleted link URL, got %v", err)
+	}
+	if err := s.UpdateURL("user2", "id1", "https://example.com/x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	// Тот же адрес не создает новую версию
+	if err := s.UpdateURL("user1", "id1", "https://example.com/1"); err != nil {
+		t.Errorf("unexpected error for unchanged URL: %v", err)
+	}
+	// Освобожденный адрес можно вернуть
+	if err := s.UpdateURL("user1", "id1", "https://example.com/typo"); err != nil {
+		t.Fatalf("failed to revert URL: %v", err)
+	}
+
+	history, err := s.GetHistory("user1", "id1")
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(history) != 2 || history[0].Version != 1 || history[0].OriginalURL != "https://example.com/typo" ||
+		history[1].Version != 2 || history[1].OriginalURL != "https://example.com/1" || history[0].ReplacedAt.IsZero() {
+		t.Errorf("unexpected history: %+v", history)
+	}
+	if _, err := s.GetHistory("user2", "id1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+}
+
+func TestInMemoryStorage_UpdateURL(t *testing.T) {
+	testUpdateURL(t, NewInMemoryStorage())
+}
+
+// testLinkOptions проверяет сохранение параметров перенаправления на хранилище s
+func testLinkOptions(t *testing.T, s Storage) {
+	t.Helper()
+	s.Save("id1", "https://example.com/1", "user1")
+
+	if err := s.UpdateOptions("user2", "id1", models.LinkOptions{PasswordHash: "x"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	until := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := models.LinkOptions{PasswordHash: "hash", RedirectCode: 308, QueryPolicy: models.QueryOverride, Rules: []models.RedirectRule{
+		{Platform: models.PlatformIOS, URL: "https://apps.apple.com/app"},
+		{Languages: []string{"de", "pt-br"}, Until: &until, URL: "https://example.com/de"},
+	}}
+	if err := s.UpdateOptions("user1", "id1", opts); err != nil {
+		t.Fatalf("failed to update options: %v", err)
+	}
+
+	link, err := s.GetLink("id1")
+	if err != nil {
+		t.Fatalf("failed to get link: %v", err)
+	}
+	if link.OriginalURL != "https://example.com/1" || link.UserID != "user1" || link.Deleted || !reflect.DeepEqual(link.LinkOptions, opts) {
+		t.Errorf("unexpected link: %+v", link)
+	}
+	if _, err := s.GetLink("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing link, got %v", err)
+	}
+
+	if err := s.DeleteURLs("user1", []string{"id1"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+	if link, err := s.GetLink("id1"); err != nil || !link.Deleted || !reflect.DeepEqual(link.LinkOptions, opts) {
+		t.Errorf("expected deleted link with options, got %+v (%v)", link, err)
+	}
+	if err := s.UpdateOptions("user1", "id1", models.LinkOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for deleted link, got %v", err)
+	}
+}
+
+func TestInMemoryStorage_LinkOptions(t *testing.T) {
+	testLinkOptions(t, NewInMemoryStorage())
+}
+
+// testPatchOptions проверяет частичное изменение параметров перенаправления на хранилище s
+func testPatchOptions(t *testing.T, s Storage) {
+	t.Helper()
+	s.Save("id1", "https://example.com/1", "user1")
+	rules := []models.RedirectRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com/app"}}
+	if err := s.UpdateOptions("user1", "id1", models.LinkOptions{PasswordHash: "hash", Interstitial: true, Rules: rules}); err != nil {
+		t.Fatalf("failed to update options: %v", err)
+	}
+
+	code, policy, interstitial := 301, models.QueryAppend, false
+	opts, err := s.PatchOptions("user1", "id1", models.OptionsPatch{RedirectCode: &code, Interstitial: &interstitial})
+	if err != nil {
+		t.Fatalf("failed to patch options: %v", err)
+	}
+	want := models.LinkOptions{PasswordHash: "hash", RedirectCode: 301, Rules: rules}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("unexpected patched options: %+v", opts)
+	}
+	if link, _ := s.GetLink("id1"); !reflect.DeepEqual(link.LinkOptions, want) {
+		t.Errorf("expected password and rules to be kept, got %+v", link.LinkOptions)
+	}
+	if opts, err := s.PatchOptions("user1", "id1", models.OptionsPatch{QueryPolicy: &policy}); err != nil || opts.RedirectCode != 301 || opts.QueryPolicy != policy {
+		t.Errorf("expected redirect code to be kept, got %+v (%v)", opts, err)
+	}
+
+	if _, err := s.PatchOptions("user2", "id1", models.OptionsPatch{RedirectCode: &code}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	if _, err := s.PatchOptions("user1", "missing", models.OptionsPatch{RedirectCode: &code}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing link, got %v", err)
+	}
+}
+
+func TestInMemoryStorage_PatchOptions(t *testing.T) {
+	testPatchOptions(t, NewInMemoryStorage())
+}
+
+// testUpdateRules проверяет замену правил перенаправления на хранилище s
+func testUpdateRules(t *testing.T, s Storage) {
+	t.Helper()
+	s.Save("id1", "https://example.com/1", "user1")
+	variants := []models.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 1}}
+	if err := s.UpdateOptions("user1", "id1", models.LinkOptions{PasswordHash: "hash", RedirectCode: 308, Variants: variants}); err != nil {
+		t.Fatalf("failed to update options: %v", err)
+	}
+
+	rules := []models.RedirectRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com/app"}}
+	if err := s.UpdateRules("user1", "id1", rules); err != nil {
+		t.Fatalf("failed to update rules: %v", err)
+	}
+	want := models.LinkOptions{PasswordHash: "hash", RedirectCode: 308, Rules: rules, Variants: variants}
+	if link, _ := s.GetLink("id1"); !reflect.DeepEqual(link.LinkOptions, want) {
+		t.Errorf("expected other options to be kept, got %+v", link.LinkOptions)
+	}
+	if err := s.UpdateRules("user1", "id1", nil); err != nil {
+		t.Fatalf("failed to remove rules: %v", err)
+	}
+	if link, _ := s.GetLink("id1"); len(link.Rules) != 0 || link.PasswordHash != "hash" {
+		t.Errorf("expected rules to be removed, got %+v", link.LinkOptions)
+	}
+
+	if err := s.UpdateRules("user2", "id1", rules); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+}
+
+func TestInMemoryStorage_UpdateRules(t *testing.T) {
+	testUpdateRules(t, NewInMemoryStorage())
+}
+
+// testUpdateVariants проверяет замену вариантов ссылки на хранилище s
+func testUpdateVariants(t *testing.T, s Storage) {
+	t.Helper()
+	s.Save("id1", "https://example.com/1", "user1")
+	rules := []models.RedirectRule{{Platform: models.PlatformIOS, URL: "https://apps.apple.com/app"}}
+	if err := s.UpdateOptions("user1", "id1", models.LinkOptions{PasswordHash: "hash", Rules: rules}); err != nil {
+		t.Fatalf("failed to update options: %v", err)
+	}
+
+	variants := []models.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 1}}
+	if err := s.UpdateVariants("user1", "id1", variants); err != nil {
+		t.Fatalf("failed to update variants: %v", err)
+	}
+	want := models.LinkOptions{PasswordHash: "hash", Rules: rules, Variants: variants}
+	if link, _ := s.GetLink("id1"); !reflect.DeepEqual(link.LinkOptions, want) {
+		t.Errorf("expected other options to be kept, got %+v", link.LinkOptions)
+	}
+	if err := s.UpdateVariants("user1", "id1", nil); err != nil {
+		t.Fatalf("failed to remove variants: %v", err)
+	}
+	if link, _ := s.GetLink("id1"); len(link.Variants) != 0 || len(link.Rules) != 1 {
+		t.Errorf("expected variants to be removed, got %+v", link.LinkOptions)
+	}
+
+	if err := s.UpdateVariants("user2", "id1", variants); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+}
+
+func TestInMemoryStorage_UpdateVariants(t *testing.T) {
+	testUpdateVariants(t, NewInMemoryStorage())
+}
+
+// testSaveBatchConflicts проверяет, что SaveBatch пропускает занятые ID,
+// сохраняет остальные ссылки пакета и перечисляет пропущенные в *BatchError
+func testSaveBatchConflicts(t *testing.T, s Storage) {
+	t.Helper()
+	if err := s.SaveBatch(map[string]string{"id1": "https://example.com/1"}, "user1"); err != nil {
+		t.Fatalf("failed to save batch: %v", err)
+	}
+
+	err := s.SaveBatch(map[string]string{"id1": "https://example.com/other", "id2": "https://example.com/2"}, "user2")
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !reflect.DeepEqual(batchErr.IDs, []string{"id1"}) {
+		t.Fatalf("expected BatchError for id1, got %v", err)
+	}
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("expected BatchError to match ErrConflict")
+	}
+	if url, _, _ := s.Get("id1"); url != "https://example.com/1" {
+		t.Errorf("expected taken link to be kept, got %q", url)
+	}
+	if url, _, exists := s.Get("id2"); !exists || url != "https://example.com/2" {
+		t.Errorf("expected the rest of the batch to be saved, got %q", url)
+	}
+}
+
+func TestInMemoryStorage_SaveBatchConflicts(t *testing.T) {
+	testSaveBatchConflicts(t, NewInMemoryStorage())
+}
+
+// testSaveLink проверяет сохранение ссылки вместе с параметрами на хранилище s
+func testSaveLink(t *testing.T, s Storage) {
+	t.Helper()
+	meta := models.LinkMetadata{Title: "Docs", Tags: []string{"go"}, Note: "note"}
+	opts := models.LinkOptions{PasswordHash: "hash", Interstitial: true}
+	if err := s.SaveLink("id1", "https://example.com/1", "user1", meta, opts); err != nil {
+		t.Fatalf("failed to save link: %v", err)
+	}
+	if link, err := s.GetLink("id1"); err != nil || link.UserID != "user1" || !reflect.DeepEqual(link.LinkOptions, opts) {
+		t.Errorf("expected link with options, got %+v (%v)", link, err)
+	}
+	if urls, err := s.GetUserURLs("user1"); err != nil || len(urls) != 1 || !reflect.DeepEqual(urls[0].LinkMetadata, meta) || urls[0].CreatedAt == nil {
+		t.Errorf("expected link with metadata, got %+v (%v)", urls, err)
+	}
+
+	if err := s.SaveLink("id1", "https://example.com/2", "user2", models.LinkMetadata{}, models.LinkOptions{}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for taken ID, got %v", err)
+	}
+	if err := s.DeleteURLs("user1", []string{"id1"}); err != nil {
+		t.Fatalf("failed to delete URL: %v", err)
+	}
+	if err := s.SaveLink("id2", "https://example.com/1", "user2", models.LinkMetadata{}, models.LinkOptions{}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for URL of deleted link, got %v", err)
+	}
+	if _, _, exists := s.Get("id2"); exists {
+		t.Error("expected conflicting link not to be saved")
+	}
+}
+
+func TestInMemoryStorage_SaveLink(t *testing.T) {
+	testSaveLink(t, NewInMemoryStorage())
+}
+
+// testRestore проверяет загрузку ссылки из выгрузки на хранилище s
+func testRestore(t *testing.T, s Storage) {
+	t.Helper()
+	created := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	history := []models.URLVersion{
+		{Version: 1, OriginalURL: "https://example.com/v1", ReplacedAt: created.Add(time.Hour)},
+		{Version: 2, OriginalURL: "https://example.com/v2", ReplacedAt: created.Add(2 * time.Hour)},
+	}
+	entries := []Entry{
+		{ShortURL: "id1", OriginalURL: "https://example.com/v3", UserID: "user1", Title: "Docs", CreatedAt: &created, History: history,
+			Clicks: map[string]int64{"a": 7, "b": 3}},
+		{ShortURL: "id2", OriginalURL: "https://example.com/deleted", UserID: "user1", Deleted: true, Options: models.LinkOptions{PasswordHash: "hash"}},
+	}
+	for _, e := range entries {
+		if err := s.Restore(e); err != nil {
+			t.Fatalf("failed to restore %s: %v", e.ShortURL, err)
+		}
+	}
+	if err := s.Restore(Entry{ShortURL: "id3", OriginalURL: "https://example.com/deleted", UserID: "user2"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict for URL of a deleted link, got %v", err)
+	}
+
+	if got, err := s.GetHistory("user1", "id1"); err != nil || !reflect.DeepEqual(got, history) {
+		t.Errorf("expected history to be restored, got %+v (%v)", got, err)
+	}
+	if clicks, err := s.GetClicks("id1"); err != nil || clicks["a"] != 7 || clicks["b"] != 3 {
+		t.Errorf("expected clicks to be restored, got %v (%v)", clicks, err)
+	}
+	if link, err := s.GetLink("id2"); err != nil || !link.Deleted || link.PasswordHash != "hash" {
+		t.Errorf("expected deleted link with options, got %+v (%v)", link, err)
+	}
+	var restored []Entry
+	if err := s.ForEach(func(e Entry) error {
+		restored = append(restored, e)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to iterate links: %v", err)
+	}
+	if len(restored) != 2 || !reflect.DeepEqual(restored[0], entries[0]) || !reflect.DeepEqual(restored[1], entries[1]) {
+		t.Errorf("expected ForEach to return restored entries, got %+v", restored)
+	}
+}
+
+func TestInMemoryStorage_Restore(t *testing.T) {
+	testRestore(t, NewInMemoryStorage())
+}
+
+// testClicks проверяет счетчики переходов на хранилище s
+func testClicks(t *testing.T, s Storage) {
+	t.Helper()
+	s.Save("ab", "https://example.com/ab", "user1")
+	s.Save("plain", "https://example.com/plain", "user1")
+
+	if err := s.AddClicks([]models.ClickCount{
+		{ShortURL: "ab", Variant: "a", Clicks: 3},
+		{ShortURL: "ab", Variant: "b", Clicks: 1},
+		{ShortURL: "plain", Clicks: 2},
+		{ShortURL: "missing", Clicks: 5},
+	}); err != nil {
+		t.Fatalf("failed to add clicks: %v", err)
+	}
+	if err := s.AddClicks([]models.ClickCount{{ShortURL: "ab", Variant: "a", Clicks: 2}}); err != nil {
+		t.Fatalf("failed to add clicks: %v", err)
+	}
+
+	clicks, err := s.GetClicks("ab")
+	if err != nil || !reflect.DeepEqual(clicks, map[string]int64{"a": 5, "b": 1}) {
+		t.Errorf("unexpected clicks of ab: %v (%v)", clicks, err)
+	}
+	if clicks, err := s.GetClicks("plain"); err != nil || clicks[""] != 2 {
+		t.Errorf("unexpected clicks of plain: %v (%v)", clicks, err)
+	}
+	if _, err := s.GetClicks("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing link, got %v", err)
+	}
+}
+
+func TestInMemoryStorage_Clicks(t *testing.T) {
+	testClicks(t, NewInMemoryStorage())
+}
@@ -0,0 +1,384 @@
+// Package transfer реализует выгрузку и загрузку ссылок между хранилищами.
+//
+// Данные передаются потоком в версионированном формате JSONL или CSV и включают
+// владельца ссылки, флаг удаления, метаданные ссылки (название, теги и заметку),
+// параметры перенаправления (например, хэш пароля, правила и варианты), момент
+// создания, историю адресов и счетчики переходов по вариантам.
+// Загрузка идемпотентна: повторный импорт того же файла не создает дубликатов.
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"uno/cmd/shortener/storage"
+)
+
+// FormatVersion текущая версия формата выгрузки
+// Версия 2 добавила в CSV метаданные и параметры перенаправления, версия 3 -
+// момент создания, историю адресов и счетчики переходов; загрузка принимает выгрузки всех версий до текущей
+const FormatVersion = 3
+
+// formatName имя формата в заголовке выгрузки
+const formatName = "uno-export"
+
+// Поддерживаемые форматы выгрузки
+const (
+	FormatJSONL = "jsonl" // Заголовок и по одной JSON записи на строку
+	FormatCSV   = "csv"   // Строка-комментарий с версией, заголовок колонок и записи
+)
+
+// csvColumns колонки CSV выгрузки. Теги и история адресов записываются JSON массивами,
+// параметры перенаправления и счетчики переходов - JSON объектами, момент создания - в RFC 3339; пустая ячейка
+// означает отсутствие значения. Колонки новых версий добавляются в конец
+var csvColumns = []string{
+	"short_url", "original_url", "user_id", "deleted",
+	"title", "tags", "note", "options",
+	"created_at", "history", "clicks",
+}
+
+// csvVersionColumns количество колонок CSV выгрузки каждой версии
+var csvVersionColumns = map[int]int{1: 4, 2: 8, 3: len(csvColumns)}
+
+// header заголовок JSONL выгрузки
+type header struct {
+	Format  string `json:"format"`  // Имя формата, всегда uno-export
+	Version int    `json:"version"` // Версия формата
+}
+
+// Encoder записывает ссылки в поток выгрузки
+type Encoder interface {
+	// Encode записывает одну ссылку
+	Encode(e storage.Entry) error
+	// Flush сбрасывает буферизованные данные в поток
+	Flush() error
+}
+
+// Decoder читает ссылки из потока выгрузки
+type Decoder interface {
+	// Decode читает следующую ссылку; в конце потока возвращает io.EOF
+	Decode() (storage.Entry, error)
+}
+
+// NewEncoder создает Encoder для указанного формата и записывает заголовок
+func NewEncoder(w io.Writer, format string) (Encoder, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatJSONL:
+		enc := &jsonlEncoder{w: bw, enc: json.NewEncoder(bw)}
+		if err := enc.enc.Encode(header{Format: formatName, Version: FormatVersion}); err != nil {
+			return nil, err
+		}
+		return enc, nil
+	case FormatCSV:
+		if _, err := fmt.Fprintf(bw, "# %s v%d\n", formatName, FormatVersion); err != nil {
+			return nil, err
+		}
+		cw := csv.NewWriter(bw)
+		if err := cw.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: bw, cw: cw}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// NewDecoder создает Decoder для указанного формата и проверяет заголовок и версию
+func NewDecoder(r io.Reader, format string) (Decoder, error) {
+	br := bufio.NewReader(r)
+	switch format {
+	case FormatJSONL:
+		line, err := br.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		var h header
+		if err := json.Unmarshal(line, &h); err != nil || h.Format != formatName {
+			return nil, fmt.Errorf("not a %s JSONL stream", formatName)
+		}
+		if h.Version < 1 || h.Version > FormatVersion {
+			return nil, fmt.Errorf("unsupported format version %d", h.Version)
+		}
+		return &jsonlDecoder{dec: json.NewDecoder(br)}, nil
+	case FormatCSV:
+		line, err := br.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		var version int
+		if _, err := fmt.Sscanf(strings.TrimSpace(line), "# "+formatName+" v%d", &version); err != nil {
+			return nil, fmt.Errorf("not a %s CSV stream", formatName)
+		}
+		if version < 1 || version > FormatVersion {
+			return nil, fmt.Errorf("unsupported format version %d", version)
+		}
+		expected := csvColumns[:csvVersionColumns[version]]
+		cr := csv.NewReader(br)
+		cr.FieldsPerRecord = len(expected)
+		columns, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV columns: %w", err)
+		}
+		if strings.Join(columns, ",") != strings.Join(expected, ",") {
+			return nil, fmt.Errorf("unexpected CSV columns %v", columns)
+		}
+		return &csvDecoder{cr: cr}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// jsonlEncoder записывает ссылки в формате JSONL
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *jsonlEncoder) Encode(entry storage.Entry) error {
+	return e.enc.Encode(entry)
+}
+
+func (e *jsonlEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+// jsonlDecoder читает ссылки в формате JSONL
+type jsonlDecoder struct {
+	dec *json.Decoder
+}
+
+func (d *jsonlDecoder) Decode() (storage.Entry, error) {
+	var e storage.Entry
+	err := d.dec.Decode(&e)
+	return e, err
+}
+
+// csvEncoder записывает ссылки в формате CSV
+type csvEncoder struct {
+	w  *bufio.Writer
+	cw *csv.Writer
+}
+
+func (e *csvEncoder) Encode(entry storage.Entry) error {
+	var tags, options, created, history, clicks string
+	if len(entry.Tags) > 0 {
+		data, err := json.Marshal(entry.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to encode tags: %w", err)
+		}
+		tags = string(data)
+	}
+	if !entry.Options.IsZero() {
+		data, err := json.Marshal(entry.Options)
+		if err != nil {
+			return fmt.Errorf("failed to encode options: %w", err)
+		}
+		options = string(data)
+	}
+	if entry.CreatedAt != nil {
+		created = entry.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	if len(entry.History) > 0 {
+		data, err := json.Marshal(entry.History)
+		if err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+		history = string(data)
+	}
+	if len(entry.Clicks) > 0 {
+		data, err := json.Marshal(entry.Clicks)
+		if err != nil {
+			return fmt.Errorf("failed to encode clicks: %w", err)
+		}
+		clicks = string(data)
+	}
+	return e.cw.Write([]string{
+		entry.ShortURL,
+		entry.OriginalURL,
+		entry.UserID,
+		strconv.FormatBool(entry.Deleted),
+		entry.Title,
+		tags,
+		entry.Note,
+		options,
+		created,
+		history,
+		clicks,
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.cw.Flush()
+	if err := e.cw.Error(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// csvDecoder читает ссылки в формате CSV
+type csvDecoder struct {
+	cr *csv.Reader
+}
+
+func (d *csvDecoder) Decode() (storage.Entry, error) {
+	row, err := d.cr.Read()
+	if err != nil {
+		return storage.Entry{}, err
+	}
+	deleted, err := strconv.ParseBool(row[3])
+	if err != nil {
+		return storage.Entry{}, fmt.Errorf("invalid deleted flag %q: %w", row[3], err)
+	}
+	e := storage.Entry{ShortURL: row[0], OriginalURL: row[1], UserID: row[2], Deleted: deleted}
+	if len(row) > 4 {
+		e.Title, e.Note = row[4], row[6]
+		if row[5] != "" {
+			if err := json.Unmarshal([]byte(row[5]), &e.Tags); err != nil {
+				return storage.Entry{}, fmt.Errorf("invalid tags %q: %w", row[5], err)
+			}
+		}
+		if row[7] != "" {
+			if err := json.Unmarshal([]byte(row[7]), &e.Options); err != nil {
+				return storage.Entry{}, fmt.Errorf("invalid options: %w", err)
+			}
+		}
+	}
+	if len(row) > 8 {
+		if row[8] != "" {
+			created, err := time.Parse(time.RFC3339Nano, row[8])
+			if err != nil {
+				return storage.Entry{}, fmt.Errorf("invalid created_at %q: %w", row[8], err)
+			}
+			e.CreatedAt = &created
+		}
+		if row[9] != "" {
+			if err := json.Unmarshal([]byte(row[9]), &e.History); err != nil {
+				return storage.Entry{}, fmt.Errorf("invalid history: %w", err)
+			}
+		}
+		if row[10] != "" {
+			if err := json.Unmarshal([]byte(row[10]), &e.Clicks); err != nil {
+				return storage.Entry{}, fmt.Errorf("invalid clicks: %w", err)
+			}
+		}
+	}
+	return e, nil
+}
+
+// progressEvery периодичность вызова функции прогресса в записях
+const progressEvery = 1000
+
+// Export выгружает все ссылки хранилища src через enc
+// progress, если задан, вызывается с количеством выгруженных ссылок каждые 1000 записей
+func Export(src storage.Storage, enc Encoder, progress func(n int)) (int, error) {
+	n := 0
+	err := src.ForEach(func(e storage.Entry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		n++
+		if progress != nil && n%progressEvery == 0 {
+			progress(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, enc.Flush()
+}
+
+// ImportStats содержит результат загрузки ссылок
+type ImportStats struct {
+	Read      int // Прочитано записей
+	Created   int // Создано новых ссылок
+	Updated   int // Существующие ссылки, помеченные удаленными
+	Skipped   int // Ссылки, уже присутствующие в хранилище
+	Conflicts int // Ссылки, конфликтующие с существующими по ID или оригинальному URL
+}
+
+// ImportOptions задает параметры загрузки
+type ImportOptions struct {
+	DryRun   bool              // Только подсчитать изменения, не записывая их
+	Progress func(ImportStats) // Вызывается каждые 1000 прочитанных записей
+}
+
+// Import загружает ссылки из dec в хранилище dst
+// Ссылка, уже существующая с тем же оригинальным URL, пропускается (или помечается
+// удаленной, если удалена в выгрузке). Ссылка, чей ID или оригинальный URL уже занят
+// другой ссылкой, считается конфликтом и не загружается
+func Import(dst storage.Storage, dec Decoder, opts ImportOptions) (ImportStats, error) {
+	var stats ImportStats
+	for {
+		e, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, fmt.Errorf("record %d: %w", stats.Read+1, err)
+		}
+		stats.Read++
+
+		if err := importEntry(dst, e, opts.DryRun, &stats); err != nil {
+			return stats, fmt.Errorf("record %d (%s): %w", stats.Read, e.ShortURL, err)
+		}
+		if opts.Progress != nil && stats.Read%progressEvery == 0 {
+			opts.Progress(stats)
+		}
+	}
+}
+
+// importEntry загружает одну ссылку и обновляет статистику
+func importEntry(dst storage.Storage, e storage.Entry, dryRun bool, stats *ImportStats) error {
+	if e.ShortURL == "" || e.OriginalURL == "" || e.UserID == "" {
+		return fmt.Errorf("short_url, original_url and user_id are required")
+	}
+
+	originalURL, deleted, exists := dst.Get(e.ShortURL)
+	if exists {
+		switch {
+		case originalURL != e.OriginalURL:
+			stats.Conflicts++
+		case e.Deleted && !deleted:
+			if !dryRun {
+				if err := dst.DeleteURLs(e.UserID, []string{e.ShortURL}); err != nil {
+					return err
+				}
+			}
+			stats.Updated++
+		default:
+			stats.Skipped++
+		}
+		return nil
+	}
+
+	if id, found := dst.FindByOriginal(e.OriginalURL); found && id != e.ShortURL {
+		stats.Conflicts++
+		return nil
+	}
+	if dryRun {
+		stats.Created++
+		return nil
+	}
+
+	// Ссылка сохраняется со всем состоянием одной операцией, поэтому ссылка
+	// с паролем не бывает доступна без него, а удаленная - доступной даже на время загрузки.
+	// Оригинальный URL может быть занят удаленной ссылкой, которую FindByOriginal не находит
+	err := dst.Restore(e)
+	if errors.Is(err, storage.ErrConflict) {
+		stats.Conflicts++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	stats.Created++
+	return nil
+}
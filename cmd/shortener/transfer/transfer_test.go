@@ -0,0 +1,166 @@
+package transfer
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+)
+
+func newSource() storage.Storage {
+	src := storage.NewInMemoryStorage()
+	src.Save("id1", "https://example.com/1", "user1")
+	src.Save("id2", "https://example.com/2", "user1")
+	src.Save("id3", "https://example.com/3", "user2")
+	_ = src.UpdateMetadata("user2", "id3", models.LinkMetadata{Title: "Third", Tags: []string{"a", "b"}})
+	_ = src.UpdateOptions("user2", "id3", models.LinkOptions{
+		PasswordHash: "hash",
+		RedirectCode: 307,
+		Rules:        []models.RedirectRule{{Languages: []string{"de"}, URL: "https://example.com/de"}},
+		Variants:     []models.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}, {Name: "b", URL: "https://example.com/b", Weight: 3}},
+	})
+	_ = src.UpdateURL("user2", "id3", "https://example.com/3/v2")
+	_ = src.UpdateURL("user2", "id3", "https://example.com/3/v3")
+	_ = src.AddClicks([]models.ClickCount{{ShortURL: "id3", Variant: "a", Clicks: 5}, {ShortURL: "id3", Variant: "b", Clicks: 2}, {ShortURL: "id1", Clicks: 9}})
+	_ = src.DeleteURLs("user1", []string{"id2"})
+	return src
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(&buf, format)
+			if err != nil {
+				t.Fatalf("failed to create encoder: %v", err)
+			}
+			src := newSource()
+			n, err := Export(src, enc, nil)
+			if err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+			if n != 3 {
+				t.Errorf("expected 3 exported records, got %d", n)
+			}
+
+			dst, err := storage.NewFileStorage(filepath.Join(t.TempDir(), "db.json"))
+			if err != nil {
+				t.Fatalf("failed to create file storage: %v", err)
+			}
+			defer dst.(*storage.FileStorage).Close()
+
+			data := buf.Bytes()
+			dec, err := NewDecoder(bytes.NewReader(data), format)
+			if err != nil {
+				t.Fatalf("failed to create decoder: %v", err)
+			}
+			stats, err := Import(dst, dec, ImportOptions{})
+			if err != nil {
+				t.Fatalf("import failed: %v", err)
+			}
+			if stats.Read != 3 || stats.Created != 3 {
+				t.Errorf("unexpected import stats: %+v", stats)
+			}
+
+			if _, deleted, exists := dst.Get("id2"); !exists || !deleted {
+				t.Errorf("expected id2 to be imported as deleted")
+			}
+			urls, err := dst.GetUserURLs("user2")
+			if err != nil || len(urls) != 1 || urls[0].ShortURL != "id3" {
+				t.Errorf("expected ownership to be preserved, got %+v (%v)", urls, err)
+			}
+			if len(urls) == 1 && (urls[0].Title != "Third" || strings.Join(urls[0].Tags, ",") != "a,b") {
+				t.Errorf("expected metadata to be preserved, got %+v", urls[0].LinkMetadata)
+			}
+			srcURLs, _ := src.GetUserURLs("user2")
+			if len(urls) == 1 && (urls[0].CreatedAt == nil || !urls[0].CreatedAt.Equal(*srcURLs[0].CreatedAt)) {
+				t.Errorf("expected creation time %v to be preserved, got %v", srcURLs[0].CreatedAt, urls[0].CreatedAt)
+			}
+			srcHistory, _ := src.GetHistory("user2", "id3")
+			history, err := dst.GetHistory("user2", "id3")
+			if err != nil || len(history) != 2 || history[1].OriginalURL != "https://example.com/3/v2" || !history[0].ReplacedAt.Equal(srcHistory[0].ReplacedAt) {
+				t.Errorf("expected history to be preserved, got %+v (%v)", history, err)
+			}
+			if clicks, err := dst.GetClicks("id3"); err != nil || clicks["a"] != 5 || clicks["b"] != 2 {
+				t.Errorf("expected variant clicks to be preserved, got %v (%v)", clicks, err)
+			}
+			if clicks, err := dst.GetClicks("id1"); err != nil || clicks[""] != 9 {
+				t.Errorf("expected clicks to be preserved, got %v (%v)", clicks, err)
+			}
+			link, _ := dst.GetLink("id3")
+			if link.PasswordHash != "hash" || link.RedirectCode != 307 || len(link.Rules) != 1 || link.Rules[0].URL != "https://example.com/de" ||
+				len(link.Variants) != 2 || link.Variants[1].Weight != 3 {
+				t.Errorf("expected options to be preserved, got %+v", link.LinkOptions)
+			}
+
+			// Повторный импорт не должен ничего менять
+			dec, err = NewDecoder(bytes.NewReader(data), format)
+			if err != nil {
+				t.Fatalf("failed to create decoder: %v", err)
+			}
+			stats, err = Import(dst, dec, ImportOptions{})
+			if err != nil {
+				t.Fatalf("second import failed: %v", err)
+			}
+			if stats.Created != 0 || stats.Skipped != 3 {
+				t.Errorf("expected idempotent import, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestImportDryRunAndConflicts(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf, FormatJSONL)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	if _, err := Export(newSource(), enc, nil); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	dst := storage.NewInMemoryStorage()
+	dst.Save("id1", "https://other.example", "someone")
+	dst.Save("other", "https://example.com/3/v3", "someone")
+
+	dec, err := NewDecoder(bytes.NewReader(buf.Bytes()), FormatJSONL)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+	stats, err := Import(dst, dec, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if stats.Conflicts != 2 || stats.Created != 1 {
+		t.Errorf("unexpected dry run stats: %+v", stats)
+	}
+	if _, _, exists := dst.Get("id2"); exists {
+		t.Error("dry run must not write to storage")
+	}
+}
+
+func TestDecodeVersion1CSV(t *testing.T) {
+	input := "# uno-export v1\nshort_url,original_url,user_id,deleted\nid1,https://example.com/1,user1,true\n"
+	dec, err := NewDecoder(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+	e, err := dec.Decode()
+	if err != nil || e.ShortURL != "id1" || e.UserID != "user1" || !e.Deleted {
+		t.Errorf("unexpected entry %+v (%v)", e, err)
+	}
+}
+
+func TestNewDecoderRejectsUnknownInput(t *testing.T) {
+	if _, err := NewDecoder(strings.NewReader(`{"format":"uno-export","version":99}`+"\n"), FormatJSONL); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+	if _, err := NewDecoder(strings.NewReader("short_url,original_url\n"), FormatCSV); err == nil {
+		t.Error("expected error for CSV without version header")
+	}
+	if _, err := NewEncoder(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Ограничения пользовательских сокращенных ID
+const (
+	MinAliasLength = 3           // Минимальная длина
+	MaxAliasLength = maxIDLength // Максимальная длина
+)
+
+// ErrInvalidAlias возвращается, если пользовательский сокращенный ID не проходит проверку
+var ErrInvalidAlias = errors.New("invalid alias")
+
+// reservedAliases первые сегменты путей сервиса, которые не могут быть сокращенными ID
+var reservedAliases = map[string]bool{"api": true, "ping": true}
+
+// ValidateAlias проверяет пользовательский сокращенный ID: от MinAliasLength
+// до MaxAliasLength латинских букв, цифр, "-" и "_", не совпадающий с путями сервиса
+func ValidateAlias(alias string) error {
+	if len(alias) < MinAliasLength || len(alias) > MaxAliasLength {
+		return fmt.Errorf("%w: length must be from %d to %d characters", ErrInvalidAlias, MinAliasLength, MaxAliasLength)
+	}
+	for _, c := range alias {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("%w: unexpected character %q", ErrInvalidAlias, c)
+		}
+	}
+	if reservedAliases[strings.ToLower(alias)] {
+		return fmt.Errorf("%w: %q is reserved", ErrInvalidAlias, alias)
+	}
+	return nil
+}
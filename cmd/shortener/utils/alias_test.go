@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	for _, alias := range []string{"abc", "Spring-Sale_2026", strings.Repeat("a", MaxAliasLength)} {
+		if err := ValidateAlias(alias); err != nil {
+			t.Errorf("ValidateAlias(%q) = %v, want nil", alias, err)
+		}
+	}
+	for _, alias := range []string{"", "ab", strings.Repeat("a", MaxAliasLength+1), "sale+", "a/b", "промо", "API", "ping"} {
+		if err := ValidateAlias(alias); !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("ValidateAlias(%q) = %v, want ErrInvalidAlias", alias, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"uno/cmd/shortener/models"
+)
+
+// DetectPlatform определяет платформу клиента по заголовку User-Agent
+// Возвращает одну из констант models.Platform* или пустую строку
+func DetectPlatform(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		return models.PlatformIOS
+	// Android проверяется раньше Linux: его User-Agent содержит оба слова
+	case strings.Contains(userAgent, "Android"):
+		return models.PlatformAndroid
+	case strings.Contains(userAgent, "Windows"):
+		return models.PlatformWindows
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		return models.PlatformMacOS
+	case strings.Contains(userAgent, "Linux"), strings.Contains(userAgent, "X11"), strings.Contains(userAgent, "CrOS"):
+		return models.PlatformLinux
+	}
+	return ""
+}
+
+// PreferredLanguage возвращает язык с наибольшим весом q из заголовка Accept-Language
+// в нижнем регистре; при равных весах побеждает указанный раньше
+// Для пустого заголовка или только "*" возвращает пустую строку
+func PreferredLanguage(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(name) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
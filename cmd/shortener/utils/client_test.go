@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+	"uno/cmd/shortener/models"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{"iphone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15", models.PlatformIOS},
+		{"ipad", "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15", models.PlatformIOS},
+		{"android", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile", models.PlatformAndroid},
+		{"windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0", models.PlatformWindows},
+		{"macos", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_1) AppleWebKit/605.1.15 Safari/605.1.15", models.PlatformMacOS},
+		{"linux", "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", models.PlatformLinux},
+		{"curl", "curl/8.4.0", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectPlatform(tt.userAgent); got != tt.want {
+				t.Errorf("DetectPlatform(%q) = %q, want %q", tt.userAgent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"*", ""},
+		{"de-AT", "de-at"},
+		{"en-US,en;q=0.9,de;q=0.8", "en-us"},
+		{"fr;q=0.5, pt-BR;q=0.9, *;q=1", "pt-br"},
+		{"es;q=0.7,it;q=0.7", "es"},
+		{"ru;q=0,uk", "uk"},
+		{"nl;q=bad", ""},
+	}
+	for _, tt := range tests {
+		if got := PreferredLanguage(tt.header); got != tt.want {
+			t.Errorf("PreferredLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
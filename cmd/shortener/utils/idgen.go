@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Поддерживаемые стратегии генерации сокращенных ID
+const (
+	StrategyRandom     = "random"     // Случайный base62 ID
+	StrategySequential = "sequential" // Счетчик в биективной base62 с перемешанным алфавитом
+	StrategyHash       = "hash"       // Хэш канонического URL: один URL - один ID
+)
+
+// Параметры автоматического увеличения длины ID
+const (
+	maxIDLength      = 32   // Максимальная длина ID
+	maxAttempts      = 8    // Попыток на одной длине до ее принудительного увеличения
+	growWindow       = 1000 // Количество генераций, по которым оценивается доля коллизий
+	growCollisionPct = 10   // Доля коллизий в процентах, при которой длина увеличивается
+)
+
+// ErrNoUniqueID возвращается, если не удалось подобрать свободный ID даже на максимальной длине
+var ErrNoUniqueID = errors.New("failed to generate unique short ID")
+
+// Strategy формирует кандидатов в сокращенные ID
+type Strategy interface {
+	// Generate возвращает кандидата длиной не меньше length для originalURL
+	// attempt - номер попытки для этого URL, начиная с 0; стратегия должна
+	// выдавать разных кандидатов для разных попыток
+	Generate(originalURL string, length, attempt int) (string, error)
+}
+
+// NewStrategy создает стратегию по имени: random, sequential или hash
+// salt перемешивает алфавит стратегии sequential, start задает первое значение ее счетчика
+func NewStrategy(name, salt string, start uint64) (Strategy, error) {
+	switch name {
+	case "", StrategyRandom:
+		return RandomStrategy{}, nil
+	case StrategySequential:
+		return NewSequentialStrategy(salt, start), nil
+	case StrategyHash:
+		return HashStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown ID strategy %q", name)
+	}
+}
+
+// RandomStrategy генерирует случайные ID из криптографически стойкого источника
+type RandomStrategy struct{}
+
+// Generate возвращает случайный ID длиной length
+func (RandomStrategy) Generate(_ string, length, _ int) (string, error) {
+	return randomID(length)
+}
+
+// randomID генерирует случайный base62 ID одним чтением из crypto/rand
+// Байты не меньше 248 (4*62) отбрасываются, чтобы распределение символов было равномерным
+func randomID(length int) (string, error) {
+	id := make([]byte, 0, length)
+	buf := make([]byte, length+length/4+1)
+	for len(id) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b >= 248 {
+				continue
+			}
+			id = append(id, charset[b%62])
+			if len(id) == length {
+				break
+			}
+		}
+	}
+	return string(id), nil
+}
+
+// SequentialStrategy выдает значения счетчика в биективной base62 записи
+// Алфавит перемешивается солью, а каждый следующий разряд кодируется алфавитом,
+// сдвинутым на сумму предыдущих, поэтому соседние ID не выглядят последовательными.
+// Кодирование взаимно однозначно: разные значения счетчика дают разные ID
+type SequentialStrategy struct {
+	alphabet string
+	counter  atomic.Uint64
+}
+
+// NewSequentialStrategy создает стратегию со счетчиком, начинающимся с start
+func NewSequentialStrategy(salt string, start uint64) *SequentialStrategy {
+	s := &SequentialStrategy{alphabet: shuffleAlphabet(charset, salt)}
+	if start > 0 {
+		s.counter.Store(start - 1)
+	}
+	return s
+}
+
+// Generate возвращает следующее значение счетчика длиной не меньше length
+func (s *SequentialStrategy) Generate(_ string, length, _ int) (string, error) {
+	return s.encode(s.counter.Add(1), length), nil
+}
+
+// encode записывает n в биективной base62
+// К n прибавляется количество всех строк короче length, поэтому результат не короче length
+func (s *SequentialStrategy) encode(n uint64, length int) string {
+	v := new(big.Int).SetUint64(n)
+	pow := big.NewInt(1)
+	base := big.NewInt(int64(len(s.alphabet)))
+	for i := 1; i < length; i++ {
+		pow.Mul(pow, base)
+		v.Add(v, pow)
+	}
+
+	// Биективная запись: разряды 1..62 вместо 0..61, поэтому нет ведущих нулей
+	var digits []int
+	one := big.NewInt(1)
+	mod := new(big.Int)
+	for v.Sign() > 0 {
+		v.Sub(v, one)
+		v.DivMod(v, base, mod)
+		digits = append(digits, int(mod.Int64()))
+	}
+
+	id := make([]byte, len(digits))
+	shift := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		id[len(digits)-1-i] = s.alphabet[(d+shift)%len(s.alphabet)]
+		shift += d + 1
+	}
+	return string(id)
+}
+
+// shuffleAlphabet детерминированно перемешивает алфавит солью (как в Hashids)
+func shuffleAlphabet(alphabet, salt string) string {
+	a := []byte(alphabet)
+	if salt == "" {
+		return alphabet
+	}
+	for i, v, p := len(a)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		c := int(salt[v])
+		p += c
+		j := (c + v + p) % i
+		a[i], a[j] = a[j], a[i]
+		v++
+	}
+	return string(a)
+}
+
+// HashStrategy строит ID из SHA-256 канонического URL
+// Первая попытка для одного и того же URL всегда дает один и тот же ID
+type HashStrategy struct{}
+
+// Generate возвращает ID длиной length из хэша URL и номера попытки
+func (HashStrategy) Generate(originalURL string, length, attempt int) (string, error) {
+	data := CanonicalURL(originalURL)
+	if attempt > 0 {
+		data += "#" + fmt.Sprint(attempt)
+	}
+
+	id := make([]byte, 0, length)
+	for block := uint32(0); len(id) < length; block++ {
+		var prefix [4]byte
+		binary.BigEndian.PutUint32(prefix[:], block)
+		sum := sha256.Sum256(append(prefix[:], data...))
+		for _, b := range sum {
+			if b >= 248 {
+				continue
+			}
+			id = append(id, charset[b%62])
+			if len(id) == length {
+				break
+			}
+		}
+	}
+	return string(id), nil
+}
+
+// CanonicalURL приводит URL к канонической форме: схема и хост в нижнем регистре,
+// без порта по умолчанию и фрагмента, пустой путь заменяется на "/"
+func CanonicalURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = host + ":" + port
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// IDGenerator подбирает свободные сокращенные ID с помощью стратегии
+// При коллизии со storage пробует следующего кандидата, а если доля коллизий
+// за последние генерации превышает порог, увеличивает длину ID
+type IDGenerator struct {
+	strategy Strategy
+	length   atomic.Int32
+
+	mu         sync.Mutex
+	generated  int
+	collisions int
+}
+
+// NewIDGenerator создает генератор со стратегией strategy и начальной длиной length
+func NewIDGenerator(strategy Strategy, length int) *IDGenerator {
+	if length <= 0 {
+		length = idLength
+	}
+	g := &IDGenerator{strategy: strategy}
+	g.length.Store(int32(length))
+	return g
+}
+
+// Length возвращает текущую длину генерируемых ID
+func (g *IDGenerator) Length() int {
+	return int(g.length.Load())
+}
+
+// Generate возвращает ID для originalURL, для которого exists возвращает false
+// exists может быть nil, тогда проверка занятости не выполняется
+func (g *IDGenerator) Generate(originalURL string, exists func(id string) bool) (string, error) {
+	attempt := 0
+	for {
+		length := g.Length()
+		for i := 0; i < maxAttempts; i++ {
+			id, err := g.strategy.Generate(originalURL, length, attempt)
+			if err != nil {
+				return "", err
+			}
+			attempt++
+			if exists == nil || !exists(id) {
+				g.record(attempt - 1)
+				return id, nil
+			}
+		}
+
+		// Все попытки на текущей длине заняты: увеличиваем длину
+		if length >= maxIDLength {
+			return "", ErrNoUniqueID
+		}
+		g.length.CompareAndSwap(int32(length), int32(length+1))
+	}
+}
+
+// record учитывает количество коллизий одной генерации и при необходимости увеличивает длину
+func (g *IDGenerator) record(collisions int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.generated++
+	g.collisions += collisions
+	if g.generated < growWindow {
+		return
+	}
+	if g.collisions*100 > g.generated*growCollisionPct {
+		if length := g.Length(); length < maxIDLength {
+			g.length.CompareAndSwap(int32(length), int32(length+1))
+		}
+	}
+	g.generated, g.collisions = 0, 0
+}
+
+// defaultGenerator генератор, используемый обработчиками
+var defaultGenerator atomic.Pointer[IDGenerator]
+
+func init() {
+	defaultGenerator.Store(NewIDGenerator(RandomStrategy{}, idLength))
+}
+
+// SetDefaultGenerator задает генератор, используемый NewShortID
+func SetDefaultGenerator(g *IDGenerator) {
+	defaultGenerator.Store(g)
+}
+
+// NewShortID подбирает свободный сокращенный ID для originalURL генератором по умолчанию
+// exists сообщает, занят ли ID в хранилище
+func NewShortID(originalURL string, exists func(id string) bool) (string, error) {
+	return defaultGenerator.Load().Generate(originalURL, exists)
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandomStrategy(t *testing.T) {
+	for _, length := range []int{1, 8, 20} {
+		id, err := RandomStrategy{}.Generate("", length, 0)
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if len(id) != length {
+			t.Errorf("expected length %d, got %q", length, id)
+		}
+		if strings.Trim(id, charset) != "" {
+			t.Errorf("invalid characters in %q", id)
+		}
+	}
+}
+
+func TestSequentialStrategy(t *testing.T) {
+	s := NewSequentialStrategy("salt", 1)
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		id, _ := s.Generate("", 4, 0)
+		if len(id) < 4 {
+			t.Fatalf("expected length at least 4, got %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %q at step %d", id, i)
+		}
+		seen[id] = true
+	}
+
+	// Одинаковые соль и стартовое значение дают одинаковую последовательность
+	a, _ := NewSequentialStrategy("salt", 42).Generate("", 6, 0)
+	b, _ := NewSequentialStrategy("salt", 42).Generate("", 6, 0)
+	c, _ := NewSequentialStrategy("other", 42).Generate("", 6, 0)
+	if a != b {
+		t.Errorf("expected deterministic IDs, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Errorf("expected salt to change IDs, got %q for both", a)
+	}
+}
+
+func TestHashStrategy(t *testing.T) {
+	a, _ := HashStrategy{}.Generate("HTTPS://Example.com:443", 8, 0)
+	b, _ := HashStrategy{}.Generate("https://example.com/", 8, 0)
+	if a != b {
+		t.Errorf("expected equal IDs for equivalent URLs, got %q and %q", a, b)
+	}
+	retry, _ := HashStrategy{}.Generate("https://example.com/", 8, 1)
+	if retry == a {
+		t.Error("expected different ID for next attempt")
+	}
+	other, _ := HashStrategy{}.Generate("https://example.org/", 8, 0)
+	if other == a {
+		t.Error("expected different IDs for different URLs")
+	}
+}
+
+func TestIDGenerator_RetriesAndGrows(t *testing.T) {
+	g := NewIDGenerator(NewSequentialStrategy("", 1), 1)
+
+	// Все односимвольные ID заняты: генератор должен перейти на длину 2
+	exists := func(id string) bool { return len(id) == 1 }
+	id, err := g.Generate("https://example.com", exists)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(id) != 2 || g.Length() != 2 {
+		t.Errorf("expected length to grow to 2, got ID %q and length %d", id, g.Length())
+	}
+
+	taken := map[string]bool{}
+	h := NewIDGenerator(HashStrategy{}, 8)
+	for i := 0; i < 3; i++ {
+		id, err := h.Generate("https://example.com", func(id string) bool { return taken[id] })
+		if err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+		if taken[id] {
+			t.Fatalf("generator returned taken ID %q", id)
+		}
+		taken[id] = true
+	}
+}
+
+func TestIDGenerator_GrowsOnCollisionRate(t *testing.T) {
+	g := NewIDGenerator(RandomStrategy{}, 4)
+	calls := 0
+	// Каждая вторая проверка - коллизия
+	exists := func(string) bool {
+		calls++
+		return calls%2 == 1
+	}
+	for i := 0; i < growWindow; i++ {
+		if _, err := g.Generate("", exists); err != nil {
+			t.Fatalf("Generate returned error: %v", err)
+		}
+	}
+	if g.Length() != 5 {
+		t.Errorf("expected length to grow to 5, got %d", g.Length())
+	}
+}
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range []string{"", StrategyRandom, StrategySequential, StrategyHash} {
+		if _, err := NewStrategy(name, "", 1); err != nil {
+			t.Errorf("NewStrategy(%q) returned error: %v", name, err)
+		}
+	}
+	if _, err := NewStrategy("uuid", "", 1); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
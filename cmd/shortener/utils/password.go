@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Параметры хэширования паролей ссылок
+const (
+	passwordScheme  = "pbkdf2-sha256"
+	passwordSaltLen = 16
+	passwordKeyLen  = 32
+
+	// MaxPasswordLength максимальная длина пароля ссылки в байтах
+	MaxPasswordLength = 128
+)
+
+// passwordIterations количество итераций PBKDF2 (рекомендация OWASP для SHA-256)
+// Хранится в самом хэше, поэтому изменение не ломает проверку старых паролей
+var passwordIterations = 600_000
+
+// ErrInvalidPassword возвращается для пустого или слишком длинного пароля
+var ErrInvalidPassword = errors.New("invalid password")
+
+// HashPassword вычисляет медленный хэш пароля со случайной солью
+// Результат имеет вид pbkdf2-sha256$<итерации>$<соль>$<ключ> (base64 без выравнивания)
+func HashPassword(password string) (string, error) {
+	if password == "" || len(password) > MaxPasswordLength {
+		return "", fmt.Errorf("%w: length must be from 1 to %d bytes", ErrInvalidPassword, MaxPasswordLength)
+	}
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeyLen)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("%s$%d$%s$%s", passwordScheme, passwordIterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// CheckPassword проверяет пароль по хэшу, созданному HashPassword
+// Неизвестный или поврежденный формат хэша считается несовпадением
+func CheckPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme || len(password) > MaxPasswordLength {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$600000$") {
+		t.Errorf("unexpected hash format: %s", hash)
+	}
+	if !CheckPassword(hash, "secret") {
+		t.Error("expected correct password to match")
+	}
+	if CheckPassword(hash, "Secret") {
+		t.Error("expected wrong password not to match")
+	}
+
+	other, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	if other == hash {
+		t.Error("expected different salts for equal passwords")
+	}
+
+	for _, p := range []string{"", strings.Repeat("x", MaxPasswordLength+1)} {
+		if _, err := HashPassword(p); !errors.Is(err, ErrInvalidPassword) {
+			t.Errorf("expected ErrInvalidPassword for %d bytes, got %v", len(p), err)
+		}
+	}
+}
+
+func TestCheckPassword_Malformed(t *testing.T) {
+	for _, hash := range []string{"", "plain", "md5$1$c2FsdA$a2V5", "pbkdf2-sha256$x$c2FsdA$a2V5", "pbkdf2-sha256$1$!!$a2V5", "pbkdf2-sha256$1$c2FsdA$"} {
+		if CheckPassword(hash, "secret") {
+			t.Errorf("expected malformed hash %q not to match", hash)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"uno/cmd/shortener/models"
+)
+
+// MergeQuery переносит query параметры incoming (строка запроса короткой ссылки
+// без "?") в адрес назначения target
+//
+// models.QueryAppend добавляет параметры после параметров адреса назначения,
+// models.QueryOverride сначала убирает из адреса назначения одноименные параметры.
+// Остальная часть адреса, порядок и кодирование его параметров сохраняются как есть.
+// Для пустого incoming, другой политики или неразбираемого адреса target
+// возвращается без изменений
+func MergeQuery(target, incoming, policy string) string {
+	if incoming == "" || policy != models.QueryAppend && policy != models.QueryOverride {
+		return target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	base := u.RawQuery
+	if policy == models.QueryOverride && base != "" {
+		replaced := make(map[string]bool)
+		for _, pair := range strings.Split(incoming, "&") {
+			replaced[queryKey(pair)] = true
+		}
+		var kept []string
+		for _, pair := range strings.Split(base, "&") {
+			if !replaced[queryKey(pair)] {
+				kept = append(kept, pair)
+			}
+		}
+		base = strings.Join(kept, "&")
+	}
+
+	if base == "" {
+		u.RawQuery = incoming
+	} else {
+		u.RawQuery = base + "&" + incoming
+	}
+	return u.String()
+}
+
+// queryKey возвращает раскодированное имя параметра пары "key=value"
+func queryKey(pair string) string {
+	key, _, _ := strings.Cut(pair, "=")
+	if k, err := url.QueryUnescape(key); err == nil {
+		return k
+	}
+	return key
+}
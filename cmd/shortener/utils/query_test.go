@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"uno/cmd/shortener/models"
+)
+
+func TestMergeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		incoming string
+		policy   string
+		want     string
+	}{
+		{"ignore", "https://a.example/p?x=1", "utm_source=x", models.QueryIgnore, "https://a.example/p?x=1"},
+		{"empty policy", "https://a.example/p", "utm_source=x", "", "https://a.example/p"},
+		{"no incoming", "https://a.example/p?x=1", "", models.QueryAppend, "https://a.example/p?x=1"},
+		{"append to empty", "https://a.example/p", "utm_source=x", models.QueryAppend, "https://a.example/p?utm_source=x"},
+		{"append keeps both", "https://a.example/p?b=2&a=1", "a=3&c=4", models.QueryAppend, "https://a.example/p?b=2&a=1&a=3&c=4"},
+		{"append keeps fragment", "https://a.example/p?x=1#top", "y=2", models.QueryAppend, "https://a.example/p?x=1&y=2#top"},
+		{"override", "https://a.example/p?utm_source=site&id=7&utm_source=old", "utm_source=x", models.QueryOverride, "https://a.example/p?id=7&utm_source=x"},
+		{"override escaped key", "https://a.example/p?a%20b=1&c=2", "a+b=3", models.QueryOverride, "https://a.example/p?c=2&a+b=3"},
+		{"override all", "https://a.example/p?a=1", "a=2", models.QueryOverride, "https://a.example/p?a=2"},
+		{"preserves encoding", "https://a.example/%D0%B0?q=%2F", "z=1", models.QueryAppend, "https://a.example/%D0%B0?q=%2F&z=1"},
+		{"unparsable target", "://bad", "a=1", models.QueryAppend, "://bad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeQuery(tt.target, tt.incoming, tt.policy); got != tt.want {
+				t.Errorf("MergeQuery(%q, %q, %q) = %q, want %q", tt.target, tt.incoming, tt.policy, got, tt.want)
+			}
+		})
+	}
+}
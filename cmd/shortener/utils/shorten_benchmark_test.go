@@ -10,3 +10,20 @@ func BenchmarkGenerateShortID(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkIDGenerator(b *testing.B) {
+	strategies := map[string]Strategy{
+		StrategyRandom:     RandomStrategy{},
+		StrategySequential: NewSequentialStrategy("salt", 1),
+		StrategyHash:       HashStrategy{},
+	}
+	for name, s := range strategies {
+		g := NewIDGenerator(s, 8)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = g.Generate("https://example.com/page", nil)
+			}
+		})
+	}
+}
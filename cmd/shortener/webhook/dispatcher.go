@@ -0,0 +1,335 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"uno/cmd/shortener/models"
+
+	"go.uber.org/zap"
+)
+
+// Параметры доставки по умолчанию
+const (
+	DefaultMaxAttempts  = 8                // Попыток доставки до перехода в failed
+	DefaultTimeout      = 10 * time.Second // Таймаут одного запроса
+	DefaultBackoff      = 10 * time.Second // Задержка перед второй попыткой
+	DefaultMaxBackoff   = time.Hour        // Максимальная задержка между попытками
+	DefaultPollInterval = time.Second      // Период опроса журнала
+	DefaultBatchSize    = 50               // Доставок за один опрос
+	DefaultWorkers      = 4                // Одновременных запросов
+)
+
+// maxErrorLength ограничивает длину сохраняемой ошибки доставки
+const maxErrorLength = 500
+
+// Options задает параметры Dispatcher; нулевые значения заменяются значениями по умолчанию
+type Options struct {
+	BaseURL      string        // Базовый URL сокращенных ссылок для данных событий
+	MaxAttempts  int           // Попыток доставки до перехода в failed
+	Timeout      time.Duration // Таймаут одного запроса
+	Backoff      time.Duration // Задержка перед второй попыткой, далее удваивается
+	MaxBackoff   time.Duration // Максимальная задержка между попытками
+	PollInterval time.Duration // Период опроса журнала
+	BatchSize    int           // Доставок за один опрос
+	Workers      int           // Одновременных запросов
+	Guard        *Guard        // Проверка адресов получателей при соединении (nil - внутренние адреса запрещены)
+	Client       *http.Client  // HTTP клиент (nil - клиент с таймаутом Timeout и проверкой Guard)
+	Logger       *zap.Logger   // Логгер ошибок (nil - без логирования)
+}
+
+// Dispatcher записывает события в журнал доставок и отправляет их получателям
+type Dispatcher struct {
+	store Store
+	opts  Options
+	now   func() time.Time
+}
+
+// NewDispatcher создает Dispatcher поверх журнала store
+func NewDispatcher(store Store, opts Options) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: opts.Guard.Transport(),
+			// Перенаправления не выполняются: получатель должен ответить сам
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+	return &Dispatcher{store: store, opts: opts, now: time.Now}
+}
+
+// Publish записывает доставки события в журнал до возврата, поэтому событие не теряется
+// при перезапуске сервиса. Подходит как обработчик событий ссылок; ошибки логируются
+func (d *Dispatcher) Publish(e models.LinkEvent) {
+	d.PublishAll([]models.LinkEvent{e})
+}
+
+// PublishAll записывает доставки событий в журнал одним вызовом Enqueue
+// Вебхуки каждого пользователя загружаются один раз; ошибки логируются
+func (d *Dispatcher) PublishAll(events []models.LinkEvent) {
+	webhooks := make(map[string][]models.Webhook)
+	var deliveries []models.WebhookDelivery
+	for _, e := range events {
+		list, ok := webhooks[e.UserID]
+		if !ok {
+			var err error
+			if list, err = d.store.Webhooks(e.UserID); err != nil {
+				d.opts.Logger.Error("failed to load webhooks", zap.String("event", e.Type), zap.String("short_id", e.ShortID), zap.Error(err))
+				continue
+			}
+			webhooks[e.UserID] = list
+		}
+		batch, err := d.deliveries(e, list)
+		if err != nil {
+			d.opts.Logger.Error("failed to encode webhook event", zap.String("event", e.Type), zap.String("short_id", e.ShortID), zap.Error(err))
+			continue
+		}
+		deliveries = append(deliveries, batch...)
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := d.store.Enqueue(deliveries); err != nil {
+		d.opts.Logger.Error("failed to enqueue webhook events", zap.Int("deliveries", len(deliveries)), zap.Error(err))
+	}
+}
+
+// deliveries формирует доставки события вебхукам из webhooks, подписанным на его тип
+func (d *Dispatcher) deliveries(e models.LinkEvent, webhooks []models.Webhook) ([]models.WebhookDelivery, error) {
+	var subscribers []models.Webhook
+	for _, w := range webhooks {
+		if Subscribed(w, e.Type) {
+			subscribers = append(subscribers, w)
+		}
+	}
+	if len(subscribers) == 0 {
+		return nil, nil
+	}
+
+	now := d.now().UTC()
+	created := e.Time
+	if created.IsZero() {
+		created = now
+	}
+	event := models.WebhookEvent{
+		ID:        randomHex(16),
+		Type:      e.Type,
+		CreatedAt: created.UTC(),
+		Data: models.WebhookEventData{
+			ShortID:     e.ShortID,
+			ShortURL:    d.opts.BaseURL + "/" + e.ShortID,
+			OriginalURL: e.OriginalURL,
+			Clicks:      e.Clicks,
+		},
+	}
+	payload, err := event.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(subscribers))
+	for _, w := range subscribers {
+		next := now
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            randomHex(16),
+			WebhookID:     w.ID,
+			UserID:        w.UserID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        StatusPending,
+			CreatedAt:     now,
+			NextAttemptAt: &next,
+		})
+	}
+	return deliveries, nil
+}
+
+// Run опрашивает журнал и отправляет доставки до завершения контекста
+// Доставки, прерванные завершением контекста, повторятся после истечения аренды
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Опрос повторяется сразу, пока журнал отдает полные пакеты
+		for ctx.Err() == nil {
+			if d.RunOnce(ctx) < d.opts.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выбирает из журнала доставки, срок которых наступил, отправляет их
+// и возвращает количество обработанных доставок
+func (d *Dispatcher) RunOnce(ctx context.Context) int {
+	// Аренда покрывает все попытки пакета с запасом на таймауты
+	lease := d.opts.Timeout * time.Duration(2+d.opts.BatchSize/d.opts.Workers)
+	deliveries, err := d.store.Claim(d.now(), lease, d.opts.BatchSize)
+	if err != nil {
+		d.opts.Logger.Error("failed to claim webhook deliveries", zap.Error(err))
+		return 0
+	}
+	if len(deliveries) == 0 {
+		return 0
+	}
+
+	// Вебхуки загружаются до запуска воркеров: карта читается без блокировок
+	webhooks := make(map[string]map[string]models.Webhook)
+	for _, delivery := range deliveries {
+		if _, ok := webhooks[delivery.UserID]; ok {
+			continue
+		}
+		list, err := d.store.Webhooks(delivery.UserID)
+		if err != nil {
+			// Доставки пользователя будут повторены после истечения аренды
+			d.opts.Logger.Error("failed to load webhooks", zap.Error(err))
+			continue
+		}
+		byID := make(map[string]models.Webhook, len(list))
+		for _, w := range list {
+			byID[w.ID] = w
+		}
+		webhooks[delivery.UserID] = byID
+	}
+
+	jobs := make(chan models.WebhookDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < d.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range jobs {
+				d.attempt(ctx, delivery, webhooks[delivery.UserID][delivery.WebhookID])
+			}
+		}()
+	}
+	for _, delivery := range deliveries {
+		if _, ok := webhooks[delivery.UserID]; ok {
+			jobs <- delivery
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return len(deliveries)
+}
+
+// attempt выполняет одну попытку доставки и сохраняет ее результат
+func (d *Dispatcher) attempt(ctx context.Context, delivery models.WebhookDelivery, w models.Webhook) {
+	delivery.Attempts++
+	if w.ID == "" {
+		// Вебхук удален после постановки события в очередь
+		delivery.Status = StatusFailed
+		delivery.Error = "webhook deleted"
+		delivery.NextAttemptAt = nil
+	} else {
+		code, err := d.send(ctx, w, delivery)
+		if ctx.Err() != nil {
+			// Сервис останавливается: попытка не засчитывается, доставка повторится после аренды
+			return
+		}
+		delivery.ResponseCode = code
+		delivery.Error = ""
+		now := d.now().UTC()
+		switch {
+		case err == nil:
+			delivery.Status = StatusDelivered
+			delivery.DeliveredAt = &now
+			delivery.NextAttemptAt = nil
+		case delivery.Attempts >= d.opts.MaxAttempts:
+			delivery.Status = StatusFailed
+			delivery.Error = truncate(err.Error())
+			delivery.NextAttemptAt = nil
+		default:
+			next := now.Add(d.backoff(delivery.Attempts))
+			delivery.Error = truncate(err.Error())
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := d.store.UpdateDelivery(delivery); err != nil {
+		d.opts.Logger.Error("failed to save webhook delivery", zap.String("delivery", delivery.ID), zap.Error(err))
+	}
+}
+
+// send отправляет подписанный запрос и возвращает код ответа
+// Успешными считаются только ответы 2xx
+func (d *Dispatcher) send(ctx context.Context, w models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "uno-shortener-webhook")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(w.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff возвращает задержку перед попыткой attempts+1: Backoff, 2*Backoff, 4*Backoff, ...
+// но не больше MaxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.Backoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.opts.MaxBackoff)
+}
+
+// truncate обрезает текст ошибки до maxErrorLength байт
+func truncate(s string) string {
+	if len(s) <= maxErrorLength {
+		return s
+	}
+	return s[:maxErrorLength]
+}
@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// receiver тестовый получатель событий, отвечающий кодами из очереди codes
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	codes    []int
+	requests int
+	verified int
+	bodies   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests++
+	if Verify(rc.secret, ts, body, r.Header.Get(HeaderSignature)) && r.Header.Get(HeaderEvent) != "" {
+		rc.verified++
+	}
+	rc.bodies = append(rc.bodies, string(body))
+	code := http.StatusOK
+	if len(rc.codes) > 0 {
+		code, rc.codes = rc.codes[0], rc.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+// testDispatcher создает Dispatcher с управляемыми часами
+func testDispatcher(store Store, clock *time.Time) *Dispatcher {
+	d := NewDispatcher(store, Options{
+		BaseURL:     "http://short.example",
+		MaxAttempts: 3,
+		Backoff:     time.Second,
+		MaxBackoff:  time.Minute,
+		Guard:       &Guard{AllowPrivate: true},
+	})
+	d.now = func() time.Time { return *clock }
+	return d
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	rc := &receiver{secret: "secret", codes: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	store.CreateWebhook(models.Webhook{ID: "h1", UserID: "user1", URL: srv.URL, Secret: "secret"})
+	store.CreateWebhook(models.Webhook{ID: "h2", UserID: "user1", URL: srv.URL, Secret: "secret", Events: []string{models.EventLinkDeleted}})
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := testDispatcher(store, &clock)
+	d.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user1", ShortID: "abc", OriginalURL: "https://example.com"})
+	d.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user2", ShortID: "def"})
+
+	// Первая попытка и повтор через Backoff завершаются ошибкой, третья - успехом
+	ctx := context.Background()
+	for i, wait := range []time.Duration{0, time.Second, 2 * time.Second} {
+		clock = clock.Add(wait)
+		if n := d.RunOnce(ctx); n != 1 {
+			t.Fatalf("attempt %d: expected one delivery, got %d", i+1, n)
+		}
+		if n := d.RunOnce(ctx); n != 0 {
+			t.Fatalf("attempt %d: expected no due deliveries, got %d", i+1, n)
+		}
+	}
+
+	if rc.requests != 3 || rc.verified != 3 {
+		t.Fatalf("expected 3 signed requests, got %d (%d verified)", rc.requests, rc.verified)
+	}
+	var event models.WebhookEvent
+	if err := event.UnmarshalJSON([]byte(rc.bodies[0])); err != nil {
+		t.Fatalf("invalid payload %s: %v", rc.bodies[0], err)
+	}
+	if event.Type != models.EventLinkCreated || event.Data.ShortURL != "http://short.example/abc" || event.Data.OriginalURL != "https://example.com" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if rc.bodies[0] != rc.bodies[2] {
+		t.Error("expected retries to send the same payload")
+	}
+
+	log, _ := store.Deliveries("user1", "h1", DeliveryLogSize)
+	if len(log) != 1 || log[0].Status != StatusDelivered || log[0].Attempts != 3 || log[0].ResponseCode != http.StatusOK || log[0].DeliveredAt == nil {
+		t.Errorf("unexpected delivery log: %+v", log)
+	}
+	if log, _ := store.Deliveries("user1", "h2", DeliveryLogSize); len(log) != 0 {
+		t.Errorf("expected no deliveries for unsubscribed webhook, got %+v", log)
+	}
+}
+
+func TestDispatcher_GiveUp(t *testing.T) {
+	rc := &receiver{secret: "secret", codes: []int{http.StatusGone, http.StatusGone, http.StatusGone, http.StatusOK}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	store.CreateWebhook(models.Webhook{ID: "h1", UserID: "user1", URL: srv.URL, Secret: "secret"})
+	clock := time.Now()
+	d := testDispatcher(store, &clock)
+	d.Publish(models.LinkEvent{Type: models.EventLinkClicks, UserID: "user1", ShortID: "abc", Clicks: 100})
+
+	for i := 0; i < 5; i++ {
+		d.RunOnce(context.Background())
+		clock = clock.Add(time.Hour)
+	}
+	log, _ := store.Deliveries("user1", "h1", DeliveryLogSize)
+	if rc.requests != 3 || len(log) != 1 || log[0].Status != StatusFailed || log[0].ResponseCode != http.StatusGone || log[0].Error == "" {
+		t.Errorf("expected delivery to fail after 3 attempts, got %d requests and %+v", rc.requests, log)
+	}
+}
+
+func TestDispatcher_InternalAddress(t *testing.T) {
+	rc := &receiver{secret: "secret"}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	// Вебхук мог пройти проверку при регистрации, а затем его имя стало указывать
+	// на внутренний адрес: соединение запрещается при каждой попытке
+	store := NewMemoryStore()
+	store.CreateWebhook(models.Webhook{ID: "h1", UserID: "user1", URL: srv.URL, Secret: "secret"})
+	d := NewDispatcher(store, Options{MaxAttempts: 1})
+	d.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user1", ShortID: "abc"})
+	d.RunOnce(context.Background())
+
+	log, _ := store.Deliveries("user1", "h1", DeliveryLogSize)
+	if rc.requests != 0 || len(log) != 1 || log[0].Status != StatusFailed || !strings.Contains(log[0].Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("expected connection to be refused, got %d requests and %+v", rc.requests, log)
+	}
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(NewMemoryStore(), Options{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+// countingStore считает обращения к журналу
+type countingStore struct {
+	Store
+	webhooks, enqueues int
+}
+
+func (s *countingStore) Webhooks(userID string) ([]models.Webhook, error) {
+	s.webhooks++
+	return s.Store.Webhooks(userID)
+}
+
+func (s *countingStore) Enqueue(deliveries []models.WebhookDelivery) error {
+	s.enqueues++
+	return s.Store.Enqueue(deliveries)
+}
+
+func TestDispatcher_PublishAll(t *testing.T) {
+	store := &countingStore{Store: NewMemoryStore()}
+	store.CreateWebhook(models.Webhook{ID: "h1", UserID: "user1", URL: "https://example.com", Secret: "secret"})
+	d := NewDispatcher(store, Options{})
+
+	// Доставки записываются в журнал до возврата: один поиск вебхуков на пользователя и одна запись
+	var events []models.LinkEvent
+	for i := 0; i < 100; i++ {
+		events = append(events,
+			models.LinkEvent{Type: models.EventLinkCreated, UserID: "user1", ShortID: strconv.Itoa(i)},
+			models.LinkEvent{Type: models.EventLinkCreated, UserID: "user2", ShortID: strconv.Itoa(i)})
+	}
+	d.PublishAll(events)
+	if store.webhooks != 2 || store.enqueues != 1 {
+		t.Errorf("expected 2 lookups and 1 enqueue, got %d and %d", store.webhooks, store.enqueues)
+	}
+	d.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user1", ShortID: "last"})
+	deliveries, _ := store.Claim(time.Now(), time.Minute, 1000)
+	if len(deliveries) != 101 {
+		t.Errorf("expected 101 deliveries, got %d", len(deliveries))
+	}
+}
+
+func TestDispatcher_Shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	// Попытка, прерванная остановкой сервиса, не засчитывается и повторится после аренды
+	store := NewMemoryStore()
+	store.CreateWebhook(models.Webhook{ID: "h1", UserID: "user1", URL: srv.URL, Secret: "secret"})
+	clock := time.Now()
+	d := testDispatcher(store, &clock)
+	d.Publish(models.LinkEvent{Type: models.EventLinkCreated, UserID: "user1", ShortID: "abc"})
+	if n := d.RunOnce(ctx); n != 1 {
+		t.Fatalf("expected one delivery, got %d", n)
+	}
+
+	log, _ := store.Deliveries("user1", "h1", DeliveryLogSize)
+	if len(log) != 1 || log[0].Status != StatusPending || log[0].Attempts != 0 {
+		t.Errorf("expected interrupted attempt not to be recorded, got %+v", log)
+	}
+}
@@ -0,0 +1,369 @@
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// compactMinRecords минимальное количество записей в файле для автоматического уплотнения
+const compactMinRecords = 1000
+
+// FileStore хранит вебхуки и журнал доставок в файле JSON Lines
+// Каждая запись содержит полное состояние вебхука или доставки: последняя запись
+// при загрузке заменяет предыдущие. Записи синхронизируются с диском до возврата
+// из метода, поэтому принятые события переживают сбой питания. Аренда доставок (Claim)
+// в файл не пишется: после перезапуска незавершенные доставки повторяются сразу
+type FileStore struct {
+	path  string     // Путь к файлу журнала
+	mu    sync.Mutex // Защищает состояние и файл
+	file  *os.File   // Файл для дописывания записей
+	st    state      // Состояние в памяти
+	lines int        // Количество записей в файле
+}
+
+// fileRecord запись файла журнала; заполнено ровно одно поле
+type fileRecord struct {
+	Webhook  *webhookRecord  `json:"webhook,omitempty"`  // Вебхук или его удаление
+	Delivery *deliveryRecord `json:"delivery,omitempty"` // Состояние доставки
+}
+
+// webhookRecord запись о вебхуке
+type webhookRecord struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	URL       string    `json:"url,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	Deleted   bool      `json:"deleted,omitempty"`
+}
+
+// deliveryRecord запись о доставке; в отличие от models.WebhookDelivery
+// сохраняет владельца и тело запроса
+type deliveryRecord struct {
+	ID            string     `json:"id"`
+	WebhookID     string     `json:"webhook_id"`
+	UserID        string     `json:"user_id"`
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Payload       string     `json:"payload"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts,omitempty"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// newWebhookRecord создает запись о вебхуке
+func newWebhookRecord(w models.Webhook) *webhookRecord {
+	return &webhookRecord{
+		ID:        w.ID,
+		UserID:    w.UserID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		Events:    w.Events,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// webhook возвращает вебхук из записи
+func (r *webhookRecord) webhook() models.Webhook {
+	return models.Webhook{
+		ID:        r.ID,
+		UserID:    r.UserID,
+		URL:       r.URL,
+		Secret:    r.Secret,
+		Events:    r.Events,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// newDeliveryRecord создает запись о доставке
+func newDeliveryRecord(d models.WebhookDelivery) *deliveryRecord {
+	return &deliveryRecord{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		UserID:        d.UserID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Payload:       string(d.Payload),
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		ResponseCode:  d.ResponseCode,
+		Error:         d.Error,
+		CreatedAt:     d.CreatedAt,
+		NextAttemptAt: d.NextAttemptAt,
+		DeliveredAt:   d.DeliveredAt,
+	}
+}
+
+// delivery возвращает доставку из записи
+func (r *deliveryRecord) delivery() models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:            r.ID,
+		WebhookID:     r.WebhookID,
+		UserID:        r.UserID,
+		EventID:       r.EventID,
+		EventType:     r.EventType,
+		Payload:       []byte(r.Payload),
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		ResponseCode:  r.ResponseCode,
+		Error:         r.Error,
+		CreatedAt:     r.CreatedAt,
+		NextAttemptAt: r.NextAttemptAt,
+		DeliveredAt:   r.DeliveredAt,
+	}
+}
+
+// NewFileStore открывает журнал в файле path и загружает его состояние
+// Поврежденные и недописанные строки пропускаются
+func NewFileStore(path string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create webhook store directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open webhook store: %w", err)
+	}
+
+	s := &FileStore{path: path, file: file, st: newState()}
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maybeCompactLocked()
+	return s, nil
+}
+
+// load восстанавливает состояние из файла
+func (s *FileStore) load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reader := bufio.NewReader(s.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read webhook store: %w", err)
+		}
+		if body := bytes.TrimSpace(line); len(body) > 0 {
+			var r fileRecord
+			if jsonErr := json.Unmarshal(body, &r); jsonErr != nil {
+				log.Printf("webhook.FileStore: skipping corrupt record in %s: %v", s.path, jsonErr)
+			} else {
+				s.applyLocked(r)
+			}
+			s.lines++
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// applyLocked применяет запись к состоянию в памяти
+func (s *FileStore) applyLocked(r fileRecord) {
+	switch {
+	case r.Webhook != nil && r.Webhook.Deleted:
+		s.st.deleteWebhook(r.Webhook.UserID, r.Webhook.ID)
+	case r.Webhook != nil:
+		w := r.Webhook.webhook()
+		if !s.st.owns(w.UserID, w.ID) {
+			// Лимит проверен при регистрации; при загрузке не применяется
+			s.st.webhooks[w.UserID] = append(s.st.webhooks[w.UserID], w)
+		}
+	case r.Delivery != nil:
+		s.st.putDelivery(r.Delivery.delivery())
+	}
+}
+
+// appendLocked дописывает записи в файл и синхронизирует его с диском
+func (s *FileStore) appendLocked(records ...fileRecord) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write webhook store: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync webhook store: %w", err)
+	}
+	s.lines += len(records)
+	s.maybeCompactLocked()
+	return nil
+}
+
+// CreateWebhook сохраняет новый вебхук
+func (s *FileStore) CreateWebhook(w models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.st.createWebhook(w); err != nil {
+		return err
+	}
+	return s.appendLocked(fileRecord{Webhook: newWebhookRecord(w)})
+}
+
+// Webhooks возвращает вебхуки пользователя в порядке регистрации
+func (s *FileStore) Webhooks(userID string) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.st.webhooks[userID]), nil
+}
+
+// DeleteWebhook удаляет вебхук пользователя вместе с его доставками
+func (s *FileStore) DeleteWebhook(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.st.deleteWebhook(userID, id); err != nil {
+		return err
+	}
+	return s.appendLocked(fileRecord{Webhook: &webhookRecord{ID: id, UserID: userID, Deleted: true}})
+}
+
+// Enqueue добавляет доставки в журнал
+func (s *FileStore) Enqueue(deliveries []models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]fileRecord, 0, len(deliveries))
+	for _, d := range deliveries {
+		s.st.putDelivery(d)
+		records = append(records, fileRecord{Delivery: newDeliveryRecord(d)})
+	}
+	return s.appendLocked(records...)
+}
+
+// Claim выбирает доставки, срок попытки которых наступил, и откладывает их на lease
+func (s *FileStore) Claim(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st.claim(now, lease, limit), nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+// Результаты доставок удаленных вебхуков не сохраняются
+func (s *FileStore) UpdateDelivery(d models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.st.updateDelivery(d) {
+		return nil
+	}
+	return s.appendLocked(fileRecord{Delivery: newDeliveryRecord(d)})
+}
+
+// Deliveries возвращает последние доставки вебхука пользователя, от новых к старым
+func (s *FileStore) Deliveries(userID, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st.deliveriesOf(userID, webhookID, limit)
+}
+
+// liveRecordsLocked возвращает количество записей, необходимых для восстановления состояния
+func (s *FileStore) liveRecordsLocked() int {
+	live := len(s.st.deliveries)
+	for _, list := range s.st.webhooks {
+		live += len(list)
+	}
+	return live
+}
+
+// maybeCompactLocked переписывает файл, если устаревших записей больше, чем актуальных
+// Ошибка уплотнения не мешает работе: журнал остается корректным
+func (s *FileStore) maybeCompactLocked() {
+	live := s.liveRecordsLocked()
+	if s.lines < compactMinRecords || s.lines <= 2*live {
+		return
+	}
+	if err := s.compactLocked(); err != nil {
+		log.Printf("webhook.FileStore: failed to compact %s: %v", s.path, err)
+	}
+}
+
+// compactLocked записывает текущее состояние во временный файл и заменяет им журнал
+// Временный файл синхронизируется с диском до переименования, а директория - после,
+// поэтому после сбоя на диске остается либо старый, либо новый журнал целиком.
+// Дописывание продолжается в тот же открытый файл, повторно открывать журнал не нужно
+func (s *FileStore) compactLocked() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	lines := 0
+	for _, list := range s.st.webhooks {
+		for _, w := range list {
+			if err := enc.Encode(fileRecord{Webhook: newWebhookRecord(w)}); err != nil {
+				return err
+			}
+			lines++
+			for _, id := range s.st.byWebhook[w.ID] {
+				if err := enc.Encode(fileRecord{Delivery: newDeliveryRecord(*s.st.deliveries[id])}); err != nil {
+					return err
+				}
+				lines++
+			}
+		}
+	}
+
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	abort := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		return abort(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return abort(err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return abort(err)
+	}
+	if err := syncDir(filepath.Dir(s.path)); err != nil {
+		log.Printf("webhook.FileStore: failed to sync directory of %s: %v", s.path, err)
+	}
+	if err := s.file.Close(); err != nil {
+		log.Printf("webhook.FileStore: failed to close old file %s: %v", s.path, err)
+	}
+	s.file = tmp
+	s.lines = lines
+	return nil
+}
+
+// syncDir синхронизирует с диском содержимое директории,
+// чтобы переименование файла пережило сбой питания
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Close закрывает файл журнала
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress возвращается, если адрес получателя находится во внутренней сети
+var ErrForbiddenAddress = errors.New("address is not allowed for webhooks")
+
+// forbiddenPrefixes диапазоны, не покрытые методами netip.Addr: "эта" сеть 0.0.0.0/8
+// и разделяемое адресное пространство провайдеров 100.64.0.0/10, где некоторые облака
+// держат служебные адреса
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// Guard не дает вебхукам обращаться к внутренней сети сервиса: loopback, частным
+// (RFC 1918, fc00::/7), link-local (в том числе адресу метаданных облака 169.254.169.254),
+// групповым и неопределенным адресам. Адрес проверяется при регистрации вебхука
+// и повторно при каждом соединении, поэтому смена DNS записи после регистрации
+// не позволяет обойти проверку. Нулевой Guard (и nil) проверяет все адреса
+type Guard struct {
+	AllowPrivate bool // Разрешить внутренние адреса (только для разработки и тестов)
+
+	// LookupIP разрешает имя хоста (nil - net.DefaultResolver)
+	LookupIP func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+// CheckHost проверяет, что все адреса хоста host допустимы для вебхука
+func (g *Guard) CheckHost(ctx context.Context, host string) error {
+	if g != nil && g.AllowPrivate {
+		return nil
+	}
+	addrs, err := g.lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("failed to resolve %q", host)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup возвращает адреса хоста; IP адрес возвращается как есть
+func (g *Guard) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	if g != nil && g.LookupIP != nil {
+		return g.LookupIP(ctx, host)
+	}
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// Control проверяет адрес перед установкой соединения; подходит для net.Dialer.Control
+func (g *Guard) Control(_, address string, _ syscall.RawConn) error {
+	if g != nil && g.AllowPrivate {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return checkAddr(addrPort.Addr())
+}
+
+// Transport возвращает транспорт HTTP клиента вебхуков, проверяющий адрес каждого
+// соединения. Прокси из окружения не используется: иначе проверялся бы адрес прокси
+func (g *Guard) Transport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: g.Control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// checkAddr проверяет, что адрес не относится к внутренней сети
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	forbidden := !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified()
+	for _, prefix := range forbiddenPrefixes {
+		forbidden = forbidden || prefix.Contains(addr)
+	}
+	if forbidden {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
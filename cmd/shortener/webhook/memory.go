@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// MemoryStore хранит вебхуки и журнал доставок в памяти
+// Используется вместе с хранилищем ссылок в памяти: после перезапуска все теряется
+type MemoryStore struct {
+	mu sync.Mutex
+	st state
+}
+
+// NewMemoryStore создает пустой MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{st: newState()}
+}
+
+// CreateWebhook сохраняет новый вебхук
+func (s *MemoryStore) CreateWebhook(w models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st.createWebhook(w)
+}
+
+// Webhooks возвращает вебхуки пользователя в порядке регистрации
+func (s *MemoryStore) Webhooks(userID string) ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.st.webhooks[userID]), nil
+}
+
+// DeleteWebhook удаляет вебхук пользователя вместе с его доставками
+func (s *MemoryStore) DeleteWebhook(userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st.deleteWebhook(userID, id)
+}
+
+// Enqueue добавляет доставки в журнал
+func (s *MemoryStore) Enqueue(deliveries []models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range deliveries {
+		s.st.putDelivery(d)
+	}
+	return nil
+}
+
+// Claim выбирает доставки, срок попытки которых наступил, и откладывает их на lease
+func (s *MemoryStore) Claim(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st.claim(now, lease, limit), nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (s *MemoryStore) UpdateDelivery(d models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.st.updateDelivery(d)
+	return nil
+}
+
+// Deliveries возвращает последние доставки вебхука пользователя, от новых к старым
+func (s *MemoryStore) Deliveries(userID, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.st.deliveriesOf(userID, webhookID, limit)
+}
+
+// state состояние вебхуков и журнала доставок, общее для MemoryStore и FileStore
+// Не потокобезопасно
+type state struct {
+	webhooks   map[string][]models.Webhook        // Пользователь -> вебхуки в порядке регистрации
+	deliveries map[string]*models.WebhookDelivery // Идентификатор доставки -> доставка
+	byWebhook  map[string][]string                // Вебхук -> доставки в порядке постановки
+	pending    map[string]bool                    // Доставки в состоянии pending
+}
+
+// newState создает пустое состояние
+func newState() state {
+	return state{
+		webhooks:   make(map[string][]models.Webhook),
+		deliveries: make(map[string]*models.WebhookDelivery),
+		byWebhook:  make(map[string][]string),
+		pending:    make(map[string]bool),
+	}
+}
+
+// owns проверяет, что у пользователя есть вебхук id
+func (st *state) owns(userID, id string) bool {
+	return slices.ContainsFunc(st.webhooks[userID], func(w models.Webhook) bool { return w.ID == id })
+}
+
+// createWebhook добавляет вебхук, соблюдая MaxWebhooks
+func (st *state) createWebhook(w models.Webhook) error {
+	if len(st.webhooks[w.UserID]) >= MaxWebhooks {
+		return ErrLimit
+	}
+	st.webhooks[w.UserID] = append(st.webhooks[w.UserID], w)
+	return nil
+}
+
+// deleteWebhook удаляет вебхук и его доставки
+func (st *state) deleteWebhook(userID, id string) error {
+	if !st.owns(userID, id) {
+		return ErrNotFound
+	}
+	st.webhooks[userID] = slices.DeleteFunc(st.webhooks[userID], func(w models.Webhook) bool { return w.ID == id })
+	if len(st.webhooks[userID]) == 0 {
+		delete(st.webhooks, userID)
+	}
+	for _, deliveryID := range st.byWebhook[id] {
+		delete(st.deliveries, deliveryID)
+		delete(st.pending, deliveryID)
+	}
+	delete(st.byWebhook, id)
+	return nil
+}
+
+// putDelivery добавляет доставку или заменяет существующую
+// Доставки удаленных вебхуков отбрасываются
+func (st *state) putDelivery(d models.WebhookDelivery) {
+	if !st.owns(d.UserID, d.WebhookID) {
+		return
+	}
+	if _, ok := st.deliveries[d.ID]; !ok {
+		st.byWebhook[d.WebhookID] = append(st.byWebhook[d.WebhookID], d.ID)
+	}
+	st.deliveries[d.ID] = &d
+	if d.Status == StatusPending {
+		st.pending[d.ID] = true
+	} else {
+		delete(st.pending, d.ID)
+	}
+	st.prune(d.WebhookID)
+}
+
+// updateDelivery сохраняет результат попытки существующей доставки
+func (st *state) updateDelivery(d models.WebhookDelivery) bool {
+	if _, ok := st.deliveries[d.ID]; !ok {
+		return false
+	}
+	st.putDelivery(d)
+	return true
+}
+
+// prune удаляет самые старые завершенные доставки вебхука сверх DeliveryLogSize
+// Доставки в состоянии pending не удаляются
+func (st *state) prune(webhookID string) {
+	ids := st.byWebhook[webhookID]
+	excess := len(ids) - DeliveryLogSize
+	if excess <= 0 {
+		return
+	}
+	kept := ids[:0]
+	for _, id := range ids {
+		if excess > 0 && !st.pending[id] {
+			delete(st.deliveries, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	st.byWebhook[webhookID] = kept
+}
+
+// claim выбирает до limit доставок, срок которых наступил, начиная с самых давних
+func (st *state) claim(now time.Time, lease time.Duration, limit int) []models.WebhookDelivery {
+	var due []*models.WebhookDelivery
+	for id := range st.pending {
+		d := st.deliveries[id]
+		if d.NextAttemptAt == nil || !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return attemptTime(due[i]).Before(attemptTime(due[j]))
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]models.WebhookDelivery, 0, len(due))
+	next := now.Add(lease)
+	for _, d := range due {
+		d.NextAttemptAt = &next
+		claimed = append(claimed, *d)
+	}
+	return claimed
+}
+
+// attemptTime возвращает момент следующей попытки доставки
+func attemptTime(d *models.WebhookDelivery) time.Time {
+	if d.NextAttemptAt == nil {
+		return d.CreatedAt
+	}
+	return *d.NextAttemptAt
+}
+
+// deliveriesOf возвращает до limit последних доставок вебхука пользователя
+func (st *state) deliveriesOf(userID, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	if !st.owns(userID, webhookID) {
+		return nil, ErrNotFound
+	}
+	ids := st.byWebhook[webhookID]
+	result := make([]models.WebhookDelivery, 0, min(limit, len(ids)))
+	for i := len(ids) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, *st.deliveries[ids[i]])
+	}
+	return result, nil
+}
@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"uno/cmd/shortener/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore хранит вебхуки и журнал доставок в PostgreSQL
+// Несколько экземпляров сервиса могут разбирать общий журнал: Claim блокирует
+// выбранные строки с SKIP LOCKED и откладывает их следующую попытку
+type PostgresStore struct {
+	pool *pgxpool.Pool // Пул соединений с базой данных
+}
+
+// NewPostgresStore создает PostgresStore и инициализирует схему
+func NewPostgresStore(pool *pgxpool.Pool) (*PostgresStore, error) {
+	s := &PostgresStore{pool: pool}
+	if err := s.initSchema(); err != nil {
+		return nil, fmt.Errorf("failed to init webhook schema: %w", err)
+	}
+	return s, nil
+}
+
+// deliveryColumns колонки доставки в порядке scanDeliveries
+const deliveryColumns = `id, webhook_id, user_id, event_id, event_type, payload, status,
+    attempts, response_code, error, created_at, next_attempt_at, delivered_at`
+
+// CreateWebhook сохраняет новый вебхук, если у пользователя меньше MaxWebhooks вебхуков
+func (s *PostgresStore) CreateWebhook(w models.Webhook) error {
+	tag, err := s.pool.Exec(context.Background(), `
+        INSERT INTO public.webhooks (id, user_id, url, secret, events, created_at)
+        SELECT $1, $2, $3, $4, COALESCE($5::text[], '{}'), $6
+        WHERE (SELECT count(*) FROM public.webhooks WHERE user_id = $2) < $7`,
+		w.ID, w.UserID, w.URL, w.Secret, w.Events, w.CreatedAt, MaxWebhooks)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLimit
+	}
+	return nil
+}
+
+// Webhooks возвращает вебхуки пользователя в порядке регистрации
+func (s *PostgresStore) Webhooks(userID string) ([]models.Webhook, error) {
+	rows, err := s.pool.Query(context.Background(),
+		`SELECT id, user_id, url, secret, events, created_at FROM public.webhooks
+         WHERE user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.Webhook
+	for rows.Next() {
+		var w models.Webhook
+		if err := rows.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &w.Events, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, w)
+	}
+	return result, rows.Err()
+}
+
+// DeleteWebhook удаляет вебхук пользователя; доставки удаляются каскадно
+func (s *PostgresStore) DeleteWebhook(userID, id string) error {
+	tag, err := s.pool.Exec(context.Background(),
+		`DELETE FROM public.webhooks WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Enqueue добавляет доставки в журнал
+// Доставки вебхуков, удаленных к этому моменту, пропускаются
+func (s *PostgresStore) Enqueue(deliveries []models.WebhookDelivery) error {
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(`
+            INSERT INTO public.webhook_deliveries (`+deliveryColumns+`)
+            SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+            WHERE EXISTS (SELECT 1 FROM public.webhooks WHERE id = $2)`,
+			d.ID, d.WebhookID, d.UserID, d.EventID, d.EventType, d.Payload, d.Status,
+			d.Attempts, d.ResponseCode, d.Error, d.CreatedAt, d.NextAttemptAt, d.DeliveredAt)
+	}
+
+	br := s.pool.SendBatch(context.Background(), batch)
+	defer br.Close()
+	for range deliveries {
+		if _, err := br.Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Claim выбирает доставки, срок попытки которых наступил, и откладывает их на lease
+func (s *PostgresStore) Claim(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := s.pool.Query(context.Background(), `
+        UPDATE public.webhook_deliveries SET next_attempt_at = $2
+        WHERE id IN (
+            SELECT id FROM public.webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= $1
+            ORDER BY next_attempt_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+deliveryColumns, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+// Завершенные доставки сверх DeliveryLogSize на вебхук удаляются
+func (s *PostgresStore) UpdateDelivery(d models.WebhookDelivery) error {
+	_, err := s.pool.Exec(context.Background(), `
+        UPDATE public.webhook_deliveries
+        SET status = $2, attempts = $3, response_code = $4, error = $5,
+            next_attempt_at = $6, delivered_at = $7
+        WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.ResponseCode, d.Error, d.NextAttemptAt, d.DeliveredAt)
+	if err != nil || d.Status == StatusPending {
+		return err
+	}
+
+	_, err = s.pool.Exec(context.Background(), `
+        DELETE FROM public.webhook_deliveries
+        WHERE webhook_id = $1 AND status <> 'pending' AND id NOT IN (
+            SELECT id FROM public.webhook_deliveries
+            WHERE webhook_id = $1
+            ORDER BY created_at DESC
+            LIMIT $2
+        )`, d.WebhookID, DeliveryLogSize)
+	if err != nil {
+		return fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// Deliveries возвращает последние доставки вебхука пользователя, от новых к старым
+func (s *PostgresStore) Deliveries(userID, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	var exists bool
+	err := s.pool.QueryRow(context.Background(),
+		`SELECT EXISTS (SELECT 1 FROM public.webhooks WHERE user_id = $1 AND id = $2)`, userID, webhookID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.pool.Query(context.Background(),
+		`SELECT `+deliveryColumns+` FROM public.webhook_deliveries
+         WHERE webhook_id = $1 ORDER BY created_at DESC, id LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// scanDeliveries читает доставки из результата запроса и закрывает его
+func scanDeliveries(rows pgx.Rows) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+	var result []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.UserID, &d.EventID, &d.EventType, &d.Payload, &d.Status,
+			&d.Attempts, &d.ResponseCode, &d.Error, &d.CreatedAt, &d.NextAttemptAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// initSchema создает таблицы вебхуков и журнала доставок, если они не существуют
+func (s *PostgresStore) initSchema() error {
+	_, err := s.pool.Exec(context.Background(), `
+        CREATE TABLE IF NOT EXISTS public.webhooks (
+            id varchar PRIMARY KEY,
+            user_id varchar NOT NULL,
+            url varchar NOT NULL,
+            secret varchar NOT NULL,
+            events text[] NOT NULL DEFAULT '{}',
+            created_at timestamptz NOT NULL DEFAULT now()
+        );
+        CREATE INDEX IF NOT EXISTS webhooks_user_id_idx ON public.webhooks (user_id);
+        CREATE TABLE IF NOT EXISTS public.webhook_deliveries (
+            id varchar PRIMARY KEY,
+            webhook_id varchar NOT NULL REFERENCES public.webhooks (id) ON DELETE CASCADE,
+            user_id varchar NOT NULL,
+            event_id varchar NOT NULL,
+            event_type varchar NOT NULL,
+            payload bytea NOT NULL,
+            status varchar NOT NULL,
+            attempts integer NOT NULL DEFAULT 0,
+            response_code integer NOT NULL DEFAULT 0,
+            error text NOT NULL DEFAULT '',
+            created_at timestamptz NOT NULL DEFAULT now(),
+            next_attempt_at timestamptz,
+            delivered_at timestamptz
+        );
+        CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+            ON public.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+        CREATE INDEX IF NOT EXISTS webhook_deliveries_log_idx
+            ON public.webhook_deliveries (webhook_id, created_at DESC)
+    `)
+	return err
+}
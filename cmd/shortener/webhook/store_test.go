@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// testDelivery создает доставку вебхука w, ожидающую первой попытки в момент at
+func testDelivery(w models.Webhook, id string, at time.Time) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:            id,
+		WebhookID:     w.ID,
+		UserID:        w.UserID,
+		EventID:       "event-" + id,
+		EventType:     models.EventLinkCreated,
+		Payload:       []byte(`{"id":"event-` + id + `"}`),
+		Status:        StatusPending,
+		CreatedAt:     at,
+		NextAttemptAt: &at,
+	}
+}
+
+// testStore проверяет общее поведение реализаций Store
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hook := models.Webhook{ID: "h1", UserID: "user1", URL: "https://example.com", Secret: "s", CreatedAt: now}
+	if err := s.CreateWebhook(hook); err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	for i := 0; i < MaxWebhooks; i++ {
+		if err := s.CreateWebhook(models.Webhook{ID: fmt.Sprintf("x%d", i), UserID: "user2", CreatedAt: now}); err != nil {
+			t.Fatalf("failed to create webhook: %v", err)
+		}
+	}
+	if err := s.CreateWebhook(models.Webhook{ID: "extra", UserID: "user2", CreatedAt: now}); !errors.Is(err, ErrLimit) {
+		t.Errorf("expected ErrLimit, got %v", err)
+	}
+	if hooks, _ := s.Webhooks("user1"); len(hooks) != 1 || hooks[0].Secret != "s" {
+		t.Fatalf("unexpected webhooks: %+v", hooks)
+	}
+
+	if err := s.Enqueue([]models.WebhookDelivery{
+		testDelivery(hook, "d1", now),
+		testDelivery(hook, "d2", now.Add(time.Minute)),
+		testDelivery(models.Webhook{ID: "missing", UserID: "user1"}, "d3", now),
+	}); err != nil {
+		t.Fatalf("failed to enqueue: %v", err)
+	}
+
+	// Доставка выдается один раз до истечения аренды
+	claimed, err := s.Claim(now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].ID != "d1" || string(claimed[0].Payload) != `{"id":"event-d1"}` {
+		t.Fatalf("unexpected claim %+v %v", claimed, err)
+	}
+	if again, _ := s.Claim(now, time.Minute, 10); len(again) != 0 {
+		t.Fatalf("expected leased delivery to be skipped, got %+v", again)
+	}
+	if later, _ := s.Claim(now.Add(time.Minute), time.Minute, 1); len(later) != 1 {
+		t.Fatalf("expected one delivery after lease, got %+v", later)
+	}
+
+	delivered := claimed[0]
+	delivered.Status = StatusDelivered
+	delivered.Attempts = 1
+	delivered.ResponseCode = 200
+	delivered.NextAttemptAt = nil
+	delivered.DeliveredAt = &now
+	if err := s.UpdateDelivery(delivered); err != nil {
+		t.Fatalf("failed to update delivery: %v", err)
+	}
+
+	log, err := s.Deliveries("user1", "h1", DeliveryLogSize)
+	if err != nil || len(log) != 2 || log[0].ID != "d2" || log[1].Status != StatusDelivered || log[1].ResponseCode != 200 {
+		t.Fatalf("unexpected deliveries %+v %v", log, err)
+	}
+	if _, err := s.Deliveries("user2", "h1", DeliveryLogSize); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+
+	if err := s.DeleteWebhook("user2", "h1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for another user, got %v", err)
+	}
+	if err := s.DeleteWebhook("user1", "h1"); err != nil {
+		t.Fatalf("failed to delete webhook: %v", err)
+	}
+	if hooks, _ := s.Webhooks("user1"); len(hooks) != 0 {
+		t.Errorf("expected webhook to be deleted, got %+v", hooks)
+	}
+	if pending, _ := s.Claim(now.Add(time.Hour), time.Minute, 10); len(pending) != 0 {
+		t.Errorf("expected deliveries of deleted webhook to be dropped, got %+v", pending)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestMemoryStore_Prune(t *testing.T) {
+	s := NewMemoryStore()
+	now := time.Now()
+	hook := models.Webhook{ID: "h1", UserID: "user1"}
+	s.CreateWebhook(hook)
+	s.Enqueue([]models.WebhookDelivery{testDelivery(hook, "pending", now)})
+	for i := 0; i < DeliveryLogSize+10; i++ {
+		d := testDelivery(hook, fmt.Sprintf("d%d", i), now)
+		d.Status = StatusFailed
+		s.Enqueue([]models.WebhookDelivery{d})
+	}
+
+	log, _ := s.Deliveries("user1", "h1", 2*DeliveryLogSize)
+	if len(log) != DeliveryLogSize || log[0].ID != fmt.Sprintf("d%d", DeliveryLogSize+9) || log[len(log)-1].ID != "pending" {
+		t.Errorf("expected oldest finished deliveries to be pruned, got %d entries", len(log))
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	testStore(t, s)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hook := models.Webhook{ID: "h2", UserID: "user1", URL: "https://example.com", Secret: "s", Events: []string{models.EventLinkClicks}, CreatedAt: now}
+	s.CreateWebhook(hook)
+	d := testDelivery(hook, "d4", now)
+	s.Enqueue([]models.WebhookDelivery{d})
+	d.Attempts = 1
+	d.Error = "timeout"
+	s.UpdateDelivery(d)
+	s.Close()
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+
+	hooks, _ := reopened.Webhooks("user1")
+	if len(hooks) != 1 || hooks[0].ID != "h2" || hooks[0].Secret != "s" || len(hooks[0].Events) != 1 {
+		t.Fatalf("unexpected webhooks after reload: %+v", hooks)
+	}
+	if others, _ := reopened.Webhooks("user2"); len(others) != MaxWebhooks {
+		t.Errorf("expected %d webhooks of user2 after reload, got %d", MaxWebhooks, len(others))
+	}
+	claimed, _ := reopened.Claim(now, time.Minute, 10)
+	if len(claimed) != 1 || claimed[0].Attempts != 1 || claimed[0].Error != "timeout" || string(claimed[0].Payload) != `{"id":"event-d4"}` {
+		t.Errorf("unexpected deliveries after reload: %+v", claimed)
+	}
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	hook := models.Webhook{ID: "h1", UserID: "user1"}
+	s.CreateWebhook(hook)
+	now := time.Now().UTC()
+	d := testDelivery(hook, "d1", now)
+	s.Enqueue([]models.WebhookDelivery{d})
+	for i := 0; i < compactMinRecords; i++ {
+		d.Attempts++
+		s.UpdateDelivery(d)
+	}
+	if s.lines >= compactMinRecords {
+		t.Errorf("expected file to be compacted, got %d records", s.lines)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected temporary file to be renamed, got %v", err)
+	}
+	// Записи после уплотнения попадают в новый файл
+	d.Attempts++
+	s.UpdateDelivery(d)
+	s.Close()
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer reopened.Close()
+	if log, _ := reopened.Deliveries("user1", "h1", 10); len(log) != 1 || log[0].Attempts != compactMinRecords+1 {
+		t.Errorf("unexpected deliveries after compaction: %+v", log)
+	}
+}
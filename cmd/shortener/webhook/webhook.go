@@ -0,0 +1,156 @@
+// Package webhook доставляет события жизненного цикла ссылок на адреса,
+// зарегистрированные пользователями.
+//
+// События сначала записываются в журнал исходящих доставок (outbox), а затем
+// Dispatcher отправляет их подписанными HMAC-SHA256 POST запросами, повторяя
+// неудачные попытки с экспоненциальной задержкой. Журнал хранится в PostgreSQL,
+// в файле рядом с файловым хранилищем ссылок или в памяти.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// Events перечисляет типы событий, на которые можно подписать вебхук
+var Events = []string{models.EventLinkCreated, models.EventLinkDeleted, models.EventLinkClicks}
+
+// Состояния доставки
+const (
+	StatusPending   = "pending"   // Ожидает очередной попытки
+	StatusDelivered = "delivered" // Доставлено
+	StatusFailed    = "failed"    // Попытки исчерпаны
+)
+
+// Заголовки запроса вебхука
+const (
+	HeaderEvent     = "X-Webhook-Event"     // Тип события
+	HeaderDelivery  = "X-Webhook-Delivery"  // Идентификатор доставки
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix время отправки
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" + HMAC-SHA256(secret, timestamp + "." + body)
+)
+
+// Ограничения вебхуков
+const (
+	MaxWebhooks     = 10  // Максимальное количество вебхуков пользователя
+	MaxSecretLength = 128 // Максимальная длина ключа подписи
+	DeliveryLogSize = 100 // Количество последних доставок, которое возвращает журнал
+)
+
+// ErrNotFound возвращается, если вебхука нет или он принадлежит другому пользователю
+var ErrNotFound = errors.New("webhook not found")
+
+// ErrInvalid возвращается, если параметры вебхука не проходят проверку
+var ErrInvalid = errors.New("invalid webhook")
+
+// ErrLimit возвращается при попытке зарегистрировать больше MaxWebhooks вебхуков
+var ErrLimit = fmt.Errorf("%w: more than %d webhooks", ErrInvalid, MaxWebhooks)
+
+// Store хранит вебхуки и журнал их доставок
+type Store interface {
+	// CreateWebhook сохраняет новый вебхук; возвращает ErrLimit, если у пользователя
+	// уже MaxWebhooks вебхуков
+	CreateWebhook(w models.Webhook) error
+
+	// Webhooks возвращает вебхуки пользователя в порядке регистрации
+	Webhooks(userID string) ([]models.Webhook, error)
+
+	// DeleteWebhook удаляет вебхук пользователя вместе с его доставками
+	// Возвращает ErrNotFound, если у пользователя нет такого вебхука
+	DeleteWebhook(userID, id string) error
+
+	// Enqueue добавляет доставки в журнал
+	Enqueue(deliveries []models.WebhookDelivery) error
+
+	// Claim выбирает до limit доставок, срок попытки которых наступил к now,
+	// и откладывает их следующую попытку до now+lease, чтобы другие экземпляры
+	// сервиса не отправили их одновременно. Если процесс завершится до сохранения
+	// результата, доставка повторится после lease
+	Claim(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+
+	// UpdateDelivery сохраняет результат попытки доставки
+	UpdateDelivery(d models.WebhookDelivery) error
+
+	// Deliveries возвращает до limit последних доставок вебхука пользователя, от новых к старым
+	// Возвращает ErrNotFound, если у пользователя нет такого вебхука
+	Deliveries(userID, webhookID string, limit int) ([]models.WebhookDelivery, error)
+}
+
+// NewWebhook проверяет запрос на регистрацию и создает вебхук пользователя
+// Адрес получателя должен разрешаться только в адреса, допустимые для guard.
+// Если ключ подписи не задан, генерируется случайный
+func NewWebhook(ctx context.Context, userID string, req models.WebhookRequest, guard *Guard) (models.Webhook, error) {
+	target := strings.TrimSpace(req.URL)
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return models.Webhook{}, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalid)
+	}
+	if err := guard.CheckHost(ctx, u.Hostname()); err != nil {
+		return models.Webhook{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if len(req.Secret) > MaxSecretLength {
+		return models.Webhook{}, fmt.Errorf("%w: secret is longer than %d bytes", ErrInvalid, MaxSecretLength)
+	}
+
+	var events []string
+	for _, e := range req.Events {
+		e = strings.TrimSpace(e)
+		if !slices.Contains(Events, e) {
+			return models.Webhook{}, fmt.Errorf("%w: unknown event %q", ErrInvalid, e)
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = randomHex(32)
+	}
+	return models.Webhook{
+		ID:        randomHex(8),
+		UserID:    userID,
+		URL:       target,
+		Secret:    secret,
+		Events:    events,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Subscribed проверяет, что вебхук получает события типа event
+func Subscribed(w models.Webhook, event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// Sign возвращает значение заголовка HeaderSignature для тела body,
+// отправленного в момент timestamp (Unix время)
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись signature тела body; пригодна для получателей событий
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// randomHex возвращает n случайных байт в шестнадцатеричном виде
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"uno/cmd/shortener/models"
+)
+
+// testGuard проверяет адреса, разрешая имена хостов без обращения к DNS
+var testGuard = &Guard{LookupIP: func(_ context.Context, host string) ([]netip.Addr, error) {
+	switch host {
+	case "example.com":
+		return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, nil
+	case "internal.example.com":
+		return []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.0.5")}, nil
+	}
+	return nil, errors.New("no such host")
+}}
+
+func TestNewWebhook(t *testing.T) {
+	w, err := NewWebhook(context.Background(), "user1", models.WebhookRequest{
+		URL:    " https://example.com/hook ",
+		Events: []string{models.EventLinkCreated, models.EventLinkCreated, models.EventLinkClicks},
+	}, testGuard)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.URL != "https://example.com/hook" || w.UserID != "user1" || len(w.Secret) != 64 || w.ID == "" {
+		t.Errorf("unexpected webhook: %+v", w)
+	}
+	if len(w.Events) != 2 || !Subscribed(w, models.EventLinkClicks) || Subscribed(w, models.EventLinkDeleted) {
+		t.Errorf("unexpected events: %v", w.Events)
+	}
+	if all, _ := NewWebhook(context.Background(), "user1", models.WebhookRequest{URL: "http://example.com", Secret: "s"}, testGuard); !Subscribed(all, models.EventLinkDeleted) || all.Secret != "s" {
+		t.Errorf("expected webhook without events to receive all events: %+v", all)
+	}
+
+	invalid := []models.WebhookRequest{
+		{URL: "ftp://example.com"},
+		{URL: "/hook"},
+		{URL: "https://example.com", Events: []string{"link.renamed"}},
+		{URL: "https://example.com", Secret: string(make([]byte, MaxSecretLength+1))},
+		{URL: "https://unknown.example.com"},
+	}
+	for _, req := range invalid {
+		if _, err := NewWebhook(context.Background(), "user1", req, testGuard); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected ErrInvalid for %+v, got %v", req, err)
+		}
+	}
+}
+
+func TestNewWebhook_InternalAddresses(t *testing.T) {
+	targets := []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.1.2.3/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+		"http://192.168.1.1/hook",
+		"https://internal.example.com/hook",
+	}
+	for _, target := range targets {
+		_, err := NewWebhook(context.Background(), "user1", models.WebhookRequest{URL: target}, testGuard)
+		if !errors.Is(err, ErrInvalid) || !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("expected %s to be rejected, got %v", target, err)
+		}
+	}
+	if _, err := NewWebhook(context.Background(), "user1", models.WebhookRequest{URL: "http://127.0.0.1/hook"}, &Guard{AllowPrivate: true}); err != nil {
+		t.Errorf("expected internal address to be allowed, got %v", err)
+	}
+}
+
+func TestGuard_Control(t *testing.T) {
+	var guard *Guard
+	for _, address := range []string{"127.0.0.1:80", "169.254.169.254:80", "10.0.0.1:443", "[::1]:80", "[fe80::1]:80", "100.100.100.200:80"} {
+		if err := guard.Control("tcp", address, nil); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("expected %s to be rejected, got %v", address, err)
+		}
+	}
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1::1]:443"} {
+		if err := guard.Control("tcp", address, nil); err != nil {
+			t.Errorf("expected %s to be allowed, got %v", address, err)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	sig := Sign("secret", 1700000000, body)
+	if sig != Sign("secret", 1700000000, body) || len(sig) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+	if !Verify("secret", 1700000000, body, sig) {
+		t.Error("expected signature to verify")
+	}
+	if Verify("other", 1700000000, body, sig) || Verify("secret", 1700000001, body, sig) || Verify("secret", 1700000000, []byte(`{}`), sig) {
+		t.Error("expected signature mismatch")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// baselineVersion версия формата файла базовой линии
+const baselineVersion = 1
+
+// Baseline принятые находки, которые не считаются ошибкой
+//
+// Находки сопоставляются по анализатору, файлу и сообщению без номеров строк,
+// чтобы правки выше по файлу не делали старые находки новыми. Для каждого ключа
+// хранится количество: если в файле появилась еще одна такая же находка,
+// лишняя считается новой.
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry ключ принятой находки и количество ее повторений
+type BaselineEntry struct {
+	Analyzer string `json:"analyzer"`
+	File     string `json:"file"`
+	Message  string `json:"message"`
+	Count    int    `json:"count"`
+}
+
+// baselineEntryKey ключ сопоставления находки с базовой линией
+type baselineEntryKey struct {
+	Analyzer string
+	File     string
+	Message  string
+}
+
+func baselineKey(f Finding) baselineEntryKey {
+	return baselineEntryKey{Analyzer: f.Analyzer, File: f.File, Message: f.Message}
+}
+
+// fingerprint возвращает стабильный отпечаток ключа (для SARIF partialFingerprints)
+func (k baselineEntryKey) fingerprint() string {
+	sum := sha256.Sum256([]byte(k.Analyzer + "\x00" + k.File + "\x00" + k.Message))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NewBaseline создает базовую линию из находок
+func NewBaseline(findings []Finding) *Baseline {
+	counts := make(map[baselineEntryKey]int)
+	for _, f := range findings {
+		counts[baselineKey(f)]++
+	}
+	b := &Baseline{Version: baselineVersion, Findings: make([]BaselineEntry, 0, len(counts))}
+	for k, n := range counts {
+		b.Findings = append(b.Findings, BaselineEntry{Analyzer: k.Analyzer, File: k.File, Message: k.Message, Count: n})
+	}
+	sort.Slice(b.Findings, func(i, j int) bool {
+		a, c := b.Findings[i], b.Findings[j]
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Analyzer != c.Analyzer {
+			return a.Analyzer < c.Analyzer
+		}
+		return a.Message < c.Message
+	})
+	return b
+}
+
+// Filter возвращает находки, не покрытые базовой линией, и количество подавленных
+// Находки должны быть упорядочены: из одинаковых подавляются первые по позиции
+func (b *Baseline) Filter(findings []Finding) ([]Finding, int) {
+	remaining := make(map[baselineEntryKey]int, len(b.Findings))
+	for _, e := range b.Findings {
+		remaining[baselineEntryKey{Analyzer: e.Analyzer, File: e.File, Message: e.Message}] += e.Count
+	}
+	var fresh []Finding
+	suppressed := 0
+	for _, f := range findings {
+		k := baselineKey(f)
+		if remaining[k] > 0 {
+			remaining[k]--
+			suppressed++
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+	return fresh, suppressed
+}
+
+// LoadBaseline читает базовую линию; отсутствующий файл - пустая базовая линия
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Baseline{Version: baselineVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+	return &b, nil
+}
+
+// Save атомарно записывает базовую линию в path
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".staticlint-baseline-*")
+	if err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
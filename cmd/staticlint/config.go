@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// configFileName имя конфигурационного файла
+const configFileName = ".staticlint.json"
+
+// configEnv переменная окружения с явным путем к конфигурационному файлу
+const configEnv = "STATICLINT_CONFIG"
+
+// Config конфигурация staticlint из .staticlint.json
+//
+// Пример:
+//
+//	{
+//	  "enable": ["*"],
+//	  "disable": ["findcall", "fieldalignment", "ST1000"],
+//	  "flags": {"shadow": {"strict": true}},
+//	  "exclude": {"paths": ["cmd/shortener/models/*_easyjson.go", "testdata/..."], "tests": false},
+//	  "baseline": ".staticlint-baseline.json"
+//	}
+type Config struct {
+	// Enable - имена или префиксы со звездочкой (SA*, SA1*, *) включаемых анализаторов
+	// Пустой список включает все анализаторы
+	Enable []string `json:"enable"`
+	// Disable - имена или префиксы анализаторов, исключаемых из включенных
+	Disable []string `json:"disable"`
+	// Flags - значения флагов анализаторов: имя анализатора -> флаг -> значение
+	Flags map[string]map[string]any `json:"flags"`
+	// Exclude - файлы, диагностики в которых не выводятся
+	Exclude ExcludeConfig `json:"exclude"`
+	// Baseline - файл базовой линии относительно каталога конфигурации (флаг -baseline)
+	Baseline string `json:"baseline"`
+}
+
+// ExcludeConfig задает исключаемые из проверки файлы
+type ExcludeConfig struct {
+	// Paths - шаблоны путей относительно каталога конфигурации:
+	// glob для пути или имени файла, либо каталог с суффиксом "/..."
+	Paths []string `json:"paths"`
+	// Tests - не выводить диагностики в тестовых файлах (*_test.go)
+	Tests bool `json:"tests"`
+}
+
+// defaultConfig конфигурация при отсутствии .staticlint.json:
+// все анализаторы, кроме отладочного findcall и шумного fieldalignment
+func defaultConfig() *Config {
+	return &Config{Disable: []string{"findcall", "fieldalignment"}}
+}
+
+// findConfig ищет .staticlint.json: путь из STATICLINT_CONFIG, иначе в текущем
+// каталоге и его родителях вплоть до корня модуля (каталога с go.mod)
+// Возвращает пустую строку, если файл не найден
+func findConfig() (string, error) {
+	if path := os.Getenv(configEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig читает конфигурацию из path
+// Неизвестные поля считаются ошибкой, чтобы опечатки не отключали проверки молча
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Apply выбирает анализаторы, задает их флаги и включает исключение файлов
+// baseDir - каталог, относительно которого заданы шаблоны исключаемых путей
+// Возвращает ошибку для имен, не совпавших ни с одним анализатором
+func (c *Config) Apply(all []*analysis.Analyzer, baseDir string) ([]*analysis.Analyzer, error) {
+	byName := make(map[string]*analysis.Analyzer, len(all))
+	for _, a := range all {
+		byName[a.Name] = a
+	}
+
+	enable := c.Enable
+	if len(enable) == 0 {
+		enable = []string{"*"}
+	}
+	enabled, err := matchAnalyzers(all, enable)
+	if err != nil {
+		return nil, fmt.Errorf("enable: %w", err)
+	}
+	disabled, err := matchAnalyzers(all, c.Disable)
+	if err != nil {
+		return nil, fmt.Errorf("disable: %w", err)
+	}
+
+	var selected []*analysis.Analyzer
+	for _, a := range all {
+		if enabled[a.Name] && !disabled[a.Name] {
+			selected = append(selected, a)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("no analyzers enabled")
+	}
+
+	if err := c.setFlags(byName); err != nil {
+		return nil, err
+	}
+
+	if len(c.Exclude.Paths) > 0 || c.Exclude.Tests {
+		for _, a := range selected {
+			c.Exclude.wrap(a, baseDir)
+		}
+	}
+	return selected, nil
+}
+
+// setFlags задает значения флагов анализаторов
+func (c *Config) setFlags(byName map[string]*analysis.Analyzer) error {
+	names := make([]string, 0, len(c.Flags))
+	for name := range c.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		a, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("flags: unknown analyzer %q", name)
+		}
+		for flagName, value := range c.Flags[name] {
+			if a.Flags.Lookup(flagName) == nil {
+				return fmt.Errorf("flags: analyzer %s has no flag %q", name, flagName)
+			}
+			if err := a.Flags.Set(flagName, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("flags: %s.%s: %w", name, flagName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchAnalyzers возвращает имена анализаторов, подходящих под шаблоны
+// Шаблон - точное имя или префикс со звездочкой на конце
+func matchAnalyzers(all []*analysis.Analyzer, patterns []string) (map[string]bool, error) {
+	matched := make(map[string]bool)
+	for _, pattern := range patterns {
+		found := false
+		prefix, wildcard := strings.CutSuffix(pattern, "*")
+		for _, a := range all {
+			if a.Name == pattern || (wildcard && strings.HasPrefix(a.Name, prefix)) {
+				matched[a.Name] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown analyzer %q", pattern)
+		}
+	}
+	return matched, nil
+}
+
+// wrap подменяет Run анализатора так, чтобы диагностики в исключенных файлах не выводились
+func (e ExcludeConfig) wrap(a *analysis.Analyzer, baseDir string) {
+	run := a.Run
+	a.Run = func(pass *analysis.Pass) (any, error) {
+		report := pass.Report
+		pass.Report = func(d analysis.Diagnostic) {
+			if !e.excluded(pass.Fset.Position(d.Pos).Filename, baseDir) {
+				report(d)
+			}
+		}
+		return run(pass)
+	}
+}
+
+// excluded сообщает, исключен ли файл filename из проверки
+func (e ExcludeConfig) excluded(filename, baseDir string) bool {
+	if filename == "" {
+		return false
+	}
+	if e.Tests && isTestFile(filename) {
+		return true
+	}
+
+	rel := filename
+	if r, err := filepath.Rel(baseDir, filename); err == nil && !strings.HasPrefix(r, "..") {
+		rel = r
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range e.Paths {
+		if matchPath(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath сопоставляет относительный путь с шаблоном исключения
+// "dir/..." совпадает со всеми файлами каталога dir (в любом месте пути),
+// остальные шаблоны - glob для всего пути или имени файла
+func matchPath(pattern, rel string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/..."); ok {
+		dir = strings.Trim(dir, "/")
+		return strings.HasPrefix(rel, dir+"/") || strings.Contains(rel, "/"+dir+"/")
+	}
+	if ok, _ := filepath.Match(pattern, rel); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, filepath.Base(rel))
+	return ok
+}
+
+// isTestFile определяет, является ли файл тестовым
+func isTestFile(filename string) bool {
+	return strings.HasSuffix(filepath.Base(filename), "_test.go")
+}
+
+// BaselinePath возвращает путь к файлу базовой линии или пустую строку
+func (c *Config) BaselinePath(baseDir string) string {
+	if c.Baseline == "" || filepath.IsAbs(c.Baseline) {
+		return c.Baseline
+	}
+	return filepath.Join(baseDir, c.Baseline)
+}
+
+// configBaseDir возвращает каталог, относительно которого заданы пути конфигурации
+func configBaseDir(path string) string {
+	if path == "" {
+		if wd, err := os.Getwd(); err == nil {
+			return wd
+		}
+		return "."
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return filepath.Dir(path)
+	}
+	return dir
+}
+
+// readConfig находит и читает конфигурацию; без файла возвращает конфигурацию по умолчанию
+func readConfig() (*Config, string, error) {
+	path, err := findConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	if path == "" {
+		return defaultConfig(), configBaseDir(""), nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, "", fmt.Errorf("config file %s does not exist", path)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, configBaseDir(path), nil
+}
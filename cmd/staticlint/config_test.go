@@ -0,0 +1,227 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// testAnalyzers создает анализаторы-заглушки с заданными именами
+func testAnalyzers(names ...string) []*analysis.Analyzer {
+	var analyzers []*analysis.Analyzer
+	for _, name := range names {
+		a := &analysis.Analyzer{
+			Name: name,
+			Doc:  name,
+			Run:  func(*analysis.Pass) (any, error) { return nil, nil },
+		}
+		a.Flags.Bool("strict", false, "strict mode")
+		analyzers = append(analyzers, a)
+	}
+	return analyzers
+}
+
+func analyzerNames(analyzers []*analysis.Analyzer) []string {
+	names := make([]string, 0, len(analyzers))
+	for _, a := range analyzers {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func TestConfigApplySelection(t *testing.T) {
+	all := []string{"shadow", "findcall", "fieldalignment", "SA1000", "SA1019", "SA4006", "ST1003", "noosexit"}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "empty enables all",
+			cfg:  Config{},
+			want: all,
+		},
+		{
+			name: "default config",
+			cfg:  *defaultConfig(),
+			want: []string{"shadow", "SA1000", "SA1019", "SA4006", "ST1003", "noosexit"},
+		},
+		{
+			name: "prefix",
+			cfg:  Config{Enable: []string{"SA1*", "noosexit"}},
+			want: []string{"SA1000", "SA1019", "noosexit"},
+		},
+		{
+			name: "disable by name and prefix",
+			cfg:  Config{Enable: []string{"*"}, Disable: []string{"SA*", "findcall"}},
+			want: []string{"shadow", "fieldalignment", "ST1003", "noosexit"},
+		},
+		{
+			name:    "unknown analyzer",
+			cfg:     Config{Enable: []string{"ST1003", "SA9999"}},
+			wantErr: `enable: unknown analyzer "SA9999"`,
+		},
+		{
+			name:    "prefix without matches",
+			cfg:     Config{Disable: []string{"QF*"}},
+			wantErr: `disable: unknown analyzer "QF*"`,
+		},
+		{
+			name:    "nothing enabled",
+			cfg:     Config{Enable: []string{"SA*"}, Disable: []string{"SA*"}},
+			wantErr: "no analyzers enabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.Apply(testAnalyzers(all...), t.TempDir())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if names := analyzerNames(got); !slices.Equal(names, tt.want) {
+				t.Errorf("Apply() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigApplyFlags(t *testing.T) {
+	analyzers := testAnalyzers("shadow")
+	cfg := Config{Flags: map[string]map[string]any{"shadow": {"strict": true}}}
+	if _, err := cfg.Apply(analyzers, t.TempDir()); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := analyzers[0].Flags.Lookup("strict").Value.String(); got != "true" {
+		t.Errorf("strict = %s, want true", got)
+	}
+
+	cfg = Config{Flags: map[string]map[string]any{"shadow": {"missing": 1}}}
+	if _, err := cfg.Apply(testAnalyzers("shadow"), t.TempDir()); err == nil || !strings.Contains(err.Error(), `has no flag "missing"`) {
+		t.Errorf("Apply() error = %v, want unknown flag error", err)
+	}
+
+	cfg = Config{Flags: map[string]map[string]any{"unknown": {"strict": true}}}
+	if _, err := cfg.Apply(testAnalyzers("shadow"), t.TempDir()); err == nil || !strings.Contains(err.Error(), `unknown analyzer "unknown"`) {
+		t.Errorf("Apply() error = %v, want unknown analyzer error", err)
+	}
+}
+
+func TestExcludeConfig(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	e := ExcludeConfig{
+		Paths: []string{"cmd/shortener/models/*_easyjson.go", "testdata/...", "generated.go"},
+		Tests: true,
+	}
+
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"/repo/cmd/shortener/models/models_easyjson.go", true},
+		{"/repo/cmd/shortener/models/models.go", false},
+		{"/repo/cmd/staticlint/noosexit/testdata/src/a/a.go", true},
+		{"/repo/pkg/generated.go", true},
+		{"/repo/cmd/shortener/main_test.go", true},
+		{"/repo/cmd/shortener/main.go", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := e.excluded(filepath.FromSlash(tt.file), base); got != tt.want {
+				t.Errorf("excluded(%q) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludeWrap(t *testing.T) {
+	fset := token.NewFileSet()
+	kept := fset.AddFile("/repo/main.go", -1, 10)
+	dropped := fset.AddFile("/repo/main_test.go", -1, 10)
+
+	a := &analysis.Analyzer{
+		Name: "test",
+		Doc:  "test",
+		Run: func(pass *analysis.Pass) (any, error) {
+			pass.Reportf(token.Pos(kept.Base()), "kept")
+			pass.Reportf(token.Pos(dropped.Base()), "dropped")
+			return nil, nil
+		},
+	}
+	cfg := Config{Exclude: ExcludeConfig{Tests: true}}
+	if _, err := cfg.Apply([]*analysis.Analyzer{a}, "/repo"); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	var got []string
+	pass := &analysis.Pass{
+		Fset:   fset,
+		Report: func(d analysis.Diagnostic) { got = append(got, d.Message) },
+	}
+	if _, err := a.Run(pass); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !slices.Equal(got, []string{"kept"}) {
+		t.Errorf("reported %v, want [kept]", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, configFileName)
+
+	writeFile(t, path, `{"enable":["SA*"],"exclude":{"tests":true}}`)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if !slices.Equal(cfg.Enable, []string{"SA*"}) || !cfg.Exclude.Tests {
+		t.Errorf("loadConfig() = %+v", cfg)
+	}
+
+	// Опечатка в имени поля не должна молча игнорироваться
+	writeFile(t, path, `{"enabled":["SA*"]}`)
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("loadConfig() error = %v, want unknown field error", err)
+	}
+}
+
+func TestReadConfigEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "custom.json")
+	writeFile(t, path, `{"disable":["findcall"]}`)
+
+	t.Setenv(configEnv, path)
+	cfg, baseDir, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig() error = %v", err)
+	}
+	if !slices.Equal(cfg.Disable, []string{"findcall"}) || baseDir != dir {
+		t.Errorf("readConfig() = %+v, %s", cfg, baseDir)
+	}
+
+	t.Setenv(configEnv, filepath.Join(dir, "missing.json"))
+	if _, _, err := readConfig(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("readConfig() error = %v, want missing file error", err)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
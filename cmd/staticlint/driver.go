@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Коды завершения, совпадающие с multichecker
+const (
+	exitOK       = 0 // новых находок нет
+	exitError    = 1 // ошибка загрузки пакетов, анализа или вывода
+	exitFindings = 3 // есть новые находки
+)
+
+// lintOptions параметры запуска
+type lintOptions struct {
+	format        string
+	output        string
+	baseline      string
+	writeBaseline bool
+	tests         bool
+	fix           bool
+	patterns      []string
+}
+
+// parseLintFlags разбирает флаги командной строки
+// defaultBaseline - путь к базовой линии из конфигурации
+func parseLintFlags(args []string, analyzers []*analysis.Analyzer, defaultBaseline string, stderr io.Writer) (*lintOptions, error) {
+	fset := flag.NewFlagSet("staticlint", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	opts := &lintOptions{}
+	fset.StringVar(&opts.format, "format", FormatText, "output format: text, json (JSON Lines) or sarif (SARIF 2.1.0)")
+	fset.StringVar(&opts.output, "o", "", "write results to file instead of stdout")
+	fset.StringVar(&opts.baseline, "baseline", defaultBaseline, "baseline file with accepted findings")
+	fset.BoolVar(&opts.writeBaseline, "write-baseline", false, "write current findings to the baseline file and exit")
+	fset.BoolVar(&opts.tests, "test", true, "also analyze test files")
+	fset.BoolVar(&opts.fix, "fix", false, "apply the first suggested fix of each finding")
+	fset.Usage = func() {
+		fmt.Fprintf(stderr, "usage: staticlint [flags] packages...\n\nflags:\n")
+		fset.PrintDefaults()
+		names := make([]string, 0, len(analyzers))
+		for _, a := range analyzers {
+			names = append(names, a.Name)
+		}
+		fmt.Fprintf(stderr, "\nenabled analyzers (see %s): %s\n", configFileName, strings.Join(names, " "))
+	}
+	if err := fset.Parse(args); err != nil {
+		return nil, err
+	}
+
+	switch opts.format {
+	case FormatText, FormatJSON, FormatSARIF:
+	default:
+		return nil, fmt.Errorf("unknown format %q", opts.format)
+	}
+	if opts.writeBaseline && opts.baseline == "" {
+		return nil, fmt.Errorf("-write-baseline requires -baseline")
+	}
+	opts.patterns = fset.Args()
+	if len(opts.patterns) == 0 {
+		fset.Usage()
+		return nil, fmt.Errorf("no packages specified")
+	}
+	return opts, nil
+}
+
+// lint загружает пакеты, запускает анализаторы и выводит находки
+// Пути в результатах отсчитываются от baseDir. Возвращает код завершения
+func lint(args []string, analyzers []*analysis.Analyzer, baseDir, defaultBaseline string, stdout, stderr io.Writer) int {
+	opts, err := parseLintFlags(args, analyzers, defaultBaseline, stderr)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "staticlint: %v\n", err)
+		return exitError
+	}
+
+	findings, err := analyze(analyzers, opts, baseDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "staticlint: %v\n", err)
+		return exitError
+	}
+
+	if opts.writeBaseline {
+		if err := NewBaseline(findings).Save(opts.baseline); err != nil {
+			fmt.Fprintf(stderr, "staticlint: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stderr, "staticlint: %d findings written to %s\n", len(findings), opts.baseline)
+		return exitOK
+	}
+
+	if opts.baseline != "" {
+		b, err := LoadBaseline(opts.baseline)
+		if err != nil {
+			fmt.Fprintf(stderr, "staticlint: %v\n", err)
+			return exitError
+		}
+		var suppressed int
+		findings, suppressed = b.Filter(findings)
+		if suppressed > 0 && opts.format == FormatText {
+			fmt.Fprintf(stderr, "staticlint: %d findings suppressed by baseline %s\n", suppressed, opts.baseline)
+		}
+	}
+
+	if opts.fix {
+		var fixed int
+		findings, fixed, err = applyFixes(findings, baseDir)
+		if err != nil {
+			fmt.Fprintf(stderr, "staticlint: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stderr, "staticlint: %d fixes applied\n", fixed)
+	}
+
+	if err := writeOutput(opts, findings, analyzers, baseDir, stdout); err != nil {
+		fmt.Fprintf(stderr, "staticlint: %v\n", err)
+		return exitError
+	}
+	if len(findings) > 0 {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// analyze загружает пакеты и собирает находки анализаторов
+func analyze(analyzers []*analysis.Analyzer, opts *lintOptions, baseDir string) ([]Finding, error) {
+	// Анализаторы с фактами обходят зависимости, поэтому загружается полный синтаксис
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadAllSyntax, Tests: opts.tests}, opts.patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		return nil, fmt.Errorf("%d errors while loading packages", n)
+	}
+
+	// Сгенерированные go test пакеты main (pkg.test) не анализируются
+	roots := pkgs[:0]
+	for _, p := range pkgs {
+		if !strings.HasSuffix(p.ID, ".test") {
+			roots = append(roots, p)
+		}
+	}
+
+	graph, err := checker.Analyze(analyzers, roots, nil)
+	if err != nil {
+		return nil, err
+	}
+	return collectFindings(graph, baseDir)
+}
+
+// writeOutput выводит находки в stdout или в файл -o
+func writeOutput(opts *lintOptions, findings []Finding, analyzers []*analysis.Analyzer, baseDir string, stdout io.Writer) error {
+	if opts.output == "" {
+		return writeFindings(stdout, opts.format, findings, analyzers, baseDir)
+	}
+	f, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+	if err := writeFindings(f, opts.format, findings, analyzers, baseDir); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// applyFixes применяет первое предложенное исправление каждой находки
+// Исправления, пересекающиеся с уже принятыми, пропускаются. Возвращает
+// оставшиеся (не исправленные) находки и количество примененных исправлений
+func applyFixes(findings []Finding, baseDir string) ([]Finding, int, error) {
+	edits := make(map[string][]Edit)
+	var remaining []Finding
+	fixed := 0
+	for _, f := range findings {
+		if len(f.SuggestedFixes) == 0 || !canApply(edits, f.SuggestedFixes[0].Edits) {
+			remaining = append(remaining, f)
+			continue
+		}
+		for _, e := range f.SuggestedFixes[0].Edits {
+			edits[e.File] = append(edits[e.File], e)
+		}
+		fixed++
+	}
+
+	files := make([]string, 0, len(edits))
+	for file := range edits {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		path := filepath.FromSlash(file)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if err := applyEdits(path, edits[file]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return remaining, fixed, nil
+}
+
+// canApply проверяет, что правки не пересекаются с уже принятыми
+func canApply(accepted map[string][]Edit, edits []Edit) bool {
+	for _, e := range edits {
+		for _, a := range accepted[e.File] {
+			if e.Start.Offset < a.End.Offset && a.Start.Offset < e.End.Offset ||
+				e.Start.Offset == a.Start.Offset {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// applyEdits применяет непересекающиеся правки к файлу
+func applyEdits(path string, edits []Edit) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start.Offset > edits[j].Start.Offset })
+	for _, e := range edits {
+		if e.Start.Offset < 0 || e.End.Offset > len(data) || e.Start.Offset > e.End.Offset {
+			return fmt.Errorf("%s: fix is out of range", path)
+		}
+		data = append(data[:e.Start.Offset], append([]byte(e.NewText), data[e.End.Offset:]...)...)
+	}
+	return os.WriteFile(path, data, info.Mode().Perm())
+}
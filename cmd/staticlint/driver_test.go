@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"uno/cmd/staticlint/noosexit"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// testPackage пакет с известной находкой noosexit в строке 12
+const testPackage = "./noosexit/testdata/src/a"
+
+func runLint(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	code := lint(args, []*analysis.Analyzer{noosexit.Analyzer}, wd, "", &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestLintText(t *testing.T) {
+	code, out, errOut := runLint(t, testPackage)
+	if code != exitFindings {
+		t.Fatalf("exit code = %d, want %d; stderr: %s", code, exitFindings, errOut)
+	}
+	want := "noosexit/testdata/src/a/a.go:12:2: прямой вызов os.Exit в функции main запрещен (noosexit)\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestLintJSON(t *testing.T) {
+	code, out, errOut := runLint(t, "-format=json", testPackage)
+	if code != exitFindings {
+		t.Fatalf("exit code = %d; stderr: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %s", len(lines), out)
+	}
+	var f Finding
+	if err := json.Unmarshal([]byte(lines[0]), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Analyzer != "noosexit" || f.File != "noosexit/testdata/src/a/a.go" || f.Start.Line != 12 || f.Start.Column != 2 {
+		t.Errorf("finding = %+v", f)
+	}
+	if f.End.Offset <= f.Start.Offset {
+		t.Errorf("end %+v is not after start %+v", f.End, f.Start)
+	}
+}
+
+func TestLintSARIF(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.sarif")
+	code, _, errOut := runLint(t, "-format=sarif", "-o", out, testPackage)
+	if code != exitFindings {
+		t.Fatalf("exit code = %d; stderr: %s", code, errOut)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || log.Schema != sarifSchema || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF header: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "noosexit" {
+		t.Errorf("rules = %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(run.Results))
+	}
+	res := run.Results[0]
+	loc := res.Locations[0].PhysicalLocation
+	if res.RuleID != "noosexit" || res.RuleIndex != 0 || loc.ArtifactLocation.URI != "noosexit/testdata/src/a/a.go" ||
+		loc.ArtifactLocation.URIBaseID != srcRoot || loc.Region.StartLine != 12 || loc.Region.StartColumn != 2 {
+		t.Errorf("result = %+v", res)
+	}
+	if res.PartialFingerprints["staticlint/v1"] == "" {
+		t.Error("fingerprint is empty")
+	}
+}
+
+func TestLintBaseline(t *testing.T) {
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	code, _, errOut := runLint(t, "-baseline", baseline, "-write-baseline", testPackage)
+	if code != exitOK {
+		t.Fatalf("write baseline: exit code = %d; stderr: %s", code, errOut)
+	}
+
+	code, out, errOut := runLint(t, "-baseline", baseline, testPackage)
+	if code != exitOK || out != "" {
+		t.Fatalf("with baseline: exit code = %d, output %q; stderr: %s", code, out, errOut)
+	}
+
+	// Пустая базовая линия не подавляет находки
+	if err := NewBaseline(nil).Save(baseline); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, _ := runLint(t, "-baseline", baseline, testPackage); code != exitFindings {
+		t.Errorf("exit code = %d, want %d", code, exitFindings)
+	}
+}
+
+func TestLintFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown format", []string{"-format=xml", testPackage}},
+		{"no packages", nil},
+		{"write baseline without path", []string{"-write-baseline", testPackage}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, _ := runLint(t, tt.args...); code != exitError {
+				t.Errorf("exit code = %d, want %d", code, exitError)
+			}
+		})
+	}
+}
+
+func TestBaselineFilter(t *testing.T) {
+	finding := func(line int, msg string) Finding {
+		return Finding{Analyzer: "a", File: "x.go", Start: Position{Line: line}, Message: msg}
+	}
+	old := []Finding{finding(10, "m1"), finding(20, "m1"), finding(30, "m2")}
+	b := NewBaseline(old)
+	if len(b.Findings) != 2 || b.Findings[0].Count != 2 {
+		t.Fatalf("baseline = %+v", b.Findings)
+	}
+
+	// Строки сдвинулись, появилась третья находка m1 и новая m3
+	current := []Finding{finding(12, "m1"), finding(22, "m1"), finding(25, "m1"), finding(32, "m2"), finding(40, "m3")}
+	fresh, suppressed := b.Filter(current)
+	if suppressed != 3 {
+		t.Errorf("suppressed = %d, want 3", suppressed)
+	}
+	if len(fresh) != 2 || fresh[0].Start.Line != 25 || fresh[1].Message != "m3" {
+		t.Errorf("fresh = %+v", fresh)
+	}
+}
+
+func TestColumnConverter(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "x.go"), []byte("package x\n// ёж 𝄞 x\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := newColumnConverter(dir)
+	// "// " - 3 байта, "ёж " - 5 байт, "𝄞 " - 5 байт: x начинается с байта 14
+	if got := c.utf16("x.go", Position{Line: 2, Column: 14}); got != 10 {
+		t.Errorf("utf16 column = %d, want 10", got)
+	}
+	if got := c.utf16("missing.go", Position{Line: 2, Column: 14}); got != 14 {
+		t.Errorf("utf16 column for missing file = %d, want 14", got)
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "x.go")
+	if err := os.WriteFile(path, []byte("aaa bbb ccc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	edit := func(start, end int, text string) []Fix {
+		return []Fix{{Edits: []Edit{{File: "x.go", Start: Position{Offset: start}, End: Position{Offset: end}, NewText: text}}}}
+	}
+	findings := []Finding{
+		{Message: "first", SuggestedFixes: edit(0, 3, "A")},
+		{Message: "overlap", SuggestedFixes: edit(1, 5, "B")},
+		{Message: "last", SuggestedFixes: edit(8, 11, "C")},
+		{Message: "no fix"},
+	}
+	remaining, fixed, err := applyFixes(findings, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != 2 || len(remaining) != 2 {
+		t.Errorf("fixed = %d, remaining = %+v", fixed, remaining)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "A bbb C" {
+		t.Errorf("file = %q, want %q", data, "A bbb C")
+	}
+}
@@ -0,0 +1,329 @@
+// Package handlererr предоставляет анализатор соглашений HTTP обработчиков сервиса.
+//
+// В обработчиках (функциях с параметрами http.ResponseWriter и *http.Request)
+// анализатор сообщает о:
+//   - проигнорированных ошибках методов хранилища uno/cmd/shortener/storage;
+//   - проигнорированных ошибках чтения тела запроса (io.ReadAll(r.Body),
+//     json.NewDecoder(r.Body).Decode и т.п.);
+//   - вызове http.Error после того, как заголовок ответа уже отправлен
+//     вызовом WriteHeader или Write: статус ответа изменить уже нельзя.
+//
+// Кроме того, в любой функции, где доступен контекст запроса (параметр
+// *http.Request или context.Context), запрещены context.Background() и
+// context.TODO(): они теряют отмену и дедлайн запроса.
+package handlererr
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// storagePkgSuffix окончание пути пакета хранилища сервиса
+const storagePkgSuffix = "cmd/shortener/storage"
+
+// Analyzer - анализатор обработки ошибок в HTTP обработчиках сервиса.
+var Analyzer = &analysis.Analyzer{
+	Name:     "handlererr",
+	Doc:      "проверяет обработку ошибок хранилища и чтения тела запроса в HTTP обработчиках, http.Error после WriteHeader и context.Background при доступном контексте запроса",
+	Run:      run,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	inspect.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil && isHandler(pass, n.Type) {
+				checkWriteHeader(pass, n.Body)
+			}
+		case *ast.FuncLit:
+			if isHandler(pass, n.Type) {
+				checkWriteHeader(pass, n.Body)
+			}
+		case *ast.CallExpr:
+			checkCall(pass, n, stack)
+		}
+		return true
+	})
+
+	return nil, nil
+}
+
+// checkCall проверяет вызов с учетом объемлющих функций из stack
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, stack []ast.Node) {
+	// Callee, в отличие от StaticCallee, возвращает и методы интерфейсов (storage.Storage)
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok {
+		return
+	}
+
+	if name := fn.FullName(); name == "context.Background" || name == "context.TODO" {
+		if hasRequestContext(pass, stack) {
+			pass.Reportf(call.Pos(), "%s() при доступном контексте запроса: используйте r.Context() или переданный ctx", name)
+		}
+		return
+	}
+
+	if !inHandler(pass, stack) || !returnsError(fn) || !errorIgnored(call, fn, stack) {
+		return
+	}
+	switch {
+	case isStorageMethod(fn):
+		pass.Reportf(call.Pos(), "ошибка %s.%s хранилища проигнорирована", recvName(fn), fn.Name())
+	case readsBody(pass, call, fn):
+		pass.Reportf(call.Pos(), "ошибка чтения тела запроса проигнорирована")
+	}
+}
+
+// checkWriteHeader сообщает о вызовах http.Error после WriteHeader или Write
+// Учитываются WriteHeader и Write, выполняемые безусловно: операторы объемлющих
+// блоков, предшествующие оператору с http.Error
+func checkWriteHeader(pass *analysis.Pass, body *ast.BlockStmt) {
+	var walk func(block []ast.Stmt, written map[types.Object]bool)
+	walk = func(block []ast.Stmt, written map[types.Object]bool) {
+		written = copyMap(written)
+		for _, stmt := range block {
+			ast.Inspect(stmt, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncLit:
+					// Вложенные обработчики проверяются отдельно
+					return false
+				case *ast.BlockStmt:
+					walk(n.List, written)
+					return false
+				case *ast.CaseClause:
+					walk(n.Body, written)
+					return false
+				case *ast.CommClause:
+					walk(n.Body, written)
+					return false
+				case *ast.CallExpr:
+					if w := httpErrorWriter(pass, n); w != nil && written[w] {
+						pass.Reportf(n.Pos(), "http.Error после отправки заголовка ответа: статус уже не изменить")
+					}
+				}
+				return true
+			})
+			if w := headerWriter(pass, stmt); w != nil {
+				written[w] = true
+			}
+		}
+	}
+	walk(body.List, nil)
+}
+
+// headerWriter возвращает ResponseWriter, если оператор - вызов его WriteHeader или Write
+func headerWriter(pass *analysis.Pass, stmt ast.Stmt) types.Object {
+	var call *ast.CallExpr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		call, _ = s.X.(*ast.CallExpr)
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			call, _ = s.Rhs[0].(*ast.CallExpr)
+		}
+	}
+	if call == nil {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (sel.Sel.Name != "WriteHeader" && sel.Sel.Name != "Write") {
+		return nil
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok || !isResponseWriter(pass.TypesInfo.TypeOf(id)) {
+		return nil
+	}
+	return pass.TypesInfo.Uses[id]
+}
+
+// httpErrorWriter возвращает ResponseWriter, переданный в http.Error
+func httpErrorWriter(pass *analysis.Pass, call *ast.CallExpr) types.Object {
+	fn := typeutil.StaticCallee(pass.TypesInfo, call)
+	if fn == nil || fn.FullName() != "net/http.Error" || len(call.Args) == 0 {
+		return nil
+	}
+	id, ok := call.Args[0].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	return pass.TypesInfo.Uses[id]
+}
+
+// errorIgnored проверяет, что ошибка, возвращаемая вызовом, отбрасывается:
+// вызов - отдельный оператор, go или defer, либо ошибка присваивается в _
+func errorIgnored(call *ast.CallExpr, fn *types.Func, stack []ast.Node) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	switch parent := stack[len(stack)-2].(type) {
+	case *ast.ExprStmt, *ast.GoStmt, *ast.DeferStmt:
+		return true
+	case *ast.AssignStmt:
+		if len(parent.Rhs) != 1 || parent.Rhs[0] != call {
+			return false
+		}
+		results := fn.Type().(*types.Signature).Results()
+		if len(parent.Lhs) != results.Len() {
+			return false
+		}
+		id, ok := parent.Lhs[len(parent.Lhs)-1].(*ast.Ident)
+		return ok && id.Name == "_"
+	case *ast.ValueSpec:
+		if len(parent.Values) != 1 || parent.Values[0] != call || len(parent.Names) == 0 {
+			return false
+		}
+		return parent.Names[len(parent.Names)-1].Name == "_"
+	}
+	return false
+}
+
+// returnsError проверяет, что последний результат функции - error
+func returnsError(fn *types.Func) bool {
+	results := fn.Type().(*types.Signature).Results()
+	return results.Len() > 0 && isError(results.At(results.Len()-1).Type())
+}
+
+// isStorageMethod проверяет, что fn - метод типа из пакета хранилища
+func isStorageMethod(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	return sig.Recv() != nil && fn.Pkg() != nil && strings.HasSuffix(fn.Pkg().Path(), storagePkgSuffix)
+}
+
+// recvName возвращает имя типа получателя метода
+func recvName(fn *types.Func) string {
+	t := fn.Type().(*types.Signature).Recv().Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return t.String()
+}
+
+// readsBody проверяет, что вызов читает тело запроса: r.Body передается аргументом
+// или входит в выражение получателя (json.NewDecoder(r.Body).Decode)
+// Закрытие тела чтением не считается
+func readsBody(pass *analysis.Pass, call *ast.CallExpr, fn *types.Func) bool {
+	if fn.Name() == "Close" {
+		return false
+	}
+	exprs := append([]ast.Expr(nil), call.Args...)
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		exprs = append(exprs, sel.X)
+	}
+	for _, e := range exprs {
+		found := false
+		ast.Inspect(e, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "Body" && isRequest(pass.TypesInfo.TypeOf(sel.X)) {
+				found = true
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// inHandler проверяет, что ближайшая объемлющая функция - HTTP обработчик
+func inHandler(pass *analysis.Pass, stack []ast.Node) bool {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch f := stack[i].(type) {
+		case *ast.FuncLit:
+			return isHandler(pass, f.Type)
+		case *ast.FuncDecl:
+			return isHandler(pass, f.Type)
+		}
+	}
+	return false
+}
+
+// hasRequestContext проверяет, что в области видимости есть параметр
+// *http.Request или context.Context одной из объемлющих функций
+func hasRequestContext(pass *analysis.Pass, stack []ast.Node) bool {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var ft *ast.FuncType
+		switch f := stack[i].(type) {
+		case *ast.FuncLit:
+			ft = f.Type
+		case *ast.FuncDecl:
+			ft = f.Type
+		default:
+			continue
+		}
+		for _, field := range ft.Params.List {
+			t := pass.TypesInfo.TypeOf(field.Type)
+			if len(field.Names) > 0 && field.Names[0].Name != "_" && (isRequest(t) || isContext(t)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isHandler проверяет, что функция имеет сигнатуру http.HandlerFunc
+func isHandler(pass *analysis.Pass, ft *ast.FuncType) bool {
+	var params []types.Type
+	for _, field := range ft.Params.List {
+		t := pass.TypesInfo.TypeOf(field.Type)
+		n := max(len(field.Names), 1)
+		for range n {
+			params = append(params, t)
+		}
+	}
+	return len(params) == 2 && isResponseWriter(params[0]) && isRequest(params[1])
+}
+
+func isResponseWriter(t types.Type) bool {
+	return isNamed(t, "net/http", "ResponseWriter")
+}
+
+func isRequest(t types.Type) bool {
+	p, ok := t.(*types.Pointer)
+	return ok && isNamed(p.Elem(), "net/http", "Request")
+}
+
+func isContext(t types.Type) bool {
+	return isNamed(t, "context", "Context")
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// isNamed проверяет, что t - именованный тип pkg.name
+func isNamed(t types.Type, pkg, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == pkg
+}
+
+func copyMap(m map[types.Object]bool) map[types.Object]bool {
+	c := make(map[types.Object]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
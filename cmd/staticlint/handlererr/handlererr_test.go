@@ -0,0 +1,12 @@
+package handlererr
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	// Пакет uno/cmd/shortener/storage в testdata заменяет хранилище сервиса
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "handlers", "ctxuse")
+}
@@ -0,0 +1,25 @@
+package ctxuse
+
+import (
+	"context"
+	"net/http"
+)
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	load(context.Background()) // want `context.Background\(\) при доступном контексте запроса`
+	go func() {
+		load(context.TODO()) // want `context.TODO\(\) при доступном контексте запроса`
+	}()
+	load(r.Context())
+}
+
+func process(ctx context.Context) {
+	load(context.Background()) // want `context.Background\(\) при доступном контексте запроса`
+}
+
+func load(ctx context.Context) {}
+
+// Без контекста в области видимости context.Background допустим
+func main() {
+	load(context.Background())
+}
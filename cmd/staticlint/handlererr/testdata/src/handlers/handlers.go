@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"uno/cmd/shortener/storage"
+)
+
+func BatchHandler(store storage.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body) // want `ошибка чтения тела запроса проигнорирована`
+		var ids []string
+		json.NewDecoder(r.Body).Decode(&ids) // want `ошибка чтения тела запроса проигнорирована`
+		_ = data
+
+		store.SaveBatch(nil, "user")          // want `ошибка Storage.SaveBatch хранилища проигнорирована`
+		go store.DeleteURLs("user", ids)      // want `ошибка Storage.DeleteURLs хранилища проигнорирована`
+		var _ = store.DeleteURLs("user", ids) // want `ошибка Storage.DeleteURLs хранилища проигнорирована`
+		store.Save("id", "url", "user")
+		defer r.Body.Close()
+
+		if err := store.SaveBatch(nil, "user"); err != nil {
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed", http.StatusBadRequest)
+			return
+		}
+
+		if len(body) == 0 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write(body)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if _, err := json.Marshal(ids); err != nil {
+			http.Error(w, "failed", http.StatusInternalServerError) // want `http.Error после отправки заголовка ответа`
+			return
+		}
+		http.Error(w, "failed", http.StatusInternalServerError) // want `http.Error после отправки заголовка ответа`
+	}
+}
+
+func FileHandler(fs *storage.FileStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fs.Compact() // want `ошибка FileStorage.Compact хранилища проигнорирована`
+		w.Write([]byte("ok"))
+		switch r.Method {
+		case http.MethodPost:
+			http.Error(w, "late", http.StatusBadRequest) // want `http.Error после отправки заголовка ответа`
+		}
+	}
+}
+
+// Вне обработчиков ошибки хранилища не проверяются
+func compact(fs *storage.FileStorage) {
+	fs.Compact()
+}
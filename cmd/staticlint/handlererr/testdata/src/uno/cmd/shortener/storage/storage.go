@@ -0,0 +1,16 @@
+// Упрощенный пакет хранилища сервиса для тестов анализатора
+package storage
+
+// Storage интерфейс хранилища
+type Storage interface {
+	Save(shortID, originalURL, userID string)
+	Get(shortID string) (string, bool, bool)
+	SaveBatch(pairs map[string]string, userID string) error
+	DeleteURLs(userID string, ids []string) error
+}
+
+// FileStorage хранилище в файле
+type FileStorage struct{}
+
+// Compact уплотняет файл
+func (fs *FileStorage) Compact() error { return nil }
@@ -5,15 +5,28 @@
 //
 // Базовое использование:
 //
-//	go run cmd/staticlint/main.go ./...
+//	go run ./cmd/staticlint ./...
 //
 // Запуск для конкретного пакета:
 //
-//	go run cmd/staticlint/main.go ./cmd/shortener/...
+//	go run ./cmd/staticlint ./cmd/shortener/...
 //
 // Запуск с дополнительными флагами:
 //
-//	go run cmd/staticlint/main.go -test=false ./...
+//	go run ./cmd/staticlint -test=false ./...
+//
+// # Форматы вывода и базовая линия
+//
+// Флаг -format задает формат: text (по умолчанию), json (JSON Lines с анализатором,
+// позицией, сообщением и предлагаемыми исправлениями) или sarif (SARIF 2.1.0).
+// Флаг -o записывает результат в файл.
+//
+// Базовая линия (-baseline или поле baseline конфигурации) подавляет принятые находки,
+// и запуск завершается с кодом 3 только при новых. Текущие находки записываются
+// в базовую линию флагом -write-baseline:
+//
+//	go run ./cmd/staticlint -baseline .staticlint-baseline.json -write-baseline ./...
+//	go run ./cmd/staticlint -baseline .staticlint-baseline.json -format=sarif -o lint.sarif ./...
 //
 // # Состав анализаторов
 //
@@ -79,38 +92,58 @@
 // - errcheck: проверяет, что все ошибки обрабатываются
 // - ineffassign: находит неэффективные присваивания переменным
 //
-// ## Собственный анализатор:
+// ## Собственные анализаторы:
+//
+//   - noosexit: запрещает os.Exit, log.Fatal* и log.Panic*, достижимые из функции main
+//     пакета main через функции пакета, в том числе в горутинах. Такие вызовы
+//     пропускают отложенные закрытия ресурсов. Допустимо только завершение с ошибкой
+//     в блоке if верхнего уровня main до первого defer.
+//   - handlererr: проверяет соглашения HTTP обработчиков сервиса: ошибки методов
+//     хранилища и чтения тела запроса не игнорируются, http.Error не вызывается
+//     после WriteHeader, context.Background не используется при доступном
+//     контексте запроса.
+//
+// # Конфигурация
+//
+// Набор анализаторов задает файл .staticlint.json. Он ищется в текущем каталоге
+// и выше вплоть до корня модуля; явный путь можно указать в STATICLINT_CONFIG.
 //
-//   - noosexit: запрещает прямой вызов os.Exit в функции main пакета main.
-//     Помогает избежать неконтролируемого завершения программы и способствует
-//     более чистому коду с правильной обработкой ошибок.
+//	{
+//	  "enable": ["*"],
+//	  "disable": ["findcall", "fieldalignment", "ST1000"],
+//	  "flags": {"shadow": {"strict": true}},
+//	  "exclude": {"paths": ["cmd/shortener/models/*_easyjson.go"], "tests": false}
+//	}
+//
+// enable и disable принимают имена анализаторов или префиксы со звездочкой (SA*, ST1*).
+// Неизвестное имя - ошибка запуска. Без файла включены все анализаторы, кроме
+// findcall и fieldalignment.
 //
 // # Примеры использования
 //
 // Проверка всего проекта:
 //
-//	go run cmd/staticlint/main.go ./...
+//	go run ./cmd/staticlint ./...
 //
 // Проверка конкретного пакета с выводом только ошибок:
 //
-//	go run cmd/staticlint/main.go ./cmd/shortener/handlers
+//	go run ./cmd/staticlint ./cmd/shortener/handlers
 //
 // Сборка и использование как standalone инструмент:
 //
-//	go build -o staticlint cmd/staticlint/main.go
+//	go build -o staticlint ./cmd/staticlint
 //	./staticlint ./...
 package main
 
 import (
-	"encoding/json"
+	"log"
 	"os"
-	"path/filepath"
 	"strings"
 
+	"uno/cmd/staticlint/handlererr"
 	"uno/cmd/staticlint/noosexit"
 
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/multichecker"
 	"golang.org/x/tools/go/analysis/passes/asmdecl"
 	"golang.org/x/tools/go/analysis/passes/assign"
 	"golang.org/x/tools/go/analysis/passes/atomic"
@@ -157,7 +190,22 @@ import (
 )
 
 func main() {
-	// Собираем все анализаторы
+	cfg, baseDir, err := readConfig()
+	if err != nil {
+		log.Fatalf("staticlint: %v", err)
+	}
+	analyzers, err := cfg.Apply(allAnalyzers(), baseDir)
+	if err != nil {
+		log.Fatalf("staticlint: %v", err)
+	}
+
+	if code := lint(os.Args[1:], analyzers, baseDir, cfg.BaselinePath(baseDir), os.Stdout, os.Stderr); code != exitOK {
+		os.Exit(code)
+	}
+}
+
+// allAnalyzers возвращает все доступные анализаторы; выбор из них задает конфигурация
+func allAnalyzers() []*analysis.Analyzer {
 	var analyzers []*analysis.Analyzer
 
 	// 1. Стандартные анализаторы из golang.org/x/tools/go/analysis/passes
@@ -229,11 +277,10 @@ func main() {
 	}
 	analyzers = append(analyzers, publicAnalyzers...)
 
-	// 5. Собственный анализатор
-	analyzers = append(analyzers, noosexit.Analyzer)
+	// 5. Собственные анализаторы
+	analyzers = append(analyzers, noosexit.Analyzer, handlererr.Analyzer)
 
-	// Запускаем multichecker
-	multichecker.Main(analyzers...)
+	return analyzers
 }
 
 // getStaticcheckAnalyzers возвращает все анализаторы класса SA из staticcheck
@@ -249,28 +296,3 @@ func getStaticcheckAnalyzers() []*analysis.Analyzer {
 
 	return analyzers
 }
-
-// Функция для чтения конфигурации (может быть расширена в будущем)
-func readConfig() map[string]interface{} {
-	configPath := ".staticlint.json"
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return make(map[string]interface{})
-	}
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return make(map[string]interface{})
-	}
-
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return make(map[string]interface{})
-	}
-
-	return config
-}
-
-// Вспомогательная функция для определения, является ли файл тестовым
-func isTestFile(filename string) bool {
-	return strings.HasSuffix(filepath.Base(filename), "_test.go")
-}
@@ -1,50 +1,143 @@
-// Package noosexit предоставляет анализатор, который запрещает прямой вызов os.Exit в функции main пакета main.
+// Package noosexit предоставляет анализатор, который запрещает завершать программу
+// в обход функции main пакета main.
 //
-// Анализатор проверяет исходный код на наличие прямых вызовов os.Exit в функции main
-// основного пакета и выдает ошибку, если такие вызовы найдены.
+// Вызовы os.Exit, log.Fatal* и log.Panic* (в том числе методов *log.Logger)
+// завершают программу, не выполняя отложенные вызовы вызывающего кода: не закрываются
+// соединения, не сбрасываются буферы логов. Анализатор строит граф вызовов функций
+// пакета main, начиная с main, и сообщает о каждом таком вызове, достижимом из main,
+// включая вызовы в горутинах.
 //
-// Это помогает избежать неконтролируемого завершения программы и способствует
-// более чистому коду с правильной обработкой ошибок.
+// Допустимо только завершение с ошибкой в самой функции main: последний оператор блока
+// if верхнего уровня, если до него в main не было defer, например
+//
+//	func main() {
+//		if err := run(); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// Для log.Fatal* и log.Panic* в функциях, возвращающих error, анализатор предлагает
+// исправление: вернуть ошибку вместо завершения программы.
 package noosexit
 
 import (
+	"bytes"
+	"fmt"
 	"go/ast"
+	"go/format"
 	"go/types"
+	"strings"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
 )
 
-// Analyzer - анализатор, запрещающий использовать прямой вызов os.Exit в функции main пакета main.
+// Analyzer - анализатор, запрещающий завершение программы, достижимое из функции main пакета main.
 var Analyzer = &analysis.Analyzer{
 	Name:     "noosexit",
-	Doc:      "запрещает прямой вызов os.Exit в функции main пакета main",
+	Doc:      "запрещает os.Exit, log.Fatal* и log.Panic*, достижимые из функции main пакета main",
 	Run:      run,
 	Requires: []*analysis.Analyzer{inspect.Analyzer},
 }
 
+// exitFuncs функции, завершающие программу без выполнения отложенных вызовов
+// Ключ - полное имя функции из types.Func.FullName
+var exitFuncs = map[string]bool{
+	"os.Exit":               true,
+	"log.Fatal":             true,
+	"log.Fatalf":            true,
+	"log.Fatalln":           true,
+	"log.Panic":             true,
+	"log.Panicf":            true,
+	"log.Panicln":           true,
+	"(*log.Logger).Fatal":   true,
+	"(*log.Logger).Fatalf":  true,
+	"(*log.Logger).Fatalln": true,
+	"(*log.Logger).Panic":   true,
+	"(*log.Logger).Panicf":  true,
+	"(*log.Logger).Panicln": true,
+}
+
+// callSite вызов внутри функции пакета
+type callSite struct {
+	call      *ast.CallExpr
+	callee    *types.Func // вызываемая функция
+	goroutine bool        // вызов выполняется в горутине
+	inLit     bool        // вызов находится в функциональном литерале
+	stmt      *ast.ExprStmt
+}
+
+// funcInfo вызовы одной функции пакета
+type funcInfo struct {
+	decl  *ast.FuncDecl
+	calls []callSite // вызовы функций этого же пакета
+	exits []callSite // вызовы функций из exitFuncs
+}
+
+// reached путь, по которому функция достижима из main
+type reached struct {
+	info      *funcInfo
+	path      []string
+	goroutine bool
+}
+
 func run(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Name() != "main" {
+		return nil, nil
+	}
+
 	// Получаем инспектор AST
 	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
 
-	// Фильтр для функций
-	nodeFilter := []ast.Node{
-		(*ast.FuncDecl)(nil),
-	}
-
-	// Проходим по всем функциям
-	inspect.Preorder(nodeFilter, func(n ast.Node) {
+	// Собираем вызовы всех функций и методов пакета
+	funcs := make(map[*types.Func]*funcInfo)
+	var mainFunc *funcInfo
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
 		funcDecl := n.(*ast.FuncDecl)
-
-		// Проверяем, что это функция main в пакете main
-		if !isMainFunctionInMainPackage(pass, funcDecl) {
+		if funcDecl.Body == nil {
 			return
 		}
-
-		// Проверяем тело функции на наличие os.Exit
-		checkForOsExit(pass, funcDecl.Body)
+		fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+		if !ok {
+			return
+		}
+		info := &funcInfo{decl: funcDecl}
+		info.collect(pass, funcDecl.Body, false, false)
+		funcs[fn] = info
+		if isMainFunctionInMainPackage(pass, funcDecl) {
+			mainFunc = info
+		}
 	})
+	if mainFunc == nil {
+		return nil, nil
+	}
+
+	// Обходим граф вызовов в ширину, чтобы сообщать кратчайший путь
+	allowed := terminalExits(mainFunc.decl.Body)
+	visited := map[*funcInfo]bool{mainFunc: true}
+	queue := []reached{{info: mainFunc, path: []string{"main"}}}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+
+		for _, exit := range r.info.exits {
+			if r.info == mainFunc && allowed[exit.call] {
+				continue
+			}
+			report(pass, r, exit)
+		}
+		for _, c := range r.info.calls {
+			callee := funcs[c.callee]
+			if callee == nil || visited[callee] {
+				continue
+			}
+			visited[callee] = true
+			path := append(append([]string(nil), r.path...), funcName(callee.decl))
+			queue = append(queue, reached{info: callee, path: path, goroutine: r.goroutine || c.goroutine})
+		}
+	}
 
 	return nil, nil
 }
@@ -56,8 +149,8 @@ func isMainFunctionInMainPackage(pass *analysis.Pass, funcDecl *ast.FuncDecl) bo
 		return false
 	}
 
-	// Проверяем имя функции
-	if funcDecl.Name.Name != "main" {
+	// Проверяем имя функции и что это не метод
+	if funcDecl.Name.Name != "main" || funcDecl.Recv != nil {
 		return false
 	}
 
@@ -73,53 +166,230 @@ func isMainFunctionInMainPackage(pass *analysis.Pass, funcDecl *ast.FuncDecl) bo
 	return true
 }
 
-// checkForOsExit рекурсивно проверяет блок кода на наличие вызовов os.Exit
-func checkForOsExit(pass *analysis.Pass, block *ast.BlockStmt) {
-	if block == nil {
+// collect собирает вызовы в node
+// goroutine - код выполняется в горутине, inLit - код находится в функциональном литерале
+func (f *funcInfo) collect(pass *analysis.Pass, node ast.Node, goroutine, inLit bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			// Аргументы вычисляются в текущей горутине, тело - в новой
+			f.add(pass, n.Call, nil, true, inLit)
+			f.collect(pass, n.Call.Fun, true, inLit)
+			for _, arg := range n.Call.Args {
+				f.collect(pass, arg, goroutine, inLit)
+			}
+			return false
+		case *ast.FuncLit:
+			f.collect(pass, n.Body, goroutine, true)
+			return false
+		case *ast.ExprStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok {
+				f.add(pass, call, n, goroutine, inLit)
+				for _, child := range append([]ast.Expr{call.Fun}, call.Args...) {
+					f.collect(pass, child, goroutine, inLit)
+				}
+				return false
+			}
+		case *ast.CallExpr:
+			f.add(pass, n, nil, goroutine, inLit)
+		}
+		return true
+	})
+}
+
+// add запоминает вызов завершающей функции или функции этого же пакета
+func (f *funcInfo) add(pass *analysis.Pass, call *ast.CallExpr, stmt *ast.ExprStmt, goroutine, inLit bool) {
+	callee := typeutil.StaticCallee(pass.TypesInfo, call)
+	if callee == nil {
 		return
 	}
+	site := callSite{call: call, callee: callee, goroutine: goroutine, inLit: inLit, stmt: stmt}
+	switch {
+	case exitFuncs[callee.FullName()]:
+		f.exits = append(f.exits, site)
+	case callee.Pkg() == pass.Pkg:
+		f.calls = append(f.calls, site)
+	}
+}
 
-	ast.Inspect(block, func(n ast.Node) bool {
-		switch node := n.(type) {
-		case *ast.CallExpr:
-			if isOsExitCall(pass, node) {
-				pass.Reportf(node.Pos(), "прямой вызов os.Exit в функции main запрещен")
-			}
+// terminalExits возвращает завершающие вызовы main, которые разрешены:
+// последний оператор блока if верхнего уровня, если до него в main не было defer
+func terminalExits(body *ast.BlockStmt) map[*ast.CallExpr]bool {
+	allowed := make(map[*ast.CallExpr]bool)
+	for _, stmt := range body.List {
+		if containsDefer(stmt) {
+			break
 		}
-		return true
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok || len(ifStmt.Body.List) == 0 {
+			continue
+		}
+		last, ok := ifStmt.Body.List[len(ifStmt.Body.List)-1].(*ast.ExprStmt)
+		if !ok {
+			continue
+		}
+		if call, ok := last.X.(*ast.CallExpr); ok {
+			allowed[call] = true
+		}
+	}
+	return allowed
+}
+
+// containsDefer проверяет, есть ли в операторе defer, относящийся к текущей функции
+func containsDefer(stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.DeferStmt:
+			found = true
+		case *ast.FuncLit:
+			return false
+		}
+		return !found
 	})
+	return found
 }
 
-// isOsExitCall проверяет, является ли вызов вызовом os.Exit
-func isOsExitCall(pass *analysis.Pass, call *ast.CallExpr) bool {
-	// Проверяем, что это селектор (package.Function)
-	sel, ok := call.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return false
+// report сообщает о завершающем вызове exit в функции r
+func report(pass *analysis.Pass, r reached, exit callSite) {
+	name := exit.callee.FullName()
+	goroutine := r.goroutine || exit.goroutine
+
+	var msg string
+	switch {
+	case len(r.path) == 1 && !goroutine:
+		msg = fmt.Sprintf("прямой вызов %s в функции main запрещен", name)
+	case len(r.path) == 1:
+		msg = fmt.Sprintf("вызов %s в горутине, запущенной из main, запрещен", name)
+	case goroutine:
+		msg = fmt.Sprintf("вызов %s в горутине достижим из main через %s", name, strings.Join(r.path, " -> "))
+	default:
+		msg = fmt.Sprintf("вызов %s достижим из main через %s", name, strings.Join(r.path, " -> "))
 	}
 
-	// Проверяем, что селектор относится к пакету os
-	ident, ok := sel.X.(*ast.Ident)
-	if !ok {
-		return false
+	diag := analysis.Diagnostic{Pos: exit.call.Pos(), End: exit.call.End(), Message: msg}
+	if fix, ok := returnErrorFix(pass, r.info.decl, exit); ok {
+		diag.SuggestedFixes = []analysis.SuggestedFix{fix}
 	}
+	pass.Report(diag)
+}
 
-	// Получаем информацию о типе
-	obj := pass.TypesInfo.Uses[ident]
-	if obj == nil {
-		return false
+// returnErrorFix предлагает заменить log.Fatal* и log.Panic* возвратом ошибки
+// Исправление возможно, если вызов - оператор тела функции, возвращающей error,
+// а ошибку можно построить без новых импортов
+func returnErrorFix(pass *analysis.Pass, decl *ast.FuncDecl, exit callSite) (analysis.SuggestedFix, bool) {
+	name := exit.callee.Name()
+	if exit.stmt == nil || exit.inLit || exit.goroutine || exit.callee.Pkg().Path() != "log" {
+		return analysis.SuggestedFix{}, false
 	}
 
-	// Проверяем, что это пакет os
-	pkg, ok := obj.(*types.PkgName)
-	if !ok {
-		return false
+	results := pass.TypesInfo.Defs[decl.Name].(*types.Func).Type().(*types.Signature).Results()
+	if results.Len() == 0 || !isErrorType(results.At(results.Len()-1).Type()) {
+		return analysis.SuggestedFix{}, false
 	}
 
-	if pkg.Imported().Path() != "os" {
-		return false
+	var values []string
+	for i := 0; i < results.Len()-1; i++ {
+		zero, ok := zeroValue(results.At(i).Type())
+		if !ok {
+			return analysis.SuggestedFix{}, false
+		}
+		values = append(values, zero)
+	}
+
+	args := exit.call.Args
+	switch {
+	case strings.HasSuffix(name, "f") && len(args) > 0 && importsFmt(pass, decl):
+		values = append(values, "fmt.Errorf("+render(pass, args)+")")
+	case len(args) == 1 && isErrorType(pass.TypesInfo.TypeOf(args[0])):
+		values = append(values, render(pass, args))
+	default:
+		return analysis.SuggestedFix{}, false
+	}
+
+	return analysis.SuggestedFix{
+		Message: fmt.Sprintf("вернуть ошибку вместо %s", exit.callee.FullName()),
+		TextEdits: []analysis.TextEdit{{
+			Pos:     exit.stmt.Pos(),
+			End:     exit.stmt.End(),
+			NewText: []byte("return " + strings.Join(values, ", ")),
+		}},
+	}, true
+}
+
+// isErrorType проверяет, что тип - интерфейс error
+func isErrorType(t types.Type) bool {
+	return t != nil && types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// zeroValue возвращает запись нулевого значения типа, если она не требует импортов
+func zeroValue(t types.Type) (string, bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	}
+	return "", false
+}
+
+// importsFmt проверяет, что файл с объявлением функции импортирует fmt
+func importsFmt(pass *analysis.Pass, decl *ast.FuncDecl) bool {
+	for _, file := range pass.Files {
+		if file.Pos() > decl.Pos() || decl.End() > file.End() {
+			continue
+		}
+		for _, imp := range file.Imports {
+			if imp.Path.Value == `"fmt"` && (imp.Name == nil || imp.Name.Name == "fmt") {
+				return true
+			}
+		}
 	}
+	return false
+}
+
+// render печатает выражения через запятую
+func render(pass *analysis.Pass, exprs []ast.Expr) string {
+	parts := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		var buf bytes.Buffer
+		if err := format.Node(&buf, pass.Fset, e); err != nil {
+			return ""
+		}
+		parts = append(parts, buf.String())
+	}
+	return strings.Join(parts, ", ")
+}
 
-	// Проверяем, что вызывается функция Exit
-	return sel.Sel.Name == "Exit"
+// funcName возвращает имя функции для пути вызовов; методы записываются как Type.Method
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := decl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	switch t := recv.(type) {
+	case *ast.Ident:
+		return t.Name + "." + decl.Name.Name
+	case *ast.IndexExpr:
+		if id, ok := t.X.(*ast.Ident); ok {
+			return id.Name + "." + decl.Name.Name
+		}
+	case *ast.IndexListExpr:
+		if id, ok := t.X.(*ast.Ident); ok {
+			return id.Name + "." + decl.Name.Name
+		}
+	}
+	return decl.Name.Name
 }
@@ -8,5 +8,10 @@ import (
 
 func TestAnalyzer(t *testing.T) {
 	// Запускаем тест анализатора с тестовыми данными
-	analysistest.Run(t, analysistest.TestData(), Analyzer, "a", "b")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a", "b", "d", "e")
+}
+
+func TestSuggestedFixes(t *testing.T) {
+	// Исправления сравниваются с файлами .golden
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "c")
 }
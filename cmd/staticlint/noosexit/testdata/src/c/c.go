@@ -0,0 +1,61 @@
+// Вызовы завершающих функций, достижимые из main через функции пакета
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+type server struct {
+	logger *log.Logger
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func run() error {
+	cfg, err := load("config.json")
+	if err != nil {
+		return err
+	}
+	s := &server{logger: log.Default()}
+	s.start(cfg)
+	count()
+	return nil
+}
+
+func load(path string) (string, error) {
+	if path == "" {
+		log.Fatalf("empty path %s", path) // want `вызов log.Fatalf достижим из main через main -> run -> load`
+	}
+	if err := check(path); err != nil {
+		log.Fatal(err) // want `вызов log.Fatal достижим из main через main -> run -> load`
+	}
+	return path, nil
+}
+
+func check(path string) error {
+	if path == "-" {
+		log.Panicln("stdin", "is not supported") // want `вызов log.Panicln достижим из main через main -> run -> load -> check`
+	}
+	return errors.New("not found")
+}
+
+func (s *server) start(cfg string) {
+	s.logger.Fatalf("failed to start with %s", cfg) // want `вызов \(\*log.Logger\).Fatalf достижим из main через main -> run -> server.start`
+}
+
+func count() (int, error) {
+	os.Exit(2) // want `вызов os.Exit достижим из main через main -> run -> count`
+	return 0, nil
+}
+
+// unused не вызывается из main, поэтому не проверяется
+func unused() {
+	log.Fatal("unused")
+}
@@ -0,0 +1,28 @@
+// Вызовы завершающих функций в горутинах
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	go func() {
+		log.Fatal("listener failed") // want `вызов log.Fatal в горутине, запущенной из main, запрещен`
+	}()
+	go os.Exit(1) // want `вызов os.Exit в горутине, запущенной из main, запрещен`
+	go worker()
+
+	done := func() {
+		os.Exit(0) // want `прямой вызов os.Exit в функции main запрещен`
+	}
+	done()
+}
+
+func worker() {
+	step()
+}
+
+func step() {
+	log.Panicf("step %d failed", 1) // want `вызов log.Panicf в горутине достижим из main через main -> worker -> step`
+}
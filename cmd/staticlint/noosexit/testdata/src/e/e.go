@@ -0,0 +1,32 @@
+// Допустимое завершение с ошибкой в main и завершение после defer
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	if err := setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open("file.txt")
+	if err != nil {
+		log.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	if err := process(f); err != nil {
+		log.Fatal(err) // want `прямой вызов log.Fatal в функции main запрещен`
+	}
+	defer os.Exit(0) // want `прямой вызов os.Exit в функции main запрещен`
+}
+
+func setup() error {
+	return nil
+}
+
+func process(f *os.File) error {
+	return f.Sync()
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// Форматы вывода результатов
+const (
+	FormatText  = "text"  // file:line:col: message, как у multichecker
+	FormatJSON  = "json"  // JSON Lines: одна находка в строке
+	FormatSARIF = "sarif" // SARIF 2.1.0
+)
+
+// sarifSchema схема SARIF 2.1.0
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// Position позиция в исходном файле; Column - номер байта в строке, начиная с 1
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Edit замена участка файла
+type Edit struct {
+	File    string   `json:"file"`
+	Start   Position `json:"start"`
+	End     Position `json:"end"`
+	NewText string   `json:"new_text"`
+}
+
+// Fix предлагаемое анализатором исправление
+type Fix struct {
+	Message string `json:"message"`
+	Edits   []Edit `json:"edits"`
+}
+
+// Finding одна находка анализатора
+// Пути файлов записываются относительно базового каталога через "/"
+type Finding struct {
+	Analyzer       string   `json:"analyzer"`
+	Category       string   `json:"category,omitempty"`
+	File           string   `json:"file"`
+	Start          Position `json:"start"`
+	End            Position `json:"end"`
+	Message        string   `json:"message"`
+	SuggestedFixes []Fix    `json:"suggested_fixes,omitempty"`
+}
+
+// collectFindings собирает находки корневых действий графа анализа
+// Находки, повторяющиеся в пакете и его тестовом варианте, выводятся один раз
+// Ошибки анализаторов возвращаются вместе
+func collectFindings(graph *checker.Graph, baseDir string) ([]Finding, error) {
+	var findings []Finding
+	var errs []string
+	seen := make(map[string]bool)
+	for act := range graph.All() {
+		if !act.IsRoot {
+			continue
+		}
+		if act.Err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", act, act.Err))
+			continue
+		}
+		for _, d := range act.Diagnostics {
+			f := newFinding(act.Analyzer, act.Package.Fset, d, baseDir)
+			key := fmt.Sprintf("%s\x00%s\x00%d\x00%s", f.Analyzer, f.File, f.Start.Offset, f.Message)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, f)
+		}
+	}
+	sortFindings(findings)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return findings, fmt.Errorf("analysis failed:\n%s", strings.Join(errs, "\n"))
+	}
+	return findings, nil
+}
+
+// newFinding преобразует диагностику анализатора в находку
+func newFinding(a *analysis.Analyzer, fset *token.FileSet, d analysis.Diagnostic, baseDir string) Finding {
+	start := fset.Position(d.Pos)
+	end := start
+	if d.End.IsValid() {
+		end = fset.Position(d.End)
+	}
+	f := Finding{
+		Analyzer: a.Name,
+		Category: d.Category,
+		File:     relPath(start.Filename, baseDir),
+		Start:    position(start),
+		End:      position(end),
+		Message:  d.Message,
+	}
+	for _, sf := range d.SuggestedFixes {
+		fix := Fix{Message: sf.Message}
+		for _, te := range sf.TextEdits {
+			s := fset.Position(te.Pos)
+			e := s
+			if te.End.IsValid() {
+				e = fset.Position(te.End)
+			}
+			fix.Edits = append(fix.Edits, Edit{
+				File:    relPath(s.Filename, baseDir),
+				Start:   position(s),
+				End:     position(e),
+				NewText: string(te.NewText),
+			})
+		}
+		f.SuggestedFixes = append(f.SuggestedFixes, fix)
+	}
+	return f
+}
+
+func position(p token.Position) Position {
+	return Position{Line: p.Line, Column: p.Column, Offset: p.Offset}
+}
+
+// relPath возвращает путь относительно baseDir, если файл находится внутри него
+func relPath(path, baseDir string) string {
+	if rel, err := filepath.Rel(baseDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	return filepath.ToSlash(path)
+}
+
+// sortFindings упорядочивает находки по файлу, позиции и анализатору
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Start.Offset != b.Start.Offset {
+			return a.Start.Offset < b.Start.Offset
+		}
+		if a.Analyzer != b.Analyzer {
+			return a.Analyzer < b.Analyzer
+		}
+		return a.Message < b.Message
+	})
+}
+
+// writeFindings выводит находки в заданном формате
+// Для SARIF нужны описания анализаторов и каталог, от которого отсчитываются пути
+func writeFindings(w io.Writer, format string, findings []Finding, analyzers []*analysis.Analyzer, baseDir string) error {
+	switch format {
+	case FormatText:
+		return writeText(w, findings)
+	case FormatJSON:
+		return writeJSONL(w, findings)
+	case FormatSARIF:
+		return writeSARIF(w, findings, analyzers, baseDir)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// writeText выводит находки в виде file:line:col: message
+func writeText(w io.Writer, findings []Finding) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range findings {
+		fmt.Fprintf(bw, "%s:%d:%d: %s (%s)\n", f.File, f.Start.Line, f.Start.Column, f.Message, f.Analyzer)
+	}
+	return bw.Flush()
+}
+
+// writeJSONL выводит находки по одной в строке
+func writeJSONL(w io.Writer, findings []Finding) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Типы SARIF 2.1.0; описаны только используемые поля
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool               sarifTool                   `json:"tool"`
+		OriginalURIBaseIDs map[string]sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+		Results            []sarifResult               `json:"results"`
+		ColumnKind         string                      `json:"columnKind"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+		FullDescription  sarifMessage `json:"fullDescription"`
+		HelpURI          string       `json:"helpUri,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID              string            `json:"ruleId"`
+		RuleIndex           int               `json:"ruleIndex"`
+		Level               string            `json:"level"`
+		Message             sarifMessage      `json:"message"`
+		Locations           []sarifLocation   `json:"locations"`
+		PartialFingerprints map[string]string `json:"partialFingerprints"`
+		Fixes               []sarifFix        `json:"fixes,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+		Region           sarifRegion      `json:"region"`
+	}
+	sarifArtifactLoc struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId,omitempty"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	}
+	sarifFix struct {
+		Description     sarifMessage          `json:"description"`
+		ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+	}
+	sarifArtifactChange struct {
+		ArtifactLocation sarifArtifactLoc   `json:"artifactLocation"`
+		Replacements     []sarifReplacement `json:"replacements"`
+	}
+	sarifReplacement struct {
+		DeletedRegion   sarifRegion  `json:"deletedRegion"`
+		InsertedContent sarifMessage `json:"insertedContent"`
+	}
+)
+
+// srcRoot базовый идентификатор путей в SARIF
+const srcRoot = "%SRCROOT%"
+
+// writeSARIF выводит находки в формате SARIF 2.1.0
+// Колонки пересчитываются в UTF-16 единицы, как требует columnKind по умолчанию
+func writeSARIF(w io.Writer, findings []Finding, analyzers []*analysis.Analyzer, baseDir string) error {
+	rules := make([]sarifRule, 0, len(analyzers))
+	ruleIndex := make(map[string]int, len(analyzers))
+	for _, a := range analyzers {
+		ruleIndex[a.Name] = len(rules)
+		rules = append(rules, sarifRule{
+			ID:               a.Name,
+			ShortDescription: sarifMessage{Text: firstLine(a.Doc)},
+			FullDescription:  sarifMessage{Text: a.Doc},
+			HelpURI:          a.URL,
+		})
+	}
+
+	cols := newColumnConverter(baseDir)
+	region := func(file string, start, end Position) sarifRegion {
+		return sarifRegion{
+			StartLine:   start.Line,
+			StartColumn: cols.utf16(file, start),
+			EndLine:     end.Line,
+			EndColumn:   cols.utf16(file, end),
+		}
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		idx, ok := ruleIndex[f.Analyzer]
+		if !ok {
+			idx = -1
+		}
+		res := sarifResult{
+			RuleID:    f.Analyzer,
+			RuleIndex: idx,
+			Level:     "warning",
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifact(f.File),
+				Region:           region(f.File, f.Start, f.End),
+			}}},
+			PartialFingerprints: map[string]string{"staticlint/v1": baselineKey(f).fingerprint()},
+		}
+		for _, fix := range f.SuggestedFixes {
+			sf := sarifFix{Description: sarifMessage{Text: fix.Message}}
+			changes := make(map[string]int)
+			for _, e := range fix.Edits {
+				i, ok := changes[e.File]
+				if !ok {
+					i = len(sf.ArtifactChanges)
+					changes[e.File] = i
+					sf.ArtifactChanges = append(sf.ArtifactChanges, sarifArtifactChange{ArtifactLocation: artifact(e.File)})
+				}
+				sf.ArtifactChanges[i].Replacements = append(sf.ArtifactChanges[i].Replacements, sarifReplacement{
+					DeletedRegion:   region(e.File, e.Start, e.End),
+					InsertedContent: sarifMessage{Text: e.NewText},
+				})
+			}
+			res.Fixes = append(res.Fixes, sf)
+		}
+		results = append(results, res)
+	}
+
+	run := sarifRun{
+		Tool:       sarifTool{Driver: sarifDriver{Name: "staticlint", Rules: rules}},
+		Results:    results,
+		ColumnKind: "utf16CodeUnits",
+	}
+	if abs, err := filepath.Abs(baseDir); err == nil {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLoc{
+			srcRoot: {URI: "file://" + filepath.ToSlash(abs) + "/"},
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: "2.1.0", Runs: []sarifRun{run}})
+}
+
+// artifact возвращает расположение файла; относительные пути отсчитываются от %SRCROOT%
+func artifact(file string) sarifArtifactLoc {
+	if filepath.IsAbs(filepath.FromSlash(file)) {
+		return sarifArtifactLoc{URI: "file://" + file}
+	}
+	return sarifArtifactLoc{URI: file, URIBaseID: srcRoot}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// columnConverter пересчитывает байтовые колонки в UTF-16 по содержимому файлов
+type columnConverter struct {
+	baseDir string
+	lines   map[string][][]byte
+}
+
+func newColumnConverter(baseDir string) *columnConverter {
+	return &columnConverter{baseDir: baseDir, lines: make(map[string][][]byte)}
+}
+
+// utf16 возвращает колонку позиции в UTF-16 единицах, начиная с 1
+// Если файл не читается, возвращается байтовая колонка
+func (c *columnConverter) utf16(file string, p Position) int {
+	lines, ok := c.lines[file]
+	if !ok {
+		path := filepath.FromSlash(file)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.baseDir, path)
+		}
+		if data, err := os.ReadFile(path); err == nil {
+			lines = bytes.Split(data, []byte("\n"))
+		}
+		c.lines[file] = lines
+	}
+	if p.Line < 1 || p.Line > len(lines) || p.Column < 1 {
+		return p.Column
+	}
+	line := lines[p.Line-1]
+	n := min(p.Column-1, len(line))
+	col := 1
+	for prefix := line[:n]; len(prefix) > 0; {
+		r, size := utf8.DecodeRune(prefix)
+		col += len(utf16.Encode([]rune{r}))
+		prefix = prefix[size:]
+	}
+	return col + (p.Column - 1 - n)
+}
@@ -0,0 +1,386 @@
+// Package client предоставляет Go клиент для API сервиса сокращения URL.
+//
+// Клиент покрывает все эндпоинты сервиса, хранит идентификатор пользователя
+// в cookie jar, повторяет запросы при временных ошибках с экспоненциальной
+// задержкой и может сжимать тела запросов в gzip.
+//
+//	c, err := client.New("http://localhost:8080")
+//	short, err := c.Shorten(ctx, "https://example.com")
+//	if errors.Is(err, client.ErrConflict) {
+//		// URL уже был сокращен, short содержит существующую ссылку
+//	}
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+	"uno/cmd/shortener/models"
+)
+
+// UserCookieName имя cookie с идентификатором пользователя
+const UserCookieName = "auth_user"
+
+// Параметры повторов по умолчанию
+const (
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 100 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// Client клиент API сервиса сокращения URL
+// Безопасен для одновременного использования из нескольких горутин
+type Client struct {
+	base       *url.URL
+	jarURL     *url.URL
+	http       *http.Client
+	gzip       bool
+	maxRetries int
+	backoff    time.Duration
+	userID     string
+}
+
+// Option настраивает Client
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP клиент
+// Если у него нет cookie jar, клиент создает собственный
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		copied := *hc
+		c.http = &copied
+	}
+}
+
+// WithRetry задает количество повторов и начальную задержку между ними
+// Задержка удваивается с каждой попыткой; maxRetries = 0 отключает повторы
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithGzip включает сжатие тел запросов в gzip
+func WithGzip() Option {
+	return func(c *Client) {
+		c.gzip = true
+	}
+}
+
+// WithUserID задает идентификатор пользователя, сохраненный в предыдущих сессиях
+func WithUserID(userID string) Option {
+	return func(c *Client) {
+		c.userID = userID
+	}
+}
+
+// New создает клиент для сервиса с адресом baseURL
+func New(baseURL string, opts ...Option) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("shortener: invalid base URL %q", baseURL)
+	}
+
+	c := &Client{
+		base:       base,
+		jarURL:     &url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/"},
+		http:       &http.Client{Timeout: 30 * time.Second},
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.http.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		c.http.Jar = jar
+	}
+	if c.userID != "" {
+		c.http.Jar.SetCookies(c.jarURL, []*http.Cookie{{Name: UserCookieName, Value: c.userID, Path: "/"}})
+	}
+	// Редиректы не выполняются: Resolve возвращает Location
+	c.http.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return c, nil
+}
+
+// UserID возвращает идентификатор пользователя, выданный сервисом
+// Пустая строка означает, что сервис еще не выдавал cookie
+func (c *Client) UserID() string {
+	for _, cookie := range c.http.Jar.Cookies(c.jarURL) {
+		if cookie.Name == UserCookieName {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// ShortURL возвращает полный сокращенный URL для ID
+func (c *Client) ShortURL(id string) string {
+	return c.base.String() + "/" + id
+}
+
+// Shorten сокращает URL через POST / (text/plain)
+// Если URL уже был сокращен, возвращает существующий сокращенный URL и *ConflictError
+func (c *Client) Shorten(ctx context.Context, originalURL string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, "/", "text/plain; charset=utf-8", []byte(originalURL))
+	if err != nil {
+		return "", err
+	}
+	shortURL := strings.TrimSpace(string(resp.body))
+	switch resp.status {
+	case http.StatusCreated:
+		return shortURL, nil
+	case http.StatusConflict:
+		return shortURL, &ConflictError{ShortURL: shortURL}
+	default:
+		return "", resp.err()
+	}
+}
+
+// ShortenJSON сокращает URL через POST /api/shorten
+// Если URL уже был сокращен, возвращает существующий сокращенный URL и *ConflictError
+func (c *Client) ShortenJSON(ctx context.Context, originalURL string) (string, error) {
+	body, err := models.APIRequest{URL: originalURL}.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/api/shorten", "application/json", body)
+	if err != nil {
+		return "", err
+	}
+	if resp.status != http.StatusCreated && resp.status != http.StatusConflict {
+		return "", resp.err()
+	}
+
+	var result models.APIResponse
+	if err := result.UnmarshalJSON(resp.body); err != nil {
+		return "", fmt.Errorf("shortener: invalid response: %w", err)
+	}
+	if resp.status == http.StatusConflict {
+		return result.Result, &ConflictError{ShortURL: result.Result}
+	}
+	return result.Result, nil
+}
+
+// ShortenBatch сокращает пакет URL через POST /api/shorten/batch
+// Результаты возвращаются в порядке запросов. Если часть ссылок не сохранена
+// или ответ оборвался раньше конца пакета, вместе с полученными результатами
+// возвращается ошибка *BatchError
+func (c *Client) ShortenBatch(ctx context.Context, requests []models.BatchRequest) ([]models.BatchResponse, error) {
+	body, err := models.BatchRequestList(requests).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/api/shorten/batch", "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusCreated {
+		return nil, resp.err()
+	}
+
+	var result models.BatchResponseList
+	if err := result.UnmarshalJSON(resp.body); err != nil {
+		return nil, fmt.Errorf("shortener: invalid response: %w", err)
+	}
+	return batchResult(requests, result)
+}
+
+// batchResult отделяет от результатов пакета завершающий элемент, которым сервис
+// обрывает ответ (только поле error на месте запроса с correlation_id или после
+// последнего запроса), и сообщает о несохраненных ссылках ошибкой *BatchError
+func batchResult(requests []models.BatchRequest, result []models.BatchResponse) ([]models.BatchResponse, error) {
+	var batchErr BatchError
+	if n := len(result); n > 0 {
+		last := result[n-1]
+		if last.CorrelationID == "" && last.ShortURL == "" && last.Error != "" &&
+			(n > len(requests) || requests[n-1].CorrelationID != "") {
+			batchErr.Reason = last.Error
+			result = result[:n-1]
+		}
+	}
+	for _, r := range result {
+		if r.Error != "" {
+			batchErr.Failed++
+		}
+	}
+	batchErr.Missing = max(len(requests)-len(result), 0)
+	if batchErr == (BatchError{}) {
+		return result, nil
+	}
+	return result, &batchErr
+}
+
+// Resolve возвращает оригинальный URL по сокращенному ID, не выполняя перехода
+// Для удаленной ссылки возвращает ошибку ErrGone, для несуществующей - ErrNotFound
+func (c *Client) Resolve(ctx context.Context, id string) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/"+url.PathEscape(id), "", nil)
+	if err != nil {
+		return "", err
+	}
+	switch resp.status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return resp.header.Get("Location"), nil
+	default:
+		return "", resp.err()
+	}
+}
+
+// UserURLs возвращает URL текущего пользователя через GET /api/user/urls
+// Если у пользователя нет URL, возвращает пустой список без ошибки
+func (c *Client) UserURLs(ctx context.Context) ([]models.UserURL, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/api/user/urls", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.status {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK:
+		var urls []models.UserURL
+		if err := json.Unmarshal(resp.body, &urls); err != nil {
+			return nil, fmt.Errorf("shortener: invalid response: %w", err)
+		}
+		return urls, nil
+	default:
+		return nil, resp.err()
+	}
+}
+
+// DeleteURLs ставит в очередь удаление URL пользователя через DELETE /api/user/urls
+// Удаление выполняется сервисом асинхронно
+func (c *Client) DeleteURLs(ctx context.Context, ids []string) error {
+	body, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodDelete, "/api/user/urls", "application/json", body)
+	if err != nil {
+		return err
+	}
+	if resp.status != http.StatusAccepted {
+		return resp.err()
+	}
+	return nil
+}
+
+// Ping проверяет доступность сервиса и его базы данных через GET /ping
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/ping", "", nil)
+	if err != nil {
+		return err
+	}
+	if resp.status != http.StatusOK {
+		return resp.err()
+	}
+	return nil
+}
+
+// response прочитанный ответ сервиса
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// err возвращает ошибку для неуспешного ответа
+func (r *response) err() error {
+	return &StatusError{StatusCode: r.status, Body: strings.TrimSpace(string(r.body))}
+}
+
+// do выполняет запрос, повторяя его при временных ошибках
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte) (*response, error) {
+	if body != nil && c.gzip {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.doOnce(ctx, method, path, contentType, body)
+		if attempt >= c.maxRetries || !retryable(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		// Экспоненциальная задержка со случайной составляющей
+		delay := c.backoff << attempt
+		if delay > maxBackoff || delay <= 0 {
+			delay = maxBackoff
+		}
+		delay = delay/2 + rand.N(delay/2+1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// doOnce выполняет одну попытку запроса
+func (c *Client) doOnce(ctx context.Context, method, path, contentType string, body []byte) (*response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base.String()+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if body != nil && c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("shortener: failed to read response: %w", err)
+	}
+	return &response{status: resp.StatusCode, header: resp.Header, body: data}, nil
+}
+
+// retryable сообщает, стоит ли повторить запрос
+// Повторяются сетевые ошибки и ответы 429, 502, 503, 504
+func retryable(resp *response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"uno/cmd/shortener/config"
+	"uno/cmd/shortener/handlers"
+	"uno/cmd/shortener/middleware"
+	"uno/cmd/shortener/models"
+	"uno/cmd/shortener/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// newTestServer запускает сервис сокращения URL на реальных обработчиках
+func newTestServer(t *testing.T) (*httptest.Server, storage.Storage) {
+	t.Helper()
+	store := storage.NewInMemoryStorage()
+	queue := make(chan handlers.DeleteRequest, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go handlers.RunDeletionWorker(ctx, store, zap.NewNop(), queue)
+
+	cfg := &config.Config{}
+	r := chi.NewRouter()
+	r.Use(middleware.GzipMiddleware)
+	r.Use(middleware.WithUserID)
+	r.Post("/", handlers.ShortenURLHandler(cfg, store))
+	r.Post("/api/shorten", handlers.APIShortenHandler(cfg, store))
+	r.Post("/api/shorten/batch", handlers.BatchShortenHandler(cfg, store))
+	r.Get("/{id}", handlers.RedirectHandler(store))
+	r.Get("/ping", handlers.PingHandler(nil))
+	r.Get("/api/user/urls", handlers.UserURLsHandler(cfg, store))
+	r.Delete("/api/user/urls", handlers.DeleteUserURLsHandler(store, zap.NewNop(), queue))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	cfg.BaseURL = srv.URL
+	return srv, store
+}
+
+func TestClient_Endpoints(t *testing.T) {
+	srv, store := newTestServer(t)
+	ctx := context.Background()
+
+	c, err := New(srv.URL, WithGzip())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	shortURL, err := c.Shorten(ctx, "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Shorten failed: %v", err)
+	}
+	if c.UserID() == "" {
+		t.Fatal("expected user cookie to be stored")
+	}
+
+	existing, err := c.ShortenJSON(ctx, "https://example.com/a")
+	var conflict *ConflictError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &conflict) || existing != shortURL || conflict.ShortURL != shortURL {
+		t.Fatalf("expected conflict with %q, got %q (%v)", shortURL, existing, err)
+	}
+
+	batch, err := c.ShortenBatch(ctx, []models.BatchRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/b"},
+		{CorrelationID: "2", OriginalURL: "https://example.com/c"},
+	})
+	if err != nil || len(batch) != 2 {
+		t.Fatalf("ShortenBatch failed: %+v (%v)", batch, err)
+	}
+
+	batch, err = c.ShortenBatch(ctx, []models.BatchRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/b"},
+		{CorrelationID: "2", OriginalURL: " "},
+	})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 2 || len(batch) != 2 {
+		t.Fatalf("expected both links of the batch to fail, got %+v (%v)", batch, err)
+	}
+
+	urls, err := c.UserURLs(ctx)
+	if err != nil || len(urls) != 3 {
+		t.Fatalf("expected 3 user URLs, got %+v (%v)", urls, err)
+	}
+
+	id := strings.TrimPrefix(shortURL, srv.URL+"/")
+	if original, err := c.Resolve(ctx, id); err != nil || original != "https://example.com/a" {
+		t.Fatalf("Resolve returned %q (%v)", original, err)
+	}
+
+	if err := c.DeleteURLs(ctx, []string{id}); err != nil {
+		t.Fatalf("DeleteURLs failed: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, deleted, _ := store.Get(id); deleted || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := c.Resolve(ctx, id); !errors.Is(err, ErrGone) {
+		t.Errorf("expected ErrGone, got %v", err)
+	}
+	if _, err := c.Resolve(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+
+	// Клиент с сохраненным идентификатором видит те же URL
+	same, err := New(srv.URL, WithUserID(c.UserID()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if urls, err := same.UserURLs(ctx); err != nil || len(urls) != 2 {
+		t.Errorf("expected 2 URLs for restored user, got %+v (%v)", urls, err)
+	}
+
+	other, err := New(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if _, err := other.Shorten(ctx, "https://example.com/d"); err != nil {
+		t.Fatalf("Shorten failed: %v", err)
+	}
+	if other.UserID() == c.UserID() {
+		t.Error("expected new client to get its own user ID")
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("expected ping to succeed after retries: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	calls.Store(0)
+	noRetry, _ := New(srv.URL, WithRetry(0, time.Millisecond))
+	var statusErr *StatusError
+	if err := noRetry.Ping(context.Background()); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 status error, got %v", err)
+	}
+}
+
+func TestClient_ContextCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithRetry(10, time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := c.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected retries to stop when context is done")
+	}
+}
+
+func TestBatchResult_Truncated(t *testing.T) {
+	requests := []models.BatchRequest{{CorrelationID: "1"}, {CorrelationID: "2"}, {CorrelationID: "3"}}
+	result := []models.BatchResponse{{CorrelationID: "1", ShortURL: "http://s/1"}, {Error: "batch is too large"}}
+
+	got, err := batchResult(requests, result)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Missing != 2 || batchErr.Failed != 0 || batchErr.Reason != "batch is too large" {
+		t.Fatalf("expected truncated batch error, got %v", err)
+	}
+	if len(got) != 1 || got[0].CorrelationID != "1" {
+		t.Errorf("expected the trailing element to be dropped, got %+v", got)
+	}
+
+	// Элемент без correlation_id на месте такого же запроса - результат запроса, а не обрыв
+	requests = []models.BatchRequest{{CorrelationID: "1"}, {}}
+	got, err = batchResult(requests, result)
+	if !errors.As(err, &batchErr) || batchErr.Failed != 1 || batchErr.Missing != 0 || batchErr.Reason != "" || len(got) != 2 {
+		t.Errorf("expected a failed item, got %+v (%v)", got, err)
+	}
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	if _, err := New("localhost:8080"); err == nil {
+		t.Error("expected error for URL without scheme")
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Ошибки, соответствующие статусам ответов сервиса
+// Проверяются через errors.Is
+var (
+	ErrConflict     = errors.New("shortener: URL already shortened") // 409 Conflict
+	ErrGone         = errors.New("shortener: short URL was deleted") // 410 Gone
+	ErrUnauthorized = errors.New("shortener: unauthorized")          // 401 Unauthorized
+	ErrNotFound     = errors.New("shortener: short URL not found")   // 404 Not Found
+)
+
+// StatusError неуспешный ответ сервиса
+// errors.Is(err, ErrConflict) и аналогичные проверки работают по коду статуса
+type StatusError struct {
+	StatusCode int    // HTTP статус ответа
+	Body       string // Тело ответа
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("shortener: unexpected status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("shortener: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is сопоставляет статус ответа с ошибками пакета
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrGone:
+		return e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// ConflictError возвращается при попытке сократить уже сокращенный URL
+// Содержит ранее выданный сокращенный URL
+type ConflictError struct {
+	ShortURL string // Существующий сокращенный URL
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrConflict, e.ShortURL)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrConflict)
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// BatchError возвращается ShortenBatch вместе с результатами, если часть ссылок
+// пакета не сохранена или ответ оборвался раньше конца пакета
+type BatchError struct {
+	Failed  int    // Количество результатов с полем Error
+	Missing int    // Количество запросов, для которых результата нет
+	Reason  string // Ошибка, которой сервис завершил ответ, если есть
+}
+
+func (e *BatchError) Error() string {
+	msg := fmt.Sprintf("shortener: %d links of the batch failed", e.Failed)
+	if e.Missing > 0 {
+		msg += fmt.Sprintf(", %d links have no result", e.Missing)
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
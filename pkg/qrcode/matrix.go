@@ -0,0 +1,284 @@
+package qrcode
+
+// Веса штрафов при выборе маски
+const (
+	penaltyN1 = 3
+	penaltyN2 = 3
+	penaltyN3 = 40
+	penaltyN4 = 10
+)
+
+// setFunction рисует служебный модуль
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+	c.isFunction[y*c.Size+x] = true
+}
+
+// drawFunctionPatterns рисует поисковые, выравнивающие и синхронизирующие узоры
+// и резервирует место под информацию о формате и версии
+func (c *Code) drawFunctionPatterns() {
+	for i := range c.Size {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.Size-4, 3)
+	c.drawFinderPattern(3, c.Size-4)
+
+	positions := alignmentPatternPositions(c.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Углы заняты поисковыми узорами
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinderPattern рисует поисковый узор с разделителем вокруг центра (x, y)
+func (c *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.Size || yy >= c.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern рисует выравнивающий узор вокруг центра (x, y)
+func (c *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPatternPositions координаты центров выравнивающих узоров версии
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+10; i > 0; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// formatBits 15 бит информации о формате с кодом БЧХ и маской XOR
+func formatBits(level Level, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormatBits рисует обе копии информации о формате
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(c.Level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	// Первая копия у левого верхнего поискового узора
+	for i := range 6 {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	// Вторая копия у правого верхнего и левого нижнего узоров
+	for i := range 8 {
+		c.setFunction(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(i))
+	}
+	c.setFunction(8, c.Size-8, true) // всегда темный модуль
+}
+
+// versionBits 18 бит информации о версии с кодом Голея
+func versionBits(version int) int {
+	rem := version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawVersion рисует информацию о версии (начиная с версии 7)
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	bits := versionBits(c.Version)
+	for i := range 18 {
+		dark := (bits>>i)&1 != 0
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords размещает кодовые слова зигзагом по парам столбцов,
+// начиная с правого нижнего угла. Оставшиеся модули светлые
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// Столбец вертикального синхронизирующего узора пропускается
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := range c.Size {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if c.isFunction[y*c.Size+x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y*c.Size+x] = (data[i>>3]>>(7-i&7))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask инвертирует модули данных по условию маски; повторное применение отменяет маску
+func (c *Code) applyMask(mask int) {
+	for y := range c.Size {
+		for x := range c.Size {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.isFunction[y*c.Size+x] {
+				c.modules[y*c.Size+x] = !c.modules[y*c.Size+x]
+			}
+		}
+	}
+}
+
+// chooseMask применяет маску с наименьшим штрафом
+func (c *Code) chooseMask() {
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask)
+	}
+	c.Mask = best
+	c.applyMask(best)
+	c.drawFormatBits(best)
+}
+
+// penalty штраф кода по правилам стандарта: длинные серии одного цвета,
+// блоки 2x2, узоры, похожие на поисковые, и перекос доли темных модулей
+func (c *Code) penalty() int {
+	result := 0
+	dark := 0
+	// Строки и столбцы проверяются одинаково: at(i, j) - j-й модуль i-й линии
+	for _, at := range []func(i, j int) bool{
+		func(i, j int) bool { return c.modules[i*c.Size+j] },
+		func(i, j int) bool { return c.modules[j*c.Size+i] },
+	} {
+		for i := range c.Size {
+			run := 0
+			for j := range c.Size {
+				if j > 0 && at(i, j) == at(i, j-1) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					result += penaltyN1
+				} else if run > 5 {
+					result++
+				}
+				if j+11 <= c.Size && finderLike(func(k int) bool { return at(i, j+k) }) {
+					result += penaltyN3
+				}
+			}
+		}
+	}
+
+	for y := range c.Size {
+		for x := range c.Size {
+			m := c.modules[y*c.Size+x]
+			if m {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size &&
+				m == c.modules[y*c.Size+x+1] &&
+				m == c.modules[(y+1)*c.Size+x] &&
+				m == c.modules[(y+1)*c.Size+x+1] {
+				result += penaltyN2
+			}
+		}
+	}
+
+	total := c.Size * c.Size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + max(k, 0)*penaltyN4
+}
+
+// finderLike сообщает, совпадают ли 11 модулей с узором 1:1:3:1:1
+// и четырьмя светлыми модулями с одной из сторон
+func finderLike(at func(k int) bool) bool {
+	const before, after = 0b00001011101, 0b10111010000
+	v := 0
+	for k := range 11 {
+		v <<= 1
+		if at(k) {
+			v |= 1
+		}
+	}
+	return v == before || v == after
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
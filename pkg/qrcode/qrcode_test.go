@@ -0,0 +1,305 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// Пример 1-M из описания стандарта
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("ecc = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Low, 0, 0b111011111000100},
+		{Medium, 0, 0b101010000010010},
+		{Quartile, 0, 0b011010101011111},
+		{High, 0, 0b001011010001001},
+		{Low, 7, 0b110100101110110},
+	}
+	for _, tt := range tests {
+		if got := formatBits(tt.level, tt.mask); got != tt.want {
+			t.Errorf("formatBits(%v, %d) = %015b, want %015b", tt.level, tt.mask, got, tt.want)
+		}
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		if got := alignmentPatternPositions(version); !slices.Equal(got, want) {
+			t.Errorf("version %d: positions = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestEncodeVersionSelection(t *testing.T) {
+	tests := []struct {
+		length  int
+		level   Level
+		version int
+	}{
+		{17, Low, 1},
+		{18, Low, 2},
+		{14, Medium, 1},
+		{7, High, 1},
+		{2953, Low, 40},
+	}
+	for _, tt := range tests {
+		c, err := Encode(bytes.Repeat([]byte("a"), tt.length), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes, %v): %v", tt.length, tt.level, err)
+		}
+		if c.Version != tt.version || c.Size != tt.version*4+17 {
+			t.Errorf("Encode(%d bytes, %v): version %d size %d, want version %d", tt.length, tt.level, c.Version, c.Size, tt.version)
+		}
+	}
+
+	if _, err := Encode(make([]byte, 2954), Low); !errors.Is(err, ErrTooLong) {
+		t.Errorf("err = %v, want ErrTooLong", err)
+	}
+	if _, err := Encode([]byte("x"), Level(9)); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+// TestEncodeRoundTrip читает готовую матрицу обратно: информацию о формате,
+// маску и кодовые слова, проверяет коды коррекции и исходные данные
+func TestEncodeRoundTrip(t *testing.T) {
+	inputs := []string{
+		"http://localhost:8080/EwHXdJfB",
+		"https://short.example/" + strings.Repeat("x", 120),
+		strings.Repeat("Ёж 0123456789 ", 40),
+	}
+	for _, input := range inputs {
+		for level := Low; level <= High; level++ {
+			c, err := Encode([]byte(input), level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decode(t, c); got != input {
+				t.Errorf("level %v version %d: decoded %q, want %q", level, c.Version, got, input)
+			}
+		}
+	}
+}
+
+// decode независимо от кодировщика разбирает матрицу кода
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+
+	// Информация о формате: первая копия вокруг левого верхнего узора
+	format := 0
+	for i := range 6 {
+		format |= bit(c.Dark(8, i)) << i
+	}
+	format |= bit(c.Dark(8, 7))<<6 | bit(c.Dark(8, 8))<<7 | bit(c.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		format |= bit(c.Dark(14-i, 8)) << i
+	}
+	if format != formatBits(c.Level, c.Mask) {
+		t.Fatalf("format bits %015b do not match level %v mask %d", format, c.Level, c.Mask)
+	}
+	for i := range 8 {
+		if bit(c.Dark(c.Size-1-i, 8)) != (format>>i)&1 {
+			t.Fatalf("second copy of format bit %d differs", i)
+		}
+	}
+	for _, p := range [][2]int{{0, 0}, {c.Size - 7, 0}, {0, c.Size - 7}} {
+		if !c.Dark(p[0], p[1]) || !c.Dark(p[0]+3, p[1]+3) || c.Dark(p[0]+1, p[1]+1) {
+			t.Fatalf("finder pattern at %v is broken", p)
+		}
+	}
+
+	// Снятие маски и чтение кодовых слов зигзагом
+	dark := func(x, y int) bool {
+		var invert bool
+		switch c.Mask {
+		case 0:
+			invert = (x+y)%2 == 0
+		case 1:
+			invert = y%2 == 0
+		case 2:
+			invert = x%3 == 0
+		case 3:
+			invert = (x+y)%3 == 0
+		case 4:
+			invert = (x/3+y/2)%2 == 0
+		case 5:
+			invert = x*y%2+x*y%3 == 0
+		case 6:
+			invert = (x*y%2+x*y%3)%2 == 0
+		case 7:
+			invert = ((x+y)%2+x*y%3)%2 == 0
+		}
+		return c.Dark(x, y) != invert
+	}
+	var bits []int
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range c.Size {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = c.Size - 1 - vert
+			}
+			for j := range 2 {
+				if x := right - j; !c.isFunction[y*c.Size+x] {
+					bits = append(bits, bit(dark(x, y)))
+				}
+			}
+		}
+	}
+	raw := make([]byte, numRawDataModules(c.Version)/8)
+	for i := range raw {
+		for _, b := range bits[i*8 : i*8+8] {
+			raw[i] = raw[i]<<1 | byte(b)
+		}
+	}
+
+	// Разбор перемежения и проверка синдромов каждого блока
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShort := numBlocks - len(raw)%numBlocks
+	shortLen := len(raw) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range shortLen + 1 {
+		for j := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				blocks[j] = append(blocks[j], raw[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for j, block := range blocks {
+		alpha := byte(1)
+		for s := range eccLen {
+			sum := byte(0)
+			for _, b := range block {
+				sum = gfMultiply(sum, alpha) ^ b
+			}
+			if sum != 0 {
+				t.Fatalf("block %d: syndrome %d is not zero", j, s)
+			}
+			alpha = gfMultiply(alpha, 2)
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	// Байтовый режим: 4 бита режима, длина, байты
+	var stream []int
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			stream = append(stream, int(b>>i)&1)
+		}
+	}
+	read := func(n int) int {
+		v := 0
+		for _, b := range stream[:n] {
+			v = v<<1 | b
+		}
+		stream = stream[n:]
+		return v
+	}
+	if mode := read(4); mode != 0x4 {
+		t.Fatalf("mode = %04b, want byte mode", mode)
+	}
+	out := make([]byte, read(charCountBits(c.Version)))
+	for i := range out {
+		out[i] = byte(read(8))
+	}
+	return string(out)
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, s := range []string{"L", "m", "Q", "h"} {
+		l, err := ParseLevel(s)
+		if err != nil || !strings.EqualFold(l.String(), s) {
+			t.Errorf("ParseLevel(%q) = %v, %v", s, l, err)
+		}
+	}
+	if _, err := ParseLevel("X"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestRender(t *testing.T) {
+	c, err := Encode([]byte("http://localhost:8080/abc"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.WritePNG(&buf, 200); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 200 {
+		t.Fatalf("image bounds = %v", b)
+	}
+	// Модули центрированы и занимают целое число пикселей
+	scale := 200 / c.MinImageSize()
+	offset := (200 - c.Size*scale) / 2
+	for y := range c.Size {
+		for x := range c.Size {
+			r, _, _, _ := img.At(offset+x*scale+scale/2, offset+y*scale+scale/2).RGBA()
+			if (r == 0) != c.Dark(x, y) {
+				t.Fatalf("pixel of module (%d, %d) has wrong color", x, y)
+			}
+		}
+	}
+	if r, _, _, _ := img.At(offset-1, offset-1).RGBA(); r == 0 {
+		t.Error("quiet zone is not white")
+	}
+
+	buf.Reset()
+	if err := c.WriteSVG(&buf, 200); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if !strings.Contains(svg, `width="200"`) || !strings.Contains(svg, `viewBox="0 0 33 33"`) || !strings.Contains(svg, "M4,4h7v1h-7z") {
+		t.Errorf("unexpected SVG: %s", svg)
+	}
+
+	if err := c.WritePNG(&buf, c.MinImageSize()-1); !errors.Is(err, ErrTooSmall) {
+		t.Errorf("err = %v, want ErrTooSmall", err)
+	}
+	if err := c.WriteSVG(&buf, c.MinImageSize()-1); !errors.Is(err, ErrTooSmall) {
+		t.Errorf("err = %v, want ErrTooSmall", err)
+	}
+}
@@ -0,0 +1,91 @@
+package qrcode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// QuietZone ширина свободной зоны вокруг кода в модулях, требуемая стандартом
+const QuietZone = 4
+
+// ErrTooSmall запрошенный размер изображения меньше одного пикселя на модуль
+var ErrTooSmall = errors.New("image size is too small for QR code")
+
+// MinImageSize наименьшая сторона изображения в пикселях, при которой
+// каждый модуль вместе со свободной зоной занимает хотя бы один пиксель
+func (c *Code) MinImageSize() int {
+	return c.Size + 2*QuietZone
+}
+
+// Image рисует код на квадратном изображении со стороной size пикселей
+// Модули занимают целое число пикселей; остаток уходит в свободную зону,
+// а код располагается по центру
+func (c *Code) Image(size int) (*image.Paletted, error) {
+	total := c.MinImageSize()
+	if size < total {
+		return nil, ErrTooSmall
+	}
+	scale := size / total
+	offset := (size - c.Size*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := range c.Size {
+		for x := range c.Size {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for py := range scale {
+				row := img.Pix[(offset+y*scale+py)*img.Stride:]
+				for px := range scale {
+					row[offset+x*scale+px] = 1
+				}
+			}
+		}
+	}
+	return img, nil
+}
+
+// WritePNG записывает код в формате PNG со стороной size пикселей
+func (c *Code) WritePNG(w io.Writer, size int) error {
+	img, err := c.Image(size)
+	if err != nil {
+		return err
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	return enc.Encode(w, img)
+}
+
+// WriteSVG записывает код в формате SVG со стороной size пикселей
+// Темные модули рисуются одним контуром в координатах модулей
+func (c *Code) WriteSVG(w io.Writer, size int) error {
+	total := c.MinImageSize()
+	if size < total {
+		return ErrTooSmall
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		size, size, total, total)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#FFFFFF"/>`+"\n")
+	fmt.Fprint(bw, `<path fill="#000000" d="`)
+	for y := range c.Size {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			// Соседние темные модули строки объединяются в один прямоугольник
+			start := x
+			for x+1 < c.Size && c.Dark(x+1, y) {
+				x++
+			}
+			fmt.Fprintf(bw, "M%d,%dh%dv1h-%dz", start+QuietZone, y+QuietZone, x-start+1, x-start+1)
+		}
+	}
+	fmt.Fprint(bw, `"/>`+"\n</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,107 @@
+// Package traffic описывает формат журнала HTTP запросов в JSONL.
+//
+// Журнал пишет middleware записи трафика сервиса, а читает генератор нагрузки
+// cmd/loadgen для воспроизведения запросов. Каждая строка - один объект Record.
+package traffic
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxLineSize максимальная длина строки журнала
+const maxLineSize = 16 << 20
+
+// Record одна пара запрос-ответ журнала
+type Record struct {
+	Time          time.Time `json:"time"`                         // Время получения запроса
+	Method        string    `json:"method"`                       // HTTP метод
+	Path          string    `json:"path"`                         // Путь вместе со строкой запроса
+	ContentType   string    `json:"content_type,omitempty"`       // Content-Type запроса
+	Body          string    `json:"body,omitempty"`               // Тело запроса (возможно усеченное)
+	BodyTruncated bool      `json:"body_truncated,omitempty"`     // Тело запроса было усечено
+	Status        int       `json:"status"`                       // HTTP статус ответа
+	Response      string    `json:"response,omitempty"`           // Тело ответа (возможно усеченное)
+	RespTruncated bool      `json:"response_truncated,omitempty"` // Тело ответа было усечено
+	LatencyMS     float64   `json:"latency_ms"`                   // Время обработки в миллисекундах
+}
+
+// Latency возвращает время обработки запроса
+func (r *Record) Latency() time.Duration {
+	return time.Duration(r.LatencyMS * float64(time.Millisecond))
+}
+
+// Reader читает записи журнала
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader создает Reader для журнала r
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// Read возвращает следующую запись; в конце журнала возвращает io.EOF
+// Пустые строки пропускаются
+func (r *Reader) Read() (Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		if rec.Method == "" || rec.Path == "" {
+			return Record{}, fmt.Errorf("line %d: method and path are required", r.line)
+		}
+		return rec, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// ReadAll читает все записи журнала
+func ReadAll(r io.Reader) ([]Record, error) {
+	reader := NewReader(r)
+	var records []Record
+	for {
+		rec, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// Writer записывает записи журнала
+type Writer struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewWriter создает Writer, пишущий журнал в w
+func NewWriter(w io.Writer) *Writer {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Writer{w: w, enc: enc}
+}
+
+// Write записывает одну запись отдельной строкой
+func (w *Writer) Write(rec Record) error {
+	return w.enc.Encode(rec)
+}
@@ -0,0 +1,45 @@
+package traffic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterReaderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	in := []Record{
+		{Time: time.Unix(1700000000, 0).UTC(), Method: "POST", Path: "/api/shorten", ContentType: "application/json", Body: `{"url":"https://a.example/?x=<y>"}`, Status: 201, LatencyMS: 1.5},
+		{Method: "GET", Path: "/abc?utm_source=x", Status: 307, LatencyMS: 0.2},
+	}
+	for _, rec := range in {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if !strings.Contains(buf.String(), "<y>") {
+		t.Error("expected HTML characters not to be escaped")
+	}
+
+	out, err := ReadAll(strings.NewReader(buf.String() + "\n"))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(out) != 2 || out[0].Body != in[0].Body || out[1].Path != in[1].Path {
+		t.Errorf("unexpected records: %+v", out)
+	}
+	if out[0].Latency() != 1500*time.Microsecond {
+		t.Errorf("unexpected latency %v", out[0].Latency())
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	if _, err := ReadAll(strings.NewReader("{\"method\":\"GET\",\"path\":\"/\"}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+	if _, err := ReadAll(strings.NewReader(`{"status":200}`)); err == nil {
+		t.Error("expected error for record without method and path")
+	}
+}